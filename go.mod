@@ -5,6 +5,9 @@ go 1.23
 require (
 	github.com/fatih/color v1.18.0
 	github.com/spf13/cobra v1.10.2
+	golang.org/x/mod v0.22.0
+	golang.org/x/sys v0.29.0
+	golang.org/x/term v0.28.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -13,5 +16,4 @@ require (
 	github.com/mattn/go-colorable v0.1.14 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
-	golang.org/x/sys v0.29.0 // indirect
 )
@@ -1,96 +1,193 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	vherrors "github.com/ksyq12/vhost/internal/errors"
+	"github.com/ksyq12/vhost/internal/logger"
 	"github.com/ksyq12/vhost/internal/output"
 	"github.com/ksyq12/vhost/internal/template"
 	"github.com/spf13/cobra"
 )
 
 var (
-	vhostType  string
-	vhostRoot  string
-	proxyPass  string
-	phpVersion string
-	withSSL    bool
-	noReload   bool
+	vhostType    string
+	tmplVariant  string
+	vhostRoot    string
+	proxyPass    string
+	redirectURL  string
+	redirectCode int
+	phpVersion   string
+	fcgiTimeout  string
+	wpMultisite  string
+	fcgiBuffers  string
+	fcgiBufSize  string
+	withSPA      bool
+	noAssetCache bool
+	assetMaxAge  string
+	withSSL      bool
+	withHTTP2    bool
+	withHTTP3    bool
+	noReload     bool
+	envFlags     []string
+	rootOwner    string
+	rootPerms    string
+	rootCreate   bool
+	enableSite   bool
+	caddyImports []string
+	blockUAs     []string
+	aliasFlags   []string
+	ownerEmail   string
+	locationArgs []string
+	vhostNotes   string
+	domainsFile  string
+	configOnly   bool
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add <domain>",
+	Use:   "add <domain> | --domains-file <path>",
 	Short: "Add a new virtual host",
 	Long: `Add a new virtual host configuration.
 
+With --domains-file, one vhost is created for each domain in the file (one
+per line; blank lines and # comments are skipped, "-" reads stdin), all
+sharing the other flags. A {{.Domain}} placeholder in --root is replaced
+with each domain. Every domain is checked before anything is written, and
+the server is tested and reloaded once; if the test fails, every new vhost
+is removed again.
+
+--config-only validates the vhost and records it in config.yaml without
+writing server files, testing or reloading, and needs no root, for setups
+where another tool applies the change. vhost render prints the config it
+would get.
+
 Examples:
   vhost add example.com --type static --root /var/www/html
   vhost add example.com --type php --root /var/www/app --php 8.2
   vhost add example.com --type proxy --proxy http://localhost:3000
   vhost add example.com --type laravel --root /var/www/laravel
-  vhost add example.com --type wordpress --root /var/www/wordpress`,
-	Args: cobra.ExactArgs(1),
+  vhost add example.com --type wordpress --root /var/www/wordpress
+  vhost add old-example.com --redirect https://example.com
+  vhost add promo.example.com --redirect https://example.com/sale --redirect-code 302
+  vhost add example.com --type php --root /var/www/app --env APP_ENV=production
+  vhost add example.com --type php --root /var/www/app --owner www-data:www-data
+  vhost add example.com --type static --root /var/www/html --enable=false
+  vhost add example.com --type static --root /mnt/site --root-create=false
+  vhost add example.com --type static --root /var/www/html --alias www.example.com
+  vhost add app.example.com --type static --root /var/www/app --spa
+  vhost add app.example.com --type static --root /var/www/app --template spa
+  vhost add example.com --type static --root /var/www/html --block-ua curl --block-ua python-requests
+  vhost add --domains-file sites.txt --type static --root '/var/www/{{.Domain}}'
+  vhost add example.com --type static --root /var/www/html --config-only`,
+	Args: domainOrFileArgs(&domainsFile),
 	RunE: runAdd,
 }
 
 func init() {
-	addCmd.Flags().StringVarP(&vhostType, "type", "t", "static", "VHost type (static, php, proxy, laravel, wordpress)")
+	addCmd.Flags().StringVarP(&vhostType, "type", "t", "static", "VHost type (static, php, proxy, laravel, wordpress, redirect)")
+	addCmd.Flags().StringVar(&tmplVariant, "template", "", "Template variant, rendering <type>.<name>.tmpl when the driver has it (e.g. spa for static)")
 	addCmd.Flags().StringVarP(&vhostRoot, "root", "r", "", "Document root path")
 	addCmd.Flags().StringVarP(&proxyPass, "proxy", "p", "", "Proxy pass URL (for proxy type)")
+	addCmd.Flags().StringVar(&redirectURL, "redirect", "", "URL every request is redirected to, keeping the path (implies --type redirect)")
+	addCmd.Flags().IntVar(&redirectCode, "redirect-code", template.DefaultRedirectCode, "Redirect status: 301 (permanent) or 302 (temporary)")
 	addCmd.Flags().StringVar(&phpVersion, "php", "", "PHP version (e.g., 8.2)")
+	addCmd.Flags().StringVar(&wpMultisite, "wp-multisite", "", "WordPress multisite rewrites: subdir or subdomain (wordpress type)")
+	addCmd.Flags().StringVar(&fcgiTimeout, "fastcgi-read-timeout", "", "FastCGI read timeout as a duration, e.g. 300s (PHP types)")
+	addCmd.Flags().StringVar(&fcgiBuffers, "fastcgi-buffers", "", "nginx fastcgi_buffers as \"<count> <size>\", e.g. \"16 16k\" (PHP types)")
+	addCmd.Flags().StringVar(&fcgiBufSize, "fastcgi-buffer-size", "", "nginx fastcgi_buffer_size, e.g. 32k (PHP types)")
+	addCmd.Flags().BoolVar(&withSPA, "spa", false, "Serve /index.html for unknown paths (single-page apps; static type)")
+	addCmd.Flags().BoolVar(&noAssetCache, "no-static-cache", false, "Don't send long cache lifetimes for CSS, JS, images and fonts (static and wordpress types)")
+	addCmd.Flags().StringVar(&assetMaxAge, "static-cache-max-age", "", "Cache lifetime for static assets, e.g. 12h, 30d or 1y (default 30d; static and wordpress types)")
 	addCmd.Flags().BoolVar(&withSSL, "ssl", false, "Enable SSL (requires certbot)")
+	addCmd.Flags().BoolVar(&withHTTP2, "http2", true, "Negotiate HTTP/2 on the SSL listener (with --ssl)")
+	addCmd.Flags().BoolVar(&withHTTP3, "http3", false, "Also serve HTTP/3 over QUIC (with --ssl; nginx 1.25+)")
 	addCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server")
-
+	addCmd.Flags().BoolVar(&enableSite, "enable", true, "Enable the site after creating it (--enable=false only writes the config)")
+	addCmd.Flags().StringVar(&rootOwner, "owner", "", "Owner of the created document root as user[:group] (applied when run as root)")
+	addCmd.Flags().StringVar(&rootPerms, "root-perms", "", "Octal mode of the created document root (default 0755)")
+	addCmd.Flags().BoolVar(&rootCreate, "root-create", true, "Create the document root if missing (--root-create=false requires it to exist)")
+	addCmd.Flags().StringArrayVar(&aliasFlags, "alias", nil, "Additional server name for the vhost (repeatable)")
+	addCmd.Flags().BoolVar(&configOnly, "config-only", false, "Only record the vhost in config.yaml; don't write server files, test or reload")
+	addCmd.Flags().StringVar(&domainsFile, "domains-file", "", "Create a vhost for each domain listed in this file, one per line (\"-\" reads stdin)")
+	addCmd.Flags().StringArrayVar(&locationArgs, "location", nil, "Serve a path from another directory as <path>:<root> (repeatable)")
+	addCmd.Flags().StringArrayVar(&blockUAs, "block-ua", nil, "Answer requests whose User-Agent contains this text with 403 (repeatable, case-insensitive)")
+	addCmd.Flags().StringArrayVar(&caddyImports, "caddy-import", nil, "Caddy snippet to import in the site block (repeatable; caddy driver only)")
+	addCmd.Flags().StringVar(&ownerEmail, "owner-email", "", "Contact for the site owner (metadata only)")
+	addCmd.Flags().StringVar(&vhostNotes, "notes", "", "Free-form notes about the site (metadata only)")
+	addCmd.Flags().StringArrayVar(&envFlags, "env", nil, "Environment variable as KEY=VALUE (repeatable; fastcgi_param for PHP, request header for proxy)")
+
+	interruptible(addCmd)
 	rootCmd.AddCommand(addCmd)
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
-	domain := args[0]
+	if domainsFile != "" {
+		return runAddBulk(cmd)
+	}
+
+	domain := config.NormalizeDomain(args[0])
+	for i, alias := range aliasFlags {
+		aliasFlags[i] = config.NormalizeDomain(alias)
+	}
 
 	// Validate domain
 	if err := validateDomain(domain); err != nil {
 		return err
 	}
 
-	// Validate type
-	if !config.IsValidType(vhostType) {
-		return fmt.Errorf("invalid type: %s. Valid types: %s", vhostType, strings.Join(config.ValidTypes(), ", "))
+	// Validate aliases
+	for _, alias := range aliasFlags {
+		if err := validateDomain(alias); err != nil {
+			return fmt.Errorf("invalid alias %s: %w", alias, err)
+		}
 	}
 
-	// Validate required options based on type
-	if err := validateAddOptions(); err != nil {
+	// Load config and driver
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
 		return err
 	}
 
-	// Load config and driver
-	cfg, drv, err := loadConfigAndDriver()
+	envVars, locations, err := validateAddFlags(cmd, cfg)
 	if err != nil {
 		return err
 	}
 
+	if err := validateAddDriver(drv.Name(), locations); err != nil {
+		return err
+	}
+
 	// Check if vhost already exists
 	if _, exists := cfg.VHosts[domain]; exists {
-		return fmt.Errorf("vhost %s already exists", domain)
+		return vherrors.Newf(vherrors.ErrCodeAlreadyExists, "vhost %s already exists", domain)
 	}
 
-	// Create vhost config
-	vhost := &config.VHost{
-		Domain:     domain,
-		Type:       vhostType,
-		Root:       vhostRoot,
-		ProxyPass:  proxyPass,
-		PHPVersion: phpVersion,
-		SSL:        withSSL,
-		Enabled:    true,
-		CreatedAt:  time.Now(),
+	// Refuse names another vhost already serves; the web server would pick one arbitrarily
+	if owner, found := findDomainConflict(cfg, domain, aliasFlags); found {
+		return fmt.Errorf("domain conflict: %s or one of its aliases is already served by vhost %s", domain, owner)
 	}
 
-	// Set default PHP version if needed
-	if vhost.PHPVersion == "" && (vhost.Type == config.TypePHP || vhost.Type == config.TypeLaravel || vhost.Type == config.TypeWordPress) {
-		vhost.PHPVersion = cfg.DefaultPHP
+	// Create vhost config
+	vhost, err := newAddVHost(cfg, domain, envVars, locations, time.Now())
+	if err != nil {
+		return err
+	}
+	logger.DebugFields("Prepared vhost", map[string]interface{}{
+		"domain":  domain,
+		"type":    vhost.Type,
+		"root":    vhost.Root,
+		"aliases": vhost.Aliases,
+	})
+
+	if tmplVariant != "" && !template.HasVariant(drv.Name(), vhostType, tmplVariant) {
+		output.Warn("No %s/%s.%s template; using the base %s template", drv.Name(), vhostType, tmplVariant, vhostType)
 	}
 
 	// Render template
@@ -98,6 +195,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to render template: %w", err)
 	}
+	logger.Debug("Rendered %d byte(s) of %s config for %s", len(configContent), drv.Name(), domain)
 
 	// Dry-run mode: show what would be done without making changes
 	if dryRun {
@@ -105,16 +203,63 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return outputAddDryRun(domain, drv.Name(), struct{ Available, Enabled string }{drvPaths.Available, drvPaths.Enabled}, vhost, configContent)
 	}
 
+	// Config-only mode: another tool writes the server files and reloads
+	if configOnly {
+		cfg.VHosts[domain] = vhost
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		return outputResult(
+			map[string]interface{}{
+				"success":     true,
+				"domain":      domain,
+				"type":        vhostType,
+				"enabled":     enableSite,
+				"config_only": true,
+			},
+			"VHost %s recorded in config (web server not changed)", domain,
+		)
+	}
+
 	// Require root for system operations
 	if err := requireRoot(); err != nil {
 		return err
 	}
 
+	// Ctrl-C between steps rolls back like a failed config test
+	ctx := commandContext(cmd)
+
 	// Add vhost via driver
 	output.Info("Creating vhost configuration...")
 	if err := drv.Add(vhost, configContent); err != nil {
 		return fmt.Errorf("failed to add vhost: %w", err)
 	}
+	recordConfigHash(vhost, configContent)
+	logger.Debug("Wrote config for %s to %s", domain, drv.Paths().Available)
+	if err := checkInterrupted(ctx); err != nil {
+		output.Info("Rolling back changes...")
+		_ = drv.Remove(domain)
+		return err
+	}
+
+	// Staged vhost: keep the config in sites-available but leave it inactive
+	if !enableSite {
+		cfg.VHosts[domain] = vhost
+		if err := saveConfig(cfg); err != nil {
+			output.Warn("VHost created but config save failed: %v", err)
+		}
+
+		return outputResult(
+			map[string]interface{}{
+				"success": true,
+				"domain":  domain,
+				"type":    vhostType,
+				"enabled": false,
+			},
+			"VHost %s created (not enabled)", domain,
+		)
+	}
 
 	// Enable the site
 	output.Info("Enabling site...")
@@ -123,6 +268,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		_ = drv.Remove(domain)
 		return fmt.Errorf("failed to enable vhost: %w", err)
 	}
+	logger.Debug("Enabled %s in %s", domain, drv.Paths().Enabled)
 
 	// Test and reload with proper rollback
 	rollback := func() error {
@@ -136,6 +282,13 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if err := checkInterrupted(ctx); err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			output.Warn("Rollback failed: %v", rbErr)
+		}
+		return err
+	}
+
 	if err := testAndReload(drv, !noReload, rollback); err != nil {
 		return err
 	}
@@ -157,20 +310,436 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	)
 }
 
+// validateAddFlags checks the flags shared by every vhost add creates and
+// parses its --env and --location values
+func validateAddFlags(cmd *cobra.Command, cfg *config.Config) (map[string]string, []config.LocationBlock, error) {
+	// --redirect on its own is enough to ask for a redirect vhost
+	if redirectURL != "" && (cmd == nil || !cmd.Flags().Changed("type")) {
+		vhostType = config.TypeRedirect
+	}
+
+	// Validate type
+	if !config.IsValidType(vhostType) {
+		return nil, nil, fmt.Errorf("invalid type: %s. Valid types: %s", vhostType, strings.Join(config.ValidTypes(), ", "))
+	}
+
+	// Without --root, root_pattern derives each vhost's root from its domain
+	if vhostRoot == "" && cfg.RootPattern != "" {
+		switch vhostType {
+		case config.TypeStatic, config.TypePHP, config.TypeLaravel, config.TypeWordPress:
+			vhostRoot = cfg.RootPattern
+		}
+	}
+
+	// Validate required options based on type
+	if err := validateAddOptions(); err != nil {
+		return nil, nil, err
+	}
+
+	// HTTP/3 is negotiated over TLS only
+	if withHTTP3 && !withSSL {
+		return nil, nil, fmt.Errorf("--http3 requires --ssl")
+	}
+
+	envVars, err := parseEnvVars(envFlags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	locations, err := parseLocations(locationArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return envVars, locations, nil
+}
+
+// validateAddDriver checks the options that only some drivers support
+func validateAddDriver(drvName string, locations []config.LocationBlock) error {
+	// Traefik routes to services and has no document root to alias
+	if len(locations) > 0 && drvName == "traefik" {
+		return fmt.Errorf("--location is not supported by the traefik driver")
+	}
+	if len(blockUAs) > 0 && drvName == "traefik" {
+		return fmt.Errorf("--block-ua is not supported by the traefik driver")
+	}
+
+	// Snippet imports only make sense for caddy
+	if len(caddyImports) > 0 {
+		if drvName != "caddy" {
+			return fmt.Errorf("--caddy-import is only supported by the caddy driver (current driver: %s)", drvName)
+		}
+		for _, name := range caddyImports {
+			if err := driver.ValidateSnippetName(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// newAddVHost builds the vhost add creates for domain from the flags, with
+// any {{.Domain}} in the root filled in
+func newAddVHost(cfg *config.Config, domain string, envVars map[string]string, locations []config.LocationBlock, now time.Time) (*config.VHost, error) {
+	root := vhostRoot
+	if root != "" {
+		expanded, err := config.ExpandRootPattern(root, domain)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateRoot(expanded); err != nil {
+			return nil, fmt.Errorf("%s: %w", domain, err)
+		}
+		root = expanded
+	}
+
+	vhost := &config.VHost{
+		Domain:             domain,
+		Type:               vhostType,
+		Template:           tmplVariant,
+		Aliases:            aliasFlags,
+		Root:               root,
+		RootOwner:          rootOwner,
+		RootPerms:          rootPerms,
+		RootNoCreate:       !rootCreate,
+		ProxyPass:          proxyPass,
+		RedirectURL:        redirectURL,
+		PHPVersion:         phpVersion,
+		WPMultisite:        wpMultisite,
+		SPA:                withSPA,
+		NoStaticCache:      noAssetCache,
+		StaticCacheMaxAge:  assetMaxAge,
+		FastCGIReadTimeout: fcgiTimeout,
+		FastCGIBuffers:     fcgiBuffers,
+		FastCGIBufferSize:  fcgiBufSize,
+		SSL:                withSSL,
+		HTTP2:              withSSL && withHTTP2,
+		HTTP3:              withHTTP3,
+		EnvVars:            envVars,
+		Locations:          locations,
+		CaddyImports:       caddyImports,
+		BlockUserAgents:    blockUAs,
+		Enabled:            enableSite,
+		Owner:              ownerEmail,
+		Notes:              vhostNotes,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	if vhost.Type == config.TypeRedirect {
+		vhost.RedirectCode = redirectCode
+	}
+
+	// Set default PHP version if needed
+	if vhost.PHPVersion == "" && (vhost.Type == config.TypePHP || vhost.Type == config.TypeLaravel || vhost.Type == config.TypeWordPress) {
+		vhost.PHPVersion = cfg.DefaultPHP
+	}
+	return vhost, nil
+}
+
+// runAddBulk creates a vhost for every domain in --domains-file with a
+// single test and reload, removing them all again if any step fails
+func runAddBulk(cmd *cobra.Command) error {
+	if len(aliasFlags) > 0 {
+		return fmt.Errorf("--alias cannot be combined with --domains-file")
+	}
+
+	domains, err := readDomainList(domainsFile)
+	if err != nil {
+		return err
+	}
+
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return err
+	}
+
+	envVars, locations, err := validateAddFlags(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	if err := validateAddDriver(drv.Name(), locations); err != nil {
+		return err
+	}
+
+	if tmplVariant != "" && !template.HasVariant(drv.Name(), vhostType, tmplVariant) {
+		output.Warn("No %s/%s.%s template; using the base %s template", drv.Name(), vhostType, tmplVariant, vhostType)
+	}
+
+	// Check and render every vhost before changing anything
+	now := time.Now()
+	vhosts := make([]*config.VHost, 0, len(domains))
+	contents := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		if _, exists := cfg.VHosts[domain]; exists {
+			return fmt.Errorf("vhost %s already exists", domain)
+		}
+		if owner, found := findDomainConflict(cfg, domain, nil); found {
+			return fmt.Errorf("domain conflict: %s is already served by vhost %s", domain, owner)
+		}
+
+		vhost, err := newAddVHost(cfg, domain, envVars, locations, now)
+		if err != nil {
+			return err
+		}
+		content, err := template.Render(drv.Name(), vhost)
+		if err != nil {
+			return fmt.Errorf("failed to render template for %s: %w", domain, err)
+		}
+		vhosts = append(vhosts, vhost)
+		contents = append(contents, content)
+	}
+
+	if dryRun {
+		return outputAddBulkDryRun(vhosts, drv.Name(), drv.Paths())
+	}
+
+	if configOnly {
+		for _, vhost := range vhosts {
+			cfg.VHosts[vhost.Domain] = vhost
+		}
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		return outputResult(
+			map[string]interface{}{
+				"success":     true,
+				"domains":     domains,
+				"type":        vhostType,
+				"enabled":     enableSite,
+				"config_only": true,
+			},
+			"Recorded %d vhost(s) in config (web server not changed)", len(domains),
+		)
+	}
+
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	// Ctrl-C between steps rolls back like a failed config test
+	ctx := commandContext(cmd)
+
+	// Remove whatever was already created
+	var added []string
+	rollback := func() error {
+		output.Info("Rolling back changes...")
+		var errs []error
+		for _, domain := range added {
+			if enableSite {
+				if err := drv.Disable(domain); err != nil {
+					output.Warn("Rollback disable of %s failed: %v", domain, err)
+				}
+			}
+			if err := drv.Remove(domain); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", domain, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+	fail := func(err error) error {
+		if rbErr := rollback(); rbErr != nil {
+			output.Warn("Rollback failed: %v", rbErr)
+		}
+		return err
+	}
+
+	for i, vhost := range vhosts {
+		output.Info("Creating %s...", vhost.Domain)
+		if err := drv.Add(vhost, contents[i]); err != nil {
+			return fail(fmt.Errorf("failed to add %s: %w", vhost.Domain, err))
+		}
+		recordConfigHash(vhost, contents[i])
+		added = append(added, vhost.Domain)
+		if enableSite {
+			if err := drv.Enable(vhost.Domain); err != nil {
+				return fail(fmt.Errorf("failed to enable %s: %w", vhost.Domain, err))
+			}
+		}
+		if err := checkInterrupted(ctx); err != nil {
+			return fail(err)
+		}
+	}
+
+	if enableSite {
+		if err := testAndReload(drv, !noReload, rollback); err != nil {
+			return err
+		}
+	}
+
+	for _, vhost := range vhosts {
+		cfg.VHosts[vhost.Domain] = vhost
+	}
+	if err := saveConfig(cfg); err != nil {
+		output.Warn("VHosts created but config save failed: %v", err)
+	}
+
+	return outputResult(
+		map[string]interface{}{
+			"success": true,
+			"domains": domains,
+			"type":    vhostType,
+			"enabled": enableSite,
+		},
+		"Created %d vhost(s)", len(domains),
+	)
+}
+
+// readDomainList reads one domain per line from path, or from stdin when
+// path is "-". Blank lines and # comments are skipped; every domain is
+// validated and duplicates are rejected.
+func readDomainList(path string) ([]string, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = readStdin()
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read domains: %w", err)
+	}
+
+	var domains []string
+	seen := make(map[string]bool)
+	for i, line := range strings.Split(string(content), "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		domain := config.NormalizeDomain(strings.TrimSpace(line))
+		if domain == "" {
+			continue
+		}
+		if err := validateDomain(domain); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if seen[domain] {
+			return nil, fmt.Errorf("line %d: %s is listed more than once", i+1, domain)
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("no domains found in %s", path)
+	}
+	return domains, nil
+}
+
+// outputAddBulkDryRun outputs the files a bulk add would create, followed
+// by a single test and reload
+func outputAddBulkDryRun(vhosts []*config.VHost, drvName string, drvPaths driver.Paths) error {
+	domains := make([]string, 0, len(vhosts))
+	var operations []DryRunOperation
+	for _, vhost := range vhosts {
+		domains = append(domains, vhost.Domain)
+		configPath := filepath.Join(drvPaths.Available, driverConfigFileName(drvName, vhost.Domain))
+		operations = append(operations, DryRunOperation{
+			Action:  "create_file",
+			Target:  configPath,
+			Details: fmt.Sprintf("VHost configuration for %s", vhost.Domain),
+		})
+		if vhost.Enabled {
+			operations = append(operations, DryRunOperation{
+				Action:  "create_symlink",
+				Target:  filepath.Join(drvPaths.Enabled, driverConfigFileName(drvName, vhost.Domain)),
+				Details: fmt.Sprintf("Link to %s", configPath),
+			})
+		}
+		if vhost.Root != "" {
+			operations = append(operations, DryRunOperation{
+				Action:  "create_directory",
+				Target:  vhost.Root,
+				Details: "Document root directory",
+			})
+		}
+	}
+
+	if enableSite && !noReload {
+		operations = append(operations,
+			DryRunOperation{
+				Action:  "test_config",
+				Target:  drvName,
+				Details: "Validate configuration syntax",
+			},
+			DryRunOperation{
+				Action:  "reload_server",
+				Target:  drvName,
+				Details: "Apply configuration changes",
+			},
+		)
+	}
+
+	return outputDryRun(&DryRunResult{
+		Domain:     strings.Join(domains, ", "),
+		Operations: operations,
+	})
+}
+
 func validateAddOptions() error {
+	if err := validateTypeOptions(vhostType, vhostRoot, proxyPass, redirectURL); err != nil {
+		return err
+	}
+	if redirectURL != "" && vhostType != config.TypeRedirect {
+		return fmt.Errorf("--redirect is only supported for the redirect type")
+	}
+	if err := template.ValidateRedirectCode(redirectCode); err != nil {
+		return err
+	}
 	switch vhostType {
 	case config.TypeStatic, config.TypePHP, config.TypeLaravel, config.TypeWordPress:
-		if vhostRoot == "" {
+		if err := validateOwner(rootOwner); err != nil {
+			return err
+		}
+		if rootPerms != "" {
+			if _, err := driver.ParseRootPerms(rootPerms); err != nil {
+				return err
+			}
+		}
+	}
+	if err := template.ValidateVariant(tmplVariant); err != nil {
+		return err
+	}
+	if err := validateSPA(vhostType, withSPA); err != nil {
+		return err
+	}
+	if err := validateStaticCache(vhostType, noAssetCache, assetMaxAge); err != nil {
+		return err
+	}
+	if err := validateWPMultisite(vhostType, wpMultisite); err != nil {
+		return err
+	}
+	for _, agent := range blockUAs {
+		if err := template.ValidateUserAgent(agent); err != nil {
+			return err
+		}
+	}
+	return validateFastCGIOptions(vhostType, fcgiTimeout, fcgiBuffers, fcgiBufSize)
+}
+
+// validateTypeOptions checks that root, proxy and redirect satisfy what the
+// vhost type needs
+func validateTypeOptions(vhostType, root, proxy, redirect string) error {
+	switch vhostType {
+	case config.TypeStatic, config.TypePHP, config.TypeLaravel, config.TypeWordPress:
+		if root == "" {
 			return fmt.Errorf("--root is required for type %s", vhostType)
 		}
-		if err := validateRoot(vhostRoot); err != nil {
+		if err := validateRoot(root); err != nil {
 			return err
 		}
 	case config.TypeProxy:
-		if proxyPass == "" {
+		if proxy == "" {
 			return fmt.Errorf("--proxy is required for type proxy")
 		}
-		if err := validateProxyURL(proxyPass); err != nil {
+		if err := validateProxyURL(proxy); err != nil {
+			return err
+		}
+	case config.TypeRedirect:
+		if redirect == "" {
+			return fmt.Errorf("--redirect is required for type redirect")
+		}
+		if err := validateRedirectURL(redirect); err != nil {
 			return err
 		}
 	}
@@ -181,11 +750,8 @@ func validateAddOptions() error {
 func outputAddDryRun(domain string, drvName string, drvPaths struct{ Available, Enabled string }, vhost *config.VHost, configContent string) error {
 	paths := drvPaths
 
-	// Determine config file name (apache uses .conf extension)
-	configFileName := domain
-	if drvName == "apache" {
-		configFileName = domain + ".conf"
-	}
+	// Determine config file name (some drivers use an extension)
+	configFileName := driverConfigFileName(drvName, domain)
 
 	configPath := filepath.Join(paths.Available, configFileName)
 	enabledPath := filepath.Join(paths.Enabled, configFileName)
@@ -196,11 +762,14 @@ func outputAddDryRun(domain string, drvName string, drvPaths struct{ Available,
 			Target:  configPath,
 			Details: fmt.Sprintf("VHost configuration for %s", domain),
 		},
-		{
+	}
+
+	if vhost.Enabled {
+		operations = append(operations, DryRunOperation{
 			Action:  "create_symlink",
 			Target:  enabledPath,
 			Details: fmt.Sprintf("Link to %s", configPath),
-		},
+		})
 	}
 
 	// Add document root creation if specified
@@ -212,8 +781,8 @@ func outputAddDryRun(domain string, drvName string, drvPaths struct{ Available,
 		})
 	}
 
-	// Add test and reload operations if not --no-reload
-	if !noReload {
+	// Add test and reload operations if enabling and not --no-reload
+	if vhost.Enabled && !noReload {
 		operations = append(operations,
 			DryRunOperation{
 				Action:  "test_config",
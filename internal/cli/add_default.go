@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/ksyq12/vhost/internal/template"
+	"github.com/spf13/cobra"
+)
+
+var addDefaultCmd = &cobra.Command{
+	Use:   "add-default",
+	Short: "Add a catch-all vhost for unknown hostnames",
+	Long: `Add a default server that refuses requests for hostnames no other
+vhost serves, so the web server never answers host-header probes with a
+real site.
+
+  nginx   listens on 80/443 as default_server, rejects TLS handshakes and
+          closes the connection with 444
+  apache  first-loaded *:80 VirtualHost that answers 403
+  caddy   :80 fallback that aborts the connection
+
+The catch-all is tracked in config.yaml as ` + config.DefaultServerDomain + ` and is removed
+with: vhost remove ` + config.DefaultServerDomain + `
+
+Examples:
+  vhost add-default
+  vhost add-default --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runAddDefault,
+}
+
+func init() {
+	addDefaultCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server")
+
+	rootCmd.AddCommand(addDefaultCmd)
+}
+
+func runAddDefault(cmd *cobra.Command, args []string) error {
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return err
+	}
+
+	if drv.Name() == "traefik" {
+		return fmt.Errorf("add-default is not supported for traefik, which already answers unknown hosts with 404")
+	}
+
+	// Only one catch-all can exist; a second would stop the server starting
+	if existing, found := findDefaultServerVHost(cfg); found {
+		return fmt.Errorf("default server already exists: %s", existing)
+	}
+	if claimed, _ := findDefaultServers(drv); len(claimed) > 0 {
+		return fmt.Errorf("an enabled config already declares a default server: %s", strings.Join(defaultServerFiles(claimed), ", "))
+	}
+
+	domain := config.DefaultServerDomain
+	now := time.Now()
+	vhost := &config.VHost{
+		Domain:    domain,
+		Type:      config.TypeDefault,
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	configContent, err := template.Render(drv.Name(), vhost)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	// Dry-run mode: show what would be done without making changes
+	if dryRun {
+		drvPaths := drv.Paths()
+		return outputAddDryRun(domain, drv.Name(), struct{ Available, Enabled string }{drvPaths.Available, drvPaths.Enabled}, vhost, configContent)
+	}
+
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	output.Info("Creating default server configuration...")
+	if err := drv.Add(vhost, configContent); err != nil {
+		return fmt.Errorf("failed to add default server: %w", err)
+	}
+	recordConfigHash(vhost, configContent)
+
+	output.Info("Enabling default server...")
+	if err := drv.Enable(domain); err != nil {
+		// Rollback: remove config file
+		_ = drv.Remove(domain)
+		return fmt.Errorf("failed to enable default server: %w", err)
+	}
+
+	rollback := func() error {
+		output.Info("Rolling back changes...")
+		if err := drv.Disable(domain); err != nil {
+			output.Warn("Rollback disable failed: %v", err)
+		}
+		if err := drv.Remove(domain); err != nil {
+			return fmt.Errorf("rollback remove failed: %w", err)
+		}
+		return nil
+	}
+
+	if err := testAndReload(drv, !noReload, rollback); err != nil {
+		return err
+	}
+
+	cfg.VHosts[domain] = vhost
+	if err := saveConfig(cfg); err != nil {
+		output.Warn("Default server created but config save failed: %v", err)
+	}
+
+	return outputResult(
+		map[string]interface{}{
+			"success": true,
+			"domain":  domain,
+			"type":    config.TypeDefault,
+			"enabled": true,
+		},
+		"Default server %s created and enabled", domain,
+	)
+}
+
+// findDefaultServerVHost returns the config key of the catch-all vhost
+func findDefaultServerVHost(cfg *config.Config) (string, bool) {
+	for _, domain := range sortedDomains(cfg) {
+		if cfg.VHosts[domain].Type == config.TypeDefault {
+			return domain, true
+		}
+	}
+	return "", false
+}
+
+// defaultServerFiles flattens findDefaultServers output into sorted file names
+func defaultServerFiles(claimed map[string][]string) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, names := range claimed {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				files = append(files, name)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+)
+
+func TestRunAddDefault(t *testing.T) {
+	newDeps := func(t *testing.T, drvName string) (*config.Config, *driver.MockDriver) {
+		tempDir := t.TempDir()
+		mockDrv := driver.NewMockDriver(drvName, filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+		cfg := config.New()
+
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+		t.Cleanup(func() { deps = oldDeps })
+		return cfg, mockDrv
+	}
+
+	t.Run("creates and tracks the catch-all", func(t *testing.T) {
+		cfg, mockDrv := newDeps(t, "nginx")
+
+		if err := runAddDefault(nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		vhost, exists := cfg.VHosts[config.DefaultServerDomain]
+		if !exists {
+			t.Fatal("default server should be saved in config")
+		}
+		if vhost.Type != config.TypeDefault || !vhost.Enabled {
+			t.Errorf("unexpected vhost: %+v", vhost)
+		}
+		if len(mockDrv.AddCalls) != 1 || !strings.Contains(mockDrv.AddCalls[0].Content, "return 444;") {
+			t.Errorf("expected catch-all config to be added, got %v", mockDrv.AddCalls)
+		}
+		if len(mockDrv.EnableCalls) != 1 || mockDrv.TestCalls != 1 || mockDrv.ReloadCalls != 1 {
+			t.Errorf("expected enable, test and reload, got %d, %d, %d", len(mockDrv.EnableCalls), mockDrv.TestCalls, mockDrv.ReloadCalls)
+		}
+
+		// Only one default server can exist
+		err := runAddDefault(nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("expected already exists error, got %v", err)
+		}
+		if len(mockDrv.AddCalls) != 1 {
+			t.Errorf("second add-default should not write a config, got %d Add calls", len(mockDrv.AddCalls))
+		}
+	})
+
+	t.Run("refuses when an enabled config is already the default", func(t *testing.T) {
+		_, mockDrv := newDeps(t, "nginx")
+		enabledDir := mockDrv.Paths().Enabled
+		if err := os.MkdirAll(enabledDir, 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(enabledDir, "default"), []byte("server {\n    listen 80 default_server;\n}\n"), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+
+		err := runAddDefault(nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "default") {
+			t.Fatalf("expected default server conflict, got %v", err)
+		}
+		if len(mockDrv.AddCalls) != 0 {
+			t.Errorf("expected no config written, got %d Add calls", len(mockDrv.AddCalls))
+		}
+	})
+
+	t.Run("traefik is not supported", func(t *testing.T) {
+		_, mockDrv := newDeps(t, "traefik")
+
+		if err := runAddDefault(nil, nil); err == nil || !strings.Contains(err.Error(), "traefik") {
+			t.Errorf("expected traefik error, got %v", err)
+		}
+		if len(mockDrv.AddCalls) != 0 {
+			t.Errorf("expected no config written, got %d Add calls", len(mockDrv.AddCalls))
+		}
+	})
+}
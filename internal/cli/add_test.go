@@ -1,13 +1,18 @@
 package cli
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/template"
+	"github.com/spf13/cobra"
 )
 
 func TestRunAdd(t *testing.T) {
@@ -463,6 +468,75 @@ func TestRunAdd(t *testing.T) {
 	}
 }
 
+func TestRunAddWithoutEnable(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+	vhostType = "static"
+	vhostRoot = "/var/www/html"
+	proxyPass = ""
+	phpVersion = ""
+	withSSL = false
+	noReload = false
+	enableSite = false
+	defer func() { enableSite = true }()
+
+	cfg := config.New()
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	if err := runAdd(nil, []string{"staged.example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mockDrv.AddCalls) != 1 {
+		t.Errorf("expected 1 Add call, got %d", len(mockDrv.AddCalls))
+	}
+	if len(mockDrv.EnableCalls) != 0 {
+		t.Errorf("expected 0 Enable calls, got %d", len(mockDrv.EnableCalls))
+	}
+	if mockDrv.TestCalls != 0 {
+		t.Errorf("expected 0 Test calls, got %d", mockDrv.TestCalls)
+	}
+	if mockDrv.ReloadCalls != 0 {
+		t.Errorf("expected 0 Reload calls, got %d", mockDrv.ReloadCalls)
+	}
+
+	saved, _ := deps.ConfigLoader.Load()
+	vhost := saved.VHosts["staged.example.com"]
+	if vhost == nil {
+		t.Fatal("staged vhost should be tracked in config")
+	}
+	if vhost.Enabled {
+		t.Error("staged vhost should be recorded with Enabled:false")
+	}
+}
+
+func TestRunAddDomainConflict(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+	vhostType, vhostRoot, proxyPass = "static", "/var/www/html", ""
+	aliasFlags = []string{"www.example.com"}
+	defer func() { aliasFlags = nil }()
+
+	cfg := config.New()
+	cfg.VHosts["example.com"] = &config.VHost{Domain: "example.com", Type: "static", Aliases: []string{"www.example.com"}}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	err := runAdd(nil, []string{"shop.example.com"})
+	if err == nil || !strings.Contains(err.Error(), "already served by vhost example.com") {
+		t.Fatalf("expected conflict naming example.com, got %v", err)
+	}
+	if len(mockDrv.AddCalls) != 0 {
+		t.Errorf("expected no Add calls on conflict, got %d", len(mockDrv.AddCalls))
+	}
+}
+
 func TestRunAddDryRun(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -609,6 +683,9 @@ func TestValidateAddOptions(t *testing.T) {
 		vhostType   string
 		root        string
 		proxy       string
+		redirect    string
+		code        int
+		perms       string
 		wantErr     bool
 		errContains string
 	}{
@@ -671,6 +748,63 @@ func TestValidateAddOptions(t *testing.T) {
 			wantErr:     true,
 			errContains: "absolute",
 		},
+		{
+			name:      "valid root perms",
+			vhostType: "static",
+			root:      "/var/www/html",
+			perms:     "2775",
+			wantErr:   false,
+		},
+		{
+			name:        "invalid root perms fails",
+			vhostType:   "static",
+			root:        "/var/www/html",
+			perms:       "0999",
+			wantErr:     true,
+			errContains: "octal",
+		},
+		{
+			name:      "redirect with target",
+			vhostType: "redirect",
+			redirect:  "https://example.com/landing",
+			wantErr:   false,
+		},
+		{
+			name:        "redirect without target",
+			vhostType:   "redirect",
+			wantErr:     true,
+			errContains: "--redirect is required",
+		},
+		{
+			name:        "redirect without scheme fails",
+			vhostType:   "redirect",
+			redirect:    "example.com",
+			wantErr:     true,
+			errContains: "http:// or https://",
+		},
+		{
+			name:        "redirect with config syntax fails",
+			vhostType:   "redirect",
+			redirect:    "https://example.com/;return 200",
+			wantErr:     true,
+			errContains: "must not contain",
+		},
+		{
+			name:        "redirect with unsupported code fails",
+			vhostType:   "redirect",
+			redirect:    "https://example.com",
+			code:        308,
+			wantErr:     true,
+			errContains: "use 301 or 302",
+		},
+		{
+			name:        "redirect target on another type fails",
+			vhostType:   "static",
+			root:        "/var/www/html",
+			redirect:    "https://example.com",
+			wantErr:     true,
+			errContains: "only supported for the redirect type",
+		},
 	}
 
 	for _, tt := range tests {
@@ -679,6 +813,10 @@ func TestValidateAddOptions(t *testing.T) {
 			vhostType = tt.vhostType
 			vhostRoot = tt.root
 			proxyPass = tt.proxy
+			redirectURL = tt.redirect
+			redirectCode = tt.code
+			rootPerms = tt.perms
+			defer func() { rootPerms, redirectURL, redirectCode = "", "", 301 }()
 
 			err := validateAddOptions()
 
@@ -697,3 +835,440 @@ func TestValidateAddOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestRunAddHTTPProtocols(t *testing.T) {
+	oldSSL, oldHTTP2, oldHTTP3 := withSSL, withHTTP2, withHTTP3
+	vhostType, vhostRoot, proxyPass, phpVersion = "static", "/var/www/h2", "", ""
+	defer func() { withSSL, withHTTP2, withHTTP3 = oldSSL, oldHTTP2, oldHTTP3 }()
+
+	run := func(t *testing.T) (*config.Config, error) {
+		tempDir := t.TempDir()
+		mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+		cfg := config.New()
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+		defer func() { deps = oldDeps }()
+		return cfg, runAdd(nil, []string{"h2.example.com"})
+	}
+
+	t.Run("http2 defaults on with ssl", func(t *testing.T) {
+		withSSL, withHTTP2, withHTTP3 = true, true, false
+		cfg, err := run(t)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vhost := cfg.VHosts["h2.example.com"]; vhost == nil || !vhost.HTTP2 || vhost.HTTP3 {
+			t.Errorf("expected http2 only, got %+v", vhost)
+		}
+	})
+
+	t.Run("http2 off without ssl", func(t *testing.T) {
+		withSSL, withHTTP2, withHTTP3 = false, true, false
+		cfg, err := run(t)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vhost := cfg.VHosts["h2.example.com"]; vhost == nil || vhost.HTTP2 {
+			t.Errorf("expected http2 off without SSL, got %+v", vhost)
+		}
+	})
+
+	t.Run("http3 requires ssl", func(t *testing.T) {
+		withSSL, withHTTP2, withHTTP3 = false, true, true
+		if _, err := run(t); err == nil || !strings.Contains(err.Error(), "--http3 requires --ssl") {
+			t.Errorf("expected --http3 requires --ssl error, got %v", err)
+		}
+	})
+}
+
+func TestRunAddInterrupted(t *testing.T) {
+	vhostType, vhostRoot, proxyPass, phpVersion, withSSL = "static", "/var/www/int", "", "", false
+	oldEnable := enableSite
+	enableSite = true
+	defer func() { enableSite = oldEnable }()
+
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+	cfg := config.New()
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	// Ctrl-C arrives while the site is being enabled
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockDrv.EnableFunc = func(domain string) error {
+		go cancel()
+		<-ctx.Done()
+		return nil
+	}
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(ctx)
+	err := runAdd(cmd, []string{"int.example.com"})
+	if !errors.Is(err, errInterrupted) {
+		t.Fatalf("expected interrupted error, got %v", err)
+	}
+
+	if len(mockDrv.DisableCalls) != 1 || len(mockDrv.RemoveCalls) != 1 {
+		t.Errorf("expected rollback disable and remove, got disable=%v remove=%v", mockDrv.DisableCalls, mockDrv.RemoveCalls)
+	}
+	if mockDrv.TestCalls != 0 || mockDrv.ReloadCalls != 0 {
+		t.Errorf("expected no test or reload after interrupt, got test=%d reload=%d", mockDrv.TestCalls, mockDrv.ReloadCalls)
+	}
+	if _, saved := cfg.VHosts["int.example.com"]; saved {
+		t.Error("interrupted vhost should not be saved to config")
+	}
+}
+
+func TestRunAddTemplateVariant(t *testing.T) {
+	vhostType, vhostRoot, proxyPass, phpVersion = "static", "/var/www/spa", "", ""
+	defer func() { tmplVariant, withSPA = "", false }()
+
+	run := func(t *testing.T) (*config.Config, *driver.MockDriver, error) {
+		tempDir := t.TempDir()
+		templateDir := filepath.Join(tempDir, "templates")
+		if err := os.MkdirAll(filepath.Join(templateDir, "nginx"), 0755); err != nil {
+			t.Fatalf("failed to create template dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(templateDir, "nginx", "static.custom.tmpl"), []byte("server { server_name {{ .Domain }}; # custom }"), 0644); err != nil {
+			t.Fatalf("failed to write template: %v", err)
+		}
+		defer template.SetTemplateDir("")
+
+		mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+		cfg := config.New()
+		cfg.TemplateDir = templateDir
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+		defer func() { deps = oldDeps }()
+		return cfg, mockDrv, runAdd(nil, []string{"spa.example.com"})
+	}
+
+	t.Run("variant", func(t *testing.T) {
+		tmplVariant, withSPA = "custom", false
+		cfg, mockDrv, err := run(t)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vhost := cfg.VHosts["spa.example.com"]; vhost == nil || vhost.Template != "custom" {
+			t.Errorf("expected template custom stored, got %+v", vhost)
+		}
+		if len(mockDrv.AddCalls) != 1 || !strings.Contains(mockDrv.AddCalls[0].Content, "# custom") {
+			t.Errorf("expected variant in rendered config, got %+v", mockDrv.AddCalls)
+		}
+	})
+
+	t.Run("spa variant", func(t *testing.T) {
+		tmplVariant, withSPA = "spa", false
+		cfg, mockDrv, err := run(t)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vhost := cfg.VHosts["spa.example.com"]; vhost == nil || vhost.Template != "spa" {
+			t.Errorf("expected template spa stored, got %+v", vhost)
+		}
+		if len(mockDrv.AddCalls) != 1 || !strings.Contains(mockDrv.AddCalls[0].Content, "try_files $uri /index.html;") {
+			t.Errorf("expected SPA fallback in rendered config, got %+v", mockDrv.AddCalls)
+		}
+	})
+
+	t.Run("spa", func(t *testing.T) {
+		tmplVariant, withSPA = "", true
+		cfg, mockDrv, err := run(t)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vhost := cfg.VHosts["spa.example.com"]; vhost == nil || !vhost.SPA {
+			t.Errorf("expected spa stored, got %+v", vhost)
+		}
+		if len(mockDrv.AddCalls) != 1 || !strings.Contains(mockDrv.AddCalls[0].Content, "try_files $uri $uri/ /index.html;") {
+			t.Errorf("expected SPA fallback in rendered config, got %+v", mockDrv.AddCalls)
+		}
+	})
+
+	t.Run("invalid variant", func(t *testing.T) {
+		tmplVariant, withSPA = "Spa!", false
+		if _, _, err := run(t); err == nil || !strings.Contains(err.Error(), "invalid template variant") {
+			t.Errorf("expected invalid template variant error, got %v", err)
+		}
+	})
+}
+
+func TestRunAddBlockUserAgents(t *testing.T) {
+	vhostType, vhostRoot, proxyPass, phpVersion, withSSL = "static", "/var/www/ua", "", "", false
+	defer func() { blockUAs = nil }()
+
+	run := func(t *testing.T, drvName string) (*config.Config, *driver.MockDriver, error) {
+		tempDir := t.TempDir()
+		mockDrv := driver.NewMockDriver(drvName, filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+		cfg := config.New()
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+		defer func() { deps = oldDeps }()
+		return cfg, mockDrv, runAdd(nil, []string{"ua.example.com"})
+	}
+
+	t.Run("stored and rendered", func(t *testing.T) {
+		blockUAs = []string{"curl", "python-requests"}
+		cfg, mockDrv, err := run(t, "nginx")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vhost := cfg.VHosts["ua.example.com"]; vhost == nil || len(vhost.BlockUserAgents) != 2 {
+			t.Errorf("expected blocked user agents stored, got %+v", vhost)
+		}
+		if len(mockDrv.AddCalls) != 1 || !strings.Contains(mockDrv.AddCalls[0].Content, `if ($http_user_agent ~* "(curl|python-requests)")`) {
+			t.Errorf("expected user agent block in rendered config, got %+v", mockDrv.AddCalls)
+		}
+	})
+
+	t.Run("empty entry", func(t *testing.T) {
+		blockUAs = []string{"curl", ""}
+		if _, _, err := run(t, "nginx"); err == nil || !strings.Contains(err.Error(), "must not be empty") {
+			t.Errorf("expected empty user agent error, got %v", err)
+		}
+	})
+
+	t.Run("traefik", func(t *testing.T) {
+		blockUAs = []string{"curl"}
+		if _, _, err := run(t, "traefik"); err == nil || !strings.Contains(err.Error(), "not supported by the traefik driver") {
+			t.Errorf("expected traefik error, got %v", err)
+		}
+	})
+}
+
+func TestRunAddRedirect(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+	// --redirect alone selects the redirect type over the static default
+	vhostType, vhostRoot, proxyPass, phpVersion, withSSL = "static", "", "", "", false
+	redirectURL = "https://example.com"
+	defer func() { redirectURL = "" }()
+
+	cfg := config.New()
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	if err := runAdd(nil, []string{"old-example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	vhost := cfg.VHosts["old-example.com"]
+	if vhost == nil || vhost.Type != config.TypeRedirect || vhost.RedirectURL != "https://example.com" {
+		t.Fatalf("expected redirect vhost stored, got %+v", vhost)
+	}
+	if len(mockDrv.AddCalls) != 1 || !strings.Contains(mockDrv.AddCalls[0].Content, "return 301 https://example.com$request_uri;") {
+		t.Errorf("expected redirect in rendered config, got %+v", mockDrv.AddCalls)
+	}
+}
+
+func TestRunAddDomainsFile(t *testing.T) {
+	setup := func(t *testing.T, content string) (*driver.MockDriver, *config.Config) {
+		t.Helper()
+		tempDir := t.TempDir()
+		mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+		domainsFile = filepath.Join(tempDir, "domains.txt")
+		if err := os.WriteFile(domainsFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		vhostType, vhostRoot, proxyPass, phpVersion, withSSL = "static", "/var/www/{{.Domain}}", "", "", false
+		enableSite, noReload = true, false
+		t.Cleanup(func() { domainsFile, vhostRoot = "", "" })
+
+		cfg := config.New()
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+		t.Cleanup(func() { deps = oldDeps })
+		return mockDrv, cfg
+	}
+
+	t.Run("templated root", func(t *testing.T) {
+		mockDrv, cfg := setup(t, "# sites\na.example.com\n\nB.example.com\nc.example.com # last\n")
+
+		if err := runAdd(nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(mockDrv.AddCalls) != 3 {
+			t.Fatalf("expected 3 Add calls, got %d", len(mockDrv.AddCalls))
+		}
+		for i, domain := range []string{"a.example.com", "b.example.com", "c.example.com"} {
+			call := mockDrv.AddCalls[i]
+			if call.VHost.Domain != domain || call.VHost.Root != "/var/www/"+domain {
+				t.Errorf("call %d: expected %s at /var/www/%s, got %s at %s", i, domain, domain, call.VHost.Domain, call.VHost.Root)
+			}
+			if !strings.Contains(call.Content, "root /var/www/"+domain+";") {
+				t.Errorf("call %d: rendered config lacks expanded root", i)
+			}
+			if cfg.VHosts[domain] == nil {
+				t.Errorf("%s not saved to config", domain)
+			}
+		}
+		if mockDrv.TestCalls != 1 || mockDrv.ReloadCalls != 1 {
+			t.Errorf("expected one test and one reload, got %d and %d", mockDrv.TestCalls, mockDrv.ReloadCalls)
+		}
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		mockDrv, cfg := setup(t, "")
+		domainsFile = "-"
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).WithStdinInput("a.example.com\nb.example.com").Build()
+
+		if err := runAdd(nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(mockDrv.AddCalls) != 2 || cfg.VHosts["a.example.com"] == nil || cfg.VHosts["b.example.com"] == nil {
+			t.Errorf("expected both domains from stdin added, got %d Add calls and %v", len(mockDrv.AddCalls), cfg.VHosts)
+		}
+	})
+
+	t.Run("test failure rolls back every vhost", func(t *testing.T) {
+		mockDrv, cfg := setup(t, "a.example.com\nb.example.com\nc.example.com\n")
+		mockDrv.TestFunc = func() error { return errors.New("syntax error") }
+
+		if err := runAdd(nil, nil); err == nil {
+			t.Fatal("expected error on test failure")
+		}
+		if len(mockDrv.RemoveCalls) != 3 {
+			t.Errorf("expected 3 Remove calls, got %v", mockDrv.RemoveCalls)
+		}
+		if mockDrv.ReloadCalls != 0 {
+			t.Errorf("expected no reload, got %d", mockDrv.ReloadCalls)
+		}
+		if len(cfg.VHosts) != 0 {
+			t.Errorf("expected nothing saved, got %v", cfg.VHosts)
+		}
+	})
+
+	t.Run("invalid domain changes nothing", func(t *testing.T) {
+		mockDrv, _ := setup(t, "a.example.com\nnot a domain\n")
+
+		err := runAdd(nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Fatalf("expected error naming line 2, got %v", err)
+		}
+		if len(mockDrv.AddCalls) != 0 {
+			t.Errorf("expected no Add calls, got %d", len(mockDrv.AddCalls))
+		}
+	})
+
+	t.Run("existing vhost changes nothing", func(t *testing.T) {
+		mockDrv, cfg := setup(t, "a.example.com\nb.example.com\n")
+		cfg.VHosts["b.example.com"] = &config.VHost{Domain: "b.example.com", Type: "static"}
+
+		err := runAdd(nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "b.example.com already exists") {
+			t.Fatalf("expected exists error, got %v", err)
+		}
+		if len(mockDrv.AddCalls) != 0 {
+			t.Errorf("expected no Add calls, got %d", len(mockDrv.AddCalls))
+		}
+	})
+
+	t.Run("duplicate domain", func(t *testing.T) {
+		setup(t, "a.example.com\nA.example.com\n")
+
+		if err := runAdd(nil, nil); err == nil || !strings.Contains(err.Error(), "listed more than once") {
+			t.Fatalf("expected duplicate error, got %v", err)
+		}
+	})
+}
+
+func TestRunAddRootPattern(t *testing.T) {
+	run := func(t *testing.T, root string) (*driver.MockDriver, error) {
+		t.Helper()
+		tempDir := t.TempDir()
+		mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+		vhostType, vhostRoot, proxyPass, phpVersion, withSSL = "static", root, "", "", false
+		defer func() { vhostRoot = "" }()
+
+		cfg := config.New()
+		cfg.RootPattern = "/var/www/{{.Domain}}/public"
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+		defer func() { deps = oldDeps }()
+
+		return mockDrv, runAdd(nil, []string{"example.com"})
+	}
+
+	t.Run("derived root", func(t *testing.T) {
+		mockDrv, err := run(t, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(mockDrv.AddCalls) != 1 || mockDrv.AddCalls[0].VHost.Root != "/var/www/example.com/public" {
+			t.Fatalf("expected root derived from pattern, got %+v", mockDrv.AddCalls)
+		}
+	})
+
+	t.Run("--root wins", func(t *testing.T) {
+		mockDrv, err := run(t, "/srv/site")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(mockDrv.AddCalls) != 1 || mockDrv.AddCalls[0].VHost.Root != "/srv/site" {
+			t.Fatalf("expected --root to override the pattern, got %+v", mockDrv.AddCalls)
+		}
+	})
+}
+
+func TestRunAddConfigOnly(t *testing.T) {
+	for _, enable := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enable=%v", enable), func(t *testing.T) {
+			tempDir := t.TempDir()
+			mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+			vhostType, vhostRoot, proxyPass, phpVersion, withSSL = "static", "/var/www/html", "", "", false
+			configOnly, enableSite = true, enable
+			defer func() { configOnly, enableSite = false, true }()
+
+			// No root access: config-only never touches the server
+			cfg := config.New()
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(false).Build()
+			defer func() { deps = oldDeps }()
+
+			if err := runAdd(nil, []string{"example.com"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(mockDrv.AddCalls) != 0 || len(mockDrv.EnableCalls) != 0 || mockDrv.TestCalls != 0 || mockDrv.ReloadCalls != 0 {
+				t.Errorf("expected no driver calls, got add=%d enable=%d test=%d reload=%d",
+					len(mockDrv.AddCalls), len(mockDrv.EnableCalls), mockDrv.TestCalls, mockDrv.ReloadCalls)
+			}
+
+			saved, _ := deps.ConfigLoader.Load()
+			vhost := saved.VHosts["example.com"]
+			if vhost == nil {
+				t.Fatal("vhost should be recorded in config")
+			}
+			if vhost.Enabled != enable || vhost.Root != "/var/www/html" {
+				t.Errorf("expected Enabled=%v with root /var/www/html, got %+v", enable, vhost)
+			}
+		})
+	}
+
+	t.Run("invalid options are still rejected", func(t *testing.T) {
+		vhostType, vhostRoot, proxyPass = "static", "", ""
+		configOnly = true
+		defer func() { configOnly = false }()
+
+		cfg := config.New()
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).Build()
+		defer func() { deps = oldDeps }()
+
+		if err := runAdd(nil, []string{"example.com"}); err == nil || !strings.Contains(err.Error(), "--root is required") {
+			t.Errorf("expected missing root error, got %v", err)
+		}
+		if len(cfg.VHosts) != 0 {
+			t.Errorf("expected nothing recorded, got %v", cfg.VHosts)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt <domain>",
+	Short: "Bring an existing config file under vhost management",
+	Long: `Record a config file that vhost did not create in config.yaml, so the other
+commands can manage it from now on.
+
+The file is read from the driver's available directory and left untouched.
+Its type, document root, proxy target, PHP version, aliases and SSL files
+are inferred from the directives it contains; --type, --root and --proxy
+override whatever was inferred. The next command that re-renders the vhost
+(set, ssl install, maintenance) replaces the file with vhost's template.
+
+Examples:
+  vhost adopt example.com
+  vhost adopt app.example.com --type laravel --root /var/www/app
+  vhost adopt api.example.com --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAdopt,
+}
+
+var (
+	adoptType  string
+	adoptRoot  string
+	adoptProxy string
+)
+
+func init() {
+	adoptCmd.Flags().StringVarP(&adoptType, "type", "t", "", "VHost type, instead of the inferred one")
+	adoptCmd.Flags().StringVarP(&adoptRoot, "root", "r", "", "Document root path, instead of the inferred one")
+	adoptCmd.Flags().StringVarP(&adoptProxy, "proxy", "p", "", "Proxy pass URL, instead of the inferred one")
+
+	rootCmd.AddCommand(adoptCmd)
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	domain := config.NormalizeDomain(args[0])
+
+	// Validate domain
+	if err := validateDomain(domain); err != nil {
+		return err
+	}
+
+	if adoptType != "" && !config.IsValidType(adoptType) {
+		return fmt.Errorf("invalid type: %s. Valid types: %s", adoptType, strings.Join(config.ValidTypes(), ", "))
+	}
+
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return err
+	}
+
+	if _, exists := cfg.VHosts[domain]; exists {
+		return fmt.Errorf("vhost %s is already managed", domain)
+	}
+
+	configPath := filepath.Join(drv.Paths().Available, driverConfigFileName(drv.Name(), domain))
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+
+	vhost := parseExistingConfig(drv.Name(), domain, string(content))
+	if adoptType != "" {
+		vhost.Type = adoptType
+	}
+	if adoptRoot != "" {
+		vhost.Root = adoptRoot
+	}
+	if adoptProxy != "" {
+		vhost.ProxyPass = adoptProxy
+	}
+	if vhost.Type == "" {
+		return fmt.Errorf("could not infer the type of %s; pass --type", configPath)
+	}
+	if err := validateTypeOptions(vhost.Type, vhost.Root, vhost.ProxyPass, vhost.RedirectURL); err != nil {
+		return fmt.Errorf("%w (pass --root or --proxy to set it)", err)
+	}
+
+	// The adopted names must not collide with vhosts already managed
+	if owner, found := findDomainConflict(cfg, domain, vhost.Aliases); found {
+		return fmt.Errorf("%s shares a name with managed vhost %s", domain, owner)
+	}
+
+	if enabled, err := drv.IsEnabled(domain); err == nil {
+		vhost.Enabled = enabled
+	}
+
+	// Dry-run mode: show what would be recorded without saving
+	if dryRun {
+		cfgPath, _ := deps.ConfigLoader.Path()
+		return outputDryRun(&DryRunResult{
+			Domain: domain,
+			Operations: []DryRunOperation{{
+				Action:  "update_config",
+				Target:  cfgPath,
+				Details: fmt.Sprintf("Record %s as type %s", configPath, vhost.Type),
+			}},
+		})
+	}
+
+	now := time.Now()
+	vhost.CreatedAt = now
+	vhost.UpdatedAt = now
+	if err := cfg.AddVHost(vhost); err != nil {
+		return err
+	}
+	if err := saveConfig(cfg); err != nil {
+		return err
+	}
+
+	if !jsonOutput {
+		output.Info("Adopted %s as type %s", configPath, vhost.Type)
+	}
+	return outputResult(
+		map[string]interface{}{
+			"success":     true,
+			"domain":      domain,
+			"type":        vhost.Type,
+			"root":        vhost.Root,
+			"proxy_pass":  vhost.ProxyPass,
+			"php_version": vhost.PHPVersion,
+			"aliases":     vhost.Aliases,
+			"ssl":         vhost.SSL,
+			"enabled":     vhost.Enabled,
+		},
+		"VHost %s adopted", domain,
+	)
+}
+
+// Directive patterns for parseExistingConfig. nginx and apache files are
+// read by the driver package's parsers instead.
+var (
+	caddyRootPattern        = regexp.MustCompile(`(?m)^\s*root\s+(?:\*\s+)?([^\s]+)`)
+	caddyProxyPattern       = regexp.MustCompile(`(?m)^\s*reverse_proxy\s+(?:[/@*]\S*\s+)?([^\s{]+)`)
+	caddyTLSPattern         = regexp.MustCompile(`(?m)^\s*tls\s+(\S+)\s+(\S+)`)
+	traefikProxyPattern     = regexp.MustCompile(`(?m)^\s*-?\s*url:\s*"?([^"\s]+)"?`)
+	phpFPMSocketPattern     = regexp.MustCompile(`php(\d+\.\d+)-fpm\.sock`)
+	wordpressMarkerPattern  = regexp.MustCompile(`wp-admin|wp-config\.php|wp-includes`)
+	laravelPublicDirPattern = regexp.MustCompile(`/public/?$`)
+)
+
+// parseExistingConfig infers a vhost from a config file vhost did not
+// write. It is best-effort: fields it can't find are left empty, and Type
+// is empty when nothing identifies it.
+func parseExistingConfig(driverName, domain, content string) *config.VHost {
+	vhost := &config.VHost{Domain: domain}
+
+	first := func(pattern *regexp.Regexp) string {
+		if m := pattern.FindStringSubmatch(content); m != nil {
+			return strings.TrimSpace(m[1])
+		}
+		return ""
+	}
+
+	var names []string
+	switch driverName {
+	case "nginx", "apache":
+		parse := driver.ParseNginx
+		if driverName == "apache" {
+			parse = driver.ParseApache
+		}
+		// A file without a server block leaves everything empty
+		parsed, _ := parse(content)
+		vhost.Root = parsed.Root
+		vhost.ProxyPass = parsed.ProxyPass
+		names = parsed.ServerNames
+		vhost.SSLCert = parsed.SSLCert
+		vhost.SSLKey = parsed.SSLKey
+	case "caddy":
+		vhost.Root = first(caddyRootPattern)
+		vhost.ProxyPass = first(caddyProxyPattern)
+		if m := caddyTLSPattern.FindStringSubmatch(content); m != nil {
+			vhost.SSLCert, vhost.SSLKey = m[1], m[2]
+		}
+	case "traefik":
+		vhost.ProxyPass = first(traefikProxyPattern)
+	}
+
+	// Every server name other than the domain itself is an alias
+	seen := map[string]bool{domain: true}
+	for _, name := range names {
+		name = config.NormalizeDomain(name)
+		if !seen[name] && validateDomain(name) == nil {
+			seen[name] = true
+			vhost.Aliases = append(vhost.Aliases, name)
+		}
+	}
+
+	vhost.SSL = vhost.SSLCert != "" && vhost.SSLKey != ""
+	if !vhost.SSL {
+		vhost.SSLCert, vhost.SSLKey = "", ""
+	}
+
+	if m := phpFPMSocketPattern.FindStringSubmatch(content); m != nil {
+		vhost.PHPVersion = m[1]
+	}
+	isPHP := vhost.PHPVersion != "" || strings.Contains(content, "php_fastcgi") || strings.Contains(content, "fastcgi_pass")
+
+	switch {
+	case vhost.ProxyPass != "":
+		vhost.Type = config.TypeProxy
+		vhost.Root = ""
+	case isPHP && wordpressMarkerPattern.MatchString(content):
+		vhost.Type = config.TypeWordPress
+	case isPHP && laravelPublicDirPattern.MatchString(vhost.Root):
+		// vhost's laravel templates append /public to the project root
+		vhost.Type = config.TypeLaravel
+		vhost.Root = laravelPublicDirPattern.ReplaceAllString(vhost.Root, "")
+	case isPHP:
+		vhost.Type = config.TypePHP
+	case vhost.Root != "":
+		vhost.Type = config.TypeStatic
+	}
+
+	return vhost
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+)
+
+const handWrittenNginxStatic = `# managed by hand
+server {
+    listen 80;
+    listen 443 ssl;
+    server_name example.com www.example.com;
+
+    root /srv/example/html;
+    index index.html;
+
+    ssl_certificate /etc/ssl/example.crt;
+    ssl_certificate_key /etc/ssl/example.key;
+
+    location / {
+        try_files $uri $uri/ =404;
+    }
+}
+`
+
+func TestParseExistingConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		content string
+		want    config.VHost
+	}{
+		{
+			name:    "nginx static",
+			driver:  "nginx",
+			content: handWrittenNginxStatic,
+			want: config.VHost{
+				Type:    config.TypeStatic,
+				Root:    "/srv/example/html",
+				Aliases: []string{"www.example.com"},
+				SSL:     true,
+				SSLCert: "/etc/ssl/example.crt",
+				SSLKey:  "/etc/ssl/example.key",
+			},
+		},
+		{
+			name:    "nginx proxy",
+			driver:  "nginx",
+			content: "server {\n    server_name example.com;\n    location / {\n        proxy_pass http://127.0.0.1:8080;\n    }\n}\n",
+			want:    config.VHost{Type: config.TypeProxy, ProxyPass: "http://127.0.0.1:8080"},
+		},
+		{
+			name:    "nginx laravel",
+			driver:  "nginx",
+			content: "server {\n    root /var/www/app/public;\n    location ~ \\.php$ {\n        fastcgi_pass unix:/run/php/php8.3-fpm.sock;\n    }\n}\n",
+			want:    config.VHost{Type: config.TypeLaravel, Root: "/var/www/app", PHPVersion: "8.3"},
+		},
+		{
+			name:    "nginx wordpress",
+			driver:  "nginx",
+			content: "server {\n    root /var/www/blog;\n    location = /wp-config.php { deny all; }\n    fastcgi_pass unix:/run/php/php8.2-fpm.sock;\n}\n",
+			want:    config.VHost{Type: config.TypeWordPress, Root: "/var/www/blog", PHPVersion: "8.2"},
+		},
+		{
+			name:    "apache php",
+			driver:  "apache",
+			content: "<VirtualHost *:80>\n    ServerName example.com\n    ServerAlias www.example.com alt.example.com\n    DocumentRoot \"/var/www/app\"\n    SetHandler \"proxy:unix:/run/php/php8.1-fpm.sock|fcgi://localhost\"\n</VirtualHost>\n",
+			want:    config.VHost{Type: config.TypePHP, Root: "/var/www/app", PHPVersion: "8.1", Aliases: []string{"www.example.com", "alt.example.com"}},
+		},
+		{
+			name:    "caddy proxy",
+			driver:  "caddy",
+			content: "example.com {\n    reverse_proxy /api/* localhost:3000\n}\n",
+			want:    config.VHost{Type: config.TypeProxy, ProxyPass: "localhost:3000"},
+		},
+		{
+			name:    "unrecognised",
+			driver:  "nginx",
+			content: "server {\n    return 444;\n}\n",
+			want:    config.VHost{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseExistingConfig(tt.driver, "example.com", tt.content)
+			if got.Domain != "example.com" {
+				t.Errorf("Domain = %q", got.Domain)
+			}
+			if got.Type != tt.want.Type || got.Root != tt.want.Root || got.ProxyPass != tt.want.ProxyPass || got.PHPVersion != tt.want.PHPVersion {
+				t.Errorf("got type=%q root=%q proxy=%q php=%q, want type=%q root=%q proxy=%q php=%q",
+					got.Type, got.Root, got.ProxyPass, got.PHPVersion, tt.want.Type, tt.want.Root, tt.want.ProxyPass, tt.want.PHPVersion)
+			}
+			if !slices.Equal(got.Aliases, tt.want.Aliases) {
+				t.Errorf("Aliases = %v, want %v", got.Aliases, tt.want.Aliases)
+			}
+			if got.SSL != tt.want.SSL || got.SSLCert != tt.want.SSLCert || got.SSLKey != tt.want.SSLKey {
+				t.Errorf("got ssl=%v %q %q, want ssl=%v %q %q", got.SSL, got.SSLCert, got.SSLKey, tt.want.SSL, tt.want.SSLCert, tt.want.SSLKey)
+			}
+		})
+	}
+}
+
+func TestRunAdopt(t *testing.T) {
+	defer func() { adoptType, adoptRoot, adoptProxy = "", "", "" }()
+
+	setup := func(t *testing.T, cfg *config.Config) *driver.MockDriver {
+		tempDir := t.TempDir()
+		available := filepath.Join(tempDir, "sites-available")
+		if err := os.MkdirAll(available, 0755); err != nil {
+			t.Fatalf("failed to create available dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(available, "example.com"), []byte(handWrittenNginxStatic), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		mockDrv := driver.NewMockDriver("nginx", available, filepath.Join(tempDir, "sites-enabled"))
+		mockDrv.IsEnabledFunc = func(domain string) (bool, error) { return true, nil }
+
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+		t.Cleanup(func() { deps = oldDeps })
+		return mockDrv
+	}
+
+	t.Run("adopts inferred fields", func(t *testing.T) {
+		adoptType, adoptRoot, adoptProxy = "", "", ""
+		cfg := config.New()
+		mockDrv := setup(t, cfg)
+
+		if err := runAdopt(nil, []string{"example.com"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		vhost := cfg.VHosts["example.com"]
+		if vhost == nil {
+			t.Fatal("expected example.com in config")
+		}
+		if vhost.Type != config.TypeStatic || vhost.Root != "/srv/example/html" || !vhost.SSL || !vhost.Enabled {
+			t.Errorf("unexpected adopted vhost: %+v", vhost)
+		}
+		if vhost.CreatedAt.IsZero() {
+			t.Error("expected CreatedAt to be set")
+		}
+		if len(mockDrv.AddCalls) != 0 || len(mockDrv.RemoveCalls) != 0 {
+			t.Error("adopt must not rewrite the config file")
+		}
+	})
+
+	t.Run("flags override inferred fields", func(t *testing.T) {
+		adoptType, adoptRoot, adoptProxy = config.TypePHP, "/srv/example/app", ""
+		cfg := config.New()
+		setup(t, cfg)
+
+		if err := runAdopt(nil, []string{"example.com"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vhost := cfg.VHosts["example.com"]; vhost == nil || vhost.Type != config.TypePHP || vhost.Root != "/srv/example/app" {
+			t.Errorf("expected overridden type and root, got %+v", vhost)
+		}
+	})
+
+	t.Run("already managed", func(t *testing.T) {
+		adoptType, adoptRoot, adoptProxy = "", "", ""
+		cfg := config.New()
+		cfg.VHosts["example.com"] = &config.VHost{Domain: "example.com", Type: config.TypeStatic, Root: "/var/www"}
+		setup(t, cfg)
+
+		if err := runAdopt(nil, []string{"example.com"}); err == nil || !strings.Contains(err.Error(), "already managed") {
+			t.Errorf("expected already managed error, got %v", err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		adoptType, adoptRoot, adoptProxy = "", "", ""
+		setup(t, config.New())
+
+		if err := runAdopt(nil, []string{"missing.com"}); err == nil || !strings.Contains(err.Error(), "failed to read") {
+			t.Errorf("expected read error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,331 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/ksyq12/vhost/internal/template"
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Make the web server match config.yaml",
+	Long: `Reconcile the web server with config.yaml, the declarative counterpart
+to add, set, enable and disable.
+
+Every vhost in config.yaml is rendered from its stored settings. Missing
+config files are created and files that differ are rewritten, so hand
+edits are replaced (check with vhost diff first). Each site is then
+enabled or disabled to match its enabled flag. The server is tested and
+reloaded once; if the test fails, every change is undone.
+
+Config files of vhosts that aren't in config.yaml are left alone.
+
+apply can't be used with --profile: vhosts are shared by every profile,
+so it would write all of them to the profile's server.
+
+Examples:
+  vhost apply --dry-run
+  sudo vhost apply
+  sudo vhost apply --json`,
+	Args: cobra.NoArgs,
+	RunE: runApply,
+}
+
+func init() {
+	interruptible(applyCmd)
+	rootCmd.AddCommand(applyCmd)
+}
+
+// applyChange is what apply does to one vhost
+type applyChange struct {
+	vhost   *config.VHost
+	content string // rendered config, written when create or update is set
+	backup  string // previous file content, restored on rollback of update
+	create  bool
+	update  bool
+	enable  bool
+	disable bool
+	// wasEnabled is the site's state before apply, restored on rollback
+	wasEnabled bool
+}
+
+// applySummary lists the domains each kind of change touched
+type applySummary struct {
+	Created, Updated, Enabled, Disabled []string
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	// Profiles don't scope vhosts, so every vhost would land on that server
+	if profileName != "" {
+		return fmt.Errorf("apply can't be combined with --profile: the vhosts in config.yaml are shared by every profile")
+	}
+
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return err
+	}
+
+	// Work out every change before making any
+	changes, err := planApply(cfg, drv)
+	if err != nil {
+		return err
+	}
+
+	summary := summarizeApply(changes)
+	if len(changes) == 0 {
+		return outputResult(
+			map[string]interface{}{
+				"success":  true,
+				"created":  summary.Created,
+				"updated":  summary.Updated,
+				"enabled":  summary.Enabled,
+				"disabled": summary.Disabled,
+			},
+			"Web server already matches config.yaml",
+		)
+	}
+
+	if dryRun {
+		return outputApplyDryRun(changes, drv.Name(), drv.Paths())
+	}
+
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	// Undo applied changes, newest first
+	var applied []*applyChange
+	rollback := func() error {
+		output.Info("Rolling back changes...")
+		var errs []error
+		for i := len(applied) - 1; i >= 0; i-- {
+			if err := undoApplyChange(drv, applied[i]); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", applied[i].vhost.Domain, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	// Ctrl-C between changes undoes the ones already made
+	ctx := commandContext(cmd)
+	for _, change := range changes {
+		if err := checkInterrupted(ctx); err != nil {
+			if rbErr := rollback(); rbErr != nil {
+				output.Warn("Rollback failed: %v", rbErr)
+			}
+			return err
+		}
+		applied = append(applied, change)
+		if err := applyVHostChange(drv, change); err != nil {
+			if rbErr := rollback(); rbErr != nil {
+				output.Warn("Rollback failed: %v", rbErr)
+			}
+			return fmt.Errorf("failed to apply %s: %w", change.vhost.Domain, err)
+		}
+	}
+
+	if err := testAndReload(drv, !noReload, rollback); err != nil {
+		return err
+	}
+
+	// Record the hashes of the files just written
+	if len(summary.Created) > 0 || len(summary.Updated) > 0 {
+		if err := saveConfig(cfg); err != nil {
+			warn("Changes applied but config save failed: %v", err)
+		}
+	}
+
+	return outputResult(
+		map[string]interface{}{
+			"success":  true,
+			"created":  summary.Created,
+			"updated":  summary.Updated,
+			"enabled":  summary.Enabled,
+			"disabled": summary.Disabled,
+		},
+		"Applied config.yaml: %d created, %d updated, %d enabled, %d disabled",
+		len(summary.Created), len(summary.Updated), len(summary.Enabled), len(summary.Disabled),
+	)
+}
+
+// planApply compares every vhost in cfg with the server and returns the
+// vhosts whose config file or enabled state need to change
+func planApply(cfg *config.Config, drv driver.Driver) ([]*applyChange, error) {
+	var changes []*applyChange
+	for _, domain := range sortedDomains(cfg) {
+		vhost := cfg.VHosts[domain]
+
+		rendered, err := template.Render(drv.Name(), vhost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", domain, err)
+		}
+
+		change := &applyChange{vhost: vhost, content: rendered}
+		configPath := filepath.Join(drv.Paths().Available, driverConfigFileName(drv.Name(), domain))
+		onDisk, err := os.ReadFile(configPath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			change.create = true
+		case err != nil:
+			return nil, fmt.Errorf("failed to read %s: %w", configPath, err)
+		case string(onDisk) != rendered:
+			change.update = true
+			change.backup = string(onDisk)
+		}
+
+		if !change.create {
+			change.wasEnabled, _ = drv.IsEnabled(domain)
+		}
+		change.enable = vhost.Enabled && !change.wasEnabled
+		change.disable = !vhost.Enabled && change.wasEnabled
+
+		if change.create || change.update || change.enable || change.disable {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// applyVHostChange writes the vhost's config and sets its enabled state
+func applyVHostChange(drv driver.Driver, change *applyChange) error {
+	domain := change.vhost.Domain
+	switch {
+	case change.create:
+		output.Info("Creating %s...", domain)
+		if err := drv.Add(change.vhost, change.content); err != nil {
+			return err
+		}
+		recordConfigHash(change.vhost, change.content)
+		if change.enable {
+			return drv.Enable(domain)
+		}
+		return nil
+	case change.update:
+		output.Info("Updating %s...", domain)
+		if err := replaceVHostConfig(drv, change.vhost, change.content, change.vhost.Enabled); err != nil {
+			return err
+		}
+		recordConfigHash(change.vhost, change.content)
+		return nil
+	case change.enable:
+		output.Info("Enabling %s...", domain)
+		return drv.Enable(domain)
+	case change.disable:
+		output.Info("Disabling %s...", domain)
+		return drv.Disable(domain)
+	}
+	return nil
+}
+
+// undoApplyChange puts one vhost back the way apply found it
+func undoApplyChange(drv driver.Driver, change *applyChange) error {
+	domain := change.vhost.Domain
+	switch {
+	case change.create:
+		if change.enable {
+			if err := drv.Disable(domain); err != nil {
+				output.Warn("Rollback disable of %s failed: %v", domain, err)
+			}
+		}
+		return drv.Remove(domain)
+	case change.update:
+		return replaceVHostConfig(drv, change.vhost, change.backup, change.wasEnabled)
+	case change.enable:
+		return drv.Disable(domain)
+	case change.disable:
+		return drv.Enable(domain)
+	}
+	return nil
+}
+
+// summarizeApply groups the planned changes by kind. Created vhosts count
+// as created only, even when they are also enabled.
+func summarizeApply(changes []*applyChange) applySummary {
+	summary := applySummary{Created: []string{}, Updated: []string{}, Enabled: []string{}, Disabled: []string{}}
+	for _, change := range changes {
+		domain := change.vhost.Domain
+		switch {
+		case change.create:
+			summary.Created = append(summary.Created, domain)
+		case change.update:
+			summary.Updated = append(summary.Updated, domain)
+		}
+		if !change.create {
+			if change.enable {
+				summary.Enabled = append(summary.Enabled, domain)
+			}
+			if change.disable {
+				summary.Disabled = append(summary.Disabled, domain)
+			}
+		}
+	}
+	return summary
+}
+
+// outputApplyDryRun outputs what apply would do in dry-run mode
+func outputApplyDryRun(changes []*applyChange, drvName string, drvPaths driver.Paths) error {
+	domains := make([]string, 0, len(changes))
+	var operations []DryRunOperation
+	for _, change := range changes {
+		domain := change.vhost.Domain
+		domains = append(domains, domain)
+		configPath := filepath.Join(drvPaths.Available, driverConfigFileName(drvName, domain))
+		linkPath := filepath.Join(drvPaths.Enabled, driverConfigFileName(drvName, domain))
+
+		if change.create {
+			operations = append(operations, DryRunOperation{
+				Action:  "create_file",
+				Target:  configPath,
+				Details: fmt.Sprintf("VHost configuration for %s", domain),
+			})
+		}
+		if change.update {
+			operations = append(operations, DryRunOperation{
+				Action:  "update_file",
+				Target:  configPath,
+				Details: fmt.Sprintf("Re-render VHost configuration for %s from config.yaml", domain),
+			})
+		}
+		if change.enable {
+			operations = append(operations, DryRunOperation{
+				Action:  "create_symlink",
+				Target:  linkPath,
+				Details: fmt.Sprintf("Link to %s", configPath),
+			})
+		}
+		if change.disable {
+			operations = append(operations, DryRunOperation{
+				Action: "remove_symlink",
+				Target: linkPath,
+			})
+		}
+	}
+
+	if !noReload {
+		operations = append(operations,
+			DryRunOperation{
+				Action:  "test_config",
+				Target:  drvName,
+				Details: "Validate configuration syntax",
+			},
+			DryRunOperation{
+				Action:  "reload_server",
+				Target:  drvName,
+				Details: "Apply configuration changes",
+			},
+		)
+	}
+
+	return outputDryRun(&DryRunResult{
+		Domain:     strings.Join(domains, ", "),
+		Operations: operations,
+	})
+}
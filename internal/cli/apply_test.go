@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/template"
+)
+
+// setupApply builds a config whose vhosts each diverge from the server in
+// a different way:
+//   - new.com has no config file yet
+//   - stale.com's file differs from its rendering
+//   - off.com is enabled but should be disabled
+//   - on.com is disabled but should be enabled
+//   - same.com already matches
+func setupApply(t *testing.T) (*config.Config, *driver.MockDriver) {
+	t.Helper()
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	if err := os.MkdirAll(availableDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.New()
+	for domain, enabled := range map[string]bool{"new.com": true, "stale.com": true, "off.com": false, "on.com": true, "same.com": true} {
+		cfg.VHosts[domain] = &config.VHost{Domain: domain, Type: "static", Root: "/var/www/" + domain, Enabled: enabled}
+	}
+
+	for _, domain := range []string{"stale.com", "off.com", "on.com", "same.com"} {
+		content, err := template.Render("nginx", cfg.VHosts[domain])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if domain == "stale.com" {
+			content = "server { listen 80; }"
+		}
+		if err := os.WriteFile(filepath.Join(availableDir, domain), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mockDrv := driver.NewMockDriver("nginx", availableDir, filepath.Join(tempDir, "sites-enabled"))
+	mockDrv.IsEnabledFunc = func(domain string) (bool, error) {
+		return domain != "on.com", nil
+	}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	t.Cleanup(func() { deps = oldDeps })
+	return cfg, mockDrv
+}
+
+func TestRunApply(t *testing.T) {
+	_, mockDrv := setupApply(t)
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+
+	var runErr error
+	out := captureStdout(func() { runErr = runApply(nil, nil) })
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+
+	var result struct {
+		Created  []string `json:"created"`
+		Updated  []string `json:"updated"`
+		Enabled  []string `json:"enabled"`
+		Disabled []string `json:"disabled"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if !slices.Equal(result.Created, []string{"new.com"}) || !slices.Equal(result.Updated, []string{"stale.com"}) ||
+		!slices.Equal(result.Enabled, []string{"on.com"}) || !slices.Equal(result.Disabled, []string{"off.com"}) {
+		t.Errorf("unexpected summary %+v", result)
+	}
+
+	var written []string
+	for _, call := range mockDrv.AddCalls {
+		written = append(written, call.VHost.Domain)
+	}
+	if !slices.Equal(written, []string{"new.com", "stale.com"}) {
+		t.Errorf("expected new.com and stale.com written, got %v", written)
+	}
+	if !slices.Contains(mockDrv.EnableCalls, "on.com") || !slices.Contains(mockDrv.EnableCalls, "new.com") {
+		t.Errorf("expected on.com and new.com enabled, got %v", mockDrv.EnableCalls)
+	}
+	if !slices.Contains(mockDrv.DisableCalls, "off.com") {
+		t.Errorf("expected off.com disabled, got %v", mockDrv.DisableCalls)
+	}
+	if slices.Contains(mockDrv.EnableCalls, "same.com") || slices.Contains(mockDrv.DisableCalls, "same.com") {
+		t.Error("same.com already matches and should be left alone")
+	}
+	if mockDrv.TestCalls != 1 || mockDrv.ReloadCalls != 1 {
+		t.Errorf("expected one test and one reload, got %d and %d", mockDrv.TestCalls, mockDrv.ReloadCalls)
+	}
+}
+
+func TestRunApplyRollback(t *testing.T) {
+	_, mockDrv := setupApply(t)
+	mockDrv.TestFunc = func() error { return errors.New("syntax error") }
+
+	if err := runApply(nil, nil); err == nil {
+		t.Fatal("expected error on test failure")
+	}
+
+	if !slices.Contains(mockDrv.RemoveCalls, "new.com") {
+		t.Errorf("expected new.com removed, got %v", mockDrv.RemoveCalls)
+	}
+	last := mockDrv.AddCalls[len(mockDrv.AddCalls)-1]
+	if last.VHost.Domain != "stale.com" || last.Content != "server { listen 80; }" {
+		t.Errorf("expected stale.com's previous file restored, got %s: %q", last.VHost.Domain, last.Content)
+	}
+	if !slices.Contains(mockDrv.EnableCalls, "off.com") {
+		t.Errorf("expected off.com re-enabled, got %v", mockDrv.EnableCalls)
+	}
+	if !slices.Contains(mockDrv.DisableCalls, "new.com") || !slices.Contains(mockDrv.DisableCalls, "on.com") {
+		t.Errorf("expected on.com and new.com disabled again, got %v", mockDrv.DisableCalls)
+	}
+	if mockDrv.ReloadCalls != 0 {
+		t.Errorf("expected no reload, got %d", mockDrv.ReloadCalls)
+	}
+}
+
+func TestRunApplyDryRun(t *testing.T) {
+	_, mockDrv := setupApply(t)
+
+	dryRun = true
+	defer func() { dryRun = false }()
+
+	if err := runApply(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockDrv.AddCalls) != 0 || len(mockDrv.EnableCalls) != 0 || len(mockDrv.DisableCalls) != 0 || mockDrv.TestCalls != 0 {
+		t.Errorf("dry-run should not change anything, got add=%d enable=%v disable=%v test=%d",
+			len(mockDrv.AddCalls), mockDrv.EnableCalls, mockDrv.DisableCalls, mockDrv.TestCalls)
+	}
+}
+
+func TestRunApplyInSync(t *testing.T) {
+	cfg, mockDrv := setupApply(t)
+	for _, domain := range []string{"new.com", "stale.com", "off.com", "on.com"} {
+		delete(cfg.VHosts, domain)
+	}
+
+	if err := runApply(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockDrv.AddCalls) != 0 || mockDrv.TestCalls != 0 || mockDrv.ReloadCalls != 0 {
+		t.Errorf("expected no changes when in sync, got add=%d test=%d reload=%d", len(mockDrv.AddCalls), mockDrv.TestCalls, mockDrv.ReloadCalls)
+	}
+}
+
+func TestRunApplyProfile(t *testing.T) {
+	_, mockDrv := setupApply(t)
+	profileName = "staging"
+	defer func() { profileName = "" }()
+
+	err := runApply(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "can't be combined with --profile") {
+		t.Fatalf("expected --profile to be rejected, got %v", err)
+	}
+	if len(mockDrv.AddCalls) != 0 {
+		t.Errorf("expected no changes, got %d Add calls", len(mockDrv.AddCalls))
+	}
+}
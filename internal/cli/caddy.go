@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var snippetFile string
+
+var caddyCmd = &cobra.Command{
+	Use:   "caddy",
+	Short: "Caddy-specific commands",
+}
+
+var caddySnippetCmd = &cobra.Command{
+	Use:   "snippet",
+	Short: "Manage shared Caddy snippets",
+	Long: `Manage named snippets in the shared snippets file (snippets.caddy next to
+sites-available). Vhosts pull them in with "vhost add --caddy-import <name>".
+
+The main Caddyfile must import the snippets file before the sites:
+
+  import snippets.caddy
+  import sites-enabled/*
+
+Examples:
+  vhost caddy snippet list
+  vhost caddy snippet set logging --file ./logging.caddy
+  vhost caddy snippet remove logging`,
+}
+
+var caddySnippetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List shared snippets",
+	Args:  cobra.NoArgs,
+	RunE:  runCaddySnippetList,
+}
+
+var caddySnippetSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Add or replace a shared snippet",
+	Long: `Add or replace a shared snippet. The body is read from --file, or from
+stdin when --file is "-".
+
+Examples:
+  vhost caddy snippet set logging --file ./logging.caddy
+  echo 'encode gzip' | vhost caddy snippet set compress --file -`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCaddySnippetSet,
+}
+
+var caddySnippetRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a shared snippet",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runCaddySnippetRemove,
+}
+
+func init() {
+	caddySnippetSetCmd.Flags().StringVar(&snippetFile, "file", "", "File containing the snippet body (- for stdin)")
+	_ = caddySnippetSetCmd.MarkFlagRequired("file")
+	caddySnippetSetCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server")
+	caddySnippetRemoveCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server")
+
+	caddySnippetCmd.AddCommand(caddySnippetListCmd, caddySnippetSetCmd, caddySnippetRemoveCmd)
+	caddyCmd.AddCommand(caddySnippetCmd)
+	rootCmd.AddCommand(caddyCmd)
+}
+
+// caddySnippetStore is implemented by drivers that manage shared snippets
+type caddySnippetStore interface {
+	SnippetsPath() string
+	ListSnippets() ([]string, error)
+	SetSnippet(name, body string) error
+	RemoveSnippet(name string) error
+}
+
+func runCaddySnippetList(cmd *cobra.Command, args []string) error {
+	store, _, err := loadSnippetStore()
+	if err != nil {
+		return err
+	}
+
+	names, err := store.ListSnippets()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return output.JSON(map[string]interface{}{
+			"path":     store.SnippetsPath(),
+			"snippets": names,
+		})
+	}
+
+	if len(names) == 0 {
+		output.Info("No snippets defined in %s", store.SnippetsPath())
+		return nil
+	}
+	for _, name := range names {
+		output.Print("%s", name)
+	}
+	return nil
+}
+
+func runCaddySnippetSet(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	body, err := readSnippetBody(snippetFile)
+	if err != nil {
+		return err
+	}
+
+	store, reload, err := loadSnippetStore()
+	if err != nil {
+		return err
+	}
+
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	rollback := snapshotSnippets(store)
+	if err := store.SetSnippet(name, body); err != nil {
+		return err
+	}
+	if err := reload(rollback); err != nil {
+		return err
+	}
+
+	return outputResult(
+		map[string]interface{}{
+			"success": true,
+			"snippet": name,
+			"path":    store.SnippetsPath(),
+		},
+		"Snippet %s saved", name,
+	)
+}
+
+func runCaddySnippetRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	store, reload, err := loadSnippetStore()
+	if err != nil {
+		return err
+	}
+
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	rollback := snapshotSnippets(store)
+	if err := store.RemoveSnippet(name); err != nil {
+		return err
+	}
+	if err := reload(rollback); err != nil {
+		return err
+	}
+
+	return outputResult(
+		map[string]interface{}{
+			"success": true,
+			"snippet": name,
+			"removed": true,
+		},
+		"Snippet %s removed", name,
+	)
+}
+
+// loadSnippetStore returns the active driver as a snippet store, plus a
+// function that tests and reloads it with the given rollback
+func loadSnippetStore() (caddySnippetStore, func(rollback func() error) error, error) {
+	_, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	store, ok := drv.(caddySnippetStore)
+	if !ok {
+		return nil, nil, fmt.Errorf("snippets are only supported by the caddy driver (current driver: %s)", drv.Name())
+	}
+
+	reload := func(rollback func() error) error {
+		return testAndReload(drv, !noReload, rollback)
+	}
+	return store, reload, nil
+}
+
+// snapshotSnippets captures the snippets file so a failed config test can restore it
+func snapshotSnippets(store caddySnippetStore) func() error {
+	path := store.SnippetsPath()
+	previous, err := os.ReadFile(path)
+	existed := err == nil
+
+	return func() error {
+		output.Info("Rolling back changes...")
+		if !existed {
+			return os.Remove(path)
+		}
+		return os.WriteFile(path, previous, 0644)
+	}
+}
+
+// readSnippetBody reads a snippet body from a file or stdin ("-")
+func readSnippetBody(path string) (string, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = readStdin()
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read snippet body: %w", err)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/executor"
+)
+
+func TestCaddySnippetCommands(t *testing.T) {
+	tempDir := t.TempDir()
+	caddy := driver.NewCaddyWithExecutor(
+		filepath.Join(tempDir, "sites-available"),
+		filepath.Join(tempDir, "sites-enabled"),
+		&executor.MockExecutor{},
+	)
+
+	oldDeps := deps
+	cfg := config.New()
+	cfg.Driver = "caddy"
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(caddy).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	bodyFile := filepath.Join(tempDir, "logging.caddy")
+	if err := os.WriteFile(bodyFile, []byte("log {\n    output stdout\n}\n"), 0644); err != nil {
+		t.Fatalf("failed to write body: %v", err)
+	}
+
+	snippetFile = bodyFile
+	defer func() { snippetFile = "" }()
+	if err := runCaddySnippetSet(nil, []string{"logging"}); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	names, _ := caddy.ListSnippets()
+	if len(names) != 1 || names[0] != "logging" {
+		t.Errorf("expected logging snippet, got %v", names)
+	}
+
+	if err := runCaddySnippetRemove(nil, []string{"logging"}); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	names, _ = caddy.ListSnippets()
+	if len(names) != 0 {
+		t.Errorf("expected no snippets, got %v", names)
+	}
+}
+
+func TestCaddySnippetSetStdin(t *testing.T) {
+	tempDir := t.TempDir()
+	caddy := driver.NewCaddyWithExecutor(
+		filepath.Join(tempDir, "sites-available"),
+		filepath.Join(tempDir, "sites-enabled"),
+		&executor.MockExecutor{},
+	)
+
+	oldDeps := deps
+	cfg := config.New()
+	cfg.Driver = "caddy"
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(caddy).WithRootAccess(true).WithStdinInput("encode gzip\nheader -Server\n").Build()
+	defer func() { deps = oldDeps }()
+
+	snippetFile = "-"
+	defer func() { snippetFile = "" }()
+	if err := runCaddySnippetSet(nil, []string{"common"}); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	content, err := os.ReadFile(caddy.SnippetsPath())
+	if err != nil {
+		t.Fatalf("failed to read snippets: %v", err)
+	}
+	for _, want := range []string{"(common)", "encode gzip", "header -Server"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected %q in snippets file:\n%s", want, content)
+		}
+	}
+}
+
+func TestCaddySnippetRequiresCaddy(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+	oldDeps := deps
+	deps = NewMockDeps().WithDriver(mockDrv).Build()
+	defer func() { deps = oldDeps }()
+
+	err := runCaddySnippetList(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "caddy driver") {
+		t.Errorf("expected caddy driver error, got %v", err)
+	}
+
+	vhostType, vhostRoot, proxyPass = "static", "/var/www/html", ""
+	caddyImports = []string{"logging"}
+	defer func() { caddyImports = nil }()
+
+	err = runAdd(nil, []string{"example.com"})
+	if err == nil || !strings.Contains(err.Error(), "caddy driver") {
+		t.Errorf("expected --caddy-import to be rejected for nginx, got %v", err)
+	}
+}
@@ -1,36 +1,52 @@
 package cli
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
+	vherrors "github.com/ksyq12/vhost/internal/errors"
+	"github.com/ksyq12/vhost/internal/logger"
 	"github.com/ksyq12/vhost/internal/output"
+	"github.com/ksyq12/vhost/internal/ssl"
+	"github.com/ksyq12/vhost/internal/template"
+	"github.com/spf13/cobra"
 )
 
 // loadConfigAndDriver loads config and returns the appropriate driver
 func loadConfigAndDriver() (*config.Config, driver.Driver, error) {
 	cfg, err := deps.ConfigLoader.Load()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+		return nil, nil, vherrors.Wrap(vherrors.ErrCodeConfig, "failed to load config", err)
 	}
 
-	// Resolve paths: config override > platform detection
-	paths, err := resolvePathsWithDetector(cfg, deps.PlatformDetector)
+	// Use custom template overrides if configured
+	template.SetTemplateDir(cfg.TemplateDir)
+
+	// --profile picks the server; cfg itself is returned so saves never
+	// copy the profile's settings to the top level
+	active, err := cfg.WithProfile(profileName)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, vherrors.Newf(vherrors.ErrCodeConfig, "%v", err)
 	}
 
-	// Create driver with factory
-	drv, err := deps.DriverFactory.Create(cfg.Driver, paths)
+	drv, err := newDriver(active)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -38,14 +54,66 @@ func loadConfigAndDriver() (*config.Config, driver.Driver, error) {
 	return cfg, drv, nil
 }
 
+// newDriver creates the driver for active, a config with the profile
+// already applied, with its paths resolved and its commands set
+func newDriver(active *config.Config) (driver.Driver, error) {
+	// Resolve paths: config override > platform detection
+	paths, err := resolvePathsWithDetector(active, deps.PlatformDetector)
+	if err != nil {
+		return nil, vherrors.Wrap(vherrors.ErrCodeConfig, "failed to resolve "+active.Driver+" paths", err)
+	}
+
+	// Custom nginx installs may keep the main config elsewhere
+	if active.Driver == "nginx" && active.NginxConfigPath != "" {
+		if !filepath.IsAbs(active.NginxConfigPath) {
+			return nil, vherrors.Newf(vherrors.ErrCodeConfig, "nginx_config_path must be an absolute path: %s", active.NginxConfigPath)
+		}
+		paths.ConfigPath = active.NginxConfigPath
+	}
+
+	// Custom systems may test or reload the server their own way
+	if paths.TestCommand, err = config.SplitCommand(active.TestCommand); err != nil {
+		return nil, vherrors.Wrap(vherrors.ErrCodeConfig, "test_command", err)
+	}
+	if paths.ReloadCommand, err = config.SplitCommand(active.ReloadCommand); err != nil {
+		return nil, vherrors.Wrap(vherrors.ErrCodeConfig, "reload_command", err)
+	}
+
+	// Systems without usable symlinks enable configs by copying them; the
+	// config wins over the detected platform's default
+	if active.ActivationMode != "" {
+		paths.ActivationMode = active.ActivationMode
+	}
+
+	logger.DebugFields("Resolved driver", map[string]interface{}{
+		"driver":    active.Driver,
+		"profile":   profileName,
+		"available": paths.Available,
+		"enabled":   paths.Enabled,
+	})
+
+	// Server commands stop with the command, e.g. on --timeout
+	paths.Context = runCtx
+
+	// Create driver with factory
+	return deps.DriverFactory.Create(active.Driver, paths)
+}
+
+// Environment variables that point the driver at config directories
+// without a config file, e.g. in containers
+const (
+	availablePathEnv = "VHOST_AVAILABLE"
+	enabledPathEnv   = "VHOST_ENABLED"
+)
+
 // resolvePaths determines the paths to use for the driver.
-// Priority: config override > platform auto-detection
+// Priority: config override > VHOST_AVAILABLE/VHOST_ENABLED > platform auto-detection
 func resolvePaths(cfg *config.Config) (driver.Paths, error) {
 	return resolvePathsWithDetector(cfg, deps.PlatformDetector)
 }
 
 // resolvePathsWithDetector determines paths using an injectable detector.
-// Priority: config override > platform auto-detection
+// Priority: config override > VHOST_AVAILABLE/VHOST_ENABLED > platform auto-detection
 func resolvePathsWithDetector(cfg *config.Config, detector PlatformDetector) (driver.Paths, error) {
 	// Priority 1: Use config paths if provided
 	if cfg.Paths != nil && cfg.Paths.Available != "" && cfg.Paths.Enabled != "" {
@@ -68,14 +136,34 @@ func resolvePathsWithDetector(cfg *config.Config, detector PlatformDetector) (dr
 		return driver.Paths{}, fmt.Errorf("both paths.available and paths.enabled must be set if either is specified")
 	}
 
-	// Priority 2: Auto-detect platform paths
+	// Priority 2: Use environment paths if provided
+	envAvailable, envEnabled := os.Getenv(availablePathEnv), os.Getenv(enabledPathEnv)
+	if envAvailable != "" || envEnabled != "" {
+		if envAvailable == "" || envEnabled == "" {
+			return driver.Paths{}, fmt.Errorf("both %s and %s must be set if either is specified", availablePathEnv, enabledPathEnv)
+		}
+		if !filepath.IsAbs(envAvailable) {
+			return driver.Paths{}, fmt.Errorf("%s must be an absolute path: %s", availablePathEnv, envAvailable)
+		}
+		if !filepath.IsAbs(envEnabled) {
+			return driver.Paths{}, fmt.Errorf("%s must be an absolute path: %s", enabledPathEnv, envEnabled)
+		}
+
+		return driver.Paths{
+			Available: envAvailable,
+			Enabled:   envEnabled,
+		}, nil
+	}
+
+	// Priority 3: Auto-detect platform paths
 	platformPaths, err := detector.DetectPaths()
 	if err != nil {
 		return driver.Paths{}, fmt.Errorf("failed to detect platform paths: %w\n\n"+
 			"To manually configure paths, add to ~/.config/vhost/config.yaml:\n"+
 			"paths:\n"+
 			"  available: /path/to/sites-available\n"+
-			"  enabled: /path/to/sites-enabled", err)
+			"  enabled: /path/to/sites-enabled\n\n"+
+			"or set %s and %s", err, availablePathEnv, enabledPathEnv)
 	}
 
 	// Get paths for the configured driver
@@ -85,30 +173,73 @@ func resolvePathsWithDetector(cfg *config.Config, detector PlatformDetector) (dr
 	}
 
 	return driver.Paths{
-		Available: pathConfig.Available,
-		Enabled:   pathConfig.Enabled,
+		Available:      pathConfig.Available,
+		Enabled:        pathConfig.Enabled,
+		ActivationMode: platformPaths.ActivationMode,
 	}, nil
 }
 
-// createDriverWithPaths creates a driver instance with the specified paths.
+// createDriverWithPaths creates a driver instance with the specified paths
+// through the driver registry, so every registered driver is available.
 func createDriverWithPaths(driverName string, paths driver.Paths) (driver.Driver, error) {
+	if err := checkDriverPaths(paths); err != nil {
+		return nil, err
+	}
+	return driver.New(driverName, paths)
+}
+
+// checkDriverPaths turns a config directory that is a file or a symlink
+// loop into a clear error up front, instead of a cryptic failure from the
+// driver later. A directory that doesn't exist yet only gets a warning.
+func checkDriverPaths(paths driver.Paths) error {
+	for _, dir := range []struct{ name, path string }{
+		{"available", paths.Available},
+		{"enabled", paths.Enabled},
+	} {
+		info, err := os.Stat(dir.path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			warn("The %s directory %s does not exist yet", dir.name, dir.path)
+		case errors.Is(err, syscall.ELOOP):
+			return fmt.Errorf("%s path %s is a symlink loop", dir.name, dir.path)
+		case err != nil:
+			return fmt.Errorf("cannot use %s path %s: %w", dir.name, dir.path, err)
+		case !info.IsDir():
+			return fmt.Errorf("%s path %s is not a directory", dir.name, dir.path)
+		}
+	}
+	return nil
+}
+
+// driverConfigFileName returns the config file name a driver uses for a domain
+// (apache uses a .conf extension, traefik uses .yml)
+func driverConfigFileName(driverName, domain string) string {
 	switch driverName {
-	case "nginx":
-		return driver.NewNginxWithPaths(paths.Available, paths.Enabled), nil
 	case "apache":
-		return driver.NewApacheWithPaths(paths.Available, paths.Enabled), nil
-	case "caddy":
-		return driver.NewCaddyWithPaths(paths.Available, paths.Enabled), nil
+		return domain + ".conf"
+	case "traefik":
+		return domain + ".yml"
 	default:
-		return nil, fmt.Errorf("unknown driver: %s (available: nginx, apache, caddy)", driverName)
+		return domain
+	}
+}
+
+// hasEnabledEntry reports whether the enabled directory holds an entry for
+// domain. Unlike IsEnabled it also counts an outdated copy, which the server
+// still loads, so commands taking sites down don't leave it behind.
+func hasEnabledEntry(drv driver.Driver, domain string) bool {
+	if enabled, _ := drv.IsEnabled(domain); enabled {
+		return true
 	}
+	_, err := os.Lstat(filepath.Join(drv.Paths().Enabled, driverConfigFileName(drv.Name(), domain)))
+	return err == nil
 }
 
 // testAndReload tests config and reloads the web server
 // If rollback is provided, it will be called on test failure
 func testAndReload(drv driver.Driver, reload bool, rollback func() error) error {
 	output.Info("Testing configuration...")
-	if err := drv.Test(); err != nil {
+	if err := timed(drv.Name()+" config test", drv.Test); err != nil {
 		if rollback != nil {
 			if rbErr := rollback(); rbErr != nil {
 				output.Warn("Rollback failed: %v", rbErr)
@@ -117,9 +248,26 @@ func testAndReload(drv driver.Driver, reload bool, rollback func() error) error
 		return fmt.Errorf("configuration test failed: %w", err)
 	}
 
+	// Interrupted before the reload: undo like a failed test
+	if err := checkInterrupted(runCtx); err != nil {
+		if rollback != nil {
+			if rbErr := rollback(); rbErr != nil {
+				output.Warn("Rollback failed: %v", rbErr)
+			}
+		}
+		return err
+	}
+
 	if reload {
 		output.Info("Reloading %s...", drv.Name())
-		if err := drv.Reload(); err != nil {
+		if err := reloadServer(drv); err != nil {
+			// A reload cut short by --timeout or Ctrl-C is undone, so
+			// config.yaml and the server files stay in step
+			if runCtx.Err() != nil && rollback != nil {
+				if rbErr := rollback(); rbErr != nil {
+					output.Warn("Rollback failed: %v", rbErr)
+				}
+			}
 			return fmt.Errorf("failed to reload %s: %w", drv.Name(), err)
 		}
 	}
@@ -127,8 +275,105 @@ func testAndReload(drv driver.Driver, reload bool, rollback func() error) error
 	return nil
 }
 
+// reloadStampFile in the config directory holds the time vhost last
+// reloaded the web server, which doctor --since-reload compares with
+const reloadStampFile = "last-reload"
+
+// reloadStampPath returns the reload record of the active profile's server
+func reloadStampPath() (string, error) {
+	dir, err := deps.ConfigLoader.Dir()
+	if err != nil {
+		return "", err
+	}
+	if profileName != "" {
+		return filepath.Join(dir, reloadStampFile+"-"+profileName), nil
+	}
+	return filepath.Join(dir, reloadStampFile), nil
+}
+
+// reloadServer reloads drv and records when, so a reload without a restart
+// doesn't leave doctor --since-reload reporting stale configs
+func reloadServer(drv driver.Driver) error {
+	if err := timed(drv.Name()+" reload", drv.Reload); err != nil {
+		return err
+	}
+	path, err := reloadStampPath()
+	if err == nil {
+		err = os.WriteFile(path, []byte(time.Now().UTC().Format(time.RFC3339Nano)+"\n"), 0644)
+	}
+	if err != nil {
+		logger.Debug("Could not record the reload time: %v", err)
+	}
+	return nil
+}
+
+// lastReload returns when vhost last reloaded the active server; ok is
+// false when it has no record
+func lastReload() (time.Time, bool) {
+	path, err := reloadStampPath()
+	if err != nil {
+		return time.Time{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	reloaded, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return reloaded, true
+}
+
+// timed runs fn and logs how long it took at Debug level, so slow reloads
+// and certbot runs show up with --verbose. fn's error is returned as is.
+func timed(name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+
+	fields := map[string]interface{}{"duration_ms": time.Since(start).Milliseconds()}
+	if err != nil {
+		fields["error"] = err
+	}
+	logger.DebugFields(name, fields)
+	return err
+}
+
+// errInterrupted is returned when a signal cancels a command between steps
+var errInterrupted = errors.New("operation interrupted")
+
+// runCtx is the context of the running command, for steps such as confirm
+// that have no cmd at hand. It is only cancelled for interruptible commands
+// and by --timeout; tests leave it at the background context.
+var runCtx = context.Background()
+
+// setRunContext sets runCtx, and makes the server and certbot commands
+// started from now on stop when it is done
+func setRunContext(ctx context.Context) {
+	runCtx = ctx
+	ssl.SetContext(ctx)
+}
+
+// commandContext returns the context of cmd, or a background context when
+// the command runs without one (as in tests)
+func commandContext(cmd *cobra.Command) context.Context {
+	if cmd != nil && cmd.Context() != nil {
+		return cmd.Context()
+	}
+	return context.Background()
+}
+
+// checkInterrupted returns errInterrupted once ctx is cancelled
+func checkInterrupted(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return errInterrupted
+	}
+	return nil
+}
+
 // saveConfig saves the config and returns error instead of just warning
 func saveConfig(cfg *config.Config) error {
+	logger.Debug("Saving config with %d vhost(s)", len(cfg.VHosts))
 	if err := deps.ConfigLoader.Save(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
@@ -144,13 +389,32 @@ func outputResult(data interface{}, successMsg string, args ...interface{}) erro
 	return nil
 }
 
+// warn prints a warning that isn't part of a command's result. With --json
+// it goes to stderr through the logger, so stdout stays one JSON document.
+func warn(format string, args ...interface{}) {
+	if jsonOutput {
+		logger.Warn(format, args...)
+		return
+	}
+	output.Warn(format, args...)
+}
+
 // Maximum domain length according to RFC 1035
 const maxDomainLength = 253
 
 // domainPattern validates domain format (RFC 1035 compliant)
 var domainPattern = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
 
-// validateDomain checks if domain is valid and secure.
+// validateDomain checks domain with checkDomainName and reports a problem
+// as a validation error
+func validateDomain(domain string) error {
+	if err := checkDomainName(domain); err != nil {
+		return vherrors.Validation(err.Error())
+	}
+	return nil
+}
+
+// checkDomainName checks if domain is valid and secure.
 // Validates against:
 //   - Empty or whitespace-only input
 //   - Maximum length (253 characters per RFC 1035)
@@ -158,7 +422,7 @@ var domainPattern = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0
 //   - Shell metacharacters (;|&$`<>)
 //   - Null byte injection
 //   - RFC 1035 domain format compliance
-func validateDomain(domain string) error {
+func checkDomainName(domain string) error {
 	// Check for empty domain
 	if domain == "" {
 		return fmt.Errorf("domain cannot be empty")
@@ -260,6 +524,340 @@ func validateProxyURL(proxyURL string) error {
 	return nil
 }
 
+// validateRedirectURL checks that a redirect target is an absolute http(s)
+// URL that can be written into every server's config unquoted
+func validateRedirectURL(target string) error {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid redirect URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("invalid redirect URL %q: use an http:// or https:// URL", target)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("invalid redirect URL %q: missing host", target)
+	}
+	if strings.ContainsAny(target, " \t\r\n\"';{}$\\") {
+		return fmt.Errorf("invalid redirect URL %q: must not contain whitespace, quotes, ';', '{', '}', '$' or '\\'", target)
+	}
+	return nil
+}
+
+// sortedDomains returns the configured vhost domains in sorted order
+func sortedDomains(cfg *config.Config) []string {
+	domains := make([]string, 0, len(cfg.VHosts))
+	for domain := range cfg.VHosts {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// findDomainConflict reports the vhost that already claims domain or one of
+// aliases, either as its domain or as one of its own aliases. Names are
+// compared case-insensitively.
+func findDomainConflict(cfg *config.Config, domain string, aliases []string) (string, bool) {
+	wanted := make(map[string]bool, len(aliases)+1)
+	wanted[strings.ToLower(domain)] = true
+	for _, alias := range aliases {
+		wanted[strings.ToLower(alias)] = true
+	}
+
+	for _, key := range sortedDomains(cfg) {
+		vhost := cfg.VHosts[key]
+		if vhost == nil {
+			continue
+		}
+		for _, name := range append([]string{vhost.Domain}, vhost.Aliases...) {
+			if wanted[strings.ToLower(name)] {
+				return key, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// ownerPartPattern matches a user or group name, or a numeric id
+var ownerPartPattern = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_.-]*\$?|[0-9]+)$`)
+
+// validateOwner checks that owner is "user" or "user:group"
+func validateOwner(owner string) error {
+	if owner == "" {
+		return nil
+	}
+
+	userName, groupName, hasGroup := strings.Cut(owner, ":")
+	if !ownerPartPattern.MatchString(userName) {
+		return fmt.Errorf("invalid owner %q: expected user or user:group", owner)
+	}
+	if hasGroup && !ownerPartPattern.MatchString(groupName) {
+		return fmt.Errorf("invalid owner %q: expected user or user:group", owner)
+	}
+
+	return nil
+}
+
+// validateSPA rejects the single-page app fallback on a non-static vhost
+func validateSPA(vhostType string, spa bool) error {
+	if spa && vhostType != config.TypeStatic {
+		return fmt.Errorf("--spa requires type static (got %s)", vhostType)
+	}
+	return nil
+}
+
+// validateStaticCache checks the static asset cache options, which only
+// apply to the types that serve files directly
+func validateStaticCache(vhostType string, disabled bool, maxAge string) error {
+	if !disabled && maxAge == "" {
+		return nil
+	}
+	if vhostType != config.TypeStatic && vhostType != config.TypeWordPress {
+		return fmt.Errorf("static asset caching requires type static or wordpress (got %s)", vhostType)
+	}
+	if disabled && maxAge != "" {
+		return fmt.Errorf("--static-cache-max-age cannot be combined with --no-static-cache")
+	}
+	_, err := template.StaticCacheSeconds(maxAge)
+	return err
+}
+
+// validateWPMultisite checks a WordPress multisite mode; empty means a
+// single site
+func validateWPMultisite(vhostType, mode string) error {
+	if mode == "" {
+		return nil
+	}
+	if mode != config.WPMultisiteSubdir && mode != config.WPMultisiteSubdomain {
+		return fmt.Errorf("invalid --wp-multisite value: %s (use %s or %s)", mode, config.WPMultisiteSubdir, config.WPMultisiteSubdomain)
+	}
+	if vhostType != config.TypeWordPress {
+		return fmt.Errorf("--wp-multisite requires type wordpress (got %s)", vhostType)
+	}
+	return nil
+}
+
+// fastCGISizePattern matches an nginx size such as 16k or 1m
+var fastCGISizePattern = regexp.MustCompile(`^[1-9][0-9]*[kKmM]?$`)
+
+// validateFastCGIOptions checks the FastCGI tuning values of a vhost. They
+// only apply to PHP-FPM types; empty values keep the server defaults.
+func validateFastCGIOptions(vhostType, timeout, buffers, bufferSize string) error {
+	if timeout == "" && buffers == "" && bufferSize == "" {
+		return nil
+	}
+
+	switch vhostType {
+	case config.TypePHP, config.TypeLaravel, config.TypeWordPress:
+	default:
+		return fmt.Errorf("fastcgi options require type php, laravel or wordpress (got %s)", vhostType)
+	}
+
+	if _, err := template.FastCGITimeoutSeconds(timeout); err != nil {
+		return err
+	}
+	if buffers != "" {
+		count, size, ok := strings.Cut(buffers, " ")
+		if !ok || !fastCGISizePattern.MatchString(count) || strings.ContainsAny(count, "kKmM") || !fastCGISizePattern.MatchString(size) {
+			return fmt.Errorf("invalid fastcgi buffers %q: expected \"<count> <size>\", e.g. \"16 16k\"", buffers)
+		}
+	}
+	if bufferSize != "" && !fastCGISizePattern.MatchString(bufferSize) {
+		return fmt.Errorf("invalid fastcgi buffer size %q: expected a size such as 32k", bufferSize)
+	}
+
+	return nil
+}
+
+// envKeyPattern matches environment variable names accepted by --env
+var envKeyPattern = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// parseEnvVars parses KEY=VALUE pairs from the --env flag.
+// Keys must match [A-Z_][A-Z0-9_]*; values are rendered inside double
+// quotes, so they may not contain newlines or double quotes.
+func parseEnvVars(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	envVars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --env %q: expected KEY=VALUE", pair)
+		}
+		if !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid env key %q: must match [A-Z_][A-Z0-9_]*", key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("env value for %s cannot contain newlines", key)
+		}
+		if strings.Contains(value, `"`) {
+			return nil, fmt.Errorf("env value for %s cannot contain double quotes", key)
+		}
+		if containsNullByte(value) {
+			return nil, fmt.Errorf("env value for %s contains null byte", key)
+		}
+		envVars[key] = value
+	}
+
+	return envVars, nil
+}
+
+// locationPathPattern matches a URL path prefix safe to embed in server configs
+var locationPathPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+/?$`)
+
+// parseLocations parses repeated --location <path>:<root> flags. Paths are
+// stored without a trailing slash and must be unique.
+func parseLocations(specs []string) ([]config.LocationBlock, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	locations := make([]config.LocationBlock, 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		path, root, ok := strings.Cut(spec, ":")
+		if !ok || path == "" || root == "" {
+			return nil, fmt.Errorf("invalid --location %q: expected <path>:<root>", spec)
+		}
+		if !locationPathPattern.MatchString(path) || containsPathTraversal(path) {
+			return nil, fmt.Errorf("invalid location path %q: must be an absolute URL path like /assets", path)
+		}
+		if err := validateRoot(root); err != nil {
+			return nil, fmt.Errorf("invalid location root for %s: %w", path, err)
+		}
+
+		path = strings.TrimSuffix(path, "/")
+		if seen[path] {
+			return nil, fmt.Errorf("duplicate location path %s", path)
+		}
+		seen[path] = true
+
+		locations = append(locations, config.LocationBlock{Path: path, Root: root})
+	}
+	return locations, nil
+}
+
+// errEditsKept is returned when the user declines to overwrite manual edits
+var errEditsKept = errors.New("manual edits kept; nothing changed (use --force to overwrite them)")
+
+// configHash returns the hash recorded in VHost.ConfigHash for content
+func configHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordConfigHash remembers content as the file vhost last rendered for
+// vhost, so later changes can tell manual edits from template upgrades
+func recordConfigHash(vhost *config.VHost, content string) {
+	vhost.ConfigHash = configHash(content)
+}
+
+// confirmOverwriteEdits checks the file on disk for stored before a
+// re-render replaces it. The file counts as untouched when it matches the
+// hash recorded when vhost last wrote it, or a fresh rendering for configs
+// without one. Otherwise it shows the edits and asks to go on; force skips
+// the question. It returns errEditsKept when the answer is no.
+func confirmOverwriteEdits(drv driver.Driver, stored *config.VHost, force bool) error {
+	configPath := filepath.Join(drv.Paths().Available, driverConfigFileName(drv.Name(), stored.Domain))
+	onDisk, err := os.ReadFile(configPath)
+	if err != nil {
+		// Nothing on disk to lose
+		return nil
+	}
+	if stored.ConfigHash != "" && configHash(string(onDisk)) == stored.ConfigHash {
+		return nil
+	}
+	rendered, err := template.Render(drv.Name(), stored)
+	if err != nil {
+		return nil
+	}
+
+	diff := diffConfig(rendered, string(onDisk), "rendered from config.yaml", configPath)
+	if diff == "" {
+		return nil
+	}
+
+	warn("%s has manual edits that re-rendering will discard", configPath)
+	if !jsonOutput {
+		output.Diff(diff)
+	}
+	if force {
+		return nil
+	}
+
+	ok, err := confirm("Overwrite the manual edits?", false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errEditsKept
+	}
+	return nil
+}
+
+// confirm asks a yes/no question on stdin. An empty answer selects the
+// default; --yes answers every prompt with yes without reading input.
+func confirm(prompt string, defaultYes bool) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+
+	choices := "[y/N]"
+	if defaultYes {
+		choices = "[Y/n]"
+	}
+	output.Print("%s %s: ", prompt, choices)
+
+	// Ctrl-C at the prompt aborts before anything has changed
+	type reply struct {
+		answer string
+		err    error
+	}
+	replies := make(chan reply, 1)
+	go func() {
+		answer, err := deps.StdinReader.ReadString('\n')
+		replies <- reply{answer, err}
+	}()
+	var r reply
+	select {
+	case r = <-replies:
+	case <-runCtx.Done():
+		return false, errInterrupted
+	}
+
+	answer, err := strings.TrimSpace(strings.ToLower(r.answer)), r.err
+	if err != nil && answer == "" {
+		return false, fmt.Errorf("failed to read confirmation (use --yes to skip): %w", err)
+	}
+
+	switch answer {
+	case "":
+		return defaultYes, nil
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// readStdin reads the rest of standard input through deps.StdinReader, for
+// flags that take "-" in place of a file
+func readStdin() ([]byte, error) {
+	var content strings.Builder
+	for {
+		line, err := deps.StdinReader.ReadString('\n')
+		content.WriteString(line)
+		if errors.Is(err, io.EOF) {
+			return []byte(content.String()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
 // CommandResult represents a common result structure for CLI commands
 type CommandResult struct {
 	Success bool   `json:"success"`
@@ -279,22 +877,134 @@ func newSuccessResult(domain, action string) CommandResult {
 
 // getCertExpiry reads an SSL certificate and returns its expiry time
 func getCertExpiry(certPath string) (time.Time, error) {
+	cert, err := readCertificate(certPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// readCertificate parses the first PEM certificate in certPath
+func readCertificate(certPath string) (*x509.Certificate, error) {
 	data, err := os.ReadFile(certPath)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to read certificate: %w", err)
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
 	}
 
 	block, _ := pem.Decode(data)
 	if block == nil {
-		return time.Time{}, fmt.Errorf("failed to parse certificate PEM")
+		return nil, fmt.Errorf("failed to parse certificate PEM")
 	}
 
 	cert, err := x509.ParseCertificate(block.Bytes)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to parse certificate: %w", err)
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
 	}
 
-	return cert.NotAfter, nil
+	return cert, nil
+}
+
+// uncoveredNames returns the vhost's domain and aliases that cert is not
+// valid for, honouring wildcard SANs
+func uncoveredNames(cert *x509.Certificate, vhost *config.VHost) []string {
+	var missing []string
+	for _, name := range append([]string{vhost.Domain}, vhost.Aliases...) {
+		if err := cert.VerifyHostname(name); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// sharedSSLFile is an SSL certificate or key file used by more than one vhost
+type sharedSSLFile struct {
+	Kind    string   // "certificate" or "key"
+	Path    string   // cleaned path
+	Domains []string // sorted config keys of the vhosts using it
+}
+
+// findSharedSSLFiles returns the certificate and key files that more than
+// one SSL vhost points at, usually a copy-paste mistake: renewing the
+// certificate for one vhost silently changes the others. Vhosts sharing
+// both files of a certificate that covers all of their names (a SAN or
+// wildcard certificate) are one logical certificate and are not reported.
+func findSharedSSLFiles(cfg *config.Config) []sharedSSLFile {
+	certs := make(map[string][]string)
+	keys := make(map[string][]string)
+	for _, domain := range sortedDomains(cfg) {
+		vhost := cfg.VHosts[domain]
+		if vhost == nil || !vhost.SSL {
+			continue
+		}
+		if vhost.SSLCert != "" {
+			path := filepath.Clean(vhost.SSLCert)
+			certs[path] = append(certs[path], domain)
+		}
+		if vhost.SSLKey != "" {
+			path := filepath.Clean(vhost.SSLKey)
+			keys[path] = append(keys[path], domain)
+		}
+	}
+
+	var shared []sharedSSLFile
+	for _, group := range []struct {
+		kind  string
+		paths map[string][]string
+	}{{"certificate", certs}, {"key", keys}} {
+		paths := make([]string, 0, len(group.paths))
+		for path := range group.paths {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			domains := group.paths[path]
+			if len(domains) > 1 && !isOneCertificate(cfg, domains) {
+				shared = append(shared, sharedSSLFile{Kind: group.kind, Path: path, Domains: domains})
+			}
+		}
+	}
+	return shared
+}
+
+// isOneCertificate reports whether the vhosts named by domains all use the
+// same certificate and key, and the certificate covers every one of them
+func isOneCertificate(cfg *config.Config, domains []string) bool {
+	first := cfg.VHosts[domains[0]]
+	for _, domain := range domains[1:] {
+		vhost := cfg.VHosts[domain]
+		if filepath.Clean(vhost.SSLCert) != filepath.Clean(first.SSLCert) || filepath.Clean(vhost.SSLKey) != filepath.Clean(first.SSLKey) {
+			return false
+		}
+	}
+
+	cert, err := readCertificate(first.SSLCert)
+	if err != nil {
+		return false
+	}
+	for _, domain := range domains {
+		if len(uncoveredNames(cert, cfg.VHosts[domain])) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// checkKeyPair checks that keyPath holds the private key for the
+// certificate in certPath
+func checkKeyPair(certPath, keyPath string) error {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return fmt.Errorf("failed to read certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return fmt.Errorf("SSL key does not match certificate: %w", err)
+	}
+	return nil
 }
 
 // getEditor returns the user's preferred editor
@@ -307,10 +1017,7 @@ func getEditor() string {
 
 // parseLogPaths extracts access_log and error_log paths from a config file
 func parseLogPaths(drv driver.Driver, domain string) (accessLog, errorLog string, err error) {
-	configPath := filepath.Join(drv.Paths().Available, domain)
-	if drv.Name() == "apache" {
-		configPath = filepath.Join(drv.Paths().Available, domain+".conf")
-	}
+	configPath := filepath.Join(drv.Paths().Available, driverConfigFileName(drv.Name(), domain))
 
 	content, err := os.ReadFile(configPath)
 	if err != nil {
@@ -376,6 +1083,22 @@ func parseCaddyLogPath(config string) string {
 	return ""
 }
 
+// driverLogDir returns the default log directory for a driver
+func driverLogDir(driverName string) string {
+	switch driverName {
+	case "nginx":
+		return "/var/log/nginx"
+	case "apache":
+		return "/var/log/apache2"
+	case "caddy":
+		return "/var/log/caddy"
+	case "traefik":
+		return "/var/log/traefik"
+	default:
+		return ""
+	}
+}
+
 // getDefaultLogPath returns default log path for a driver
 func getDefaultLogPath(driverName, domain, logType string) string {
 	switch driverName {
@@ -439,6 +1162,44 @@ func isValidDomainFormat(domain string) bool {
 	return domainPattern.MatchString(domain)
 }
 
+// resolveDomain maps an alias to the primary domain of the vhost serving it.
+// Unknown names are returned unchanged so commands can still act on
+// server configs that aren't tracked in config.yaml.
+func resolveDomain(cfg *config.Config, name string) string {
+	if vhost, found := cfg.FindByDomainOrAlias(name); found {
+		return vhost.Domain
+	}
+	return name
+}
+
+// domainOrAllArgs accepts exactly one domain, or none when the --all flag
+// behind all is set
+func domainOrAllArgs(all *bool) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if *all {
+			if len(args) > 0 {
+				return fmt.Errorf("--all does not take a domain")
+			}
+			return nil
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	}
+}
+
+// domainOrFileArgs accepts a single domain, or no arguments when the flag
+// naming a file of domains is set
+func domainOrFileArgs(path *string) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if *path != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("--domains-file does not take a domain")
+			}
+			return nil
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	}
+}
+
 // requireRoot checks if the current process is running as root (UID 0).
 // Returns an error if not running as root, enforcing security policy.
 func requireRoot() error {
@@ -461,6 +1222,38 @@ type DryRunResult struct {
 	ConfigPreview string            `json:"config_preview,omitempty"`
 }
 
+// outputBulkDryRun outputs a dry-run for an --all operation: one symlink
+// action per domain followed by a single test and reload
+func outputBulkDryRun(domains []string, action, drvName, enabledDir string) error {
+	operations := make([]DryRunOperation, 0, len(domains)+2)
+	for _, domain := range domains {
+		operations = append(operations, DryRunOperation{
+			Action: action,
+			Target: filepath.Join(enabledDir, driverConfigFileName(drvName, domain)),
+		})
+	}
+
+	if !noReload {
+		operations = append(operations,
+			DryRunOperation{
+				Action:  "test_config",
+				Target:  drvName,
+				Details: "Validate configuration syntax",
+			},
+			DryRunOperation{
+				Action:  "reload_server",
+				Target:  drvName,
+				Details: "Apply configuration changes",
+			},
+		)
+	}
+
+	return outputDryRun(&DryRunResult{
+		Domain:     strings.Join(domains, ", "),
+		Operations: operations,
+	})
+}
+
 // outputDryRun outputs dry-run operation details
 func outputDryRun(result *DryRunResult) error {
 	result.DryRun = true
@@ -490,3 +1283,151 @@ func outputDryRun(result *DryRunResult) error {
 	output.Success("[DRY-RUN] VHost %s would be processed successfully", result.Domain)
 	return nil
 }
+
+// diffContext is the number of unchanged lines diffConfig shows around a change
+const diffContext = 3
+
+// diffEdit is one line of a line diff. oldLine and newLine are the 0-based
+// positions in each text where the line sits.
+type diffEdit struct {
+	op      byte // ' ', '-' or '+'
+	text    string
+	oldLine int
+	newLine int
+}
+
+// diffConfig returns a unified diff from oldText to newText, or "" when
+// they are identical. Config files are small, so a plain LCS is enough.
+func diffConfig(oldText, newText, oldLabel, newLabel string) string {
+	if oldText == newText {
+		return ""
+	}
+	a, b := splitLines(oldText), splitLines(newText)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []diffEdit
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, diffEdit{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, diffEdit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, diffEdit{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldLabel, newLabel)
+	for start := 0; start < len(edits); {
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		// Changes at most two contexts apart share a hunk
+		end := start
+		for k := start; k < len(edits) && k-end <= 2*diffContext+1; k++ {
+			if edits[k].op != ' ' {
+				end = k
+			}
+		}
+		lo, hi := max(start-diffContext, 0), min(end+diffContext+1, len(edits))
+
+		oldCount, newCount := 0, 0
+		for _, e := range edits[lo:hi] {
+			if e.op != '+' {
+				oldCount++
+			}
+			if e.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(edits[lo].oldLine, oldCount), hunkRange(edits[lo].newLine, newCount))
+		for _, e := range edits[lo:hi] {
+			sb.WriteByte(e.op)
+			sb.WriteString(e.text)
+			sb.WriteByte('\n')
+		}
+		start = hi
+	}
+	return sb.String()
+}
+
+// hunkRange formats a unified diff range from a 0-based start line
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits text into lines without their newlines
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// switchSites disables then enables the given domains, stopping at the first
+// failure. The returned rollback undoes whatever was applied, and is valid
+// even when an error is returned. Bulk enable, disable and maintenance share it.
+func switchSites(drv driver.Driver, toDisable, toEnable []string) (func() error, error) {
+	var disabled, enabled []string
+	rollback := func() error {
+		output.Info("Rolling back changes...")
+		var errs []error
+		for _, domain := range enabled {
+			if err := drv.Disable(domain); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", domain, err))
+			}
+		}
+		for _, domain := range disabled {
+			if err := drv.Enable(domain); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", domain, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, domain := range toDisable {
+		output.Info("Disabling %s...", domain)
+		if err := drv.Disable(domain); err != nil {
+			return rollback, fmt.Errorf("failed to disable %s: %w", domain, err)
+		}
+		disabled = append(disabled, domain)
+	}
+	for _, domain := range toEnable {
+		output.Info("Enabling %s...", domain)
+		if err := drv.Enable(domain); err != nil {
+			return rollback, fmt.Errorf("failed to enable %s: %w", domain, err)
+		}
+		enabled = append(enabled, domain)
+	}
+
+	return rollback, nil
+}
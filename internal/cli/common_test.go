@@ -1,10 +1,29 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
+	vherrors "github.com/ksyq12/vhost/internal/errors"
+	"github.com/ksyq12/vhost/internal/logger"
+	"github.com/ksyq12/vhost/internal/platform"
 )
 
 func TestValidateDomain(t *testing.T) {
@@ -84,6 +103,204 @@ func TestValidateProxyURL(t *testing.T) {
 	}
 }
 
+func TestValidateOwner(t *testing.T) {
+	tests := []struct {
+		name    string
+		owner   string
+		wantErr bool
+	}{
+		{"empty (allowed)", "", false},
+		{"user only", "www-data", false},
+		{"user and group", "www-data:www-data", false},
+		{"numeric ids", "33:33", false},
+		{"machine account", "svc$:staff", false},
+		{"empty group", "www-data:", true},
+		{"empty user", ":www-data", true},
+		{"too many parts", "a:b:c", true},
+		{"space", "www data", true},
+		{"shell metachar", "www;rm", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOwner(tt.owner)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateOwner(%q) error = %v, wantErr %v", tt.owner, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSPA(t *testing.T) {
+	if err := validateSPA("static", true); err != nil {
+		t.Errorf("expected --spa to be allowed on static, got %v", err)
+	}
+	if err := validateSPA("php", true); err == nil {
+		t.Error("expected --spa to be rejected on php")
+	}
+	if err := validateSPA("php", false); err != nil {
+		t.Errorf("expected no error without --spa, got %v", err)
+	}
+}
+
+func TestValidateWPMultisite(t *testing.T) {
+	tests := []struct {
+		name      string
+		vhostType string
+		mode      string
+		wantErr   bool
+	}{
+		{"single site", "php", "", false},
+		{"subdir", "wordpress", "subdir", false},
+		{"subdomain", "wordpress", "subdomain", false},
+		{"unknown mode", "wordpress", "subfolder", true},
+		{"non-wordpress type", "php", "subdir", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWPMultisite(tt.vhostType, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateWPMultisite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateStaticCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		vhostType string
+		disabled  bool
+		maxAge    string
+		wantErr   bool
+	}{
+		{"defaults on php", "php", false, "", false},
+		{"max-age on static", "static", false, "12h", false},
+		{"disabled on wordpress", "wordpress", true, "", false},
+		{"invalid max-age", "static", false, "30", true},
+		{"disabled with max-age", "static", true, "30d", true},
+		{"non-static type", "proxy", true, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStaticCache(tt.vhostType, tt.disabled, tt.maxAge)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateStaticCache() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateFastCGIOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		vhostType  string
+		timeout    string
+		buffers    string
+		bufferSize string
+		wantErr    bool
+	}{
+		{"unset on static", "static", "", "", "", false},
+		{"all set", "php", "300s", "16 16k", "32k", false},
+		{"minutes", "laravel", "5m", "", "", false},
+		{"megabyte buffers", "wordpress", "", "8 1m", "1M", false},
+		{"non-php type", "proxy", "300s", "", "", true},
+		{"bad duration", "php", "300", "", "", true},
+		{"fractional seconds", "php", "1500ms", "", "", true},
+		{"zero timeout", "php", "0s", "", "", true},
+		{"buffers missing size", "php", "", "16", "", true},
+		{"buffers unit on count", "php", "", "16k 16k", "", true},
+		{"bad buffer size", "php", "", "", "32kb", true},
+		{"injection", "php", "", "", "32k; deny all", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFastCGIOptions(tt.vhostType, tt.timeout, tt.buffers, tt.bufferSize)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateFastCGIOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseLocations(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []string
+		want    []config.LocationBlock
+		wantErr bool
+	}{
+		{"none", nil, nil, false},
+		{"single", []string{"/assets:/srv/assets"}, []config.LocationBlock{{Path: "/assets", Root: "/srv/assets"}}, false},
+		{"trailing slash trimmed", []string{"/static/:/srv/static"}, []config.LocationBlock{{Path: "/static", Root: "/srv/static"}}, false},
+		{"nested path", []string{"/media/uploads:/data/uploads"}, []config.LocationBlock{{Path: "/media/uploads", Root: "/data/uploads"}}, false},
+		{"missing root", []string{"/assets"}, nil, true},
+		{"relative path", []string{"assets:/srv/assets"}, nil, true},
+		{"root location", []string{"/:/srv/assets"}, nil, true},
+		{"path traversal", []string{"/../etc:/srv/assets"}, nil, true},
+		{"path with semicolon", []string{"/a;b:/srv/assets"}, nil, true},
+		{"relative root", []string{"/assets:srv/assets"}, nil, true},
+		{"root traversal", []string{"/assets:/srv/../etc"}, nil, true},
+		{"duplicate path", []string{"/assets:/srv/a", "/assets/:/srv/b"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLocations(tt.specs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLocations(%q) error = %v, wantErr %v", tt.specs, err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseLocations(%q) = %v, want %v", tt.specs, got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseLocations(%q)[%d] = %v, want %v", tt.specs, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseEnvVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"none", nil, nil, false},
+		{"single", []string{"APP_ENV=production"}, map[string]string{"APP_ENV": "production"}, false},
+		{"value with equals", []string{"DSN=a=b"}, map[string]string{"DSN": "a=b"}, false},
+		{"empty value", []string{"_FLAG="}, map[string]string{"_FLAG": ""}, false},
+		{"missing equals", []string{"APP_ENV"}, nil, true},
+		{"lowercase key", []string{"app_env=production"}, nil, true},
+		{"key starts with digit", []string{"1KEY=value"}, nil, true},
+		{"value with newline", []string{"APP_ENV=prod\nuction"}, nil, true},
+		{"value with quote", []string{`APP_ENV=prod"uction`}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnvVars(tt.pairs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEnvVars(%q) error = %v, wantErr %v", tt.pairs, err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseEnvVars(%q) = %v, want %v", tt.pairs, got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("parseEnvVars(%q)[%s] = %q, want %q", tt.pairs, k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
 func TestNewSuccessResult(t *testing.T) {
 	result := newSuccessResult("example.com", "added")
 
@@ -207,6 +424,79 @@ func TestResolvePaths(t *testing.T) {
 		}
 	})
 
+	t.Run("environment paths", func(t *testing.T) {
+		t.Setenv(availablePathEnv, "/ci/available")
+		t.Setenv(enabledPathEnv, "/ci/enabled")
+
+		paths, err := resolvePaths(&config.Config{Driver: "nginx"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if paths.Available != "/ci/available" || paths.Enabled != "/ci/enabled" {
+			t.Errorf("expected environment paths, got %+v", paths)
+		}
+	})
+
+	t.Run("config paths take priority over environment", func(t *testing.T) {
+		t.Setenv(availablePathEnv, "/ci/available")
+		t.Setenv(enabledPathEnv, "/ci/enabled")
+		cfg := &config.Config{
+			Driver: "nginx",
+			Paths: &config.DriverPaths{
+				Available: "/custom/available",
+				Enabled:   "/custom/enabled",
+			},
+		}
+
+		paths, err := resolvePaths(cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if paths.Available != "/custom/available" || paths.Enabled != "/custom/enabled" {
+			t.Errorf("expected config paths, got %+v", paths)
+		}
+	})
+
+	t.Run("invalid environment paths return error", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			available   string
+			enabled     string
+			errContains string
+		}{
+			{"only available", "/ci/available", "", "both VHOST_AVAILABLE and VHOST_ENABLED"},
+			{"only enabled", "", "/ci/enabled", "both VHOST_AVAILABLE and VHOST_ENABLED"},
+			{"relative available", "ci/available", "/ci/enabled", "VHOST_AVAILABLE must be an absolute path"},
+			{"relative enabled", "/ci/available", "./enabled", "VHOST_ENABLED must be an absolute path"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Setenv(availablePathEnv, tt.available)
+				t.Setenv(enabledPathEnv, tt.enabled)
+
+				_, err := resolvePaths(&config.Config{Driver: "nginx"})
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error containing %q, got %v", tt.errContains, err)
+				}
+			})
+		}
+	})
+
+	t.Run("detected activation mode", func(t *testing.T) {
+		detector := &MockPlatformDetector{Paths: &platform.PlatformPaths{
+			Nginx:          platform.PathConfig{Available: `C:\nginx\conf\sites-available`, Enabled: `C:\nginx\conf\sites-enabled`},
+			ActivationMode: config.ActivationCopy,
+		}}
+
+		paths, err := resolvePathsWithDetector(&config.Config{Driver: "nginx"}, detector)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if paths.Available != `C:\nginx\conf\sites-available` || paths.ActivationMode != config.ActivationCopy {
+			t.Errorf("expected detected paths with copy activation, got %+v", paths)
+		}
+	})
+
 	t.Run("auto-detection fallback", func(t *testing.T) {
 		cfg := &config.Config{
 			Driver: "nginx",
@@ -230,6 +520,73 @@ func TestResolvePaths(t *testing.T) {
 	})
 }
 
+func TestCheckDriverPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	dir := filepath.Join(tempDir, "dir")
+	file := filepath.Join(tempDir, "file")
+	loop := filepath.Join(tempDir, "loop")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("not a directory"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(loop+"2", loop); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(loop, loop+"2"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		paths   driver.Paths
+		wantErr string
+	}{
+		{"directories", driver.Paths{Available: dir, Enabled: dir}, ""},
+		{"missing directories only warn", driver.Paths{Available: filepath.Join(tempDir, "missing"), Enabled: dir}, ""},
+		{"available is a file", driver.Paths{Available: file, Enabled: dir}, "available path " + file + " is not a directory"},
+		{"enabled is a file", driver.Paths{Available: dir, Enabled: file}, "enabled path " + file + " is not a directory"},
+		{"symlink loop", driver.Paths{Available: loop, Enabled: dir}, "is a symlink loop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDriverPaths(tt.paths)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("missing directory warning stays off stdout with --json", func(t *testing.T) {
+		jsonOutput = true
+		defer func() { jsonOutput = false }()
+
+		out := captureOutput(func() {
+			if err := checkDriverPaths(driver.Paths{Available: filepath.Join(tempDir, "missing"), Enabled: dir}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+		if out != "" {
+			t.Errorf("expected no stdout, got %q", out)
+		}
+	})
+
+	t.Run("driver creation fails early", func(t *testing.T) {
+		_, err := createDriverWithPaths("nginx", driver.Paths{Available: file, Enabled: dir})
+		if err == nil || !strings.Contains(err.Error(), "not a directory") {
+			t.Errorf("expected not a directory error, got %v", err)
+		}
+	})
+}
+
 func TestCreateDriverWithPaths(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -239,9 +596,16 @@ func TestCreateDriverWithPaths(t *testing.T) {
 		{"nginx", "nginx", false},
 		{"apache", "apache", false},
 		{"caddy", "caddy", false},
+		{"traefik", "traefik", false},
+		{"registered", "custom", false},
 		{"unknown", "unknown", true},
 	}
 
+	// Third-party drivers registered with a factory are created the same way
+	driver.RegisterFactory("custom", func(paths driver.Paths) driver.Driver {
+		return driver.NewMockDriver("custom", paths.Available, paths.Enabled)
+	})
+
 	paths := driver.Paths{
 		Available: "/test/available",
 		Enabled:   "/test/enabled",
@@ -276,6 +640,266 @@ func TestCreateDriverWithPaths(t *testing.T) {
 	}
 }
 
+// pathsRecordingFactory records the paths a driver was created with
+type pathsRecordingFactory struct {
+	paths driver.Paths
+}
+
+func (f *pathsRecordingFactory) Create(name string, paths driver.Paths) (driver.Driver, error) {
+	f.paths = paths
+	return driver.NewMockDriver(name, paths.Available, paths.Enabled), nil
+}
+
+func TestLoadConfigAndDriverDetectionError(t *testing.T) {
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(config.New()).WithPlatformError(errors.New("no web server found")).Build()
+	defer func() { deps = oldDeps }()
+
+	_, _, err := loadConfigAndDriver()
+	if err == nil || !strings.Contains(err.Error(), "no web server found") {
+		t.Fatalf("expected the detection error, got %v", err)
+	}
+	if code := vherrors.CodeOf(err); code != vherrors.ErrCodeConfig {
+		t.Errorf("expected code %s, got %s", vherrors.ErrCodeConfig, code)
+	}
+}
+
+func TestLoadConfigAndDriverNginxConfigPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		driverName string
+		configPath string
+		want       string
+		wantErr    bool
+	}{
+		{"unset", "nginx", "", "", false},
+		{"absolute", "nginx", "/opt/nginx/conf/nginx.conf", "/opt/nginx/conf/nginx.conf", false},
+		{"relative", "nginx", "conf/nginx.conf", "", true},
+		{"ignored for other drivers", "apache", "/opt/nginx/conf/nginx.conf", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Driver:          tt.driverName,
+				NginxConfigPath: tt.configPath,
+				Paths: &config.DriverPaths{
+					Available: "/test/available",
+					Enabled:   "/test/enabled",
+				},
+				VHosts: make(map[string]*config.VHost),
+			}
+			factory := &pathsRecordingFactory{}
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithDriverFactory(factory).Build()
+			defer func() { deps = oldDeps }()
+
+			_, _, err := loadConfigAndDriver()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error but got nil")
+				} else if code := vherrors.CodeOf(err); code != vherrors.ErrCodeConfig {
+					t.Errorf("expected code %s, got %s", vherrors.ErrCodeConfig, code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if factory.paths.ConfigPath != tt.want {
+				t.Errorf("expected config path %q, got %q", tt.want, factory.paths.ConfigPath)
+			}
+		})
+	}
+}
+
+func TestLoadConfigAndDriverCommands(t *testing.T) {
+	tests := []struct {
+		name       string
+		test       string
+		reload     string
+		wantTest   []string
+		wantReload []string
+		wantErr    string
+	}{
+		{"unset", "", "", nil, nil, ""},
+		{"custom", "nginx -t", "docker exec web nginx -s reload", []string{"nginx", "-t"}, []string{"docker", "exec", "web", "nginx", "-s", "reload"}, ""},
+		{"quoted", "", `sh -c 'rc-service nginx reload'`, nil, []string{"sh", "-c", "rc-service nginx reload"}, ""},
+		{"unterminated", "", `sh -c "nginx`, nil, nil, "reload_command: command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Driver:        "nginx",
+				TestCommand:   tt.test,
+				ReloadCommand: tt.reload,
+				Paths: &config.DriverPaths{
+					Available: "/test/available",
+					Enabled:   "/test/enabled",
+				},
+				VHosts: make(map[string]*config.VHost),
+			}
+			factory := &pathsRecordingFactory{}
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithDriverFactory(factory).Build()
+			defer func() { deps = oldDeps }()
+
+			_, _, err := loadConfigAndDriver()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(factory.paths.TestCommand, tt.wantTest) || !slices.Equal(factory.paths.ReloadCommand, tt.wantReload) {
+				t.Errorf("expected commands %q and %q, got %q and %q", tt.wantTest, tt.wantReload, factory.paths.TestCommand, factory.paths.ReloadCommand)
+			}
+		})
+	}
+}
+
+func TestLoadConfigAndDriverActivationMode(t *testing.T) {
+	t.Setenv(availablePathEnv, "")
+	t.Setenv(enabledPathEnv, "")
+	detected := &platform.PlatformPaths{
+		Nginx:          platform.PathConfig{Available: "/test/available", Enabled: "/test/enabled"},
+		ActivationMode: config.ActivationCopy,
+	}
+
+	tests := []struct {
+		name string
+		mode string
+		want string
+	}{
+		{"platform default", "", config.ActivationCopy},
+		{"config override", config.ActivationSymlink, config.ActivationSymlink},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Driver: "nginx", ActivationMode: tt.mode, VHosts: make(map[string]*config.VHost)}
+			factory := &pathsRecordingFactory{}
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithPlatformPaths(detected).WithDriverFactory(factory).Build()
+			defer func() { deps = oldDeps }()
+
+			if _, _, err := loadConfigAndDriver(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if factory.paths.ActivationMode != tt.want {
+				t.Errorf("expected activation mode %q, got %q", tt.want, factory.paths.ActivationMode)
+			}
+		})
+	}
+}
+
+func TestLoadConfigAndDriverProfile(t *testing.T) {
+	newConfig := func() *config.Config {
+		cfg := config.New()
+		cfg.Paths = &config.DriverPaths{Available: "/prod/available", Enabled: "/prod/enabled"}
+		cfg.Profiles = map[string]*config.Profile{
+			"staging": {Paths: &config.DriverPaths{Available: "/staging/available", Enabled: "/staging/enabled"}},
+			"edge":    {Driver: "apache", Paths: &config.DriverPaths{Available: "/edge/available", Enabled: "/edge/enabled"}},
+		}
+		return cfg
+	}
+
+	tests := []struct {
+		name          string
+		profile       string
+		wantDriver    string
+		wantAvailable string
+		wantErr       string
+	}{
+		{"default", "", "nginx", "/prod/available", ""},
+		{"paths profile", "staging", "nginx", "/staging/available", ""},
+		{"driver profile", "edge", "apache", "/edge/available", ""},
+		{"unknown profile", "qa", "", "", "unknown profile: qa (available: edge, staging)"},
+	}
+
+	oldProfile := profileName
+	defer func() { profileName = oldProfile }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newConfig()
+			factory := &pathsRecordingFactory{}
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithDriverFactory(factory).Build()
+			defer func() { deps = oldDeps }()
+			profileName = tt.profile
+
+			loaded, drv, err := loadConfigAndDriver()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if code := vherrors.CodeOf(err); code != vherrors.ErrCodeConfig {
+					t.Errorf("expected code %s, got %s", vherrors.ErrCodeConfig, code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if drv.Name() != tt.wantDriver {
+				t.Errorf("driver = %s, want %s", drv.Name(), tt.wantDriver)
+			}
+			if factory.paths.Available != tt.wantAvailable {
+				t.Errorf("available = %s, want %s", factory.paths.Available, tt.wantAvailable)
+			}
+			// The returned config is the one to save, so it keeps the top level
+			if loaded.Driver != "nginx" || loaded.Paths.Available != "/prod/available" {
+				t.Errorf("top-level config changed: driver=%s paths=%+v", loaded.Driver, loaded.Paths)
+			}
+		})
+	}
+}
+
+func TestTimed(t *testing.T) {
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.Init(true)
+	defer func() {
+		logger.Init(false)
+		logger.SetOutput(os.Stderr)
+	}()
+
+	t.Run("success", func(t *testing.T) {
+		buf.Reset()
+		calls := 0
+		if err := timed("nginx reload", func() error { calls++; return nil }); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected fn to run once, ran %d times", calls)
+		}
+		out := buf.String()
+		if !strings.Contains(out, "[DEBUG]") || !strings.Contains(out, "nginx reload duration_ms=") || strings.Contains(out, "error=") {
+			t.Errorf("unexpected log output: %q", out)
+		}
+	})
+
+	t.Run("error is returned and logged", func(t *testing.T) {
+		buf.Reset()
+		want := errors.New("reload failed")
+		if err := timed("nginx reload", func() error { return want }); err != want {
+			t.Errorf("expected %v, got %v", want, err)
+		}
+		if out := buf.String(); !strings.Contains(out, "duration_ms=") || !strings.Contains(out, "error=reload failed") {
+			t.Errorf("unexpected log output: %q", out)
+		}
+	})
+}
+
 func TestDryRunOperation(t *testing.T) {
 	t.Run("create operation", func(t *testing.T) {
 		op := DryRunOperation{
@@ -340,3 +964,222 @@ func TestDryRunResult(t *testing.T) {
 		}
 	})
 }
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		defaultYes bool
+		assumeYes  bool
+		want       bool
+		wantErr    bool
+	}{
+		{"y", "y\n", false, false, true, false},
+		{"yes", "yes\n", false, false, true, false},
+		{"uppercase YES", "YES\n", false, false, true, false},
+		{"n", "n\n", true, false, false, false},
+		{"other answer is no", "maybe\n", true, false, false, false},
+		{"empty defaults to no", "\n", false, false, false, false},
+		{"empty defaults to yes", "\n", true, false, true, false},
+		{"no trailing newline", "y", false, false, true, false},
+		{"no input fails", "", false, false, false, true},
+		{"--yes skips prompt", "", false, true, true, false},
+		{"--yes overrides default no", "n\n", false, true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldDeps := deps
+			deps = NewMockDeps().WithStdinInput(tt.input).Build()
+			assumeYes = tt.assumeYes
+			defer func() {
+				deps = oldDeps
+				assumeYes = false
+			}()
+
+			got, err := confirm("Proceed?", tt.defaultYes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("confirm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("confirm() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// blockingStdinReader never returns, like a terminal nobody answers
+type blockingStdinReader struct{}
+
+func (blockingStdinReader) ReadString(delim byte) (string, error) {
+	select {}
+}
+
+func TestConfirmInterrupted(t *testing.T) {
+	oldDeps := deps
+	deps = NewMockDeps().Build()
+	deps.StdinReader = blockingStdinReader{}
+	ctx, cancel := context.WithCancel(context.Background())
+	setRunContext(ctx)
+	defer func() {
+		deps = oldDeps
+		setRunContext(context.Background())
+	}()
+
+	cancel()
+	if _, err := confirm("Proceed?", false); !errors.Is(err, errInterrupted) {
+		t.Errorf("expected an interrupted prompt to fail, got %v", err)
+	}
+}
+
+func TestFindDomainConflict(t *testing.T) {
+	cfg := config.New()
+	cfg.VHosts["example.com"] = &config.VHost{Domain: "example.com", Aliases: []string{"www.example.com"}}
+	cfg.VHosts["api.test"] = &config.VHost{Domain: "api.test"}
+
+	tests := []struct {
+		name      string
+		domain    string
+		aliases   []string
+		wantOwner string
+		wantFound bool
+	}{
+		{"domain vs domain", "api.test", nil, "api.test", true},
+		{"domain vs domain case-insensitive", "API.test", nil, "api.test", true},
+		{"domain vs alias", "www.example.com", nil, "example.com", true},
+		{"alias vs domain", "new.test", []string{"example.com"}, "example.com", true},
+		{"alias vs alias", "new.test", []string{"www.example.com"}, "example.com", true},
+		{"no conflict", "new.test", []string{"www.new.test"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, found := findDomainConflict(cfg, tt.domain, tt.aliases)
+			if found != tt.wantFound || owner != tt.wantOwner {
+				t.Errorf("findDomainConflict(%q, %v) = (%q, %v), want (%q, %v)", tt.domain, tt.aliases, owner, found, tt.wantOwner, tt.wantFound)
+			}
+		})
+	}
+}
+
+// writeTestCert writes a self-signed certificate for names and its private
+// key into dir, returning both paths
+func writeTestCert(t *testing.T, dir, name string, names ...string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return certPath, keyPath
+}
+
+func TestCertificateChecks(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeTestCert(t, dir, "site", "example.com", "*.example.com")
+	_, otherKey := writeTestCert(t, dir, "other", "other.com")
+
+	cert, err := readCertificate(certPath)
+	if err != nil {
+		t.Fatalf("readCertificate failed: %v", err)
+	}
+
+	t.Run("covered names", func(t *testing.T) {
+		vhost := &config.VHost{Domain: "example.com", Aliases: []string{"www.example.com"}}
+		if missing := uncoveredNames(cert, vhost); len(missing) != 0 {
+			t.Errorf("expected all names covered, missing %v", missing)
+		}
+	})
+
+	t.Run("uncovered names", func(t *testing.T) {
+		vhost := &config.VHost{Domain: "example.org", Aliases: []string{"www.example.com", "a.b.example.com"}}
+		missing := uncoveredNames(cert, vhost)
+		if strings.Join(missing, ",") != "example.org,a.b.example.com" {
+			t.Errorf("uncoveredNames() = %v", missing)
+		}
+	})
+
+	t.Run("matching key", func(t *testing.T) {
+		if err := checkKeyPair(certPath, keyPath); err != nil {
+			t.Errorf("expected matching pair, got %v", err)
+		}
+	})
+
+	t.Run("mismatched key", func(t *testing.T) {
+		err := checkKeyPair(certPath, otherKey)
+		if err == nil || !strings.Contains(err.Error(), "does not match") {
+			t.Errorf("expected mismatch error, got %v", err)
+		}
+	})
+}
+
+func TestDiffConfig(t *testing.T) {
+	lines := func(n int) []string {
+		out := make([]string, n)
+		for i := range out {
+			out[i] = "line " + strconv.Itoa(i+1)
+		}
+		return out
+	}
+	join := func(l []string) string { return strings.Join(l, "\n") + "\n" }
+
+	t.Run("identical", func(t *testing.T) {
+		if got := diffConfig("a\nb\n", "a\nb\n", "old", "new"); got != "" {
+			t.Errorf("expected no diff, got %q", got)
+		}
+	})
+
+	t.Run("single change", func(t *testing.T) {
+		old := lines(10)
+		changed := lines(10)
+		changed[4] = "edited"
+		want := "--- old\n+++ new\n@@ -2,7 +2,7 @@\n line 2\n line 3\n line 4\n-line 5\n+edited\n line 6\n line 7\n line 8\n"
+		if got := diffConfig(join(old), join(changed), "old", "new"); got != want {
+			t.Errorf("diff =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("distant changes get separate hunks", func(t *testing.T) {
+		changed := lines(20)
+		changed[0] = "first"
+		changed[19] = "last"
+		got := diffConfig(join(lines(20)), join(changed), "old", "new")
+		if strings.Count(got, "@@ -") != 2 {
+			t.Errorf("expected two hunks, got\n%s", got)
+		}
+		if !strings.Contains(got, "@@ -1,4 +1,4 @@\n-line 1\n+first\n") || !strings.Contains(got, "@@ -17,4 +17,4 @@\n") {
+			t.Errorf("unexpected hunks:\n%s", got)
+		}
+	})
+
+	t.Run("added lines at end", func(t *testing.T) {
+		got := diffConfig("a\n", "a\nb\n", "old", "new")
+		if want := "--- old\n+++ new\n@@ -1 +1,2 @@\n a\n+b\n"; got != want {
+			t.Errorf("diff = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate shell completion scripts",
+	Long: `Generate a shell completion script for vhost.
+
+Bash:
+  source <(vhost completion bash)
+  # Load for every session (Linux):
+  vhost completion bash | sudo tee /etc/bash_completion.d/vhost > /dev/null
+
+Zsh:
+  vhost completion zsh > "${fpath[1]}/_vhost"
+
+Fish:
+  vhost completion fish > ~/.config/fish/completions/vhost.fish
+
+PowerShell:
+  vhost completion powershell | Out-String | Invoke-Expression`,
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	RunE:                  runCompletion,
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	out := os.Stdout
+
+	switch args[0] {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(out, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(out)
+	case "fish":
+		return rootCmd.GenFishCompletion(out, true)
+	case "powershell":
+		return rootCmd.GenPowerShellCompletionWithDesc(out)
+	default:
+		return fmt.Errorf("unsupported shell: %s", args[0])
+	}
+}
+
+// validDomainsForCompletion completes the <domain> argument with the union of
+// vhosts in the config and sites the driver finds on disk. Errors yield no
+// completions instead of failing the shell.
+func validDomainsForCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	seen := make(map[string]bool, len(cfg.VHosts))
+	for domain := range cfg.VHosts {
+		seen[domain] = true
+	}
+
+	// Sites on disk but not in config are still worth completing
+	if listed, err := drv.List(); err == nil {
+		for _, domain := range listed {
+			seen[domain] = true
+		}
+	}
+
+	var domains []string
+	for domain := range seen {
+		if strings.HasPrefix(domain, toComplete) {
+			domains = append(domains, domain)
+		}
+	}
+	sort.Strings(domains)
+
+	return domains, cobra.ShellCompDirectiveNoFileComp
+}
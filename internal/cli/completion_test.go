@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/spf13/cobra"
+)
+
+func TestValidDomainsForCompletion(t *testing.T) {
+	cfg := config.New()
+	for _, domain := range []string{"api.example.com", "app.example.com", "blog.test"} {
+		cfg.VHosts[domain] = &config.VHost{Domain: domain, Type: config.TypeStatic}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		toComplete string
+		listFunc   func() ([]string, error)
+		want       []string
+	}{
+		{
+			name:       "partial prefix from config",
+			toComplete: "ap",
+			want:       []string{"api.example.com", "app.example.com"},
+		},
+		{
+			name:       "driver-only domains included and deduped",
+			toComplete: "a",
+			listFunc: func() ([]string, error) {
+				return []string{"app.example.com", "admin.example.com"}, nil
+			},
+			want: []string{"admin.example.com", "api.example.com", "app.example.com"},
+		},
+		{
+			name:       "empty prefix returns all sorted",
+			toComplete: "",
+			listFunc: func() ([]string, error) {
+				return []string{"legacy.local"}, nil
+			},
+			want: []string{"api.example.com", "app.example.com", "blog.test", "legacy.local"},
+		},
+		{
+			name:       "driver list error falls back to config",
+			toComplete: "bl",
+			listFunc: func() ([]string, error) {
+				return nil, errors.New("permission denied")
+			},
+			want: []string{"blog.test"},
+		},
+		{
+			name:       "no match",
+			toComplete: "zzz",
+		},
+		{
+			name:       "domain already given",
+			args:       []string{"api.example.com"},
+			toComplete: "a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+			mockDrv.ListFunc = tt.listFunc
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+			defer func() { deps = oldDeps }()
+
+			got, directive := validDomainsForCompletion(nil, tt.args, tt.toComplete)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validDomainsForCompletion(%q) = %v, want %v", tt.toComplete, got, tt.want)
+			}
+			if directive != cobra.ShellCompDirectiveNoFileComp {
+				t.Errorf("expected NoFileComp directive, got %v", directive)
+			}
+		})
+	}
+
+	t.Run("config load error returns no completions", func(t *testing.T) {
+		oldDeps := deps
+		deps = NewMockDeps().WithConfigLoader(&MockConfigLoader{LoadErr: errors.New("boom")}).Build()
+		defer func() { deps = oldDeps }()
+
+		got, directive := validDomainsForCompletion(nil, nil, "a")
+		if got != nil {
+			t.Errorf("expected no candidates, got %v", got)
+		}
+		if directive != cobra.ShellCompDirectiveNoFileComp {
+			t.Errorf("expected NoFileComp directive, got %v", directive)
+		}
+	})
+}
+
+func TestDomainCommandsComplete(t *testing.T) {
+	for _, cmd := range []*cobra.Command{enableCmd, disableCmd, removeCmd, showCmd, editCmd, logsCmd, sslInstallCmd, sslRenewCmd} {
+		if cmd.ValidArgsFunction == nil {
+			t.Errorf("%s has no domain completion", cmd.Name())
+		}
+	}
+}
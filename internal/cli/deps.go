@@ -2,7 +2,13 @@ package cli
 
 import (
 	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
@@ -16,12 +22,18 @@ type Dependencies struct {
 	DriverFactory    DriverFactory
 	RootChecker      RootChecker
 	StdinReader      StdinReader
+	ReleaseChecker   ReleaseChecker
+	Downloader       ReleaseDownloader
+	BinaryInstaller  BinaryInstaller
 }
 
 // ConfigLoader handles configuration loading and saving
 type ConfigLoader interface {
 	Load() (*config.Config, error)
+	LoadUnchecked() (*config.Config, error) // skips structural validation
 	Save(cfg *config.Config) error
+	Dir() (string, error)  // directory holding config.yaml and vhost's state files
+	Path() (string, error) // path of config.yaml
 }
 
 // PlatformDetector handles platform path detection
@@ -44,13 +56,33 @@ type StdinReader interface {
 	ReadString(delim byte) (string, error)
 }
 
+// ReleaseChecker looks up the latest published vhost release
+type ReleaseChecker interface {
+	LatestVersion() (string, error)
+}
+
+// ReleaseDownloader fetches release assets
+type ReleaseDownloader interface {
+	Download(url string) ([]byte, error)
+}
+
+// BinaryInstaller locates and replaces the running vhost binary
+type BinaryInstaller interface {
+	Executable() (string, error)
+	CheckWritable(path string) error
+	Replace(path string, binary []byte) error
+}
+
 // Package-level dependencies (can be overridden for testing)
 var deps = &Dependencies{
-	ConfigLoader:     &realConfigLoader{},
+	ConfigLoader:     config.NewLoader(""),
 	PlatformDetector: &realPlatformDetector{},
 	DriverFactory:    &realDriverFactory{},
 	RootChecker:      &realRootChecker{},
 	StdinReader:      &realStdinReader{},
+	ReleaseChecker:   &githubReleaseChecker{url: latestReleaseURL, timeout: releaseCheckTimeout},
+	Downloader:       &httpReleaseDownloader{timeout: releaseDownloadTimeout},
+	BinaryInstaller:  &realBinaryInstaller{},
 }
 
 // SetDeps replaces the package dependencies (for testing)
@@ -65,16 +97,6 @@ func GetDeps() *Dependencies {
 
 // Real implementations that delegate to existing functions
 
-type realConfigLoader struct{}
-
-func (r *realConfigLoader) Load() (*config.Config, error) {
-	return config.Load()
-}
-
-func (r *realConfigLoader) Save(cfg *config.Config) error {
-	return cfg.Save()
-}
-
 type realPlatformDetector struct{}
 
 func (r *realPlatformDetector) DetectPaths() (*platform.PlatformPaths, error) {
@@ -89,13 +111,6 @@ func (r *realDriverFactory) Create(name string, paths driver.Paths) (driver.Driv
 
 type realRootChecker struct{}
 
-func (r *realRootChecker) RequireRoot() error {
-	if os.Geteuid() != 0 {
-		return errRootRequired
-	}
-	return nil
-}
-
 type realStdinReader struct {
 	reader *bufio.Reader
 }
@@ -107,6 +122,119 @@ func (r *realStdinReader) ReadString(delim byte) (string, error) {
 	return r.reader.ReadString(delim)
 }
 
+// githubReleaseChecker queries the GitHub releases API
+type githubReleaseChecker struct {
+	url     string
+	timeout time.Duration
+}
+
+func (g *githubReleaseChecker) LatestVersion() (string, error) {
+	client := &http.Client{Timeout: g.timeout}
+
+	req, err := http.NewRequest(http.MethodGet, g.url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to query releases: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to query releases: unexpected status %s", resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", fmt.Errorf("failed to parse release response: %w", err)
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("release response has no tag_name")
+	}
+
+	return release.TagName, nil
+}
+
+// httpReleaseDownloader downloads release assets over HTTP
+type httpReleaseDownloader struct {
+	timeout time.Duration
+}
+
+func (h *httpReleaseDownloader) Download(url string) ([]byte, error) {
+	client := &http.Client{Timeout: h.timeout}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: unexpected status %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// realBinaryInstaller replaces the binary through a temp file in the same
+// directory, so the rename is atomic
+type realBinaryInstaller struct{}
+
+func (r *realBinaryInstaller) Executable() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the vhost binary: %w", err)
+	}
+	return filepath.EvalSymlinks(path)
+}
+
+func (r *realBinaryInstaller) CheckWritable(path string) error {
+	// Replacing needs a new file in the directory, not just a writable binary
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".vhost-update-*")
+	if err != nil {
+		return err
+	}
+	_ = tmp.Close()
+	return os.Remove(tmp.Name())
+}
+
+func (r *realBinaryInstaller) Replace(path string, binary []byte) error {
+	mode := os.FileMode(0755)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".vhost-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(binary); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
 // Command runner for edit and logs commands
 type CommandRunner interface {
 	Run(name string, args ...string) error
@@ -3,16 +3,20 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
+	vherrors "github.com/ksyq12/vhost/internal/errors"
 	"github.com/ksyq12/vhost/internal/platform"
 )
 
-// MockConfigLoader is a test double for ConfigLoader
+// MockConfigLoader is a test double for ConfigLoader. The config lives in
+// memory; ConfigDir is only used for the state files vhost keeps beside it.
 type MockConfigLoader struct {
 	Cfg       *config.Config
+	ConfigDir string
 	LoadErr   error
 	SaveErr   error
 	SaveCalls int
@@ -28,6 +32,11 @@ func (m *MockConfigLoader) Load() (*config.Config, error) {
 	return m.Cfg, nil
 }
 
+// LoadUnchecked returns the same config as Load; the mock never validates
+func (m *MockConfigLoader) LoadUnchecked() (*config.Config, error) {
+	return m.Load()
+}
+
 func (m *MockConfigLoader) Save(cfg *config.Config) error {
 	m.SaveCalls++
 	if m.SaveErr != nil {
@@ -37,6 +46,20 @@ func (m *MockConfigLoader) Save(cfg *config.Config) error {
 	return nil
 }
 
+func (m *MockConfigLoader) Dir() (string, error) {
+	if m.ConfigDir == "" {
+		return "", errors.New("mock config loader has no config directory")
+	}
+	return m.ConfigDir, nil
+}
+
+func (m *MockConfigLoader) Path() (string, error) {
+	if _, err := m.Dir(); err != nil {
+		return "", err
+	}
+	return config.NewLoader(m.ConfigDir).Path()
+}
+
 // MockPlatformDetector is a test double for PlatformDetector
 type MockPlatformDetector struct {
 	Paths *platform.PlatformPaths
@@ -64,6 +87,10 @@ func (m *MockPlatformDetector) DetectPaths() (*platform.PlatformPaths, error) {
 			Available: "/etc/caddy/sites-available",
 			Enabled:   "/etc/caddy/sites-enabled",
 		},
+		Traefik: platform.PathConfig{
+			Available: "/etc/traefik/sites-available",
+			Enabled:   "/etc/traefik/dynamic",
+		},
 	}, nil
 }
 
@@ -106,7 +133,7 @@ type MockStdinReader struct {
 
 func (m *MockStdinReader) ReadString(delim byte) (string, error) {
 	if m.pos >= len(m.Input) {
-		return "", errors.New("EOF")
+		return "", io.EOF
 	}
 	idx := strings.IndexByte(m.Input[m.pos:], delim)
 	if idx == -1 {
@@ -119,6 +146,63 @@ func (m *MockStdinReader) ReadString(delim byte) (string, error) {
 	return result, nil
 }
 
+// MockReleaseChecker is a test double for ReleaseChecker
+type MockReleaseChecker struct {
+	Version string
+	Err     error
+	Calls   int
+}
+
+func (m *MockReleaseChecker) LatestVersion() (string, error) {
+	m.Calls++
+	if m.Err != nil {
+		return "", m.Err
+	}
+	return m.Version, nil
+}
+
+// MockReleaseDownloader is a test double for ReleaseDownloader. Files maps
+// URLs to their content; other URLs fail.
+type MockReleaseDownloader struct {
+	Files map[string][]byte
+	Calls []string
+}
+
+func (m *MockReleaseDownloader) Download(url string) ([]byte, error) {
+	m.Calls = append(m.Calls, url)
+	data, ok := m.Files[url]
+	if !ok {
+		return nil, fmt.Errorf("failed to download %s: not found", url)
+	}
+	return data, nil
+}
+
+// MockBinaryInstaller is a test double for BinaryInstaller
+type MockBinaryInstaller struct {
+	Path        string
+	WritableErr error
+	ReplaceErr  error
+	Replaced    []byte
+	Calls       int
+}
+
+func (m *MockBinaryInstaller) Executable() (string, error) {
+	return m.Path, nil
+}
+
+func (m *MockBinaryInstaller) CheckWritable(path string) error {
+	return m.WritableErr
+}
+
+func (m *MockBinaryInstaller) Replace(path string, binary []byte) error {
+	m.Calls++
+	if m.ReplaceErr != nil {
+		return m.ReplaceErr
+	}
+	m.Replaced = binary
+	return nil
+}
+
 // MockCommandRunner is a test double for CommandRunner
 type MockCommandRunner struct {
 	Calls        [][]string
@@ -151,7 +235,8 @@ func (m *MockCommandRunner) LookPath(file string) (string, error) {
 
 // MockDependenciesBuilder helps create mock dependencies for tests
 type MockDependenciesBuilder struct {
-	deps *Dependencies
+	deps      *Dependencies
+	configDir string
 }
 
 // NewMockDeps creates a new MockDependenciesBuilder with sensible defaults
@@ -163,13 +248,25 @@ func NewMockDeps() *MockDependenciesBuilder {
 			DriverFactory:    &MockDriverFactory{},
 			RootChecker:      &MockRootChecker{IsRoot: true},
 			StdinReader:      &MockStdinReader{Input: "y\n"},
+			ReleaseChecker:   &MockReleaseChecker{Err: errors.New("release check disabled in tests")},
+			Downloader:       &MockReleaseDownloader{},
+			BinaryInstaller:  &MockBinaryInstaller{Path: "/usr/local/bin/vhost"},
 		},
 	}
 }
 
 // WithConfig sets the config for the mock
 func (b *MockDependenciesBuilder) WithConfig(cfg *config.Config) *MockDependenciesBuilder {
-	b.deps.ConfigLoader = &MockConfigLoader{Cfg: cfg}
+	b.deps.ConfigLoader = &MockConfigLoader{Cfg: cfg, ConfigDir: b.configDir}
+	return b
+}
+
+// WithConfigDir sets the directory the mock config loader reports
+func (b *MockDependenciesBuilder) WithConfigDir(dir string) *MockDependenciesBuilder {
+	b.configDir = dir
+	if loader, ok := b.deps.ConfigLoader.(*MockConfigLoader); ok {
+		loader.ConfigDir = dir
+	}
 	return b
 }
 
@@ -215,6 +312,24 @@ func (b *MockDependenciesBuilder) WithPlatformError(err error) *MockDependencies
 	return b
 }
 
+// WithReleaseChecker sets a custom release checker
+func (b *MockDependenciesBuilder) WithReleaseChecker(checker ReleaseChecker) *MockDependenciesBuilder {
+	b.deps.ReleaseChecker = checker
+	return b
+}
+
+// WithDownloader sets a custom release downloader
+func (b *MockDependenciesBuilder) WithDownloader(downloader ReleaseDownloader) *MockDependenciesBuilder {
+	b.deps.Downloader = downloader
+	return b
+}
+
+// WithBinaryInstaller sets a custom binary installer
+func (b *MockDependenciesBuilder) WithBinaryInstaller(installer BinaryInstaller) *MockDependenciesBuilder {
+	b.deps.BinaryInstaller = installer
+	return b
+}
+
 // Build returns the configured Dependencies
 func (b *MockDependenciesBuilder) Build() *Dependencies {
 	return b.deps
@@ -285,4 +400,4 @@ func (h *TestHelper) GetConfig() *config.Config {
 }
 
 // errRootRequired is the sentinel error for root privilege check
-var errRootRequired = fmt.Errorf("this operation requires root privileges. Please run with sudo")
+var errRootRequired = vherrors.Newf(vherrors.ErrCodePermission, "this operation requires root privileges. Please run with sudo")
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ksyq12/vhost/internal/config"
+	vherrors "github.com/ksyq12/vhost/internal/errors"
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/ksyq12/vhost/internal/template"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <domain>",
+	Short: "Show manual edits to a vhost's config file",
+	Long: `Compare a vhost's config file with what vhost would render from its
+stored settings.
+
+Lines marked + are in the file on disk but not in the rendered template,
+usually hand edits; lines marked - are what a re-render would put back.
+Commands that re-render the file (set, ssl install, maintenance) replace
+it with the rendered version, so check here before running them on a
+file that may have been edited by hand.
+
+Examples:
+  vhost diff example.com
+  vhost diff example.com --json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
+
+// diffResult represents the diff command output
+type diffResult struct {
+	Domain  string `json:"domain"`
+	Path    string `json:"path"`
+	Drifted bool   `json:"drifted"`
+	Diff    string `json:"diff,omitempty"`
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	domain := config.NormalizeDomain(args[0])
+
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return err
+	}
+
+	vhost, exists := cfg.VHosts[domain]
+	if !exists {
+		return vherrors.Newf(vherrors.ErrCodeNotFound, "vhost %s not found", domain)
+	}
+
+	rendered, err := template.Render(drv.Name(), vhost)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	configPath := filepath.Join(drv.Paths().Available, driverConfigFileName(drv.Name(), domain))
+	onDisk, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+
+	result := diffResult{
+		Domain: domain,
+		Path:   configPath,
+		Diff:   diffConfig(rendered, string(onDisk), "rendered from config.yaml", configPath),
+	}
+	result.Drifted = result.Diff != ""
+
+	if jsonOutput {
+		return output.JSON(result)
+	}
+
+	if !result.Drifted {
+		output.Success("%s matches its stored settings", configPath)
+		return nil
+	}
+	output.Warn("%s differs from its stored settings", configPath)
+	output.Diff(result.Diff)
+	return nil
+}
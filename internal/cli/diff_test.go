@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/template"
+)
+
+func TestRunDiff(t *testing.T) {
+	vhost := &config.VHost{Domain: "test.com", Type: "static", Root: "/var/www/test"}
+	rendered, err := template.Render("nginx", vhost)
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		onDisk      string
+		wantDrifted bool
+		wantLines   []string
+	}{
+		{
+			name:   "matches rendered template",
+			onDisk: rendered,
+		},
+		{
+			name:        "hand edited",
+			onDisk:      strings.Replace(rendered, "root /var/www/test;", "root /var/www/edited;", 1),
+			wantDrifted: true,
+			wantLines:   []string{"-    root /var/www/test;", "+    root /var/www/edited;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available := t.TempDir()
+			if err := os.WriteFile(filepath.Join(available, "test.com"), []byte(tt.onDisk), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := config.New()
+			cfg.VHosts["test.com"] = vhost
+			mockDrv := driver.NewMockDriver("nginx", available, t.TempDir())
+
+			oldDeps, oldJSON := deps, jsonOutput
+			deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+			jsonOutput = true
+			defer func() { deps, jsonOutput = oldDeps, oldJSON }()
+
+			var runErr error
+			out := captureStdout(func() { runErr = runDiff(nil, []string{"test.com"}) })
+			if runErr != nil {
+				t.Fatalf("unexpected error: %v", runErr)
+			}
+
+			var result diffResult
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("invalid JSON %q: %v", out, err)
+			}
+			if result.Drifted != tt.wantDrifted {
+				t.Errorf("drifted = %v, want %v (diff %q)", result.Drifted, tt.wantDrifted, result.Diff)
+			}
+			for _, line := range tt.wantLines {
+				if !strings.Contains(result.Diff, line+"\n") {
+					t.Errorf("diff missing %q:\n%s", line, result.Diff)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown vhost", func(t *testing.T) {
+		oldDeps := deps
+		deps = NewMockDeps().WithDriver(driver.NewMockDriver("nginx", t.TempDir(), t.TempDir())).Build()
+		defer func() { deps = oldDeps }()
+
+		if err := runDiff(nil, []string{"missing.com"}); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+}
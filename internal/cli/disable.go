@@ -4,28 +4,43 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/ksyq12/vhost/internal/driver"
 	"github.com/ksyq12/vhost/internal/output"
 	"github.com/spf13/cobra"
 )
 
+var disableAll bool
+
 var disableCmd = &cobra.Command{
 	Use:   "disable <domain>",
 	Short: "Disable a virtual host",
 	Long: `Disable a virtual host by removing its symlink from sites-enabled.
 
+With --all, every enabled vhost is disabled with a single test and reload.
+config.yaml keeps recording them as enabled, so "vhost enable --all" brings
+the same set back after maintenance.
+
 Examples:
-  vhost disable example.com`,
-	Args: cobra.ExactArgs(1),
-	RunE: runDisable,
+  vhost disable example.com
+  vhost disable --all --yes`,
+	Args:              domainOrAllArgs(&disableAll),
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runDisable,
 }
 
 func init() {
 	disableCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server")
+	disableCmd.Flags().BoolVar(&disableAll, "all", false, "Disable every enabled vhost")
 
+	interruptible(disableCmd)
 	rootCmd.AddCommand(disableCmd)
 }
 
 func runDisable(cmd *cobra.Command, args []string) error {
+	if disableAll {
+		return runDisableAll()
+	}
+
 	domain := args[0]
 
 	// Validate domain
@@ -38,6 +53,7 @@ func runDisable(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	domain = resolveDomain(cfg, domain)
 
 	// Dry-run mode: show what would be done without making changes
 	if dryRun {
@@ -49,6 +65,11 @@ func runDisable(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Ctrl-C at this point leaves the vhost untouched
+	if err := checkInterrupted(commandContext(cmd)); err != nil {
+		return err
+	}
+
 	// Disable via driver
 	output.Info("Disabling vhost...")
 	if err := drv.Disable(domain); err != nil {
@@ -64,6 +85,7 @@ func runDisable(cmd *cobra.Command, args []string) error {
 	// Update config
 	if vhost, exists := cfg.VHosts[domain]; exists {
 		vhost.Enabled = false
+		_ = cfg.TouchVHost(domain)
 		if err := saveConfig(cfg); err != nil {
 			output.Warn("VHost disabled but config save failed: %v", err)
 		}
@@ -79,14 +101,73 @@ func runDisable(cmd *cobra.Command, args []string) error {
 	)
 }
 
-// outputDisableDryRun outputs what disable command would do in dry-run mode
-func outputDisableDryRun(domain string, drvName string, drvPaths struct{ Available, Enabled string }) error {
-	// Determine config file name (apache uses .conf extension)
-	configFileName := domain
-	if drvName == "apache" {
-		configFileName = domain + ".conf"
+// runDisableAll disables every configured vhost that is currently enabled,
+// re-enabling them all if any step fails
+func runDisableAll() error {
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return err
+	}
+
+	var domains []string
+	for _, domain := range sortedDomains(cfg) {
+		if hasEnabledEntry(drv, domain) {
+			domains = append(domains, domain)
+		}
+	}
+
+	if len(domains) == 0 {
+		return outputResult(
+			map[string]interface{}{
+				"success":  true,
+				"disabled": []string{},
+			},
+			"No enabled vhosts to disable",
+		)
+	}
+
+	if dryRun {
+		return outputBulkDryRun(domains, "remove_symlink", drv.Name(), drv.Paths().Enabled)
+	}
+
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	ok, err := confirm(fmt.Sprintf("Disable %d vhost(s)?", len(domains)), false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		output.Info("Disable cancelled")
+		return nil
+	}
+
+	// A failure re-enables whatever was already taken down
+	rollback, err := switchSites(drv, domains, nil)
+	if err == nil {
+		err = testAndReload(drv, !noReload, rollback)
+	} else if rbErr := rollback(); rbErr != nil {
+		output.Warn("Rollback failed: %v", rbErr)
+	}
+	if err != nil {
+		return err
 	}
 
+	return outputResult(
+		map[string]interface{}{
+			"success":  true,
+			"disabled": domains,
+		},
+		"Disabled %d vhost(s)", len(domains),
+	)
+}
+
+// outputDisableDryRun outputs what disable command would do in dry-run mode
+func outputDisableDryRun(domain string, drvName string, drvPaths driver.Paths) error {
+	// Determine config file name (some drivers use an extension)
+	configFileName := driverConfigFileName(drvName, domain)
+
 	enabledPath := filepath.Join(drvPaths.Enabled, configFileName)
 
 	operations := []DryRunOperation{
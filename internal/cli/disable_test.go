@@ -2,6 +2,7 @@ package cli
 
 import (
 	"errors"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -308,3 +309,158 @@ func TestRunDisableDryRun(t *testing.T) {
 		})
 	}
 }
+
+func TestRunDisableAll(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		disableErr   string // domain whose Disable fails
+		testErr      error
+		wantErr      bool
+		errContains  string
+		wantDisabled []string
+		wantEnabled  []string // rollback Enable calls
+		wantReloads  int
+	}{
+		{
+			name:         "disables every enabled vhost with one reload",
+			input:        "y\n",
+			wantDisabled: []string{"a.com", "b.com"},
+			wantReloads:  1,
+		},
+		{
+			name:        "declined confirmation changes nothing",
+			input:       "n\n",
+			wantReloads: 0,
+		},
+		{
+			name:         "test failure re-enables disabled sites",
+			input:        "y\n",
+			testErr:      errors.New("syntax error"),
+			wantErr:      true,
+			errContains:  "configuration test failed",
+			wantDisabled: []string{"a.com", "b.com"},
+			wantEnabled:  []string{"a.com", "b.com"},
+		},
+		{
+			name:         "disable failure rolls back earlier sites",
+			input:        "y\n",
+			disableErr:   "b.com",
+			wantErr:      true,
+			errContains:  "failed to disable b.com",
+			wantDisabled: []string{"a.com", "b.com"},
+			wantEnabled:  []string{"a.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+			mockDrv.IsEnabledFunc = func(domain string) (bool, error) {
+				return domain != "off.com", nil
+			}
+			mockDrv.DisableFunc = func(domain string) error {
+				if domain == tt.disableErr {
+					return errors.New("permission denied")
+				}
+				return nil
+			}
+			if tt.testErr != nil {
+				mockDrv.TestFunc = func() error { return tt.testErr }
+			}
+
+			cfg := config.New()
+			for _, domain := range []string{"a.com", "b.com", "off.com"} {
+				cfg.VHosts[domain] = &config.VHost{Domain: domain, Type: "static", Enabled: domain != "off.com"}
+			}
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).WithStdinInput(tt.input).Build()
+			defer func() { deps = oldDeps }()
+
+			noReload = false
+			disableAll = true
+			defer func() { disableAll = false }()
+
+			err := runDisable(nil, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error %q does not contain %q", err.Error(), tt.errContains)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if strings.Join(mockDrv.DisableCalls, ",") != strings.Join(tt.wantDisabled, ",") {
+				t.Errorf("expected Disable calls %v, got %v", tt.wantDisabled, mockDrv.DisableCalls)
+			}
+			if strings.Join(mockDrv.EnableCalls, ",") != strings.Join(tt.wantEnabled, ",") {
+				t.Errorf("expected rollback Enable calls %v, got %v", tt.wantEnabled, mockDrv.EnableCalls)
+			}
+			if mockDrv.ReloadCalls != tt.wantReloads {
+				t.Errorf("expected %d Reload calls, got %d", tt.wantReloads, mockDrv.ReloadCalls)
+			}
+			if !cfg.VHosts["a.com"].Enabled {
+				t.Error("config should keep recording a.com as enabled")
+			}
+		})
+	}
+}
+
+func TestRunDisableAllOutdatedCopy(t *testing.T) {
+	tempDir := t.TempDir()
+	enabledDir := filepath.Join(tempDir, "sites-enabled")
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), enabledDir)
+
+	// stale.com's copy differs from sites-available, so IsEnabled says no,
+	// but the server still loads it
+	if err := os.MkdirAll(enabledDir, 0755); err != nil {
+		t.Fatalf("failed to create enabled dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(enabledDir, "stale.com"), []byte("server {}"), 0644); err != nil {
+		t.Fatalf("failed to write copy: %v", err)
+	}
+
+	cfg := config.New()
+	for _, domain := range []string{"off.com", "stale.com"} {
+		cfg.VHosts[domain] = &config.VHost{Domain: domain, Type: "static"}
+	}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).WithStdinInput("y\n").Build()
+	defer func() { deps = oldDeps }()
+
+	disableAll = true
+	defer func() { disableAll = false }()
+
+	if err := runDisable(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(mockDrv.DisableCalls, ",") != "stale.com" {
+		t.Errorf("expected only stale.com to be disabled, got %v", mockDrv.DisableCalls)
+	}
+}
+
+func TestDomainOrAllArgs(t *testing.T) {
+	all := false
+	validate := domainOrAllArgs(&all)
+
+	if err := validate(nil, []string{"a.com"}); err != nil {
+		t.Errorf("single domain should be accepted: %v", err)
+	}
+	if err := validate(nil, nil); err == nil {
+		t.Error("missing domain should fail without --all")
+	}
+
+	all = true
+	if err := validate(nil, nil); err != nil {
+		t.Errorf("--all without domain should be accepted: %v", err)
+	}
+	if err := validate(nil, []string{"a.com"}); err == nil {
+		t.Error("--all with a domain should fail")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package cli
+
+// freeDiskSpace is not implemented on this platform; the disk space check is skipped
+func freeDiskSpace(path string) (uint64, bool) {
+	return 0, false
+}
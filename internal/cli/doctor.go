@@ -1,17 +1,29 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
+	vherrors "github.com/ksyq12/vhost/internal/errors"
 	"github.com/ksyq12/vhost/internal/executor"
 	"github.com/ksyq12/vhost/internal/output"
 	"github.com/ksyq12/vhost/internal/ssl"
+	"github.com/ksyq12/vhost/internal/template"
 	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
 )
 
 var doctorCmd = &cobra.Command{
@@ -21,23 +33,118 @@ var doctorCmd = &cobra.Command{
 
 Checks:
   - Web server installation (nginx, apache, caddy)
+  - Web server listening on port 80 (and 443 with SSL vhosts)
   - PHP-FPM status
   - Certbot installation
+  - Log directory writability and free disk space
   - Configuration file validity
+  - Config files not tracked in config.yaml, and tracked vhosts without one
+  - SSL certificate or key files shared by more than one vhost
   - Virtual host status
 
+--since-reload also warns about enabled config files modified after the web
+server last loaded its config, which it may not be serving yet: the later
+of its systemd start (ActiveEnterTimestamp) and the last reload vhost ran.
+Reloads run outside vhost aren't seen.
+
+JSON output includes a schema_version and a stable "code" for every check,
+suitable for alerting.
+
+--watch re-runs the checks every interval (default 5s) and redraws the
+screen until Ctrl-C. With --json it prints one compact report per line
+instead.
+
 Examples:
   vhost doctor
-  vhost doctor --json`,
+  vhost doctor --json
+  vhost doctor --watch
+  vhost doctor --since-reload
+  vhost doctor --watch=30s --json`,
 	RunE: runDoctor,
 }
 
+var (
+	// doctorWatch is the refresh interval for --watch; zero runs once
+	doctorWatch time.Duration
+	// doctorSinceReload adds the config staleness check
+	doctorSinceReload bool
+	// doctorStrict makes warnings fail doctor as well as errors
+	doctorStrict bool
+)
+
+// defaultDoctorWatch is the interval used by a bare --watch
+const defaultDoctorWatch = "5s"
+
 func init() {
+	doctorCmd.Flags().DurationVar(&doctorWatch, "watch", 0, "Re-run the checks every interval until interrupted (--watch uses "+defaultDoctorWatch+")")
+	doctorCmd.Flags().Lookup("watch").NoOptDefVal = defaultDoctorWatch
+	doctorCmd.Flags().BoolVar(&doctorStrict, "strict", false, "Exit non-zero on warnings as well as errors")
+	doctorCmd.Flags().BoolVar(&doctorSinceReload, "since-reload", false, "Warn about enabled configs modified since the web server last started or vhost reloaded it")
+
 	rootCmd.AddCommand(doctorCmd)
 }
 
+// doctorSchemaVersion is the version of the doctor JSON report format.
+// Bump it whenever fields or check codes change incompatibly.
+const doctorSchemaVersion = 1
+
+// Check statuses
+const (
+	statusSuccess = "success"
+	statusWarning = "warning"
+	statusError   = "error"
+)
+
+// Check codes are stable identifiers for each diagnostic, independent of the
+// human-readable message. Web server codes are "<driver>_installed" or
+// "<driver>_missing" (e.g. nginx_installed, apache_missing).
+const (
+	codePHPFPMRunning     = "php_fpm_running"
+	codePHPFPMMissing     = "php_fpm_missing"
+	codePHPVersionOK      = "php_fpm_version_ok"
+	codePHPVersionMissing = "php_fpm_version_missing"
+	codeCertbotInstalled  = "certbot_installed"
+	codeCertbotMissing    = "certbot_missing"
+	codeConfigFileFound   = "config_file_found"
+	codeConfigFileMissing = "config_file_missing"
+	codeConfigPathUnknown = "config_path_unknown"
+	codeConfigSyntaxOK    = "config_syntax_ok"
+	codeConfigSyntaxError = "config_syntax_error"
+	codeEnabledMismatch   = "enabled_mismatch"
+	codeRootMissing       = "root_missing"
+	codeSSLCertMissing    = "ssl_cert_missing"
+	codeSSLKeyMissing     = "ssl_key_missing"
+	codeSSLCertInvalid    = "ssl_cert_invalid"
+	codeSSLCertNames      = "ssl_cert_name_mismatch"
+	codeSSLKeyMismatch    = "ssl_key_mismatch"
+	codeVHostOK           = "vhost_ok"
+	codeLogDirWritable    = "log_dir_writable"
+	codeLogDirMissing     = "log_dir_missing"
+	codeLogDirNotWritable = "log_dir_not_writable"
+	codeDiskSpaceOK       = "disk_space_ok"
+	codeDiskSpaceLow      = "disk_space_low"
+	codePortListening     = "port_listening"
+	codePortNotListening  = "port_not_listening"
+	codeDefaultServerOK   = "default_server_ok"
+	codeDefaultConflict   = "default_server_conflict"
+	codeConfigsTracked    = "configs_tracked"
+	codeConfigUntracked   = "config_untracked"
+	codeVHostFileMissing  = "vhost_file_missing"
+	codeRenewalScheduled  = "cert_renewal_scheduled"
+	codeRenewalMissing    = "cert_renewal_missing"
+	codeConfigLoaded      = "config_loaded"
+	codeConfigStale       = "config_stale"
+	codeStartTimeUnknown  = "start_time_unknown"
+	codeSSLFilesDistinct  = "ssl_files_distinct"
+	codeSSLFileShared     = "ssl_file_shared"
+)
+
+// minFreeDiskSpace is the free space below which doctor warns
+const minFreeDiskSpace = 100 * 1024 * 1024
+
 // CheckResult represents a single diagnostic check result
 type CheckResult struct {
+	Code    string `json:"code"`   // stable machine-readable identifier
 	Status  string `json:"status"` // "success", "warning", "error"
 	Message string `json:"message"`
 }
@@ -51,9 +158,49 @@ type VHostStatus struct {
 
 // DoctorReport contains all diagnostic results
 type DoctorReport struct {
+	SchemaVersion      int           `json:"schema_version"`
 	SystemRequirements []CheckResult `json:"system_requirements"`
 	Configuration      []CheckResult `json:"configuration"`
 	VHosts             []VHostStatus `json:"vhosts"`
+	Passed             bool          `json:"passed"` // false makes doctor exit with exitDoctorFailed
+}
+
+// exitDoctorFailed is the exit status of a doctor run with failing checks
+const exitDoctorFailed = 2
+
+// counts returns the number of error and warning checks in the report
+func (r *DoctorReport) counts() (errs, warnings int) {
+	count := func(checks []CheckResult) {
+		for _, c := range checks {
+			switch c.Status {
+			case statusError:
+				errs++
+			case statusWarning:
+				warnings++
+			}
+		}
+	}
+	count(r.SystemRequirements)
+	count(r.Configuration)
+	for _, v := range r.VHosts {
+		count(v.Checks)
+	}
+	return errs, warnings
+}
+
+// failure returns the error doctor exits with, or nil when the report
+// passes. Warnings fail it only in strict mode.
+func (r *DoctorReport) failure(strict bool) error {
+	errs, warnings := r.counts()
+	switch {
+	case errs > 0 && strict && warnings > 0:
+		return &exitCodeError{code: exitDoctorFailed, err: fmt.Errorf("doctor found %d error(s) and %d warning(s)", errs, warnings)}
+	case errs > 0:
+		return &exitCodeError{code: exitDoctorFailed, err: fmt.Errorf("doctor found %d error(s)", errs)}
+	case strict && warnings > 0:
+		return &exitCodeError{code: exitDoctorFailed, err: fmt.Errorf("doctor found %d warning(s) (--strict)", warnings)}
+	}
+	return nil
 }
 
 func runDoctor(cmd *cobra.Command, args []string) error {
@@ -61,30 +208,94 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	exec := executor.NewSystemExecutor()
 
 	// Load config
-	cfg, err := config.Load()
+	cfg, err := deps.ConfigLoader.Load()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return vherrors.Wrap(vherrors.ErrCodeConfig, "failed to load config", err)
+	}
+	// doctor never saves, so it can check the profile's settings directly
+	if cfg, err = cfg.WithProfile(profileName); err != nil {
+		return err
 	}
 
-	// Get driver
-	drv, ok := driver.Get(cfg.Driver)
-	if !ok {
-		return fmt.Errorf("driver %s not found", cfg.Driver)
+	// Check the driver with the paths every other command uses
+	drv, err := newDriver(cfg)
+	if err != nil {
+		return err
+	}
+
+	check := func() *DoctorReport {
+		return runDoctorChecks(exec, drv, cfg)
+	}
+
+	if doctorWatch > 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// Reload config each round so added or removed vhosts show up
+		check = func() *DoctorReport {
+			if latest, err := deps.ConfigLoader.Load(); err == nil {
+				if active, err := latest.WithProfile(profileName); err == nil {
+					cfg = active
+				}
+			}
+			return runDoctorChecks(exec, drv, cfg)
+		}
+
+		if jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			return watchDoctor(ctx, doctorWatch, check, func(report *DoctorReport) error {
+				return encoder.Encode(report)
+			})
+		}
+		return watchDoctor(ctx, doctorWatch, check, func(report *DoctorReport) error {
+			output.ClearScreen()
+			output.Print("Every %s: vhost doctor    %s\n", doctorWatch, time.Now().Format(time.DateTime))
+			displayDoctorResults(report)
+			return nil
+		})
 	}
 
-	// Run all checks
-	report := &DoctorReport{}
+	// Output results; the full report is shown even when doctor fails
+	report := check()
+	if jsonOutput {
+		if err := output.JSON(report); err != nil {
+			return err
+		}
+	} else {
+		displayDoctorResults(report)
+	}
+	return reported(report.failure(doctorStrict))
+}
+
+// runDoctorChecks runs every diagnostic and returns the report
+func runDoctorChecks(exec executor.CommandExecutor, drv driver.Driver, cfg *config.Config) *DoctorReport {
+	report := &DoctorReport{SchemaVersion: doctorSchemaVersion}
 	report.SystemRequirements = checkSystemRequirements(exec, cfg)
 	report.Configuration = checkConfiguration(drv, cfg)
+	if doctorSinceReload {
+		report.Configuration = append(report.Configuration, checkConfigStaleness(exec, drv)...)
+	}
 	report.VHosts = checkVHosts(drv, cfg)
+	report.Passed = report.failure(doctorStrict) == nil
+	return report
+}
 
-	// Output results
-	if jsonOutput {
-		return output.JSON(report)
-	}
+// watchDoctor passes a fresh report to show immediately and then on every
+// tick of interval, until ctx is cancelled or show fails
+func watchDoctor(ctx context.Context, interval time.Duration, check func() *DoctorReport, show func(*DoctorReport) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	displayDoctorResults(report)
-	return nil
+	for {
+		if err := show(check()); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
 }
 
 func checkSystemRequirements(exec executor.CommandExecutor, cfg *config.Config) []CheckResult {
@@ -100,13 +311,14 @@ func checkSystemRequirements(exec executor.CommandExecutor, cfg *config.Config)
 	// Check web servers
 	webServers := []struct {
 		name        string
+		driver      string
 		binary      string
 		versionFlag string
 		optional    bool
 	}{
-		{"Nginx", "nginx", "-v", cfg.Driver != "nginx"},
-		{"Apache", "apache2", "-v", cfg.Driver != "apache"},
-		{"Caddy", "caddy", "version", cfg.Driver != "caddy"},
+		{"Nginx", "nginx", "nginx", "-v", cfg.Driver != "nginx"},
+		{"Apache", "apache", "apache2", "-v", cfg.Driver != "apache"},
+		{"Caddy", "caddy", "caddy", "version", cfg.Driver != "caddy"},
 	}
 
 	for _, ws := range webServers {
@@ -122,17 +334,19 @@ func checkSystemRequirements(exec executor.CommandExecutor, cfg *config.Config)
 				}
 			}
 			results = append(results, CheckResult{
-				Status:  "success",
+				Code:    ws.driver + "_installed",
+				Status:  statusSuccess,
 				Message: fmt.Sprintf("%s installed (%s)", ws.name, version),
 			})
 		} else {
-			status := "error"
+			status := statusError
 			suffix := ""
 			if ws.optional {
-				status = "warning"
+				status = statusWarning
 				suffix = " (optional)"
 			}
 			results = append(results, CheckResult{
+				Code:    ws.driver + "_missing",
 				Status:  status,
 				Message: fmt.Sprintf("%s not installed%s", ws.name, suffix),
 			})
@@ -140,12 +354,12 @@ func checkSystemRequirements(exec executor.CommandExecutor, cfg *config.Config)
 	}
 
 	// Check PHP-FPM
-	phpVersions := []string{"8.3", "8.2", "8.1", "8.0", "7.4"}
 	phpFound := false
-	for _, v := range phpVersions {
+	for _, v := range installedPHPFPMVersions() {
 		if isPHPFPMRunning(exec, v) {
 			results = append(results, CheckResult{
-				Status:  "success",
+				Code:    codePHPFPMRunning,
+				Status:  statusSuccess,
 				Message: fmt.Sprintf("PHP-FPM %s running", v),
 			})
 			phpFound = true
@@ -161,92 +375,372 @@ func checkSystemRequirements(exec executor.CommandExecutor, cfg *config.Config)
 				break
 			}
 		}
-		status := "warning"
+		status := statusWarning
 		if needsPHP {
-			status = "error"
+			status = statusError
 		}
 		results = append(results, CheckResult{
+			Code:    codePHPFPMMissing,
 			Status:  status,
 			Message: "PHP-FPM not detected",
 		})
 	}
+	results = append(results, checkPHPVersions(exec, cfg)...)
 
 	// Check Certbot
 	if ssl.IsInstalled() {
 		results = append(results, CheckResult{
-			Status:  "success",
+			Code:    codeCertbotInstalled,
+			Status:  statusSuccess,
 			Message: "Certbot installed",
 		})
 	} else {
-		// Check if any SSL vhosts exist
-		needsSSL := false
-		for _, vhost := range cfg.VHosts {
-			if vhost.SSL {
-				needsSSL = true
-				break
-			}
-		}
-		status := "warning"
-		if needsSSL {
-			status = "error"
+		status := statusWarning
+		if hasSSLVHost(cfg) {
+			status = statusError
 		}
 		results = append(results, CheckResult{
+			Code:    codeCertbotMissing,
 			Status:  status,
 			Message: "Certbot not installed",
 		})
 	}
 
+	// Certificates expire silently without a renewal timer or cron job
+	if result, ok := checkCertRenewal(exec, cfg); ok {
+		results = append(results, result)
+	}
+
+	// Check the web server is actually bound to its ports; a service can be
+	// "active" after failing to bind
+	results = append(results, checkPortListening("127.0.0.1:80", portProbeTimeout))
+	if hasSSLVHost(cfg) {
+		results = append(results, checkPortListening("127.0.0.1:443", portProbeTimeout))
+	}
+
+	// Check the web server log directory
+	if logDir := driverLogDir(cfg.Driver); logDir != "" {
+		results = append(results, checkLogDir(logDir))
+	}
+
+	// Check free space where the config lives
+	if configPath, err := deps.ConfigLoader.Path(); err == nil {
+		if result, ok := checkDiskSpace(filepath.Dir(configPath)); ok {
+			results = append(results, result)
+		}
+	}
+
 	return results
 }
 
-func isPHPFPMRunning(exec executor.CommandExecutor, version string) bool {
-	serviceName := fmt.Sprintf("php%s-fpm", version)
+// portProbeTimeout bounds each local port probe
+const portProbeTimeout = time.Second
 
-	// Try systemctl
-	if out, err := exec.Execute("systemctl", "is-active", serviceName); err == nil {
-		if strings.TrimSpace(string(out)) == "active" {
-			return true
+// checkPortListening dials addr and reports whether something accepts connections
+func checkPortListening(addr string, timeout time.Duration) CheckResult {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return CheckResult{
+			Code:    codePortNotListening,
+			Status:  statusError,
+			Message: fmt.Sprintf("Nothing listening on %s", addr),
 		}
 	}
+	_ = conn.Close()
+
+	return CheckResult{
+		Code:    codePortListening,
+		Status:  statusSuccess,
+		Message: fmt.Sprintf("Listening on %s", addr),
+	}
+}
 
-	// Try service command
-	if out, err := exec.Execute("service", serviceName, "status"); err == nil {
-		if strings.Contains(string(out), "running") || strings.Contains(string(out), "active") {
+// hasSSLVHost reports whether any configured vhost uses SSL
+func hasSSLVHost(cfg *config.Config) bool {
+	for _, vhost := range cfg.VHosts {
+		if vhost.SSL {
 			return true
 		}
 	}
+	return false
+}
 
-	// Check socket file
-	socketPath := fmt.Sprintf("/run/php/php%s-fpm.sock", version)
-	if _, err := os.Stat(socketPath); err == nil {
-		return true
+// checkLogDir verifies that a log directory exists and is writable
+func checkLogDir(dir string) CheckResult {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return CheckResult{
+			Code:    codeLogDirMissing,
+			Status:  statusWarning,
+			Message: fmt.Sprintf("Log directory missing (%s)", dir),
+		}
 	}
 
-	return false
+	// Probe with a real file; permission bits alone don't account for ACLs or read-only mounts.
+	// Only a warning: the server writes its logs as root, and doctor often runs without sudo.
+	probe, err := os.CreateTemp(dir, ".vhost-doctor-*")
+	if err != nil {
+		return CheckResult{
+			Code:    codeLogDirNotWritable,
+			Status:  statusWarning,
+			Message: fmt.Sprintf("Log directory not writable by this user (%s)", dir),
+		}
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+
+	return CheckResult{
+		Code:    codeLogDirWritable,
+		Status:  statusSuccess,
+		Message: fmt.Sprintf("Log directory writable (%s)", dir),
+	}
+}
+
+// checkDiskSpace reports free space on the filesystem holding path.
+// It returns false when free space can't be determined on this platform.
+func checkDiskSpace(path string) (CheckResult, bool) {
+	// The config directory may not exist yet; measure its nearest existing parent
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return CheckResult{}, false
+		}
+		path = parent
+	}
+
+	free, ok := freeDiskSpace(path)
+	if !ok {
+		return CheckResult{}, false
+	}
+
+	if free < minFreeDiskSpace {
+		return CheckResult{
+			Code:    codeDiskSpaceLow,
+			Status:  statusWarning,
+			Message: fmt.Sprintf("Low disk space: %d MB free on %s", free/(1024*1024), path),
+		}, true
+	}
+
+	return CheckResult{
+		Code:    codeDiskSpaceOK,
+		Status:  statusSuccess,
+		Message: fmt.Sprintf("Disk space OK (%d MB free)", free/(1024*1024)),
+	}, true
+}
+
+// certbotTimers are the systemd timers certbot packages install for renewal
+var certbotTimers = []string{"certbot.timer", "snap.certbot.renew.timer"}
+
+// certbotCronFiles are cron files certbot packages install for renewal
+var certbotCronFiles = []string{"/etc/cron.d/certbot"}
+
+// checkCertRenewal reports whether certbot renewal is scheduled by a systemd
+// timer or cron. It returns false when no vhost uses SSL, and for caddy,
+// which renews its own certificates.
+func checkCertRenewal(exec executor.CommandExecutor, cfg *config.Config) (CheckResult, bool) {
+	if !hasSSLVHost(cfg) || cfg.Driver == "caddy" {
+		return CheckResult{}, false
+	}
+
+	for _, timer := range certbotTimers {
+		if out, err := exec.Execute("systemctl", "is-active", timer); err == nil && strings.TrimSpace(string(out)) == "active" {
+			return CheckResult{
+				Code:    codeRenewalScheduled,
+				Status:  statusSuccess,
+				Message: fmt.Sprintf("Certificate renewal scheduled (%s)", timer),
+			}, true
+		}
+	}
+
+	for _, path := range certbotCronFiles {
+		if _, err := exec.Execute("grep", "-qs", "certbot", path); err == nil {
+			return CheckResult{
+				Code:    codeRenewalScheduled,
+				Status:  statusSuccess,
+				Message: fmt.Sprintf("Certificate renewal scheduled (%s)", path),
+			}, true
+		}
+	}
+
+	if out, err := exec.Execute("crontab", "-l"); err == nil && strings.Contains(string(out), "certbot") {
+		return CheckResult{
+			Code:    codeRenewalScheduled,
+			Status:  statusSuccess,
+			Message: "Certificate renewal scheduled (crontab)",
+		}, true
+	}
+
+	return CheckResult{
+		Code:    codeRenewalMissing,
+		Status:  statusWarning,
+		Message: "No certbot renewal timer or cron job found; certificates will expire",
+	}, true
+}
+
+// phpFPMRunDir holds the PHP-FPM sockets the PHP templates connect to
+var phpFPMRunDir = "/run/php"
+
+// phpFPMConfigGlob matches the per-version PHP-FPM config directories of
+// Debian-style installs, so stopped versions are found too
+var phpFPMConfigGlob = "/etc/php/*/fpm"
+
+// phpFPMSocket returns the socket the templates use for version
+func phpFPMSocket(version string) string {
+	return filepath.Join(phpFPMRunDir, "php"+version+"-fpm.sock")
+}
+
+// installedPHPFPMVersions returns the PHP-FPM versions found on this host,
+// from their sockets and config directories, newest first
+func installedPHPFPMVersions() []string {
+	seen := map[string]bool{}
+	var versions []string
+	add := func(version string) {
+		if version != "" && !seen[version] {
+			seen[version] = true
+			versions = append(versions, version)
+		}
+	}
+
+	sockets, _ := filepath.Glob(filepath.Join(phpFPMRunDir, "php*-fpm.sock"))
+	for _, socket := range sockets {
+		add(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(socket), "php"), "-fpm.sock"))
+	}
+	dirs, _ := filepath.Glob(phpFPMConfigGlob)
+	for _, dir := range dirs {
+		add(filepath.Base(filepath.Dir(dir)))
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.Compare("v"+versions[i], "v"+versions[j]) > 0
+	})
+	return versions
+}
+
+// checkPHPVersions verifies that the PHP-FPM version each PHP vhost uses is
+// running. Vhosts without a version use the config's default_php.
+func checkPHPVersions(exec executor.CommandExecutor, cfg *config.Config) []CheckResult {
+	// Group the vhosts by the version they need
+	needed := map[string][]string{}
+	for _, vhost := range cfg.ListVHosts() {
+		switch vhost.Type {
+		case config.TypePHP, config.TypeLaravel, config.TypeWordPress:
+		default:
+			continue
+		}
+		version := vhost.PHPVersion
+		if version == "" {
+			version = cfg.DefaultPHP
+		}
+		if version == "" {
+			version = template.DefaultPHPVersion
+		}
+		needed[version] = append(needed[version], vhost.Domain)
+	}
+	if len(needed) == 0 {
+		return nil
+	}
+
+	installed := installedPHPFPMVersions()
+	var running []string
+	for _, v := range installed {
+		if isPHPFPMRunning(exec, v) {
+			running = append(running, v)
+		}
+	}
+
+	versions := make([]string, 0, len(needed))
+	for v := range needed {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	results := []CheckResult{}
+	for _, version := range versions {
+		domains := needed[version]
+		sort.Strings(domains)
+		// Versions not found installed weren't probed above
+		isRunning := slices.Contains(running, version)
+		if !slices.Contains(installed, version) {
+			isRunning = isPHPFPMRunning(exec, version)
+		}
+		if isRunning {
+			results = append(results, CheckResult{
+				Code:    codePHPVersionOK,
+				Status:  statusSuccess,
+				Message: fmt.Sprintf("PHP-FPM %s running (used by %s)", version, strings.Join(domains, ", ")),
+			})
+			continue
+		}
+		available := "no PHP-FPM is running"
+		switch len(running) {
+		case 0:
+		case 1:
+			available = "only " + running[0] + " is running"
+		default:
+			available = "only " + strings.Join(running, ", ") + " are running"
+		}
+		for _, domain := range domains {
+			results = append(results, CheckResult{
+				Code:    codePHPVersionMissing,
+				Status:  statusError,
+				Message: fmt.Sprintf("vhost %s needs PHP %s-FPM but %s", domain, version, available),
+			})
+		}
+	}
+	return results
+}
+
+// isPHPFPMRunning reports whether PHP-FPM version is serving the socket the
+// vhost configs connect to. A running service without that socket doesn't
+// help them, so the socket must exist; where a service manager is present,
+// the service must be active too, which rules out a stale socket file.
+func isPHPFPMRunning(exec executor.CommandExecutor, version string) bool {
+	if _, err := os.Stat(phpFPMSocket(version)); err != nil {
+		return false
+	}
+
+	serviceName := fmt.Sprintf("php%s-fpm", version)
+	switch executor.DetectInitSystem(exec) {
+	case executor.InitSystemd:
+		out, err := exec.Execute("systemctl", "is-active", serviceName)
+		return err == nil && strings.TrimSpace(string(out)) == "active"
+	case executor.InitOpenRC:
+		_, err := exec.Execute("rc-service", serviceName, "status")
+		return err == nil
+	}
+
+	// Without a service manager (e.g. in a container) the socket is all there is
+	return true
 }
 
 func checkConfiguration(drv driver.Driver, cfg *config.Config) []CheckResult {
 	results := []CheckResult{}
 
 	// Check config file exists
-	configPath, pathErr := config.ConfigPath()
+	configPath, pathErr := deps.ConfigLoader.Path()
 	if pathErr == nil {
 		if _, err := os.Stat(configPath); err == nil {
 			// Use ~ notation for display
 			displayPath := strings.Replace(configPath, os.Getenv("HOME"), "~", 1)
 			results = append(results, CheckResult{
-				Status:  "success",
+				Code:    codeConfigFileFound,
+				Status:  statusSuccess,
 				Message: fmt.Sprintf("Config file exists (%s)", displayPath),
 			})
 		} else {
 			results = append(results, CheckResult{
-				Status:  "error",
+				Code:    codeConfigFileMissing,
+				Status:  statusError,
 				Message: "Config file not found",
 			})
 		}
 	} else {
 		results = append(results, CheckResult{
-			Status:  "error",
+			Code:    codeConfigPathUnknown,
+			Status:  statusError,
 			Message: "Could not determine config path",
 		})
 	}
@@ -254,23 +748,290 @@ func checkConfiguration(drv driver.Driver, cfg *config.Config) []CheckResult {
 	// Test web server config syntax
 	if err := drv.Test(); err == nil {
 		results = append(results, CheckResult{
-			Status:  "success",
+			Code:    codeConfigSyntaxOK,
+			Status:  statusSuccess,
 			Message: fmt.Sprintf("%s config syntax OK", capitalize(drv.Name())),
 		})
 	} else {
 		results = append(results, CheckResult{
-			Status:  "error",
+			Code:    codeConfigSyntaxError,
+			Status:  statusError,
 			Message: fmt.Sprintf("%s config syntax error", capitalize(drv.Name())),
 		})
 	}
 
+	// Two catch-all servers on one address stop the web server from starting
+	results = append(results, checkDefaultServers(drv)...)
+
+	// Files written by hand or other tools drift from config.yaml
+	results = append(results, checkTrackedConfigs(drv, cfg)...)
+
+	// Renewing a certificate shared by mistake changes every vhost using it
+	results = append(results, checkSharedSSLFiles(cfg)...)
+
+	return results
+}
+
+// serviceNames maps each driver to its systemd unit
+var serviceNames = map[string]string{
+	"nginx":  "nginx",
+	"apache": "apache2",
+	"caddy":  "caddy",
+}
+
+// systemdTimestampLayout is how systemctl show formats timestamps
+const systemdTimestampLayout = "Mon 2006-01-02 15:04:05 MST"
+
+// serviceStartTime returns when systemd last brought service up. systemd
+// does not record reloads, so a reload without a restart isn't reflected.
+func serviceStartTime(exec executor.CommandExecutor, service string) (time.Time, error) {
+	out, err := exec.Execute("systemctl", "show", service, "--property=ActiveEnterTimestamp")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("systemctl show %s failed: %w", service, err)
+	}
+
+	value := strings.TrimPrefix(strings.TrimSpace(string(out)), "ActiveEnterTimestamp=")
+	if value == "" {
+		return time.Time{}, fmt.Errorf("%s has not been started", service)
+	}
+
+	// systemctl prints local time; the zone abbreviation resolves against it
+	started, err := time.ParseInLocation(systemdTimestampLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse start time %q: %w", value, err)
+	}
+	return started, nil
+}
+
+// checkConfigStaleness warns about each enabled config file modified after
+// the web server last loaded its config: the later of its systemd start and
+// the last reload vhost ran
+func checkConfigStaleness(exec executor.CommandExecutor, drv driver.Driver) []CheckResult {
+	// Traefik watches its file provider directory and needs no reload
+	service, ok := serviceNames[drv.Name()]
+	if !ok {
+		return []CheckResult{{
+			Code:    codeConfigLoaded,
+			Status:  statusSuccess,
+			Message: fmt.Sprintf("%s picks up config changes without a reload", capitalize(drv.Name())),
+		}}
+	}
+
+	loaded, event := time.Time{}, ""
+	started, startErr := serviceStartTime(exec, service)
+	if startErr == nil {
+		loaded, event = started, "started"
+	}
+	if reloaded, ok := lastReload(); ok && reloaded.After(loaded) {
+		loaded, event = reloaded, "was reloaded"
+	}
+	if event == "" {
+		return []CheckResult{{
+			Code:    codeStartTimeUnknown,
+			Status:  statusWarning,
+			Message: fmt.Sprintf("Could not determine when %s started: %v", service, startErr),
+		}}
+	}
+
+	entries, err := os.ReadDir(drv.Paths().Enabled)
+	if err != nil {
+		return nil
+	}
+
+	var results []CheckResult
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		// Stat follows the enabled symlink to the file that was edited
+		info, err := os.Stat(filepath.Join(drv.Paths().Enabled, entry.Name()))
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(loaded) {
+			results = append(results, CheckResult{
+				Code:    codeConfigStale,
+				Status:  statusWarning,
+				Message: fmt.Sprintf("%s modified %s, after %s %s %s; reload to apply", entry.Name(), info.ModTime().Format(time.DateTime), service, event, loaded.Format(time.DateTime)),
+			})
+		}
+	}
+
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			Code:    codeConfigLoaded,
+			Status:  statusSuccess,
+			Message: fmt.Sprintf("No enabled config modified since %s %s", service, event),
+		})
+	}
+	return results
+}
+
+// checkTrackedConfigs diffs the driver's config files against config.yaml,
+// warning about files vhost doesn't track and tracked vhosts without a file
+func checkTrackedConfigs(drv driver.Driver, cfg *config.Config) []CheckResult {
+	listed, err := drv.List()
+	if err != nil {
+		return nil
+	}
+
+	onDisk := make(map[string]bool, len(listed))
+	var results []CheckResult
+	for _, domain := range listed {
+		onDisk[domain] = true
+		if _, tracked := cfg.VHosts[domain]; !tracked {
+			results = append(results, CheckResult{
+				Code:    codeConfigUntracked,
+				Status:  statusWarning,
+				Message: fmt.Sprintf("%s config exists but is not in config.yaml; bring it under management with 'vhost adopt %s'", domain, domain),
+			})
+		}
+	}
+
+	domains := make([]string, 0, len(cfg.VHosts))
+	for domain := range cfg.VHosts {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	for _, domain := range domains {
+		if !onDisk[domain] {
+			results = append(results, CheckResult{
+				Code:    codeVHostFileMissing,
+				Status:  statusWarning,
+				Message: fmt.Sprintf("%s is in config.yaml but has no %s config file", domain, drv.Name()),
+			})
+		}
+	}
+
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			Code:    codeConfigsTracked,
+			Status:  statusSuccess,
+			Message: "All config files are tracked in config.yaml",
+		})
+	}
+	return results
+}
+
+// checkSharedSSLFiles warns about each certificate or key file more than
+// one vhost points at. Configs without SSL vhosts get no result.
+func checkSharedSSLFiles(cfg *config.Config) []CheckResult {
+	if !hasSSLVHost(cfg) {
+		return nil
+	}
+
+	var results []CheckResult
+	for _, file := range findSharedSSLFiles(cfg) {
+		results = append(results, CheckResult{
+			Code:    codeSSLFileShared,
+			Status:  statusWarning,
+			Message: fmt.Sprintf("SSL %s %s is shared by %s; renewing it for one changes the others", file.Kind, file.Path, strings.Join(file.Domains, ", ")),
+		})
+	}
+
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			Code:    codeSSLFilesDistinct,
+			Status:  statusSuccess,
+			Message: "No SSL certificate or key shared between vhosts",
+		})
+	}
+	return results
+}
+
+// Patterns for catch-all server declarations. Each captures the address the
+// catch-all is bound to, so only catch-alls sharing an address conflict.
+var (
+	nginxDefaultServerPattern = regexp.MustCompile(`(?m)^\s*listen\s+([^\s;]+)[^;]*\bdefault_server\b`)
+	apacheDefaultHostPattern  = regexp.MustCompile(`(?m)^\s*<VirtualHost\s+(_default_(?::\d+)?)\s*>`)
+	caddyCatchAllPattern      = regexp.MustCompile(`(?m)^((?:https?://)?:\d+|https?://)\s*\{`)
+)
+
+// findDefaultServers scans the driver's enabled config files and maps each
+// catch-all address (nginx default_server, apache _default_, caddy bare
+// port) to the files declaring it. Unreadable files are skipped.
+func findDefaultServers(drv driver.Driver) (map[string][]string, error) {
+	var pattern *regexp.Regexp
+	switch drv.Name() {
+	case "nginx":
+		pattern = nginxDefaultServerPattern
+	case "apache":
+		pattern = apacheDefaultHostPattern
+	case "caddy":
+		pattern = caddyCatchAllPattern
+	default:
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(drv.Paths().Enabled)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string][]string)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		// Enabled entries are usually symlinks; ReadFile follows them
+		content, err := os.ReadFile(filepath.Join(drv.Paths().Enabled, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, match := range pattern.FindAllStringSubmatch(string(content), -1) {
+			addr := match[1]
+			if !seen[addr] {
+				seen[addr] = true
+				found[addr] = append(found[addr], entry.Name())
+			}
+		}
+	}
+	return found, nil
+}
+
+// checkDefaultServers reports each address claimed as the catch-all by more
+// than one enabled config
+func checkDefaultServers(drv driver.Driver) []CheckResult {
+	found, err := findDefaultServers(drv)
+	if err != nil || found == nil {
+		return nil
+	}
+
+	addrs := make([]string, 0, len(found))
+	for addr := range found {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	var results []CheckResult
+	for _, addr := range addrs {
+		if files := found[addr]; len(files) > 1 {
+			results = append(results, CheckResult{
+				Code:    codeDefaultConflict,
+				Status:  statusError,
+				Message: fmt.Sprintf("Multiple default servers for %s: %s", addr, strings.Join(files, ", ")),
+			})
+		}
+	}
+
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			Code:    codeDefaultServerOK,
+			Status:  statusSuccess,
+			Message: "No conflicting default servers",
+		})
+	}
 	return results
 }
 
 func checkVHosts(drv driver.Driver, cfg *config.Config) []VHostStatus {
 	statuses := []VHostStatus{}
 
-	for domain, vhost := range cfg.VHosts {
+	// Sorted so doctor --json lists vhosts in the same order every run
+	for _, domain := range sortedDomains(cfg) {
+		vhost := cfg.VHosts[domain]
 		status := VHostStatus{
 			Domain:  domain,
 			Enabled: false,
@@ -288,7 +1049,8 @@ func checkVHosts(drv driver.Driver, cfg *config.Config) []VHostStatus {
 		// Check if enabled status matches config
 		if status.Enabled != vhost.Enabled {
 			status.Checks = append(status.Checks, CheckResult{
-				Status:  "warning",
+				Code:    codeEnabledMismatch,
+				Status:  statusWarning,
 				Message: "enabled status mismatch",
 			})
 			allOK = false
@@ -298,7 +1060,8 @@ func checkVHosts(drv driver.Driver, cfg *config.Config) []VHostStatus {
 		if vhost.Root != "" {
 			if _, err := os.Stat(vhost.Root); os.IsNotExist(err) {
 				status.Checks = append(status.Checks, CheckResult{
-					Status:  "warning",
+					Code:    codeRootMissing,
+					Status:  statusWarning,
 					Message: "root directory missing",
 				})
 				allOK = false
@@ -310,7 +1073,8 @@ func checkVHosts(drv driver.Driver, cfg *config.Config) []VHostStatus {
 			if vhost.SSLCert != "" {
 				if _, err := os.Stat(vhost.SSLCert); os.IsNotExist(err) {
 					status.Checks = append(status.Checks, CheckResult{
-						Status:  "error",
+						Code:    codeSSLCertMissing,
+						Status:  statusError,
 						Message: "SSL certificate missing",
 					})
 					allOK = false
@@ -319,12 +1083,17 @@ func checkVHosts(drv driver.Driver, cfg *config.Config) []VHostStatus {
 			if vhost.SSLKey != "" {
 				if _, err := os.Stat(vhost.SSLKey); os.IsNotExist(err) {
 					status.Checks = append(status.Checks, CheckResult{
-						Status:  "error",
+						Code:    codeSSLKeyMissing,
+						Status:  statusError,
 						Message: "SSL key missing",
 					})
 					allOK = false
 				}
 			}
+			if certChecks := checkCertificate(vhost); len(certChecks) > 0 {
+				status.Checks = append(status.Checks, certChecks...)
+				allOK = false
+			}
 		}
 
 		// Add success check if all OK
@@ -334,7 +1103,8 @@ func checkVHosts(drv driver.Driver, cfg *config.Config) []VHostStatus {
 				statusText = "enabled"
 			}
 			status.Checks = append(status.Checks, CheckResult{
-				Status:  "success",
+				Code:    codeVHostOK,
+				Status:  statusSuccess,
 				Message: fmt.Sprintf("%s, config valid", statusText),
 			})
 		}
@@ -345,6 +1115,45 @@ func checkVHosts(drv driver.Driver, cfg *config.Config) []VHostStatus {
 	return statuses
 }
 
+// checkCertificate reports an SSL vhost whose certificate doesn't cover its
+// names or doesn't match its key. Missing files are reported by the caller.
+func checkCertificate(vhost *config.VHost) []CheckResult {
+	if vhost.SSLCert == "" || vhost.SSLKey == "" {
+		return nil
+	}
+	if _, err := os.Stat(vhost.SSLCert); err != nil {
+		return nil
+	}
+
+	cert, err := readCertificate(vhost.SSLCert)
+	if err != nil {
+		return []CheckResult{{
+			Code:    codeSSLCertInvalid,
+			Status:  statusError,
+			Message: fmt.Sprintf("SSL certificate unreadable: %v", err),
+		}}
+	}
+
+	var results []CheckResult
+	if missing := uncoveredNames(cert, vhost); len(missing) > 0 {
+		results = append(results, CheckResult{
+			Code:    codeSSLCertNames,
+			Status:  statusError,
+			Message: fmt.Sprintf("SSL certificate does not cover %s", strings.Join(missing, ", ")),
+		})
+	}
+	if _, err := os.Stat(vhost.SSLKey); err == nil {
+		if err := checkKeyPair(vhost.SSLCert, vhost.SSLKey); err != nil {
+			results = append(results, CheckResult{
+				Code:    codeSSLKeyMismatch,
+				Status:  statusError,
+				Message: err.Error(),
+			})
+		}
+	}
+	return results
+}
+
 func displayDoctorResults(report *DoctorReport) {
 	// System requirements
 	output.Print("Checking system requirements...")
@@ -368,11 +1177,11 @@ func displayDoctorResults(report *DoctorReport) {
 			if len(vhost.Checks) > 0 {
 				mainCheck := vhost.Checks[len(vhost.Checks)-1]
 				switch mainCheck.Status {
-				case "success":
+				case statusSuccess:
 					output.Success("%s - %s", vhost.Domain, mainCheck.Message)
-				case "warning":
+				case statusWarning:
 					output.Warn("%s - %s", vhost.Domain, mainCheck.Message)
-				case "error":
+				case statusError:
 					output.Error("%s - %s", vhost.Domain, mainCheck.Message)
 				}
 			}
@@ -384,11 +1193,11 @@ func displayDoctorResults(report *DoctorReport) {
 
 func displayCheck(check CheckResult) {
 	switch check.Status {
-	case "success":
+	case statusSuccess:
 		output.Success("%s", check.Message)
-	case "warning":
+	case statusWarning:
 		output.Warn("%s", check.Message)
-	case "error":
+	case statusError:
 		output.Error("%s", check.Message)
 	}
 }
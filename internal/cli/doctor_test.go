@@ -1,15 +1,23 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
 	"github.com/ksyq12/vhost/internal/executor"
+	"github.com/ksyq12/vhost/internal/ssl"
 )
 
 func TestCheckSystemRequirements(t *testing.T) {
@@ -318,6 +326,28 @@ func TestCheckVHosts(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "vhosts in domain order",
+			setupDriver: func(available, enabled string) *driver.MockDriver {
+				return driver.NewMockDriver("nginx", available, enabled)
+			},
+			setupConfig: func(t *testing.T) *config.Config {
+				cfg := config.New()
+				for _, domain := range []string{"c.com", "a.com", "d.com", "b.com"} {
+					cfg.VHosts[domain] = &config.VHost{Domain: domain, Type: "proxy", ProxyPass: "http://localhost:3000"}
+				}
+				return cfg
+			},
+			checkResults: func(t *testing.T, statuses []VHostStatus) {
+				var got []string
+				for _, status := range statuses {
+					got = append(got, status.Domain)
+				}
+				if strings.Join(got, ",") != "a.com,b.com,c.com,d.com" {
+					t.Errorf("expected vhosts sorted by domain, got %v", got)
+				}
+			},
+		},
 		{
 			name: "vhost enabled status mismatch",
 			setupDriver: func(available, enabled string) *driver.MockDriver {
@@ -468,6 +498,173 @@ func TestCheckVHosts(t *testing.T) {
 	}
 }
 
+// checkCodes returns the codes of the given check results
+func checkCodes(results []CheckResult) map[string]string {
+	codes := make(map[string]string, len(results))
+	for _, r := range results {
+		codes[r.Code] = r.Status
+	}
+	return codes
+}
+
+func TestDoctorCheckCodes(t *testing.T) {
+	t.Run("system requirements all installed", func(t *testing.T) {
+		fakePHPFPM(t, []string{"8.3"}, nil)
+		mockExec := &executor.MockExecutor{
+			ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+				if name == "systemctl" && len(args) >= 2 && args[0] == "is-active" {
+					return []byte("active"), nil
+				}
+				return []byte(""), nil
+			},
+		}
+		ssl.SetExecutor(mockExec)
+		defer ssl.ResetExecutor()
+
+		codes := checkCodes(checkSystemRequirements(mockExec, config.New()))
+		for _, code := range []string{"nginx_installed", "apache_installed", "caddy_installed", codePHPFPMRunning, codeCertbotInstalled} {
+			if codes[code] != statusSuccess {
+				t.Errorf("expected code %s with success status, got %v", code, codes)
+			}
+		}
+	})
+
+	t.Run("system requirements missing", func(t *testing.T) {
+		fakePHPFPM(t, nil, nil)
+		mockExec := &executor.MockExecutor{
+			LookPathFunc: func(file string) (string, error) {
+				return "", os.ErrNotExist
+			},
+			ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+				return []byte("inactive"), fmt.Errorf("not running")
+			},
+		}
+		ssl.SetExecutor(mockExec)
+		defer ssl.ResetExecutor()
+
+		cfg := config.New()
+		cfg.Driver = "apache"
+		codes := checkCodes(checkSystemRequirements(mockExec, cfg))
+		if codes["apache_missing"] != statusError {
+			t.Errorf("expected apache_missing error, got %v", codes)
+		}
+		if codes["nginx_missing"] != statusWarning {
+			t.Errorf("expected nginx_missing warning, got %v", codes)
+		}
+		if codes[codeCertbotMissing] != statusWarning {
+			t.Errorf("expected %s warning, got %v", codeCertbotMissing, codes)
+		}
+		if codes[codePHPFPMMissing] != statusWarning {
+			t.Errorf("expected %s warning, got %v", codePHPFPMMissing, codes)
+		}
+	})
+
+	t.Run("configuration syntax", func(t *testing.T) {
+		drv := driver.NewMockDriver("nginx", "/tmp/available", "/tmp/enabled")
+		codes := checkCodes(checkConfiguration(drv, config.New()))
+		if codes[codeConfigSyntaxOK] != statusSuccess {
+			t.Errorf("expected %s, got %v", codeConfigSyntaxOK, codes)
+		}
+		_, found := codes[codeConfigFileFound]
+		_, missing := codes[codeConfigFileMissing]
+		if !found && !missing {
+			t.Errorf("expected a config file code, got %v", codes)
+		}
+
+		drv.TestFunc = func() error { return os.ErrInvalid }
+		codes = checkCodes(checkConfiguration(drv, config.New()))
+		if codes[codeConfigSyntaxError] != statusError {
+			t.Errorf("expected %s, got %v", codeConfigSyntaxError, codes)
+		}
+	})
+
+	t.Run("vhost checks", func(t *testing.T) {
+		rootDir := t.TempDir()
+		drv := driver.NewMockDriver("nginx", "/tmp/available", "/tmp/enabled")
+		drv.IsEnabledFunc = func(domain string) (bool, error) {
+			return domain == "ok.com", nil
+		}
+
+		cfg := config.New()
+		cfg.VHosts["ok.com"] = &config.VHost{Domain: "ok.com", Root: rootDir, Enabled: true}
+		cfg.VHosts["broken.com"] = &config.VHost{
+			Domain:  "broken.com",
+			Root:    "/nonexistent/root",
+			SSL:     true,
+			SSLCert: "/nonexistent/cert.pem",
+			SSLKey:  "/nonexistent/key.pem",
+			Enabled: true,
+		}
+
+		for _, status := range checkVHosts(drv, cfg) {
+			codes := checkCodes(status.Checks)
+			switch status.Domain {
+			case "ok.com":
+				if codes[codeVHostOK] != statusSuccess {
+					t.Errorf("expected %s for ok.com, got %v", codeVHostOK, codes)
+				}
+			case "broken.com":
+				expected := map[string]string{
+					codeEnabledMismatch: statusWarning,
+					codeRootMissing:     statusWarning,
+					codeSSLCertMissing:  statusError,
+					codeSSLKeyMissing:   statusError,
+				}
+				for code, want := range expected {
+					if codes[code] != want {
+						t.Errorf("expected %s=%s for broken.com, got %v", code, want, codes)
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestCheckVHostsCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeTestCert(t, dir, "site", "example.com", "www.example.com")
+	_, otherKey := writeTestCert(t, dir, "other", "other.com")
+	drv := driver.NewMockDriver("nginx", dir, dir)
+
+	tests := []struct {
+		name     string
+		domain   string
+		key      string
+		expected map[string]string
+	}{
+		{"matching pair", "example.com", keyPath, map[string]string{codeVHostOK: statusSuccess}},
+		{"wrong domain", "example.org", keyPath, map[string]string{codeSSLCertNames: statusError}},
+		{"mismatched key", "example.com", otherKey, map[string]string{codeSSLKeyMismatch: statusError}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.VHosts[tt.domain] = &config.VHost{
+				Domain:  tt.domain,
+				Aliases: []string{"www.example.com"},
+				SSL:     true,
+				SSLCert: certPath,
+				SSLKey:  tt.key,
+			}
+
+			statuses := checkVHosts(drv, cfg)
+			if len(statuses) != 1 {
+				t.Fatalf("expected 1 status, got %d", len(statuses))
+			}
+			codes := checkCodes(statuses[0].Checks)
+			if len(codes) != len(tt.expected) {
+				t.Errorf("expected codes %v, got %v", tt.expected, codes)
+			}
+			for code, want := range tt.expected {
+				if codes[code] != want {
+					t.Errorf("expected %s=%s, got %v", code, want, codes)
+				}
+			}
+		})
+	}
+}
+
 func TestCapitalize(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -490,3 +687,751 @@ func TestCapitalize(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckLogDir(t *testing.T) {
+	t.Run("writable", func(t *testing.T) {
+		dir := t.TempDir()
+		result := checkLogDir(dir)
+		if result.Code != codeLogDirWritable || result.Status != statusSuccess {
+			t.Errorf("expected %s success, got %+v", codeLogDirWritable, result)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 0 {
+			t.Errorf("probe file left behind: %v", entries)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		result := checkLogDir(filepath.Join(t.TempDir(), "nope"))
+		if result.Code != codeLogDirMissing || result.Status != statusWarning {
+			t.Errorf("expected %s warning, got %+v", codeLogDirMissing, result)
+		}
+	})
+
+	t.Run("not writable", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("root bypasses directory permissions")
+		}
+		dir := t.TempDir()
+		if err := os.Chmod(dir, 0500); err != nil {
+			t.Fatalf("chmod failed: %v", err)
+		}
+		defer func() { _ = os.Chmod(dir, 0700) }()
+
+		result := checkLogDir(dir)
+		if result.Code != codeLogDirNotWritable || result.Status != statusWarning {
+			t.Errorf("expected %s warning, got %+v", codeLogDirNotWritable, result)
+		}
+	})
+}
+
+func TestCheckCertRenewal(t *testing.T) {
+	sslConfig := func() *config.Config {
+		cfg := config.New()
+		cfg.VHosts["secure.com"] = &config.VHost{Domain: "secure.com", SSL: true}
+		return cfg
+	}
+	systemctl := func(active string) *executor.MockExecutor {
+		return &executor.MockExecutor{
+			ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+				if name == "systemctl" && len(args) == 2 && args[1] == active {
+					return []byte("active\n"), nil
+				}
+				return []byte("inactive\n"), fmt.Errorf("exit status 3")
+			},
+		}
+	}
+
+	t.Run("timer active", func(t *testing.T) {
+		result, ok := checkCertRenewal(systemctl("certbot.timer"), sslConfig())
+		if !ok || result.Code != codeRenewalScheduled || result.Status != statusSuccess {
+			t.Errorf("expected %s success, got %+v (ok=%v)", codeRenewalScheduled, result, ok)
+		}
+	})
+
+	t.Run("timer inactive with SSL vhosts", func(t *testing.T) {
+		result, ok := checkCertRenewal(systemctl(""), sslConfig())
+		if !ok || result.Code != codeRenewalMissing || result.Status != statusWarning {
+			t.Errorf("expected %s warning, got %+v (ok=%v)", codeRenewalMissing, result, ok)
+		}
+	})
+
+	t.Run("cron entry", func(t *testing.T) {
+		mockExec := &executor.MockExecutor{
+			ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+				if name == "grep" && args[len(args)-1] == "/etc/cron.d/certbot" {
+					return nil, nil
+				}
+				return nil, fmt.Errorf("exit status 1")
+			},
+		}
+		result, ok := checkCertRenewal(mockExec, sslConfig())
+		if !ok || result.Code != codeRenewalScheduled || !strings.Contains(result.Message, "/etc/cron.d/certbot") {
+			t.Errorf("expected %s from the cron file, got %+v (ok=%v)", codeRenewalScheduled, result, ok)
+		}
+	})
+
+	t.Run("crontab entry", func(t *testing.T) {
+		mockExec := &executor.MockExecutor{
+			ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+				if name == "crontab" {
+					return []byte("0 3 * * * certbot renew -q\n"), nil
+				}
+				return nil, fmt.Errorf("exit status 1")
+			},
+		}
+		result, ok := checkCertRenewal(mockExec, sslConfig())
+		if !ok || result.Code != codeRenewalScheduled || !strings.Contains(result.Message, "crontab") {
+			t.Errorf("expected %s from crontab, got %+v (ok=%v)", codeRenewalScheduled, result, ok)
+		}
+	})
+
+	t.Run("caddy skips the check", func(t *testing.T) {
+		cfg := sslConfig()
+		cfg.Driver = "caddy"
+		mockExec := systemctl("")
+		if _, ok := checkCertRenewal(mockExec, cfg); ok {
+			t.Error("expected the check to be skipped for caddy")
+		}
+		if len(mockExec.Calls) != 0 {
+			t.Errorf("expected no commands, got %v", mockExec.Calls)
+		}
+	})
+
+	t.Run("no SSL vhosts skips the check", func(t *testing.T) {
+		mockExec := systemctl("")
+		if _, ok := checkCertRenewal(mockExec, config.New()); ok {
+			t.Error("expected the check to be skipped")
+		}
+		if len(mockExec.Calls) != 0 {
+			t.Errorf("expected no commands, got %v", mockExec.Calls)
+		}
+	})
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	// A not-yet-created config directory is measured via its parent
+	result, ok := checkDiskSpace(filepath.Join(t.TempDir(), "missing", "vhost"))
+	if !ok {
+		t.Skip("free disk space not available on this platform")
+	}
+	if result.Code != codeDiskSpaceOK && result.Code != codeDiskSpaceLow {
+		t.Errorf("unexpected disk space code: %+v", result)
+	}
+}
+
+func TestCheckPortListening(t *testing.T) {
+	t.Run("listening", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		defer func() { _ = listener.Close() }()
+
+		result := checkPortListening(listener.Addr().String(), time.Second)
+		if result.Code != codePortListening || result.Status != statusSuccess {
+			t.Errorf("expected port_listening success, got %+v", result)
+		}
+	})
+
+	t.Run("closed port", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		addr := listener.Addr().String()
+		_ = listener.Close()
+
+		result := checkPortListening(addr, time.Second)
+		if result.Code != codePortNotListening || result.Status != statusError {
+			t.Errorf("expected port_not_listening error, got %+v", result)
+		}
+		if !strings.Contains(result.Message, addr) {
+			t.Errorf("message should name the address, got %q", result.Message)
+		}
+	})
+}
+
+func TestCheckDefaultServers(t *testing.T) {
+	tests := []struct {
+		name     string
+		driver   string
+		files    map[string]string
+		wantCode string
+		wantAddr string
+	}{
+		{
+			name:   "nginx duplicate default_server",
+			driver: "nginx",
+			files: map[string]string{
+				"a.com": "server {\n    listen 80 default_server;\n    server_name a.com;\n}\n",
+				"b.com": "server {\n    listen 80 default_server;\n    listen [::]:80 default_server;\n}\n",
+			},
+			wantCode: codeDefaultConflict,
+			wantAddr: "80: a.com, b.com",
+		},
+		{
+			name:   "nginx single default_server",
+			driver: "nginx",
+			files: map[string]string{
+				"a.com": "server {\n    listen 80 default_server;\n    listen [::]:80 default_server;\n}\n",
+				"b.com": "server {\n    listen 80;\n    server_name b.com;\n}\n",
+			},
+			wantCode: codeDefaultServerOK,
+		},
+		{
+			name:   "nginx defaults on different ports",
+			driver: "nginx",
+			files: map[string]string{
+				"a.com": "server {\n    listen 80 default_server;\n}\n",
+				"b.com": "server {\n    listen 443 ssl default_server;\n}\n",
+			},
+			wantCode: codeDefaultServerOK,
+		},
+		{
+			name:   "apache duplicate _default_",
+			driver: "apache",
+			files: map[string]string{
+				"a.com.conf": "<VirtualHost _default_:80>\n</VirtualHost>\n",
+				"b.com.conf": "<VirtualHost _default_:80>\n</VirtualHost>\n",
+			},
+			wantCode: codeDefaultConflict,
+			wantAddr: "_default_:80: a.com.conf, b.com.conf",
+		},
+		{
+			name:   "caddy duplicate catch-all",
+			driver: "caddy",
+			files: map[string]string{
+				"a.com": ":80 {\n    respond 404\n}\n",
+				"b.com": ":80 {\n    respond 404\n}\n",
+				"c.com": "http://c.com {\n}\n",
+			},
+			wantCode: codeDefaultConflict,
+			wantAddr: ":80: a.com, b.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			available := filepath.Join(tempDir, "sites-available")
+			enabled := filepath.Join(tempDir, "sites-enabled")
+			for _, dir := range []string{available, enabled} {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatalf("failed to create dir: %v", err)
+				}
+			}
+			for name, content := range tt.files {
+				path := filepath.Join(available, name)
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write config: %v", err)
+				}
+				if err := os.Symlink(path, filepath.Join(enabled, name)); err != nil {
+					t.Fatalf("failed to enable config: %v", err)
+				}
+			}
+			// Disabled configs are not scanned
+			if err := os.WriteFile(filepath.Join(available, "disabled.com"), []byte("listen 80 default_server;\n<VirtualHost _default_:80>\n:80 {\n"), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			results := checkDefaultServers(driver.NewMockDriver(tt.driver, available, enabled))
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %v", results)
+			}
+			if results[0].Code != tt.wantCode {
+				t.Errorf("expected code %s, got %s (%s)", tt.wantCode, results[0].Code, results[0].Message)
+			}
+			if tt.wantAddr != "" && !strings.HasSuffix(results[0].Message, tt.wantAddr) {
+				t.Errorf("expected message ending in %q, got %q", tt.wantAddr, results[0].Message)
+			}
+		})
+	}
+
+	t.Run("traefik is not scanned", func(t *testing.T) {
+		tempDir := t.TempDir()
+		if results := checkDefaultServers(driver.NewMockDriver("traefik", tempDir, tempDir)); len(results) != 0 {
+			t.Errorf("expected no results, got %v", results)
+		}
+	})
+}
+
+func TestWatchDoctor(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+	mockExec := &executor.MockExecutor{
+		ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+			return nil, fmt.Errorf("not available")
+		},
+		LookPathFunc: func(file string) (string, error) {
+			return "", fmt.Errorf("not found")
+		},
+	}
+	cfg := config.New()
+	cfg.VHosts["example.com"] = &config.VHost{Domain: "example.com", Type: "static", Root: tempDir, Enabled: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	rounds := 0
+	err := watchDoctor(ctx, 10*time.Millisecond, func() *DoctorReport {
+		return runDoctorChecks(mockExec, mockDrv, cfg)
+	}, func(report *DoctorReport) error {
+		rounds++
+		if rounds == 3 {
+			cancel()
+		}
+		return encoder.Encode(report)
+	})
+	if err != nil {
+		t.Fatalf("watchDoctor failed: %v", err)
+	}
+	if rounds != 3 {
+		t.Errorf("expected 3 rounds, got %d", rounds)
+	}
+
+	// --json --watch emits one compact report object per line
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Errorf("expected one line per report, got %d lines", lines)
+	}
+	decoder := json.NewDecoder(&buf)
+	reports := 0
+	for decoder.More() {
+		var report DoctorReport
+		if err := decoder.Decode(&report); err != nil {
+			t.Fatalf("invalid JSON stream: %v", err)
+		}
+		if report.SchemaVersion != doctorSchemaVersion || len(report.VHosts) != 1 {
+			t.Errorf("unexpected report: %+v", report)
+		}
+		reports++
+	}
+	if reports != 3 {
+		t.Errorf("expected 3 reports, got %d", reports)
+	}
+
+	t.Run("show error stops watch", func(t *testing.T) {
+		err := watchDoctor(context.Background(), time.Millisecond, func() *DoctorReport {
+			return &DoctorReport{}
+		}, func(*DoctorReport) error {
+			return fmt.Errorf("broken pipe")
+		})
+		if err == nil || err.Error() != "broken pipe" {
+			t.Errorf("expected broken pipe error, got %v", err)
+		}
+	})
+}
+
+func TestRunDoctorResolvesDriver(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := config.New()
+	cfg.Paths = &config.DriverPaths{
+		Available: filepath.Join(tempDir, "sites-available"),
+		Enabled:   filepath.Join(tempDir, "sites-enabled"),
+	}
+
+	// Two catch-alls in the configured enabled directory, found only when
+	// the checks scan the driver's paths rather than the distro defaults
+	for _, dir := range []string{cfg.Paths.Available, cfg.Paths.Enabled} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+	for _, name := range []string{"a.com", "b.com"} {
+		if err := os.WriteFile(filepath.Join(cfg.Paths.Enabled, name), []byte("server {\n    listen 80 default_server;\n}\n"), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+
+	factory := &pathsRecordingFactory{}
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriverFactory(factory).WithConfigDir(t.TempDir()).Build()
+	defer func() { deps = oldDeps }()
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+
+	// The report may fail on this machine; only the driver matters here
+	out := captureStdout(func() { _ = runDoctor(doctorCmd, nil) })
+
+	if factory.paths.Available != cfg.Paths.Available || factory.paths.Enabled != cfg.Paths.Enabled {
+		t.Errorf("expected the configured paths, got %+v", factory.paths)
+	}
+
+	var report DoctorReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if _, ok := checkCodes(report.Configuration)[codeDefaultConflict]; !ok {
+		t.Errorf("expected %s from the configured enabled directory, got %+v", codeDefaultConflict, report.Configuration)
+	}
+}
+
+func TestCheckConfigStaleness(t *testing.T) {
+	started := time.Date(2026, 10, 13, 9, 0, 0, 0, time.UTC)
+	systemctl := func(out string, err error) *executor.MockExecutor {
+		return &executor.MockExecutor{
+			ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+				if name == "systemctl" && len(args) == 3 && args[0] == "show" && args[1] == "nginx" && args[2] == "--property=ActiveEnterTimestamp" {
+					return []byte(out), err
+				}
+				return nil, fmt.Errorf("unexpected command %s %v", name, args)
+			},
+		}
+	}
+
+	setup := func(t *testing.T, modified time.Time) *driver.MockDriver {
+		available, enabled := t.TempDir(), t.TempDir()
+		target := filepath.Join(available, "example.com.conf")
+		if err := os.WriteFile(target, []byte("server {}"), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if err := os.Chtimes(target, modified, modified); err != nil {
+			t.Fatalf("failed to set mtime: %v", err)
+		}
+		if err := os.Symlink(target, filepath.Join(enabled, "example.com.conf")); err != nil {
+			t.Fatalf("failed to link config: %v", err)
+		}
+		return driver.NewMockDriver("nginx", available, enabled)
+	}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfigDir(t.TempDir()).Build()
+	defer func() { deps = oldDeps }()
+
+	t.Run("config newer than start", func(t *testing.T) {
+		drv := setup(t, started.Add(time.Hour))
+		results := checkConfigStaleness(systemctl("ActiveEnterTimestamp=Tue 2026-10-13 09:00:00 UTC\n", nil), drv)
+		if len(results) != 1 || results[0].Code != codeConfigStale || results[0].Status != statusWarning {
+			t.Fatalf("expected one %s warning, got %+v", codeConfigStale, results)
+		}
+		if !strings.Contains(results[0].Message, "example.com.conf") {
+			t.Errorf("expected file name in message, got %q", results[0].Message)
+		}
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		drv := setup(t, started.Add(-time.Hour))
+		results := checkConfigStaleness(systemctl("ActiveEnterTimestamp=Tue 2026-10-13 09:00:00 UTC\n", nil), drv)
+		if len(results) != 1 || results[0].Code != codeConfigLoaded || results[0].Status != statusSuccess {
+			t.Errorf("expected %s success, got %+v", codeConfigLoaded, results)
+		}
+	})
+
+	t.Run("never started", func(t *testing.T) {
+		drv := setup(t, started)
+		results := checkConfigStaleness(systemctl("ActiveEnterTimestamp=\n", nil), drv)
+		if len(results) != 1 || results[0].Code != codeStartTimeUnknown {
+			t.Errorf("expected %s, got %+v", codeStartTimeUnknown, results)
+		}
+	})
+
+	t.Run("traefik needs no reload", func(t *testing.T) {
+		drv := driver.NewMockDriver("traefik", t.TempDir(), t.TempDir())
+		results := checkConfigStaleness(systemctl("", errors.New("no unit")), drv)
+		if len(results) != 1 || results[0].Code != codeConfigLoaded || results[0].Status != statusSuccess {
+			t.Errorf("expected %s success, got %+v", codeConfigLoaded, results)
+		}
+	})
+
+	// Runs last: the reload record it writes would mask the start times above
+	t.Run("reloaded by vhost after the edit", func(t *testing.T) {
+		drv := setup(t, time.Now().Add(-time.Minute))
+		if err := reloadServer(drv); err != nil {
+			t.Fatalf("reload failed: %v", err)
+		}
+		results := checkConfigStaleness(systemctl("ActiveEnterTimestamp=Tue 2026-10-13 09:00:00 UTC\n", nil), drv)
+		if len(results) != 1 || results[0].Code != codeConfigLoaded || !strings.Contains(results[0].Message, "was reloaded") {
+			t.Errorf("expected %s after the recorded reload, got %+v", codeConfigLoaded, results)
+		}
+
+		// Without a record the systemd start time is all there is
+		if err := os.Remove(filepath.Join(deps.ConfigLoader.(*MockConfigLoader).ConfigDir, reloadStampFile)); err != nil {
+			t.Fatalf("expected a reload record: %v", err)
+		}
+		results = checkConfigStaleness(systemctl("ActiveEnterTimestamp=Tue 2026-10-13 09:00:00 UTC\n", nil), drv)
+		if len(results) != 1 || results[0].Code != codeConfigStale {
+			t.Errorf("expected %s without the record, got %+v", codeConfigStale, results)
+		}
+	})
+}
+
+func TestCheckTrackedConfigs(t *testing.T) {
+	drv := driver.NewMockDriver("nginx", "/tmp/available", "/tmp/enabled")
+	cfg := config.New()
+	cfg.VHosts["tracked.com"] = &config.VHost{Domain: "tracked.com", Type: config.TypeStatic}
+
+	t.Run("in sync", func(t *testing.T) {
+		drv.ListFunc = func() ([]string, error) { return []string{"tracked.com"}, nil }
+		codes := checkCodes(checkTrackedConfigs(drv, cfg))
+		if len(codes) != 1 || codes[codeConfigsTracked] != statusSuccess {
+			t.Errorf("expected only %s, got %v", codeConfigsTracked, codes)
+		}
+	})
+
+	t.Run("untracked file on disk", func(t *testing.T) {
+		drv.ListFunc = func() ([]string, error) { return []string{"orphan.com", "tracked.com"}, nil }
+		results := checkTrackedConfigs(drv, cfg)
+		if len(results) != 1 || results[0].Code != codeConfigUntracked || results[0].Status != statusWarning {
+			t.Fatalf("expected one %s warning, got %+v", codeConfigUntracked, results)
+		}
+		if !strings.Contains(results[0].Message, "orphan.com") || !strings.Contains(results[0].Message, "vhost adopt orphan.com") {
+			t.Errorf("expected orphan domain and suggestion in message, got %q", results[0].Message)
+		}
+	})
+
+	t.Run("tracked vhost without file", func(t *testing.T) {
+		drv.ListFunc = func() ([]string, error) { return []string{}, nil }
+		results := checkTrackedConfigs(drv, cfg)
+		if len(results) != 1 || results[0].Code != codeVHostFileMissing || results[0].Status != statusWarning {
+			t.Fatalf("expected one %s warning, got %+v", codeVHostFileMissing, results)
+		}
+		if !strings.Contains(results[0].Message, "tracked.com") {
+			t.Errorf("expected tracked domain in message, got %q", results[0].Message)
+		}
+	})
+
+	t.Run("list error skips the check", func(t *testing.T) {
+		drv.ListFunc = func() ([]string, error) { return nil, os.ErrPermission }
+		if results := checkTrackedConfigs(drv, cfg); len(results) != 0 {
+			t.Errorf("expected no results, got %+v", results)
+		}
+	})
+}
+
+// fakePHPFPM points the PHP-FPM detection at a temporary run directory
+// holding sockets for running, and config directories for installed
+func fakePHPFPM(t *testing.T, running, installed []string) {
+	t.Helper()
+	runDir, etcDir := t.TempDir(), t.TempDir()
+	for _, version := range running {
+		if err := os.WriteFile(filepath.Join(runDir, "php"+version+"-fpm.sock"), nil, 0644); err != nil {
+			t.Fatalf("failed to create socket: %v", err)
+		}
+	}
+	for _, version := range installed {
+		if err := os.MkdirAll(filepath.Join(etcDir, version, "fpm"), 0755); err != nil {
+			t.Fatalf("failed to create config dir: %v", err)
+		}
+	}
+
+	oldRun, oldGlob := phpFPMRunDir, phpFPMConfigGlob
+	phpFPMRunDir, phpFPMConfigGlob = runDir, filepath.Join(etcDir, "*", "fpm")
+	t.Cleanup(func() { phpFPMRunDir, phpFPMConfigGlob = oldRun, oldGlob })
+}
+
+func TestInstalledPHPFPMVersions(t *testing.T) {
+	fakePHPFPM(t, []string{"8.3", "7.4"}, []string{"8.1", "8.3", "8.10"})
+
+	got := installedPHPFPMVersions()
+	want := []string{"8.10", "8.3", "8.1", "7.4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("installedPHPFPMVersions() = %v, want %v", got, want)
+	}
+}
+
+func TestIsPHPFPMRunning(t *testing.T) {
+	systemctl := func(state string) *executor.MockExecutor {
+		return &executor.MockExecutor{
+			ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+				if state == "active" {
+					return []byte("active\n"), nil
+				}
+				return []byte(state + "\n"), fmt.Errorf("exit status 3")
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		socket  bool
+		exec    *executor.MockExecutor
+		running bool
+	}{
+		{"active with socket", true, systemctl("active"), true},
+		{"active without socket", false, systemctl("active"), false},
+		{"stale socket", true, systemctl("inactive"), false},
+		{"socket without a service manager", true, &executor.MockExecutor{
+			LookPathFunc: func(string) (string, error) { return "", os.ErrNotExist },
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running []string
+			if tt.socket {
+				running = []string{"8.2"}
+			}
+			fakePHPFPM(t, running, nil)
+			if got := isPHPFPMRunning(tt.exec, "8.2"); got != tt.running {
+				t.Errorf("isPHPFPMRunning() = %v, want %v", got, tt.running)
+			}
+		})
+	}
+}
+
+func TestCheckPHPVersions(t *testing.T) {
+	// 8.1 is installed but stopped; only PHP-FPM 8.2 is active
+	fakePHPFPM(t, []string{"8.2"}, []string{"8.1", "8.2"})
+	mockExec := &executor.MockExecutor{
+		ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+			if name == "systemctl" && len(args) == 2 && args[0] == "is-active" && args[1] == "php8.2-fpm" {
+				return []byte("active\n"), nil
+			}
+			return []byte("inactive\n"), fmt.Errorf("not running")
+		},
+	}
+
+	cfg := config.New()
+	cfg.VHosts["app.com"] = &config.VHost{Domain: "app.com", Type: config.TypeLaravel, PHPVersion: "8.1"}
+	cfg.VHosts["blog.com"] = &config.VHost{Domain: "blog.com", Type: config.TypeWordPress}
+	cfg.VHosts["static.com"] = &config.VHost{Domain: "static.com", Type: config.TypeStatic, PHPVersion: "7.4"}
+
+	results := checkPHPVersions(mockExec, cfg)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %v", results)
+	}
+
+	// Results are ordered by version: 8.1 for app.com, then the default 8.2
+	missing := results[0]
+	if missing.Code != codePHPVersionMissing || missing.Status != statusError {
+		t.Errorf("expected %s error, got %+v", codePHPVersionMissing, missing)
+	}
+	want := "vhost app.com needs PHP 8.1-FPM but only 8.2 is running"
+	if missing.Message != want {
+		t.Errorf("message = %q, want %q", missing.Message, want)
+	}
+	if results[1].Code != codePHPVersionOK || !strings.Contains(results[1].Message, "blog.com") {
+		t.Errorf("expected %s for blog.com, got %+v", codePHPVersionOK, results[1])
+	}
+
+	t.Run("no php vhosts", func(t *testing.T) {
+		cfg := config.New()
+		cfg.VHosts["static.com"] = &config.VHost{Domain: "static.com", Type: config.TypeStatic}
+		if results := checkPHPVersions(mockExec, cfg); len(results) != 0 {
+			t.Errorf("expected no results, got %v", results)
+		}
+	})
+}
+
+func TestDoctorReportFailure(t *testing.T) {
+	ok := CheckResult{Code: codeVHostOK, Status: statusSuccess, Message: "ok"}
+	warn := CheckResult{Code: codeLogDirMissing, Status: statusWarning, Message: "warn"}
+	fail := CheckResult{Code: codeSSLCertMissing, Status: statusError, Message: "fail"}
+
+	tests := []struct {
+		name    string
+		report  *DoctorReport
+		strict  bool
+		wantErr string
+	}{
+		{
+			name:   "successes only",
+			report: &DoctorReport{SystemRequirements: []CheckResult{ok}, Configuration: []CheckResult{ok}},
+		},
+		{
+			name:   "warnings do not fail",
+			report: &DoctorReport{SystemRequirements: []CheckResult{ok, warn}},
+		},
+		{
+			name:    "vhost error fails",
+			report:  &DoctorReport{VHosts: []VHostStatus{{Domain: "a.com", Checks: []CheckResult{fail, ok}}}},
+			wantErr: "doctor found 1 error(s)",
+		},
+		{
+			name:    "strict fails on warnings",
+			report:  &DoctorReport{Configuration: []CheckResult{warn}},
+			strict:  true,
+			wantErr: "doctor found 1 warning(s) (--strict)",
+		},
+		{
+			name:    "strict counts both",
+			report:  &DoctorReport{SystemRequirements: []CheckResult{fail}, Configuration: []CheckResult{warn, warn}},
+			strict:  true,
+			wantErr: "doctor found 1 error(s) and 2 warning(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.report.failure(tt.strict)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			var exitErr *exitCodeError
+			if !errors.As(err, &exitErr) || exitErr.code != exitDoctorFailed {
+				t.Errorf("expected exit code %d, got %v", exitDoctorFailed, err)
+			}
+		})
+	}
+}
+
+func TestCheckSharedSSLFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	aCert, aKey := writeTestCert(t, tempDir, "a", "a.com")
+	bCert, bKey := writeTestCert(t, tempDir, "b", "b.com")
+	sanCert, sanKey := writeTestCert(t, tempDir, "san", "a.com", "b.com")
+
+	sslVHost := func(domain, cert, key string) *config.VHost {
+		return &config.VHost{Domain: domain, Type: config.TypeStatic, SSL: true, SSLCert: cert, SSLKey: key}
+	}
+
+	tests := []struct {
+		name  string
+		a, b  *config.VHost
+		codes []string // codes of the results, in order
+	}{
+		{"distinct files", sslVHost("a.com", aCert, aKey), sslVHost("b.com", bCert, bKey), []string{codeSSLFilesDistinct}},
+		{"same certificate", sslVHost("a.com", aCert, aKey), sslVHost("b.com", aCert, aKey), []string{codeSSLFileShared, codeSSLFileShared}},
+		{"same key only", sslVHost("a.com", aCert, aKey), sslVHost("b.com", bCert, aKey), []string{codeSSLFileShared}},
+		{"one certificate for both names", sslVHost("a.com", sanCert, sanKey), sslVHost("b.com", sanCert, sanKey), []string{codeSSLFilesDistinct}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.VHosts["a.com"] = tt.a
+			cfg.VHosts["b.com"] = tt.b
+
+			results := checkSharedSSLFiles(cfg)
+			var codes []string
+			for _, r := range results {
+				codes = append(codes, r.Code)
+				if r.Code == codeSSLFileShared {
+					if r.Status != statusWarning || !strings.Contains(r.Message, "a.com, b.com") {
+						t.Errorf("expected a warning naming both vhosts, got %+v", r)
+					}
+				}
+			}
+			if !slices.Equal(codes, tt.codes) {
+				t.Errorf("expected codes %v, got %+v", tt.codes, results)
+			}
+		})
+	}
+
+	t.Run("shared certificate names the file", func(t *testing.T) {
+		cfg := config.New()
+		cfg.VHosts["a.com"] = sslVHost("a.com", aCert, aKey)
+		cfg.VHosts["b.com"] = sslVHost("b.com", aCert, bKey)
+
+		results := checkSharedSSLFiles(cfg)
+		if len(results) != 1 || !strings.Contains(results[0].Message, "SSL certificate "+aCert+" is shared by a.com, b.com") {
+			t.Errorf("unexpected results %+v", results)
+		}
+	})
+
+	t.Run("no SSL vhosts", func(t *testing.T) {
+		cfg := config.New()
+		cfg.VHosts["a.com"] = &config.VHost{Domain: "a.com", Type: config.TypeStatic}
+		if results := checkSharedSSLFiles(cfg); len(results) != 0 {
+			t.Errorf("expected no results, got %+v", results)
+		}
+	})
+}
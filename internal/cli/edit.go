@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"path/filepath"
 
+	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/output"
 	"github.com/spf13/cobra"
 )
@@ -17,17 +18,45 @@ var editCmd = &cobra.Command{
 
 Uses $EDITOR environment variable or defaults to vi.
 
+With --reload the configuration is tested and the web server reloaded once
+the editor closes. With --json the editor draws on stderr so stdout carries
+only the result.
+
 Examples:
   vhost edit example.com
+  vhost edit example.com --reload
   EDITOR=nano vhost edit example.com`,
-	Args: cobra.ExactArgs(1),
-	RunE: runEdit,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runEdit,
 }
 
+var editReload bool
+
 func init() {
+	editCmd.Flags().BoolVar(&editReload, "reload", false, "Test the configuration and reload the web server after editing")
+
 	rootCmd.AddCommand(editCmd)
 }
 
+// EditResult reports what vhost edit did
+type EditResult struct {
+	CommandResult
+	Edited   bool   `json:"edited"`
+	Reloaded bool   `json:"reloaded"`
+	Error    string `json:"error,omitempty"`
+}
+
+// editFailed reports err as JSON when requested and returns it
+func editFailed(result *EditResult, err error) error {
+	if jsonOutput {
+		result.Success = false
+		result.Error = err.Error()
+		_ = output.JSON(result)
+	}
+	return reported(err)
+}
+
 func runEdit(cmd *cobra.Command, args []string) error {
 	domain := args[0]
 
@@ -37,16 +66,14 @@ func runEdit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Load config and driver
-	_, drv, err := loadConfigAndDriver()
+	cfg, drv, err := loadConfigAndDriver()
 	if err != nil {
 		return err
 	}
+	domain = resolveDomain(cfg, config.NormalizeDomain(domain))
 
 	// Build config file path
-	configPath := filepath.Join(drv.Paths().Available, domain)
-	if drv.Name() == "apache" {
-		configPath = filepath.Join(drv.Paths().Available, domain+".conf")
-	}
+	configPath := filepath.Join(drv.Paths().Available, driverConfigFileName(drv.Name(), domain))
 
 	// Check if config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -64,18 +91,45 @@ func runEdit(cmd *cobra.Command, args []string) error {
 
 	output.Info("Opening %s with %s...", configPath, editor)
 
-	// Create and run editor command
+	result := &EditResult{CommandResult: newSuccessResult(domain, "edited")}
+
+	// Create and run editor command; keep stdout clean for JSON, which
+	// also has the messages on stderr (see setupOutput)
 	editCmd := exec.Command(editorPath, configPath)
 	editCmd.Stdin = os.Stdin
 	editCmd.Stdout = os.Stdout
+	if jsonOutput {
+		editCmd.Stdout = os.Stderr
+	}
 	editCmd.Stderr = os.Stderr
 
 	if err := editCmd.Run(); err != nil {
-		return fmt.Errorf("editor exited with error: %w", err)
+		return editFailed(result, fmt.Errorf("editor exited with error: %w", err))
 	}
+	result.Edited = true
 
 	output.Success("Editor closed")
-	output.Info("Run 'vhost test' or reload your web server to apply changes")
 
-	return nil
+	// Record the modification time for managed vhosts
+	if _, exists := cfg.VHosts[domain]; exists {
+		_ = cfg.TouchVHost(domain)
+		if err := saveConfig(cfg); err != nil {
+			output.Warn("Config save failed: %v", err)
+		}
+	}
+
+	if !editReload {
+		output.Info("Run 'vhost test' or reload your web server to apply changes")
+		if jsonOutput {
+			return output.JSON(result)
+		}
+		return nil
+	}
+
+	if err := testAndReload(drv, true, nil); err != nil {
+		return editFailed(result, err)
+	}
+	result.Reloaded = true
+
+	return outputResult(result, "Configuration tested and %s reloaded", drv.Name())
 }
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -8,6 +10,7 @@ import (
 
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/output"
 )
 
 func TestRunEdit(t *testing.T) {
@@ -198,3 +201,105 @@ func TestGetEditor(t *testing.T) {
 		})
 	}
 }
+
+func TestRunEditJSON(t *testing.T) {
+	tests := []struct {
+		name         string
+		editor       string
+		reload       bool
+		testErr      error
+		wantErr      bool
+		wantEdited   bool
+		wantReloaded bool
+		wantError    string
+	}{
+		{
+			name:       "edit without reload",
+			editor:     "true",
+			wantEdited: true,
+		},
+		{
+			name:         "edit and reload",
+			editor:       "true",
+			reload:       true,
+			wantEdited:   true,
+			wantReloaded: true,
+		},
+		{
+			name:       "config test fails",
+			editor:     "true",
+			reload:     true,
+			testErr:    errors.New("unexpected \"}\""),
+			wantErr:    true,
+			wantEdited: true,
+			wantError:  "configuration test failed",
+		},
+		{
+			name:      "editor fails",
+			editor:    "false",
+			reload:    true,
+			wantErr:   true,
+			wantError: "editor exited with error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDITOR", tt.editor)
+
+			tempDir := t.TempDir()
+			availableDir := filepath.Join(tempDir, "sites-available")
+			if err := os.MkdirAll(availableDir, 0755); err != nil {
+				t.Fatalf("failed to create dir: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(availableDir, "test.com"), []byte("server {}"), 0644); err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+
+			mockDrv := driver.NewMockDriver("nginx", availableDir, filepath.Join(tempDir, "sites-enabled"))
+			mockDrv.TestFunc = func() error { return tt.testErr }
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(config.New()).WithDriver(mockDrv).Build()
+			oldJSON, oldReload := jsonOutput, editReload
+			jsonOutput, editReload = true, tt.reload
+			defer func() {
+				deps = oldDeps
+				jsonOutput, editReload = oldJSON, oldReload
+				output.SetMessageOutput(nil)
+			}()
+
+			// Progress messages must not precede the JSON result
+			var err error
+			out := captureOutput(func() {
+				if err := setupOutput(); err != nil {
+					t.Fatal(err)
+				}
+				err = runEdit(nil, []string{"test.com"})
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var result EditResult
+			if jsonErr := json.Unmarshal([]byte(out), &result); jsonErr != nil {
+				t.Fatalf("invalid JSON %q: %v", out, jsonErr)
+			}
+			if result.Success == tt.wantErr {
+				t.Errorf("success = %v, want %v", result.Success, !tt.wantErr)
+			}
+			if result.Domain != "test.com" {
+				t.Errorf("domain = %q, want test.com", result.Domain)
+			}
+			if result.Edited != tt.wantEdited || result.Reloaded != tt.wantReloaded {
+				t.Errorf("edited/reloaded = %v/%v, want %v/%v", result.Edited, result.Reloaded, tt.wantEdited, tt.wantReloaded)
+			}
+			if !strings.Contains(result.Error, tt.wantError) || (tt.wantError == "") != (result.Error == "") {
+				t.Errorf("error = %q, want containing %q", result.Error, tt.wantError)
+			}
+			if got := mockDrv.ReloadCalls; got > 0 != tt.wantReloaded {
+				t.Errorf("reload calls = %d, want reloaded %v", got, tt.wantReloaded)
+			}
+		})
+	}
+}
@@ -2,30 +2,60 @@ package cli
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/logger"
 	"github.com/ksyq12/vhost/internal/output"
 	"github.com/spf13/cobra"
 )
 
+var (
+	enableAll   bool
+	enableForce bool
+)
+
 var enableCmd = &cobra.Command{
 	Use:   "enable <domain>",
 	Short: "Enable a virtual host",
 	Long: `Enable a virtual host by creating a symlink in sites-enabled.
 
+With --all, every vhost marked enabled in config.yaml is re-enabled with a
+single test and reload, for example after "vhost disable --all".
+
+A vhost that is already enabled is refused unless --force is given, which
+repoints a symlink that leads to another file (or refreshes a copy in
+copy mode). The link is replaced atomically, and the old one is restored
+if the config test fails.
+
 Examples:
-  vhost enable example.com`,
-	Args: cobra.ExactArgs(1),
-	RunE: runEnable,
+  vhost enable example.com
+  vhost enable example.com --force
+  vhost enable --all --yes`,
+	Args:              domainOrAllArgs(&enableAll),
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runEnable,
 }
 
 func init() {
 	enableCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server")
+	enableCmd.Flags().BoolVar(&enableAll, "all", false, "Enable every vhost marked enabled in config.yaml")
+	enableCmd.Flags().BoolVar(&enableForce, "force", false, "Replace the enabled symlink even if the vhost is already enabled")
 
+	interruptible(enableCmd)
 	rootCmd.AddCommand(enableCmd)
 }
 
 func runEnable(cmd *cobra.Command, args []string) error {
+	if enableAll {
+		if enableForce {
+			return fmt.Errorf("--force can't be combined with --all")
+		}
+		return runEnableAll()
+	}
+
 	domain := args[0]
 
 	// Validate domain
@@ -38,6 +68,7 @@ func runEnable(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	domain = resolveDomain(cfg, domain)
 
 	// Dry-run mode: show what would be done without making changes
 	if dryRun {
@@ -49,17 +80,31 @@ func runEnable(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// A forced enable replaces the current entry, so undoing it restores that
+	enable := drv.Enable
+	rollback := func() error {
+		logger.Debug("Disabling %s again", domain)
+		return drv.Disable(domain)
+	}
+	if enableForce {
+		enable = relinkFunc(drv)
+		rollback = snapshotEnabledEntry(drv, domain)
+	}
+
+	// Ctrl-C at this point leaves the vhost untouched; later, testAndReload
+	// rolls back
+	if err := checkInterrupted(commandContext(cmd)); err != nil {
+		return err
+	}
+
 	// Enable via driver
 	output.Info("Enabling vhost...")
-	if err := drv.Enable(domain); err != nil {
+	logger.Debug("Enabling %s in %s", domain, drv.Paths().Enabled)
+	if err := enable(domain); err != nil {
 		return fmt.Errorf("failed to enable vhost: %w", err)
 	}
 
 	// Test and reload with rollback
-	rollback := func() error {
-		return drv.Disable(domain)
-	}
-
 	if err := testAndReload(drv, !noReload, rollback); err != nil {
 		return err
 	}
@@ -67,6 +112,7 @@ func runEnable(cmd *cobra.Command, args []string) error {
 	// Update config
 	if vhost, exists := cfg.VHosts[domain]; exists {
 		vhost.Enabled = true
+		_ = cfg.TouchVHost(domain)
 		if err := saveConfig(cfg); err != nil {
 			output.Warn("VHost enabled but config save failed: %v", err)
 		}
@@ -82,14 +128,131 @@ func runEnable(cmd *cobra.Command, args []string) error {
 	)
 }
 
-// outputEnableDryRun outputs what enable command would do in dry-run mode
-func outputEnableDryRun(domain string, drvName string, drvPaths struct{ Available, Enabled string }) error {
-	// Determine config file name (apache uses .conf extension)
-	configFileName := domain
-	if drvName == "apache" {
-		configFileName = domain + ".conf"
+// runEnableAll enables every vhost marked enabled in config that is not
+// active yet, disabling them all again if any step fails
+func runEnableAll() error {
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return err
+	}
+
+	var domains []string
+	for _, domain := range sortedDomains(cfg) {
+		if !cfg.VHosts[domain].Enabled {
+			continue
+		}
+		if enabled, _ := drv.IsEnabled(domain); !enabled {
+			domains = append(domains, domain)
+		}
+	}
+
+	if len(domains) == 0 {
+		return outputResult(
+			map[string]interface{}{
+				"success": true,
+				"enabled": []string{},
+			},
+			"No vhosts to enable",
+		)
+	}
+
+	if dryRun {
+		return outputBulkDryRun(domains, "create_symlink", drv.Name(), drv.Paths().Enabled)
+	}
+
+	if err := requireRoot(); err != nil {
+		return err
 	}
 
+	ok, err := confirm(fmt.Sprintf("Enable %d vhost(s)?", len(domains)), false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		output.Info("Enable cancelled")
+		return nil
+	}
+
+	// A failure disables whatever was already brought up
+	logger.Debug("Enabling %d vhost(s): %s", len(domains), strings.Join(domains, ", "))
+	rollback, err := switchSites(drv, nil, domains)
+	if err == nil {
+		err = testAndReload(drv, !noReload, rollback)
+	} else if rbErr := rollback(); rbErr != nil {
+		output.Warn("Rollback failed: %v", rbErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	return outputResult(
+		map[string]interface{}{
+			"success": true,
+			"enabled": domains,
+		},
+		"Enabled %d vhost(s)", len(domains),
+	)
+}
+
+// relinkFunc returns how a forced enable replaces the enabled entry of a
+// domain: the driver's Relink, or for a driver without one, Disable of any
+// existing entry followed by Enable
+func relinkFunc(drv driver.Driver) func(domain string) error {
+	if r, ok := drv.(driver.Relinker); ok {
+		return r.Relink
+	}
+	return func(domain string) error {
+		if hasEnabledEntry(drv, domain) {
+			if err := drv.Disable(domain); err != nil {
+				return err
+			}
+		}
+		return drv.Enable(domain)
+	}
+}
+
+// snapshotEnabledEntry captures the enabled entry of domain before a forced
+// enable replaces it, returning a rollback that puts it back. Without an
+// entry to restore, the rollback disables the vhost again.
+func snapshotEnabledEntry(drv driver.Driver, domain string) func() error {
+	path := filepath.Join(drv.Paths().Enabled, driverConfigFileName(drv.Name(), domain))
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return func() error {
+			logger.Debug("Disabling %s again", domain)
+			return drv.Disable(domain)
+		}
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err == nil {
+			return func() error {
+				logger.Debug("Pointing %s back at %s", path, target)
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				return os.Symlink(target, path)
+			}
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return func() error { return fmt.Errorf("failed to snapshot %s: %w", path, err) }
+	}
+	return func() error {
+		logger.Debug("Restoring the previous copy at %s", path)
+		return os.WriteFile(path, content, info.Mode().Perm())
+	}
+}
+
+// outputEnableDryRun outputs what enable command would do in dry-run mode
+func outputEnableDryRun(domain string, drvName string, drvPaths driver.Paths) error {
+	// Determine config file name (some drivers use an extension)
+	configFileName := driverConfigFileName(drvName, domain)
+
 	configPath := filepath.Join(drvPaths.Available, configFileName)
 	enabledPath := filepath.Join(drvPaths.Enabled, configFileName)
 
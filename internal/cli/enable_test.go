@@ -2,9 +2,11 @@ package cli
 
 import (
 	"errors"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
@@ -27,9 +29,10 @@ func TestRunEnable(t *testing.T) {
 			setupDeps: func(t *testing.T, mockDrv *driver.MockDriver) (*Dependencies, *config.Config) {
 				cfg := config.New()
 				cfg.VHosts["test.com"] = &config.VHost{
-					Domain:  "test.com",
-					Type:    "static",
-					Enabled: false,
+					Domain:    "test.com",
+					Type:      "static",
+					Enabled:   false,
+					UpdatedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
 				}
 				return NewMockDeps().
 					WithConfig(cfg).
@@ -46,6 +49,9 @@ func TestRunEnable(t *testing.T) {
 				if !vhost.Enabled {
 					t.Error("vhost should be enabled in config")
 				}
+				if !vhost.UpdatedAt.After(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("expected enable to move UpdatedAt forward, got %v", vhost.UpdatedAt)
+				}
 				if len(mockDrv.EnableCalls) != 1 {
 					t.Errorf("expected 1 Enable call, got %d", len(mockDrv.EnableCalls))
 				}
@@ -57,6 +63,33 @@ func TestRunEnable(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:     "enable by alias resolves to primary domain",
+			domain:   "www.alias.com",
+			noReload: false,
+			setupDeps: func(t *testing.T, mockDrv *driver.MockDriver) (*Dependencies, *config.Config) {
+				cfg := config.New()
+				cfg.VHosts["alias.com"] = &config.VHost{
+					Domain:  "alias.com",
+					Type:    "static",
+					Aliases: []string{"www.alias.com"},
+				}
+				return NewMockDeps().
+					WithConfig(cfg).
+					WithDriver(mockDrv).
+					WithRootAccess(true).
+					Build(), cfg
+			},
+			wantErr: false,
+			validate: func(t *testing.T, cfg *config.Config, mockDrv *driver.MockDriver) {
+				if len(mockDrv.EnableCalls) != 1 || mockDrv.EnableCalls[0] != "alias.com" {
+					t.Errorf("expected Enable(alias.com), got %v", mockDrv.EnableCalls)
+				}
+				if !cfg.VHosts["alias.com"].Enabled {
+					t.Error("vhost should be enabled in config")
+				}
+			},
+		},
 		{
 			name:     "enable with no-reload flag",
 			domain:   "noreload.com",
@@ -305,3 +338,151 @@ func TestRunEnableDryRun(t *testing.T) {
 		})
 	}
 }
+
+func TestRunEnableAll(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+	mockDrv.IsEnabledFunc = func(domain string) (bool, error) {
+		return domain == "up.com", nil
+	}
+	mockDrv.TestFunc = func() error { return errors.New("syntax error") }
+
+	cfg := config.New()
+	cfg.VHosts["a.com"] = &config.VHost{Domain: "a.com", Type: "static", Enabled: true}
+	cfg.VHosts["b.com"] = &config.VHost{Domain: "b.com", Type: "static", Enabled: true}
+	cfg.VHosts["up.com"] = &config.VHost{Domain: "up.com", Type: "static", Enabled: true}
+	cfg.VHosts["staged.com"] = &config.VHost{Domain: "staged.com", Type: "static", Enabled: false}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	noReload = false
+	assumeYes = true
+	enableAll = true
+	defer func() {
+		assumeYes = false
+		enableAll = false
+	}()
+
+	err := runEnable(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "configuration test failed") {
+		t.Fatalf("expected test failure, got %v", err)
+	}
+
+	// Only config-enabled sites that are down are touched
+	if strings.Join(mockDrv.EnableCalls, ",") != "a.com,b.com" {
+		t.Errorf("expected Enable calls [a.com b.com], got %v", mockDrv.EnableCalls)
+	}
+	if strings.Join(mockDrv.DisableCalls, ",") != "a.com,b.com" {
+		t.Errorf("expected rollback Disable calls [a.com b.com], got %v", mockDrv.DisableCalls)
+	}
+	if mockDrv.TestCalls != 1 {
+		t.Errorf("expected a single Test call, got %d", mockDrv.TestCalls)
+	}
+}
+
+func TestRunEnableForce(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	enabledDir := filepath.Join(tempDir, "sites-enabled")
+	for _, dir := range []string{availableDir, enabledDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+	source := filepath.Join(availableDir, "test.com")
+	if err := os.WriteFile(source, []byte("server {}"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	link := filepath.Join(enabledDir, "test.com")
+	wrong := filepath.Join(tempDir, "old", "test.com")
+
+	mockDrv := driver.NewMockDriver("nginx", availableDir, enabledDir)
+	mockDrv.RelinkFunc = func(domain string) error {
+		if err := os.Remove(link); err != nil {
+			return err
+		}
+		return os.Symlink(source, link)
+	}
+
+	cfg := config.New()
+	cfg.VHosts["test.com"] = &config.VHost{Domain: "test.com", Type: "static"}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	noReload = false
+	enableForce = true
+	defer func() { enableForce = false }()
+
+	reset := func(t *testing.T) {
+		t.Helper()
+		_ = os.Remove(link)
+		if err := os.Symlink(wrong, link); err != nil {
+			t.Fatalf("failed to create symlink: %v", err)
+		}
+		mockDrv.Reset()
+		mockDrv.TestFunc = nil
+	}
+
+	t.Run("repoints a wrong symlink", func(t *testing.T) {
+		reset(t)
+		if err := runEnable(nil, []string{"test.com"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(mockDrv.RelinkCalls) != 1 || len(mockDrv.EnableCalls) != 0 {
+			t.Errorf("expected Relink instead of Enable, got %v and %v", mockDrv.RelinkCalls, mockDrv.EnableCalls)
+		}
+		if target, _ := os.Readlink(link); target != source {
+			t.Errorf("expected the link to point at %s, got %s", source, target)
+		}
+		if !cfg.VHosts["test.com"].Enabled {
+			t.Error("vhost should be enabled in config")
+		}
+	})
+
+	t.Run("failed test restores the old symlink", func(t *testing.T) {
+		reset(t)
+		mockDrv.TestFunc = func() error { return errors.New("syntax error") }
+		err := runEnable(nil, []string{"test.com"})
+		if err == nil || !strings.Contains(err.Error(), "configuration test failed") {
+			t.Fatalf("expected test failure, got %v", err)
+		}
+		if target, _ := os.Readlink(link); target != wrong {
+			t.Errorf("expected the link to point back at %s, got %s", wrong, target)
+		}
+		if len(mockDrv.DisableCalls) != 0 {
+			t.Errorf("expected the old link restored rather than disabled, got Disable calls %v", mockDrv.DisableCalls)
+		}
+	})
+
+	t.Run("driver without Relink disables and enables", func(t *testing.T) {
+		reset(t)
+		// Embedding only the Driver interface hides the mock's Relink
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(struct{ driver.Driver }{mockDrv}).WithRootAccess(true).Build()
+		mockDrv.DisableFunc = func(domain string) error { return os.Remove(link) }
+		mockDrv.EnableFunc = func(domain string) error { return os.Symlink(source, link) }
+		defer func() { mockDrv.DisableFunc, mockDrv.EnableFunc = nil, nil }()
+
+		if err := runEnable(nil, []string{"test.com"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(mockDrv.RelinkCalls) != 0 || len(mockDrv.DisableCalls) != 1 || len(mockDrv.EnableCalls) != 1 {
+			t.Errorf("expected Disable then Enable, got Relink %v, Disable %v, Enable %v", mockDrv.RelinkCalls, mockDrv.DisableCalls, mockDrv.EnableCalls)
+		}
+		if target, _ := os.Readlink(link); target != source {
+			t.Errorf("expected the link to point at %s, got %s", source, target)
+		}
+	})
+
+	t.Run("not combined with --all", func(t *testing.T) {
+		enableAll = true
+		defer func() { enableAll = false }()
+		err := runEnable(nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "can't be combined with --all") {
+			t.Errorf("expected flag conflict error, got %v", err)
+		}
+	})
+}
@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"fmt"
 	"sort"
 
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/logger"
 	"github.com/ksyq12/vhost/internal/output"
 	"github.com/spf13/cobra"
 )
@@ -13,62 +16,122 @@ var listCmd = &cobra.Command{
 	Short:   "List all virtual hosts",
 	Long: `List all configured virtual hosts.
 
+The enabled state comes from the web server driver. JSON output also reports
+enabled_config (config.yaml), enabled_actual (driver, null if unknown) and a
+mismatch flag when the two disagree.
+
+The table is fitted to the terminal width, cutting long roots and proxy
+URLs with an ellipsis; --wide prints every cell in full.
+
+--output csv or --output tsv prints the table rows for spreadsheets and
+scripts.
+
 Examples:
   vhost list
   vhost ls
-  vhost list --json`,
+  vhost list --json
+  vhost list --wide
+  vhost list --output csv > vhosts.csv`,
 	RunE: runList,
 }
 
+var (
+	listFormat string
+	listWide   bool
+)
+
 func init() {
+	listCmd.Flags().StringVarP(&listFormat, "output", "o", output.FormatTable, "Output format: table, csv or tsv")
+	listCmd.Flags().BoolVar(&listWide, "wide", false, "Don't truncate table columns to fit the terminal")
+
 	rootCmd.AddCommand(listCmd)
 }
 
 type vhostListItem struct {
-	Domain  string `json:"domain"`
-	Type    string `json:"type"`
-	Root    string `json:"root,omitempty"`
-	Proxy   string `json:"proxy,omitempty"`
-	SSL     bool   `json:"ssl"`
-	Enabled bool   `json:"enabled"`
+	Domain        string `json:"domain"`
+	Type          string `json:"type"`
+	Root          string `json:"root,omitempty"`
+	Proxy         string `json:"proxy,omitempty"`
+	SSL           bool   `json:"ssl"`
+	Enabled       bool   `json:"enabled"`        // actual state when known, otherwise config
+	EnabledConfig bool   `json:"enabled_config"` // state recorded in config.yaml
+	EnabledActual *bool  `json:"enabled_actual"` // on-disk state from the driver; null if unknown
+	Mismatch      bool   `json:"mismatch"`       // config and driver disagree
+	Owner         string `json:"owner,omitempty"`
+	Notes         string `json:"notes,omitempty"`
+}
+
+// listWarn prints a warning for table output; --json, csv and tsv send it
+// to stderr so the listing on stdout stays parseable
+func listWarn(format string, args ...interface{}) {
+	if listFormat != output.FormatTable {
+		logger.Warn(format, args...)
+		return
+	}
+	warn(format, args...)
 }
 
 func runList(cmd *cobra.Command, args []string) error {
-	// Load config and driver
+	switch listFormat {
+	case output.FormatTable, output.FormatCSV, output.FormatTSV:
+	default:
+		return fmt.Errorf("invalid output format: %s (use %s, %s or %s)", listFormat, output.FormatTable, output.FormatCSV, output.FormatTSV)
+	}
+
+	// Load config and driver; fall back to config-only if the driver is unavailable
 	cfg, drv, err := loadConfigAndDriver()
 	if err != nil {
-		return err
+		var loadErr error
+		if cfg, loadErr = deps.ConfigLoader.Load(); loadErr != nil {
+			return err
+		}
+		listWarn("Could not load driver, showing config state only: %v", err)
+		drv = nil
 	}
 
 	// Get list from driver (to check enabled status)
-	driverDomains, err := drv.List()
-	if err != nil {
-		output.Warn("Could not read from %s: %v", drv.Name(), err)
+	var driverDomains []string
+	if drv != nil {
+		driverDomains, err = drv.List()
+		if err != nil {
+			listWarn("Could not read from %s: %v", drv.Name(), err)
+		}
 	}
 
 	// Build list items
 	items := make([]vhostListItem, 0)
 	for domain, vhost := range cfg.VHosts {
-		enabled, _ := drv.IsEnabled(domain)
-		items = append(items, vhostListItem{
-			Domain:  domain,
-			Type:    vhost.Type,
-			Root:    vhost.Root,
-			Proxy:   vhost.ProxyPass,
-			SSL:     vhost.SSL,
-			Enabled: enabled,
-		})
+		item := vhostListItem{
+			Domain:        domain,
+			Type:          vhost.Type,
+			Root:          vhost.Root,
+			Proxy:         vhost.ProxyPass,
+			SSL:           vhost.SSL,
+			Enabled:       vhost.Enabled,
+			EnabledConfig: vhost.Enabled,
+			Owner:         vhost.Owner,
+			Notes:         vhost.Notes,
+		}
+		if actual, ok := actualEnabled(drv, domain); ok {
+			item.Enabled = actual
+			item.EnabledActual = &actual
+			item.Mismatch = actual != vhost.Enabled
+		}
+		items = append(items, item)
 	}
 
 	// Also add domains found in driver but not in config
 	for _, domain := range driverDomains {
 		if _, exists := cfg.VHosts[domain]; !exists {
-			enabled, _ := drv.IsEnabled(domain)
-			items = append(items, vhostListItem{
-				Domain:  domain,
-				Type:    "unknown",
-				Enabled: enabled,
-			})
+			item := vhostListItem{
+				Domain: domain,
+				Type:   "unknown",
+			}
+			if actual, ok := actualEnabled(drv, domain); ok {
+				item.Enabled = actual
+				item.EnabledActual = &actual
+			}
+			items = append(items, item)
 		}
 	}
 
@@ -77,18 +140,15 @@ func runList(cmd *cobra.Command, args []string) error {
 		return items[i].Domain < items[j].Domain
 	})
 
-	if len(items) == 0 {
-		if jsonOutput {
-			return output.JSON([]vhostListItem{})
-		}
-		output.Info("No virtual hosts configured")
-		return nil
-	}
-
 	if jsonOutput {
 		return output.JSON(items)
 	}
 
+	if len(items) == 0 && listFormat == output.FormatTable {
+		output.Info("No virtual hosts configured")
+		return nil
+	}
+
 	// Build table
 	headers := []string{"DOMAIN", "TYPE", "ROOT/PROXY", "SSL", "ENABLED"}
 	rows := make([][]string, 0, len(items))
@@ -108,6 +168,9 @@ func runList(cmd *cobra.Command, args []string) error {
 		if item.Enabled {
 			enabled = "yes"
 		}
+		if item.Mismatch {
+			enabled += " (config differs)"
+		}
 
 		rows = append(rows, []string{
 			item.Domain,
@@ -118,6 +181,25 @@ func runList(cmd *cobra.Command, args []string) error {
 		})
 	}
 
-	output.Table(headers, rows)
+	switch listFormat {
+	case output.FormatCSV:
+		return output.CSV(headers, rows)
+	case output.FormatTSV:
+		return output.TSV(headers, rows)
+	}
+	output.TableWithOptions(headers, rows, output.TableOptions{Wide: listWide})
 	return nil
 }
+
+// actualEnabled returns the driver's view of whether domain is enabled.
+// ok is false when there is no driver or the state can't be read.
+func actualEnabled(drv driver.Driver, domain string) (enabled bool, ok bool) {
+	if drv == nil {
+		return false, false
+	}
+	enabled, err := drv.IsEnabled(domain)
+	if err != nil {
+		return false, false
+	}
+	return enabled, true
+}
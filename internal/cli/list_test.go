@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/ksyq12/vhost/internal/config"
@@ -185,3 +188,145 @@ func TestRunList(t *testing.T) {
 		})
 	}
 }
+
+func TestRunListJSONEnabledState(t *testing.T) {
+	newConfig := func() *config.Config {
+		cfg := config.New()
+		cfg.VHosts["drift.com"] = &config.VHost{Domain: "drift.com", Type: "static", Enabled: true}
+		cfg.VHosts["match.com"] = &config.VHost{Domain: "match.com", Type: "static", Enabled: true}
+		return cfg
+	}
+
+	runListJSON := func(t *testing.T) []map[string]interface{} {
+		t.Helper()
+		jsonOutput = true
+		defer func() { jsonOutput = false }()
+
+		var runErr error
+		out := captureOutput(func() {
+			runErr = runList(nil, nil)
+		})
+		if runErr != nil {
+			t.Fatalf("unexpected error: %v", runErr)
+		}
+
+		var items []map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &items); err != nil {
+			t.Fatalf("invalid JSON output %q: %v", out, err)
+		}
+		return items
+	}
+
+	t.Run("mismatch flagged when driver and config disagree", func(t *testing.T) {
+		tempDir := t.TempDir()
+		mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+		mockDrv.IsEnabledFunc = func(domain string) (bool, error) {
+			return domain == "match.com", nil
+		}
+
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(newConfig()).WithDriver(mockDrv).Build()
+		defer func() { deps = oldDeps }()
+
+		items := runListJSON(t)
+		if len(items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(items))
+		}
+
+		drift, match := items[0], items[1]
+		if drift["domain"] != "drift.com" || drift["mismatch"] != true {
+			t.Errorf("expected drift.com mismatch, got %v", drift)
+		}
+		if drift["enabled_config"] != true || drift["enabled_actual"] != false || drift["enabled"] != false {
+			t.Errorf("unexpected drift.com state: %v", drift)
+		}
+		if match["mismatch"] != false || match["enabled_actual"] != true {
+			t.Errorf("expected match.com in sync, got %v", match)
+		}
+	})
+
+	t.Run("driver unavailable falls back to config", func(t *testing.T) {
+		oldDeps := deps
+		deps = NewMockDeps().
+			WithConfig(newConfig()).
+			WithDriverFactory(&MockDriverFactory{Err: errors.New("driver unavailable")}).
+			Build()
+		defer func() { deps = oldDeps }()
+
+		items := runListJSON(t)
+		if len(items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(items))
+		}
+		for _, item := range items {
+			if v, ok := item["enabled_actual"]; !ok || v != nil {
+				t.Errorf("expected enabled_actual null, got %v", item)
+			}
+			if item["mismatch"] != false || item["enabled"] != true {
+				t.Errorf("expected config-only state, got %v", item)
+			}
+		}
+	})
+}
+
+func TestRunListCSV(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+	mockDrv.IsEnabledFunc = func(domain string) (bool, error) { return true, nil }
+
+	cfg := config.New()
+	cfg.VHosts["a.com"] = &config.VHost{Domain: "a.com", Type: "static", Root: "/var/www/a,b", Enabled: true}
+	cfg.VHosts["b.com"] = &config.VHost{Domain: "b.com", Type: "proxy", ProxyPass: "http://localhost:3000", SSL: true, Enabled: true}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+	defer func() {
+		deps = oldDeps
+		listFormat = "table"
+	}()
+
+	t.Run("csv", func(t *testing.T) {
+		listFormat = "csv"
+		var runErr error
+		out := captureStdout(func() { runErr = runList(nil, nil) })
+		if runErr != nil {
+			t.Fatalf("unexpected error: %v", runErr)
+		}
+
+		expected := "DOMAIN,TYPE,ROOT/PROXY,SSL,ENABLED\n" +
+			"a.com,static,\"/var/www/a,b\",no,yes\n" +
+			"b.com,proxy,http://localhost:3000,yes,yes\n"
+		if out != expected {
+			t.Errorf("expected %q, got %q", expected, out)
+		}
+	})
+
+	t.Run("tsv", func(t *testing.T) {
+		listFormat = "tsv"
+		out := captureStdout(func() { _ = runList(nil, nil) })
+
+		if !strings.HasPrefix(out, "DOMAIN\tTYPE\tROOT/PROXY\tSSL\tENABLED\n") {
+			t.Errorf("unexpected TSV header: %q", out)
+		}
+	})
+
+	t.Run("csv stays parseable without a driver", func(t *testing.T) {
+		listFormat = "csv"
+		deps = NewMockDeps().
+			WithConfig(cfg).
+			WithDriverFactory(&MockDriverFactory{Err: errors.New("driver unavailable")}).
+			Build()
+		defer func() { deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build() }()
+
+		out := captureOutput(func() { _ = runList(nil, nil) })
+		if !strings.HasPrefix(out, "DOMAIN,TYPE,ROOT/PROXY,SSL,ENABLED\n") {
+			t.Errorf("expected CSV header first, got %q", out)
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		listFormat = "xml"
+		if err := runList(nil, nil); err == nil || !strings.Contains(err.Error(), "invalid output format") {
+			t.Errorf("expected invalid output format error, got %v", err)
+		}
+	})
+}
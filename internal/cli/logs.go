@@ -1,10 +1,22 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
 	"github.com/ksyq12/vhost/internal/output"
 	"github.com/spf13/cobra"
 )
@@ -14,36 +26,78 @@ var (
 	logsError  bool
 	logsFollow bool
 	logsLines  int
+	logsAll    bool
 )
 
+// logsRotated makes logs read rotated copies of a log when the current
+// file holds fewer lines than asked for
+var logsRotated bool
+
+// logsPollInterval is how often logs --all --follow checks files for new lines
+var logsPollInterval = 250 * time.Millisecond
+
 var logsCmd = &cobra.Command{
-	Use:   "logs <domain>",
+	Use:   "logs <domain> | --all",
 	Short: "View logs for a virtual host",
 	Long: `View access and error logs for a virtual host.
 
-By default, shows both access and error logs.
-Use --access or --error to show only one log type.
+By default both logs are shown, merged in time order by the timestamp on
+each line, each line labelled [access] or [error]. --access shows only the access log and
+--error (or its alias --error-only) only the error log; the two can't be
+combined, leave both off to see both.
+
+--all shows the logs of every enabled vhost at once, each line prefixed
+with its domain, merged the same way; with --follow the files are watched
+together and new lines are printed as they are written.
+
+--all-rotations continues into the rotated copies (access.log.1,
+access.log.2.gz, ...; gzipped ones are decompressed) when the current file
+has fewer lines than --lines, oldest lines first.
+
+--stats summarizes the access log instead of printing it: requests per
+status code and the top 10 client IPs and paths, over the last 1000 lines
+or --lines, including rotations with --all-rotations.
 
 Examples:
   vhost logs example.com           # Show both logs
   vhost logs example.com --access  # Show only access log
   vhost logs example.com --error   # Show only error log
+  vhost logs example.com --error-only -f
   vhost logs example.com -f        # Follow logs in real-time
-  vhost logs example.com -n 50     # Show last 50 lines`,
-	Args: cobra.ExactArgs(1),
-	RunE: runLogs,
+  vhost logs example.com -n 50     # Show last 50 lines
+  vhost logs --all -f              # Follow every enabled vhost
+  vhost logs example.com -n 5000 --all-rotations
+  vhost logs example.com --stats --all-rotations -n 50000`,
+	Args:              domainOrAllArgs(&logsAll),
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runLogs,
 }
 
 func init() {
 	logsCmd.Flags().BoolVar(&logsAccess, "access", false, "Show access log only")
 	logsCmd.Flags().BoolVar(&logsError, "error", false, "Show error log only")
+	logsCmd.Flags().BoolVar(&logsError, "error-only", false, "Show error log only (same as --error)")
 	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow log output (like tail -f)")
 	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 20, "Number of lines to show")
+	logsCmd.Flags().BoolVar(&logsAll, "all", false, "Show logs of all enabled vhosts, prefixed with the domain")
+	logsCmd.Flags().BoolVar(&logsStats, "stats", false, "Summarize status codes, top client IPs and top paths of the access log")
+	logsCmd.Flags().BoolVar(&logsRotated, "all-rotations", false, "Also read rotated logs (.1, .2.gz, ...) when the current file has too few lines")
+	logsCmd.Flags().BoolVar(&logsRotated, "rotated", false, "Same as --all-rotations")
 
 	rootCmd.AddCommand(logsCmd)
 }
 
 func runLogs(cmd *cobra.Command, args []string) error {
+	if logsStats {
+		if err := checkLogsStatsFlags(); err != nil {
+			return err
+		}
+	}
+
+	if logsAll {
+		return runLogsAll()
+	}
+
 	domain := args[0]
 
 	// Validate domain
@@ -56,6 +110,7 @@ func runLogs(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	domain = resolveDomain(cfg, config.NormalizeDomain(domain))
 
 	// Check if vhost exists
 	if _, exists := cfg.VHosts[domain]; !exists {
@@ -67,78 +122,440 @@ func runLogs(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get log paths: %w", err)
 	}
+	if logsStats {
+		return runLogsStats(cmd, domain, accessLog)
+	}
 
 	// Determine which logs to show
-	showAccess := true
-	showError := true
-	if logsAccess && !logsError {
+	showAccess, showError, err := selectedLogTypes()
+	if err != nil {
+		return err
+	}
+
+	// Collect log files, labelled with their type
+	var sources []logSource
+	if showAccess && showError && accessLog != "" && accessLog == errorLog {
+		// Caddy writes both to one file
 		showError = false
-	} else if logsError && !logsAccess {
-		showAccess = false
+		sources = appendLogSource(sources, "log", accessLog)
+	}
+	if showAccess {
+		sources = appendLogSource(sources, "access", accessLog)
+	}
+	if showError {
+		sources = appendLogSource(sources, "error", errorLog)
 	}
 
-	// Collect log files to tail
-	var logFiles []string
-	if showAccess && accessLog != "" {
-		if _, err := os.Stat(accessLog); err == nil {
-			logFiles = append(logFiles, accessLog)
-		} else {
-			output.Warn("Access log not found: %s", accessLog)
-		}
+	if len(sources) == 0 {
+		return fmt.Errorf("no log files found for %s", domain)
 	}
-	if showError && errorLog != "" {
-		if _, err := os.Stat(errorLog); err == nil {
-			logFiles = append(logFiles, errorLog)
-		} else {
-			output.Warn("Error log not found: %s", errorLog)
+
+	// Print info about which logs we're showing
+	if len(sources) == 1 {
+		output.Info("Showing logs from: %s", sources[0].path)
+	} else {
+		output.Info("Showing logs from:")
+		for _, src := range sources {
+			output.Print("  - %s", src.path)
 		}
 	}
+	output.Print("")
 
-	if len(logFiles) == 0 {
-		return fmt.Errorf("no log files found for %s", domain)
+	return showLogs(sources)
+}
+
+// appendLogSource adds the log at path to sources if it exists, warning
+// when it doesn't
+func appendLogSource(sources []logSource, label, path string) []logSource {
+	if path == "" || (len(sources) > 0 && sources[len(sources)-1].path == path) {
+		return sources
 	}
+	if _, err := os.Stat(path); err != nil {
+		output.Warn("%s log not found: %s", capitalize(label), path)
+		return sources
+	}
+	return append(sources, logSource{label: label, path: path})
+}
 
-	// Build tail command
-	tailArgs := []string{}
-	if logsFollow {
-		tailArgs = append(tailArgs, "-f")
+// showLogs prints the last lines of sources merged in time order and,
+// with --follow, keeps printing new lines as they are written until
+// interrupted
+func showLogs(sources []logSource) error {
+	printInterleavedLogs(os.Stdout, sources, logsLines)
+	if !logsFollow {
+		return nil
 	}
-	tailArgs = append(tailArgs, "-n", fmt.Sprintf("%d", logsLines))
-	tailArgs = append(tailArgs, logFiles...)
 
-	// Find tail command
-	tailPath, err := exec.LookPath("tail")
+	// Stop cleanly on Ctrl+C like tail -f does
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	followLogs(ctx, os.Stdout, sources, logsPollInterval)
+	return nil
+}
+
+// selectedLogTypes reports which logs --access and --error ask for. With
+// neither flag both are shown; passing both is an error.
+func selectedLogTypes() (showAccess, showError bool, err error) {
+	switch {
+	case logsAccess && logsError:
+		return false, false, fmt.Errorf("--access and --error can't be combined; leave both off to see both logs")
+	case logsAccess:
+		return true, false, nil
+	case logsError:
+		return false, true, nil
+	default:
+		return true, true, nil
+	}
+}
+
+// logSource is a log file shown by logs, labelled with its log type, or
+// with its vhost for --all
+type logSource struct {
+	label string
+	path  string
+}
+
+// runLogsAll shows the logs of every enabled vhost, prefixed with the domain
+func runLogsAll() error {
+	if _, _, err := selectedLogTypes(); err != nil {
+		return err
+	}
+
+	cfg, drv, err := loadConfigAndDriver()
 	if err != nil {
-		return fmt.Errorf("tail command not found")
+		return err
 	}
 
-	// Print info about which logs we're showing
-	if len(logFiles) == 1 {
-		output.Info("Showing logs from: %s", logFiles[0])
-	} else {
-		output.Info("Showing logs from:")
-		for _, f := range logFiles {
-			output.Print("  - %s", f)
-		}
+	domains := enabledDomains(cfg, drv)
+	if len(domains) == 0 {
+		return fmt.Errorf("no enabled vhosts")
+	}
+
+	sources := collectLogSources(drv, domains)
+	if len(sources) == 0 {
+		return fmt.Errorf("no log files found for enabled vhosts")
 	}
+
+	output.Info("Showing logs for %d vhost(s)", len(domains))
 	output.Print("")
 
-	// Run tail command
-	tailCmd := exec.Command(tailPath, tailArgs...)
-	tailCmd.Stdin = os.Stdin
-	tailCmd.Stdout = os.Stdout
-	tailCmd.Stderr = os.Stderr
-
-	if err := tailCmd.Run(); err != nil {
-		// Check for interrupt signals (130 = SIGINT/Ctrl+C, 143 = SIGTERM)
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode := exitErr.ExitCode()
-			if exitCode == 130 || exitCode == 143 {
+	return showLogs(sources)
+}
+
+// collectLogSources resolves the selected log files of each domain. A
+// domain whose config can't be read or whose logs don't exist is reported
+// and skipped so it doesn't hide the others.
+func collectLogSources(drv driver.Driver, domains []string) []logSource {
+	showAccess, showError, _ := selectedLogTypes()
+
+	var sources []logSource
+	seen := make(map[string]bool)
+	for _, domain := range domains {
+		accessLog, errorLog, err := parseLogPaths(drv, domain)
+		if err != nil {
+			output.Warn("Skipping %s: %v", domain, err)
+			continue
+		}
+
+		var paths []string
+		if showAccess {
+			paths = append(paths, accessLog)
+		}
+		if showError {
+			paths = append(paths, errorLog)
+		}
+		for _, path := range paths {
+			// Caddy, and shared log setups, use one file for several entries
+			if path == "" || seen[path] {
+				continue
+			}
+			seen[path] = true
+			if _, err := os.Stat(path); err != nil {
+				output.Warn("Log not found for %s: %s", domain, path)
+				continue
+			}
+			sources = append(sources, logSource{label: domain, path: path})
+		}
+	}
+	return sources
+}
+
+// printInterleavedLogs writes the last n lines of each source in time
+// order, prefixed with the source's label. Lines keep their order within a
+// file; a line without a recognizable timestamp (a stack trace, say) takes
+// the time of the line before it, and ties are broken by taking one line
+// from each file in turn.
+func printInterleavedLogs(w io.Writer, sources []logSource, n int) {
+	type logLine struct {
+		text string
+		at   time.Time
+	}
+
+	tails := make([][]logLine, len(sources))
+	for i, src := range sources {
+		lines, err := recentLogLines(src.path, n, logsRotated)
+		if err != nil {
+			output.Warn("Failed to read %s: %v", src.path, err)
+			continue
+		}
+		var last time.Time
+		for _, line := range lines {
+			if at, ok := logLineTime(line); ok {
+				last = at
+			}
+			tails[i] = append(tails[i], logLine{text: line, at: last})
+		}
+	}
+
+	next := make([]int, len(sources))
+	for {
+		pick := -1
+		for i := range sources {
+			if next[i] >= len(tails[i]) {
+				continue
+			}
+			if pick < 0 {
+				pick = i
+				continue
+			}
+			a, b := tails[i][next[i]], tails[pick][next[pick]]
+			if a.at.Before(b.at) || (a.at.Equal(b.at) && next[i] < next[pick]) {
+				pick = i
+			}
+		}
+		if pick < 0 {
+			return
+		}
+		_, _ = fmt.Fprintf(w, "[%s] %s\n", sources[pick].label, tails[pick][next[pick]].text)
+		next[pick]++
+	}
+}
+
+// logLineTime extracts the timestamp of a log line written in one of the
+// formats the drivers produce: nginx's error log, the common/combined access
+// log, Apache's error log and Caddy's JSON log
+func logLineTime(line string) (time.Time, bool) {
+	// nginx error log: 2006/01/02 15:04:05 [error] ...
+	if len(line) >= 19 {
+		if at, err := time.ParseInLocation("2006/01/02 15:04:05", line[:19], time.Local); err == nil {
+			return at, true
+		}
+	}
+
+	// Access log: ... [02/Jan/2006:15:04:05 -0700] ...
+	// Apache error log: [Mon Jan 02 15:04:05.000000 2006] ...
+	if open := strings.IndexByte(line, '['); open >= 0 {
+		if end := strings.IndexByte(line[open:], ']'); end > 0 {
+			stamp := line[open+1 : open+end]
+			if at, err := time.Parse("02/Jan/2006:15:04:05 -0700", stamp); err == nil {
+				return at, true
+			}
+			if at, err := time.ParseInLocation("Mon Jan 02 15:04:05.999999999 2006", stamp, time.Local); err == nil {
+				return at, true
+			}
+		}
+	}
+
+	// Caddy JSON log: {"level":"info","ts":1700000000.123,...}
+	if i := strings.Index(line, `"ts":`); i >= 0 {
+		rest := line[i+len(`"ts":`):]
+		end := strings.IndexAny(rest, ",}")
+		if end < 0 {
+			end = len(rest)
+		}
+		if ts, err := strconv.ParseFloat(strings.TrimSpace(rest[:end]), 64); err == nil {
+			sec := int64(ts)
+			return time.Unix(sec, int64((ts-float64(sec))*1e9)), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// followLogs writes lines appended to any source, as they arrive, until ctx
+// is done. A file that can't be read stops only its own stream.
+func followLogs(ctx context.Context, w io.Writer, sources []logSource, interval time.Duration) {
+	lines := make(chan string)
+	done := make(chan struct{})
+	remaining := len(sources)
+
+	for _, src := range sources {
+		go func(src logSource) {
+			defer func() { done <- struct{}{} }()
+			if err := followLogFile(ctx, src, interval, lines); err != nil {
+				output.Warn("Stopped following %s: %v", src.path, err)
+			}
+		}(src)
+	}
+
+	for remaining > 0 {
+		select {
+		case line := <-lines:
+			_, _ = fmt.Fprintln(w, line)
+		case <-done:
+			remaining--
+		}
+	}
+}
+
+// followLogFile polls one file from its current end and sends each complete
+// new line, prefixed with the source's label. A truncated file is re-read from the
+// start so log rotation by copytruncate keeps working.
+func followLogFile(ctx context.Context, src logSource, interval time.Duration, lines chan<- string) error {
+	f, err := os.Open(src.path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(f)
+	var partial string
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if info.Size() < offset {
+			if offset, err = f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			reader.Reset(f)
+			partial = ""
+		}
+
+		for {
+			chunk, err := reader.ReadString('\n')
+			offset += int64(len(chunk))
+			if err != nil {
+				// Keep an unterminated line until the rest is written
+				partial += chunk
+				if err == io.EOF {
+					break
+				}
+				return err
+			}
+
+			line := strings.TrimRight(partial+chunk, "\r\n")
+			partial = ""
+			select {
+			case lines <- fmt.Sprintf("[%s] %s", src.label, line):
+			case <-ctx.Done():
 				return nil
 			}
 		}
-		return fmt.Errorf("failed to read logs: %w", err)
 	}
+}
 
-	return nil
+// recentLogLines returns the last n lines of the log at path. With rotated,
+// lines missing from path are taken from its rotated copies, newest first:
+// path.1, then path.2.gz, path.3.gz and so on (plain or gzipped).
+func recentLogLines(path string, n int, rotated bool) ([]string, error) {
+	lines, err := tailLines(path, n)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 1; rotated && len(lines) < n; i++ {
+		older, err := readRotatedLog(fmt.Sprintf("%s.%d", path, i))
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if missing := n - len(lines); len(older) > missing {
+			older = older[len(older)-missing:]
+		}
+		lines = append(older, lines...)
+	}
+	return lines, nil
+}
+
+// readRotatedLog returns every line of the rotated log at path, or of
+// path.gz when only a compressed copy exists
+func readRotatedLog(path string) ([]string, error) {
+	f, err := os.Open(path)
+	compressed := false
+	if errors.Is(err, os.ErrNotExist) {
+		f, err = os.Open(path + ".gz")
+		compressed = true
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	if compressed {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s.gz: %w", path, err)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// tailLines returns the last n lines of a file, reading backwards in chunks
+// so large logs aren't loaded into memory
+func tailLines(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	const chunkSize = 4096
+	var buf []byte
+	offset := info.Size()
+	for offset > 0 && bytes.Count(buf, []byte("\n")) <= n {
+		readSize := int64(chunkSize)
+		if offset < readSize {
+			readSize = offset
+		}
+		offset -= readSize
+
+		chunk := make([]byte, readSize)
+		if _, err := f.ReadAt(chunk, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		buf = append(chunk, buf...)
+	}
+
+	text := strings.TrimRight(string(buf), "\n")
+	if text == "" {
+		return []string{}, nil
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
 }
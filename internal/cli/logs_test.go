@@ -1,10 +1,16 @@
 package cli
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
@@ -140,6 +146,400 @@ func TestRunLogs(t *testing.T) {
 	}
 }
 
+// setupLogsAll writes nginx configs whose access logs point at synthetic
+// files, and returns the log path for each domain
+func setupLogsAll(t *testing.T, availableDir string, logs map[string]string) map[string]string {
+	t.Helper()
+	if err := os.MkdirAll(availableDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	logDir := t.TempDir()
+	paths := make(map[string]string)
+	for domain, content := range logs {
+		logPath := filepath.Join(logDir, domain+".access.log")
+		if err := os.WriteFile(logPath, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write log: %v", err)
+		}
+		conf := fmt.Sprintf("server {\n    access_log %s;\n}\n", logPath)
+		if err := os.WriteFile(filepath.Join(availableDir, domain), []byte(conf), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		paths[domain] = logPath
+	}
+	return paths
+}
+
+func TestRunLogsAll(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	setupLogsAll(t, availableDir, map[string]string{
+		"a.com": "a1\na2\na3\n",
+		"b.com": "b1\nb2\n",
+	})
+
+	cfg := config.New()
+	for _, domain := range []string{"a.com", "b.com", "missing.com", "disabled.com"} {
+		cfg.VHosts[domain] = &config.VHost{Domain: domain, Type: "static", Enabled: true}
+	}
+
+	mockDrv := driver.NewMockDriver("nginx", availableDir, filepath.Join(tempDir, "sites-enabled"))
+	mockDrv.IsEnabledFunc = func(domain string) (bool, error) {
+		return domain != "disabled.com", nil
+	}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+	defer func() { deps = oldDeps }()
+
+	logsAll = true
+	logsAccess = true
+	logsError = false
+	logsFollow = false
+	logsLines = 2
+	defer func() {
+		logsAll = false
+		logsAccess = false
+		logsLines = 20
+	}()
+
+	var err error
+	out := captureStdout(func() {
+		err = runLogs(nil, nil)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// missing.com has no config file and is skipped without failing the rest
+	want := "[a.com] a2\n[b.com] b1\n[a.com] a3\n[b.com] b2\n"
+	if !strings.HasSuffix(out, want) {
+		t.Errorf("expected interleaved output ending in %q, got %q", want, out)
+	}
+}
+
+func TestRunLogsTypes(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	if err := os.MkdirAll(availableDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	accessLog := filepath.Join(tempDir, "access.log")
+	errorLog := filepath.Join(tempDir, "error.log")
+	if err := os.WriteFile(accessLog, []byte("GET /\nGET /about\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	if err := os.WriteFile(errorLog, []byte("timeout\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	conf := fmt.Sprintf("server {\n    access_log %s;\n    error_log %s;\n}\n", accessLog, errorLog)
+	if err := os.WriteFile(filepath.Join(availableDir, "example.com"), []byte(conf), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg := config.New()
+	cfg.VHosts["example.com"] = &config.VHost{Domain: "example.com", Type: "static", Aliases: []string{"www.example.com"}}
+	mockDrv := driver.NewMockDriver("nginx", availableDir, filepath.Join(tempDir, "sites-enabled"))
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+	defer func() { deps = oldDeps }()
+
+	tests := []struct {
+		name        string
+		domain      string
+		flags       []string
+		want        string
+		errContains string
+	}{
+		{
+			name: "default shows both",
+			want: "[access] GET /\n[error] timeout\n[access] GET /about\n",
+		},
+		{
+			name:  "access only",
+			flags: []string{"--access"},
+			want:  "[access] GET /\n[access] GET /about\n",
+		},
+		{
+			name:  "error only",
+			flags: []string{"--error-only"},
+			want:  "[error] timeout\n",
+		},
+		{
+			name:   "alias in another case",
+			domain: "WWW.Example.com",
+			flags:  []string{"--error-only"},
+			want:   "[error] timeout\n",
+		},
+		{
+			name:        "access and error",
+			flags:       []string{"--access", "--error"},
+			errContains: "can't be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logsAccess, logsError, logsFollow, logsLines = false, false, false, 20
+			defer func() { logsAccess, logsError = false, false }()
+			if err := logsCmd.ParseFlags(tt.flags); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+
+			domain := tt.domain
+			if domain == "" {
+				domain = "example.com"
+			}
+			var err error
+			out := captureStdout(func() {
+				err = runLogs(nil, []string{domain})
+			})
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.HasSuffix(out, tt.want) {
+				t.Errorf("expected output ending in %q, got %q", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestRecentLogLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	if err := os.WriteFile(path, []byte("c1\nc2\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	if err := os.WriteFile(path+".1", []byte("r1\nr2\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	f, err := os.Create(path + ".2.gz")
+	if err != nil {
+		t.Fatalf("failed to create log: %v", err)
+	}
+	gz := gzip.NewWriter(f)
+	_, _ = gz.Write([]byte("g1\ng2\ng3\n"))
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to compress log: %v", err)
+	}
+	_ = f.Close()
+
+	tests := []struct {
+		name    string
+		n       int
+		rotated bool
+		want    []string
+	}{
+		{"current only", 5, false, []string{"c1", "c2"}},
+		{"plain rotation", 3, true, []string{"r2", "c1", "c2"}},
+		{"gzipped rotation", 6, true, []string{"g2", "g3", "r1", "r2", "c1", "c2"}},
+		{"runs out of files", 100, true, []string{"g1", "g2", "g3", "r1", "r2", "c1", "c2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := recentLogLines(path, tt.n, tt.rotated)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunLogsAllRotations(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	logs := setupLogsAll(t, availableDir, map[string]string{"example.com": "new1\n"})
+	accessLog := logs["example.com"]
+
+	// access.log.1.gz holds older lines than access.log
+	f, err := os.Create(accessLog + ".1.gz")
+	if err != nil {
+		t.Fatalf("failed to create log: %v", err)
+	}
+	gz := gzip.NewWriter(f)
+	_, _ = gz.Write([]byte("old1\nold2\n"))
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to compress log: %v", err)
+	}
+	_ = f.Close()
+
+	cfg := config.New()
+	cfg.VHosts["example.com"] = &config.VHost{Domain: "example.com", Type: "static"}
+	mockDrv := driver.NewMockDriver("nginx", availableDir, filepath.Join(tempDir, "sites-enabled"))
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+	defer func() { deps = oldDeps }()
+
+	tests := []struct {
+		name    string
+		rotated bool
+		want    string
+	}{
+		{"current file by default", false, "\n[access] new1\n"},
+		{"rotations when asked", true, "\n[access] old1\n[access] old2\n[access] new1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logsAccess, logsFollow, logsLines, logsRotated = true, false, 20, tt.rotated
+			defer func() { logsAccess, logsRotated = false, false }()
+
+			var err error
+			out := captureStdout(func() {
+				err = runLogs(nil, []string{"example.com"})
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.HasSuffix(out, tt.want) {
+				t.Errorf("expected output ending in %q, got %q", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestPrintInterleavedLogsTimeOrder(t *testing.T) {
+	dir := t.TempDir()
+	sources := []logSource{
+		{label: "access", path: filepath.Join(dir, "access.log")},
+		{label: "error", path: filepath.Join(dir, "error.log")},
+	}
+	access := `1.2.3.4 - - [02/Jan/2024:15:04:01 +0000] "GET / HTTP/1.1" 200 5
+1.2.3.4 - - [02/Jan/2024:15:04:02 +0000] "GET /a HTTP/1.1" 200 5
+1.2.3.4 - - [02/Jan/2024:15:04:09 +0000] "GET /b HTTP/1.1" 500 5
+`
+	// Apache's error log has no zone and is read in local time
+	stamp := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC).In(time.Local).Format("Mon Jan 02 15:04:05.000000 2006")
+	errLog := "[" + stamp + "] [php:error] boom\n  #0 trace\n"
+	for path, content := range map[string]string{sources[0].path: access, sources[1].path: errLog} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write log: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	printInterleavedLogs(&buf, sources, 10)
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		got = append(got, line[:strings.IndexByte(line, ']')+1])
+	}
+	// The trace line stays with the error before it
+	want := "[access] [access] [error] [error] [access]"
+	if strings.Join(got, " ") != want {
+		t.Errorf("expected labels %q, got %q\n%s", want, strings.Join(got, " "), buf.String())
+	}
+}
+
+func TestLogLineTime(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want time.Time
+		ok   bool
+	}{
+		{
+			name: "access log",
+			line: `1.2.3.4 - - [02/Jan/2024:15:04:05 +0200] "GET / HTTP/1.1" 200 5`,
+			want: time.Date(2024, 1, 2, 13, 4, 5, 0, time.UTC),
+			ok:   true,
+		},
+		{
+			name: "nginx error log",
+			line: "2024/01/02 15:04:05 [error] 123#123: *1 open() failed",
+			want: time.Date(2024, 1, 2, 15, 4, 5, 0, time.Local),
+			ok:   true,
+		},
+		{
+			name: "apache error log",
+			line: "[Tue Jan 02 15:04:05.250000 2024] [core:error] [pid 1] AH00037",
+			want: time.Date(2024, 1, 2, 15, 4, 5, 250000000, time.Local),
+			ok:   true,
+		},
+		{
+			name: "caddy json log",
+			line: `{"level":"info","ts":1704207845.5,"msg":"handled request"}`,
+			want: time.Unix(1704207845, 500000000),
+			ok:   true,
+		},
+		{
+			name: "no timestamp",
+			line: "  #0 {main}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := logLineTime(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("expected ok=%v, got %v", tt.ok, ok)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFollowLogs(t *testing.T) {
+	dir := t.TempDir()
+	sources := []logSource{
+		{label: "a.com", path: filepath.Join(dir, "a.log")},
+		{label: "b.com", path: filepath.Join(dir, "b.log")},
+		{label: "gone.com", path: filepath.Join(dir, "gone.log")},
+	}
+	for _, src := range sources[:2] {
+		if err := os.WriteFile(src.path, []byte("old\n"), 0644); err != nil {
+			t.Fatalf("failed to write log: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var buf bytes.Buffer
+	finished := make(chan struct{})
+	go func() {
+		followLogs(ctx, &buf, sources, 5*time.Millisecond)
+		close(finished)
+	}()
+
+	appendLog := func(path, text string) {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("failed to open log: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+		if _, err := f.WriteString(text); err != nil {
+			t.Fatalf("failed to append log: %v", err)
+		}
+	}
+
+	// Give the followers time to open the files and reach the end
+	time.Sleep(50 * time.Millisecond)
+	appendLog(sources[0].path, "a new\n")
+	time.Sleep(50 * time.Millisecond)
+	appendLog(sources[1].path, "b new\npartial")
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-finished
+
+	want := "[a.com] a new\n[b.com] b new\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+}
+
 func TestParseNginxLogPath(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// logsStats makes logs print a summary of the access log
+var logsStats bool
+
+// defaultStatsLines is how many access log lines logs --stats reads when
+// --lines isn't given
+const defaultStatsLines = 1000
+
+// logStatsTop is how many client IPs and paths logs --stats lists
+const logStatsTop = 10
+
+// accessLogEntry is the part of an access log line logs --stats counts
+type accessLogEntry struct {
+	IP     string
+	Method string
+	Path   string
+	Status int
+}
+
+// logCount is one row of a logs --stats tally
+type logCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// logStats is the summary logs --stats prints
+type logStats struct {
+	Domain   string     `json:"domain"`
+	Path     string     `json:"path"`
+	Lines    int        `json:"lines"`  // lines read
+	Parsed   int        `json:"parsed"` // lines recognised as requests
+	Statuses []logCount `json:"statuses"`
+	TopIPs   []logCount `json:"top_ips"`
+	TopPaths []logCount `json:"top_paths"`
+}
+
+// checkLogsStatsFlags rejects flags that don't apply to --stats
+func checkLogsStatsFlags() error {
+	switch {
+	case logsAll:
+		return fmt.Errorf("--stats summarizes one vhost; pass a domain instead of --all")
+	case logsFollow:
+		return fmt.Errorf("--stats can't be combined with --follow")
+	case logsError:
+		return fmt.Errorf("--stats reads the access log; drop --error")
+	}
+	return nil
+}
+
+// runLogsStats prints the status codes, top client IPs and top paths of
+// the last lines of accessLog
+func runLogsStats(cmd *cobra.Command, domain, accessLog string) error {
+	if accessLog == "" {
+		return fmt.Errorf("no access log found for %s", domain)
+	}
+
+	n := defaultStatsLines
+	if cmd != nil && cmd.Flags().Changed("lines") {
+		n = logsLines
+	}
+
+	lines, err := recentLogLines(accessLog, n, logsRotated)
+	if err != nil {
+		return fmt.Errorf("failed to read access log: %w", err)
+	}
+
+	stats := summarizeAccessLog(lines)
+	stats.Domain = domain
+	stats.Path = accessLog
+
+	if jsonOutput {
+		return output.JSON(stats)
+	}
+	printLogStats(stats)
+	return nil
+}
+
+// summarizeAccessLog tallies the requests in lines. Lines that aren't
+// requests are counted as read but not parsed.
+func summarizeAccessLog(lines []string) *logStats {
+	statuses := make(map[string]int)
+	ips := make(map[string]int)
+	paths := make(map[string]int)
+
+	stats := &logStats{Lines: len(lines)}
+	for _, line := range lines {
+		entry, ok := parseAccessLogLine(line)
+		if !ok {
+			continue
+		}
+		stats.Parsed++
+		statuses[strconv.Itoa(entry.Status)]++
+		ips[entry.IP]++
+		paths[entry.Path]++
+	}
+
+	// Status codes read best in code order, the rest by count
+	stats.Statuses = sortedCounts(statuses, 0)
+	sort.Slice(stats.Statuses, func(i, j int) bool {
+		return stats.Statuses[i].Value < stats.Statuses[j].Value
+	})
+	stats.TopIPs = sortedCounts(ips, logStatsTop)
+	stats.TopPaths = sortedCounts(paths, logStatsTop)
+	return stats
+}
+
+// sortedCounts returns counts from most to least frequent, ties in value
+// order, keeping only the first limit (0 keeps all)
+func sortedCounts(counts map[string]int, limit int) []logCount {
+	result := make([]logCount, 0, len(counts))
+	for value, count := range counts {
+		result = append(result, logCount{Value: value, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Value < result[j].Value
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// parseAccessLogLine parses one access log line in the combined or common
+// log format nginx and apache write by default, or in Caddy's JSON format.
+// The query string is dropped from the path. ok is false for lines that
+// aren't requests.
+func parseAccessLogLine(line string) (entry accessLogEntry, ok bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		return parseCaddyAccessLogLine(line)
+	}
+
+	// 203.0.113.7 - - [10/Oct/2026:13:55:36 +0000] "GET /index.html HTTP/1.1" 200 2326 ...
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return entry, false
+	}
+	start := strings.IndexByte(line, '"')
+	if start < 0 {
+		return entry, false
+	}
+	end := strings.IndexByte(line[start+1:], '"')
+	if end < 0 {
+		return entry, false
+	}
+	request := strings.Fields(line[start+1 : start+1+end])
+	rest := strings.Fields(line[start+end+2:])
+	if len(request) < 2 || len(rest) == 0 {
+		return entry, false
+	}
+	status, err := strconv.Atoi(rest[0])
+	if err != nil {
+		return entry, false
+	}
+
+	return accessLogEntry{IP: fields[0], Method: request[0], Path: stripQuery(request[1]), Status: status}, true
+}
+
+// parseCaddyAccessLogLine parses a line of Caddy's JSON access log
+func parseCaddyAccessLogLine(line string) (entry accessLogEntry, ok bool) {
+	var record struct {
+		Status  int `json:"status"`
+		Request struct {
+			ClientIP string `json:"client_ip"`
+			RemoteIP string `json:"remote_ip"`
+			Method   string `json:"method"`
+			URI      string `json:"uri"`
+		} `json:"request"`
+	}
+	if err := json.Unmarshal([]byte(line), &record); err != nil || record.Status == 0 || record.Request.URI == "" {
+		return entry, false
+	}
+
+	ip := record.Request.ClientIP
+	if ip == "" {
+		ip = record.Request.RemoteIP
+	}
+	return accessLogEntry{IP: ip, Method: record.Request.Method, Path: stripQuery(record.Request.URI), Status: record.Status}, true
+}
+
+// stripQuery returns a request target without its query string
+func stripQuery(target string) string {
+	if i := strings.IndexByte(target, '?'); i >= 0 {
+		return target[:i]
+	}
+	return target
+}
+
+// printLogStats prints stats as one table per tally
+func printLogStats(stats *logStats) {
+	output.Info("Access log stats for %s (%d line(s), %d request(s)): %s", stats.Domain, stats.Lines, stats.Parsed, stats.Path)
+	if stats.Parsed == 0 {
+		output.Warn("No requests found in the log")
+		return
+	}
+
+	sections := []struct {
+		header string
+		counts []logCount
+	}{
+		{"STATUS", stats.Statuses},
+		{"CLIENT IP", stats.TopIPs},
+		{"PATH", stats.TopPaths},
+	}
+	for _, section := range sections {
+		rows := make([][]string, 0, len(section.counts))
+		for _, c := range section.counts {
+			rows = append(rows, []string{c.Value, strconv.Itoa(c.Count)})
+		}
+		output.Print("")
+		output.Table([]string{section.header, "REQUESTS"}, rows)
+	}
+}
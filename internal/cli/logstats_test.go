@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+)
+
+// accessLine returns a combined log format line
+func accessLine(ip, path string, status int) string {
+	return fmt.Sprintf(`%s - - [10/Oct/2026:13:55:36 +0000] "GET %s HTTP/1.1" %d 512 "-" "curl/8.0"`, ip, path, status)
+}
+
+func TestParseAccessLogLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want accessLogEntry
+		ok   bool
+	}{
+		{"combined", accessLine("203.0.113.7", "/index.html?page=2", 200), accessLogEntry{IP: "203.0.113.7", Method: "GET", Path: "/index.html", Status: 200}, true},
+		{"common", `::1 - bob [10/Oct/2026:13:55:36 +0000] "POST /login HTTP/2.0" 302 0`, accessLogEntry{IP: "::1", Method: "POST", Path: "/login", Status: 302}, true},
+		{"caddy json", `{"level":"info","request":{"remote_ip":"10.0.0.1","client_ip":"198.51.100.4","method":"GET","uri":"/api?x=1"},"status":404}`, accessLogEntry{IP: "198.51.100.4", Method: "GET", Path: "/api", Status: 404}, true},
+		{"caddy json without client ip", `{"request":{"remote_ip":"10.0.0.1","method":"GET","uri":"/"},"status":200}`, accessLogEntry{IP: "10.0.0.1", Method: "GET", Path: "/", Status: 200}, true},
+		{"caddy non-request entry", `{"level":"info","msg":"server running"}`, accessLogEntry{}, false},
+		{"malformed request", `203.0.113.7 - - [10/Oct/2026] "-" 400 0`, accessLogEntry{}, false},
+		{"no status", `203.0.113.7 - - [10/Oct/2026] "GET / HTTP/1.1"`, accessLogEntry{}, false},
+		{"empty", "", accessLogEntry{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseAccessLogLine(tt.line)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("expected %+v, %v; got %+v, %v", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestSummarizeAccessLog(t *testing.T) {
+	var lines []string
+	for i := 0; i < 5; i++ {
+		lines = append(lines, accessLine("203.0.113.7", "/", 200))
+	}
+	for i := 0; i < 3; i++ {
+		lines = append(lines, accessLine("198.51.100.4", "/missing", 404))
+	}
+	lines = append(lines, accessLine("192.0.2.1", "/", 500), "garbage")
+	// Twelve one-off clients push the list past the top 10
+	for i := 1; i <= 12; i++ {
+		lines = append(lines, accessLine(fmt.Sprintf("10.0.0.%d", i), "/health", 200))
+	}
+
+	stats := summarizeAccessLog(lines)
+	if stats.Lines != 22 || stats.Parsed != 21 {
+		t.Errorf("expected 22 lines and 21 requests, got %d and %d", stats.Lines, stats.Parsed)
+	}
+
+	wantStatuses := []logCount{{"200", 17}, {"404", 3}, {"500", 1}}
+	if !reflect.DeepEqual(stats.Statuses, wantStatuses) {
+		t.Errorf("expected statuses %v, got %v", wantStatuses, stats.Statuses)
+	}
+
+	if len(stats.TopIPs) != logStatsTop {
+		t.Fatalf("expected %d top IPs, got %d: %v", logStatsTop, len(stats.TopIPs), stats.TopIPs)
+	}
+	wantTop := []logCount{{"203.0.113.7", 5}, {"198.51.100.4", 3}, {"10.0.0.1", 1}}
+	if !reflect.DeepEqual(stats.TopIPs[:3], wantTop) {
+		t.Errorf("expected top IPs to start with %v, got %v", wantTop, stats.TopIPs)
+	}
+
+	wantPaths := []logCount{{"/health", 12}, {"/", 6}, {"/missing", 3}}
+	if !reflect.DeepEqual(stats.TopPaths, wantPaths) {
+		t.Errorf("expected top paths %v, got %v", wantPaths, stats.TopPaths)
+	}
+}
+
+func TestRunLogsStats(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	if err := os.MkdirAll(availableDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	accessLog := filepath.Join(tempDir, "access.log")
+	content := strings.Join([]string{
+		accessLine("203.0.113.7", "/", 200),
+		accessLine("203.0.113.7", "/about", 200),
+		accessLine("198.51.100.4", "/", 404),
+	}, "\n") + "\n"
+	if err := os.WriteFile(accessLog, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	conf := fmt.Sprintf("server {\n    access_log %s;\n}\n", accessLog)
+	if err := os.WriteFile(filepath.Join(availableDir, "example.com"), []byte(conf), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg := config.New()
+	cfg.VHosts["example.com"] = &config.VHost{Domain: "example.com", Type: "static"}
+	mockDrv := driver.NewMockDriver("nginx", availableDir, filepath.Join(tempDir, "sites-enabled"))
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+	defer func() { deps = oldDeps }()
+
+	resetFlags := func() {
+		logsStats, logsRotated, logsAll, logsFollow, logsError, logsAccess = false, false, false, false, false, false
+		jsonOutput = false
+	}
+	defer resetFlags()
+
+	t.Run("json", func(t *testing.T) {
+		resetFlags()
+		logsStats, jsonOutput = true, true
+
+		var err error
+		out := captureStdout(func() { err = runLogs(nil, []string{"example.com"}) })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var stats logStats
+		if err := json.Unmarshal([]byte(out), &stats); err != nil {
+			t.Fatalf("invalid JSON %q: %v", out, err)
+		}
+		if stats.Path != accessLog || stats.Parsed != 3 {
+			t.Errorf("unexpected stats %+v", stats)
+		}
+		if len(stats.TopIPs) == 0 || stats.TopIPs[0] != (logCount{"203.0.113.7", 2}) {
+			t.Errorf("expected 203.0.113.7 first with 2 requests, got %v", stats.TopIPs)
+		}
+		if !reflect.DeepEqual(stats.Statuses, []logCount{{"200", 2}, {"404", 1}}) {
+			t.Errorf("unexpected statuses %v", stats.Statuses)
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		resetFlags()
+		logsStats = true
+
+		var err error
+		out := captureStdout(func() { err = runLogs(nil, []string{"example.com"}) })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, want := range []string{"STATUS", "404", "CLIENT IP", "198.51.100.4", "PATH", "/about"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("flag conflicts", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			set   func()
+			errIs string
+		}{
+			{"follow", func() { logsStats, logsFollow = true, true }, "can't be combined with --follow"},
+			{"error log", func() { logsStats, logsError = true, true }, "reads the access log"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				resetFlags()
+				tt.set()
+				err := runLogs(nil, []string{"example.com"})
+				if err == nil || !strings.Contains(err.Error(), tt.errIs) {
+					t.Errorf("expected error containing %q, got %v", tt.errIs, err)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,396 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// maintenanceFile holds the enabled set recorded by "maintenance on"
+const maintenanceFile = "maintenance.json"
+
+// preMaintenanceDir holds the live config of vhosts switched to their
+// maintenance page with "vhost set --maintenance on"
+const preMaintenanceDir = "pre-maintenance"
+
+var maintenancePage string
+
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Take all sites down and bring them back",
+	Long: `Disable every site for a maintenance window and restore them afterwards.
+
+"maintenance on" records which vhosts are enabled in
+~/.config/vhost/maintenance.json before disabling them. "maintenance off"
+re-enables exactly that set, so sites that were already down stay down.
+
+Examples:
+  vhost maintenance on
+  vhost maintenance on --page maintenance.example.com
+  vhost maintenance status
+  vhost maintenance off`,
+}
+
+var maintenanceOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Record the enabled sites and disable them",
+	Args:  cobra.NoArgs,
+	RunE:  runMaintenanceOn,
+}
+
+var maintenanceOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Re-enable the sites recorded by maintenance on",
+	Args:  cobra.NoArgs,
+	RunE:  runMaintenanceOff,
+}
+
+var maintenanceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether maintenance mode is on",
+	Args:  cobra.NoArgs,
+	RunE:  runMaintenanceStatus,
+}
+
+func init() {
+	maintenanceOnCmd.Flags().StringVar(&maintenancePage, "page", "", "VHost serving the maintenance page; kept or made enabled")
+	maintenanceOnCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server")
+	maintenanceOffCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server")
+
+	maintenanceCmd.AddCommand(maintenanceOnCmd)
+	maintenanceCmd.AddCommand(maintenanceOffCmd)
+	maintenanceCmd.AddCommand(maintenanceStatusCmd)
+
+	rootCmd.AddCommand(maintenanceCmd)
+}
+
+// maintenanceState is the snapshot written by "maintenance on"
+type maintenanceState struct {
+	StartedAt time.Time `json:"started_at"`
+	Enabled   []string  `json:"enabled"`        // sites enabled before maintenance
+	Page      string    `json:"page,omitempty"` // maintenance page vhost, if any
+}
+
+func runMaintenanceOn(cmd *cobra.Command, args []string) error {
+	if state, err := loadMaintenanceState(); err != nil {
+		return err
+	} else if state != nil {
+		return fmt.Errorf("maintenance mode is already on (since %s); run \"vhost maintenance off\" first",
+			state.StartedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return err
+	}
+
+	if maintenancePage != "" {
+		if err := validateDomain(maintenancePage); err != nil {
+			return err
+		}
+		if _, exists := cfg.VHosts[maintenancePage]; !exists {
+			return fmt.Errorf("maintenance page vhost %s not found", maintenancePage)
+		}
+	}
+
+	state := &maintenanceState{
+		StartedAt: time.Now(),
+		Enabled:   enabledDomains(cfg, drv),
+		Page:      maintenancePage,
+	}
+
+	var toDisable, toEnable []string
+	for _, domain := range state.Enabled {
+		if domain != maintenancePage {
+			toDisable = append(toDisable, domain)
+		}
+	}
+	if maintenancePage != "" && !slices.Contains(state.Enabled, maintenancePage) {
+		toEnable = append(toEnable, maintenancePage)
+	}
+
+	if dryRun {
+		return outputMaintenanceDryRun(drv, toDisable, toEnable)
+	}
+
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	ok, err := confirm(fmt.Sprintf("Disable %d vhost(s) for maintenance?", len(toDisable)), false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		output.Info("Maintenance cancelled")
+		return nil
+	}
+
+	// Record the snapshot before touching anything so it survives a crash
+	if err := saveMaintenanceState(state); err != nil {
+		return err
+	}
+
+	rollback, err := switchSites(drv, toDisable, toEnable)
+	if err == nil {
+		err = testAndReload(drv, !noReload, rollback)
+	} else if rbErr := rollback(); rbErr != nil {
+		output.Warn("Rollback failed: %v", rbErr)
+	}
+	if err != nil {
+		_ = removeMaintenanceState()
+		return err
+	}
+
+	return outputResult(
+		map[string]interface{}{
+			"success":  true,
+			"disabled": toDisable,
+			"page":     maintenancePage,
+		},
+		"Maintenance mode on: disabled %d vhost(s)", len(toDisable),
+	)
+}
+
+func runMaintenanceOff(cmd *cobra.Command, args []string) error {
+	state, err := loadMaintenanceState()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("maintenance mode is not on")
+	}
+
+	_, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return err
+	}
+
+	// Bring back the recorded set; everything else is left as it is
+	var toEnable, toDisable []string
+	for _, domain := range state.Enabled {
+		if enabled, _ := drv.IsEnabled(domain); !enabled {
+			toEnable = append(toEnable, domain)
+		}
+	}
+	if state.Page != "" && !slices.Contains(state.Enabled, state.Page) {
+		if enabled, _ := drv.IsEnabled(state.Page); enabled {
+			toDisable = append(toDisable, state.Page)
+		}
+	}
+
+	if dryRun {
+		return outputMaintenanceDryRun(drv, toDisable, toEnable)
+	}
+
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	rollback, err := switchSites(drv, toDisable, toEnable)
+	if err == nil {
+		err = testAndReload(drv, !noReload, rollback)
+	} else if rbErr := rollback(); rbErr != nil {
+		output.Warn("Rollback failed: %v", rbErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := removeMaintenanceState(); err != nil {
+		output.Warn("Sites restored but %v", err)
+	}
+
+	return outputResult(
+		map[string]interface{}{
+			"success": true,
+			"enabled": toEnable,
+		},
+		"Maintenance mode off: re-enabled %d vhost(s)", len(toEnable),
+	)
+}
+
+func runMaintenanceStatus(cmd *cobra.Command, args []string) error {
+	state, err := loadMaintenanceState()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		result := map[string]interface{}{"active": state != nil}
+		if state != nil {
+			result["started_at"] = state.StartedAt
+			result["enabled"] = state.Enabled
+			result["page"] = state.Page
+		}
+		return output.JSON(result)
+	}
+
+	if state == nil {
+		output.Info("Maintenance mode is off")
+		return nil
+	}
+
+	output.Warn("Maintenance mode is on since %s", state.StartedAt.Format("2006-01-02 15:04:05"))
+	if state.Page != "" {
+		output.Print("Page:    %s", state.Page)
+	}
+	output.Print("Will re-enable %d vhost(s):", len(state.Enabled))
+	for _, domain := range state.Enabled {
+		output.Print("  %s", domain)
+	}
+	return nil
+}
+
+// enabledDomains returns the configured domains with an enabled entry,
+// including outdated copies the server still serves
+func enabledDomains(cfg *config.Config, drv driver.Driver) []string {
+	domains := []string{}
+	for _, domain := range sortedDomains(cfg) {
+		if hasEnabledEntry(drv, domain) {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// outputMaintenanceDryRun outputs what maintenance on/off would do
+func outputMaintenanceDryRun(drv driver.Driver, toDisable, toEnable []string) error {
+	enabledDir := drv.Paths().Enabled
+
+	var operations []DryRunOperation
+	for _, domain := range toDisable {
+		operations = append(operations, DryRunOperation{
+			Action: "remove_symlink",
+			Target: filepath.Join(enabledDir, driverConfigFileName(drv.Name(), domain)),
+		})
+	}
+	for _, domain := range toEnable {
+		operations = append(operations, DryRunOperation{
+			Action: "create_symlink",
+			Target: filepath.Join(enabledDir, driverConfigFileName(drv.Name(), domain)),
+		})
+	}
+	if !noReload {
+		operations = append(operations,
+			DryRunOperation{
+				Action:  "test_config",
+				Target:  drv.Name(),
+				Details: "Validate configuration syntax",
+			},
+			DryRunOperation{
+				Action:  "reload_server",
+				Target:  drv.Name(),
+				Details: "Apply configuration changes",
+			},
+		)
+	}
+
+	return outputDryRun(&DryRunResult{
+		Domain:     "maintenance",
+		Operations: operations,
+	})
+}
+
+// maintenanceStatePath returns the path of the maintenance snapshot
+func maintenanceStatePath() (string, error) {
+	dir, err := deps.ConfigLoader.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, maintenanceFile), nil
+}
+
+// loadMaintenanceState reads the snapshot; nil means maintenance is off
+func loadMaintenanceState() (*maintenanceState, error) {
+	path, err := maintenanceStatePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read maintenance state: %w", err)
+	}
+
+	var state maintenanceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse maintenance state %s: %w", path, err)
+	}
+	sort.Strings(state.Enabled)
+	return &state, nil
+}
+
+// saveMaintenanceState writes the snapshot to the config directory
+func saveMaintenanceState(state *maintenanceState) error {
+	path, err := maintenanceStatePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal maintenance state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write maintenance state: %w", err)
+	}
+	return nil
+}
+
+// removeMaintenanceState deletes the snapshot, ending maintenance mode
+func removeMaintenanceState() error {
+	path, err := maintenanceStatePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove maintenance state: %w", err)
+	}
+	return nil
+}
+
+// maintenanceBackupPath returns where the pre-maintenance config of domain is kept
+func maintenanceBackupPath(drvName, domain string) (string, error) {
+	dir, err := deps.ConfigLoader.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, preMaintenanceDir, driverConfigFileName(drvName, domain)), nil
+}
+
+// backupForMaintenance copies the live config of domain to backupPath.
+// A missing file is not an error; leaving maintenance then re-renders it.
+func backupForMaintenance(drv driver.Driver, domain, backupPath string) error {
+	configPath := filepath.Join(drv.Paths().Available, driverConfigFileName(drv.Name(), domain))
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read current config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to back up current config: %w", err)
+	}
+	return nil
+}
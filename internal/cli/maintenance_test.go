@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+)
+
+// newMaintenanceTestDeps returns a mock driver whose enabled set is tracked
+// in the returned map, plus a config listing every domain in it
+func newMaintenanceTestDeps(t *testing.T, state map[string]bool) (*Dependencies, *driver.MockDriver) {
+	t.Helper()
+	tempDir := t.TempDir()
+
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+	mockDrv.IsEnabledFunc = func(domain string) (bool, error) { return state[domain], nil }
+	mockDrv.EnableFunc = func(domain string) error { state[domain] = true; return nil }
+	mockDrv.DisableFunc = func(domain string) error { state[domain] = false; return nil }
+
+	cfg := config.New()
+	for domain := range state {
+		cfg.VHosts[domain] = &config.VHost{Domain: domain, Type: "static", Enabled: true}
+	}
+
+	return NewMockDeps().WithConfig(cfg).WithConfigDir(filepath.Join(tempDir, "config")).WithDriver(mockDrv).WithRootAccess(true).Build(), mockDrv
+}
+
+func enabledSet(state map[string]bool) []string {
+	var domains []string
+	for domain, enabled := range state {
+		if enabled {
+			domains = append(domains, domain)
+		}
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+func TestMaintenanceRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		page  string
+		state map[string]bool
+	}{
+		{
+			name:  "restores exact enabled set",
+			state: map[string]bool{"a.com": true, "b.com": true, "down.com": false},
+		},
+		{
+			name:  "maintenance page is enabled during the window only",
+			page:  "maint.com",
+			state: map[string]bool{"a.com": true, "down.com": false, "maint.com": false},
+		},
+		{
+			name:  "already enabled maintenance page stays enabled",
+			page:  "maint.com",
+			state: map[string]bool{"a.com": true, "maint.com": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldDeps := deps
+			mockDeps, _ := newMaintenanceTestDeps(t, tt.state)
+			deps = mockDeps
+			defer func() { deps = oldDeps }()
+
+			noReload = false
+			assumeYes = true
+			maintenancePage = tt.page
+			defer func() {
+				assumeYes = false
+				maintenancePage = ""
+			}()
+
+			before := enabledSet(tt.state)
+
+			if err := runMaintenanceOn(nil, nil); err != nil {
+				t.Fatalf("maintenance on failed: %v", err)
+			}
+			var wantDuring []string
+			if tt.page != "" {
+				wantDuring = []string{tt.page}
+			}
+			if during := enabledSet(tt.state); !reflect.DeepEqual(during, wantDuring) {
+				t.Errorf("expected only %v enabled during maintenance, got %v", wantDuring, during)
+			}
+
+			saved, err := loadMaintenanceState()
+			if err != nil || saved == nil {
+				t.Fatalf("expected saved maintenance state, got %v (err %v)", saved, err)
+			}
+			if !reflect.DeepEqual(saved.Enabled, before) {
+				t.Errorf("expected snapshot %v, got %v", before, saved.Enabled)
+			}
+
+			if err := runMaintenanceOn(nil, nil); err == nil || !strings.Contains(err.Error(), "already on") {
+				t.Errorf("second maintenance on should fail, got %v", err)
+			}
+
+			if err := runMaintenanceOff(nil, nil); err != nil {
+				t.Fatalf("maintenance off failed: %v", err)
+			}
+			if after := enabledSet(tt.state); !reflect.DeepEqual(after, before) {
+				t.Errorf("expected enabled set %v after maintenance, got %v", before, after)
+			}
+
+			if state, _ := loadMaintenanceState(); state != nil {
+				t.Error("maintenance state should be removed after off")
+			}
+		})
+	}
+}
+
+func TestMaintenanceOnRollback(t *testing.T) {
+	state := map[string]bool{"a.com": true, "b.com": true}
+
+	oldDeps := deps
+	mockDeps, mockDrv := newMaintenanceTestDeps(t, state)
+	deps = mockDeps
+	defer func() { deps = oldDeps }()
+	mockDrv.TestFunc = func() error { return errors.New("syntax error") }
+
+	noReload = false
+	assumeYes = true
+	defer func() { assumeYes = false }()
+
+	err := runMaintenanceOn(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "configuration test failed") {
+		t.Fatalf("expected test failure, got %v", err)
+	}
+	if got := enabledSet(state); !reflect.DeepEqual(got, []string{"a.com", "b.com"}) {
+		t.Errorf("expected sites re-enabled after rollback, got %v", got)
+	}
+
+	path, _ := maintenanceStatePath()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("maintenance state should not be left behind after a failed on")
+	}
+}
+
+func TestMaintenanceOffWithoutOn(t *testing.T) {
+	oldDeps := deps
+	mockDeps, _ := newMaintenanceTestDeps(t, map[string]bool{"a.com": true})
+	deps = mockDeps
+	defer func() { deps = oldDeps }()
+
+	err := runMaintenanceOff(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "not on") {
+		t.Errorf("expected not on error, got %v", err)
+	}
+}
@@ -3,8 +3,9 @@ package cli
 import (
 	"fmt"
 	"path/filepath"
-	"strings"
 
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/logger"
 	"github.com/ksyq12/vhost/internal/output"
 	"github.com/spf13/cobra"
 )
@@ -21,15 +22,18 @@ var removeCmd = &cobra.Command{
 
 Examples:
   vhost remove example.com
-  vhost rm example.com --force`,
-	Args: cobra.ExactArgs(1),
-	RunE: runRemove,
+  vhost rm example.com --force
+  vhost remove example.com --yes`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runRemove,
 }
 
 func init() {
 	removeCmd.Flags().BoolVarP(&forceRemove, "force", "f", false, "Force removal without confirmation")
 	removeCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server")
 
+	interruptible(removeCmd)
 	rootCmd.AddCommand(removeCmd)
 }
 
@@ -46,6 +50,7 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	domain = resolveDomain(cfg, domain)
 
 	// Dry-run mode: show what would be done without making changes
 	if dryRun {
@@ -59,17 +64,24 @@ func runRemove(cmd *cobra.Command, args []string) error {
 
 	// Confirm removal if not forced
 	if !forceRemove {
-		output.Print("Are you sure you want to remove vhost '%s'? [y/N]: ", domain)
-		answer, _ := deps.StdinReader.ReadString('\n')
-		answer = strings.TrimSpace(strings.ToLower(answer))
-		if answer != "y" && answer != "yes" {
+		ok, err := confirm(fmt.Sprintf("Are you sure you want to remove vhost '%s'?", domain), false)
+		if err != nil {
+			return err
+		}
+		if !ok {
 			output.Info("Removal cancelled")
 			return nil
 		}
 	}
 
+	// Ctrl-C at this point leaves the vhost untouched
+	if err := checkInterrupted(commandContext(cmd)); err != nil {
+		return err
+	}
+
 	// Remove via driver
 	output.Info("Removing vhost configuration...")
+	logger.Debug("Removing %s from %s and %s", domain, drv.Paths().Available, drv.Paths().Enabled)
 	if err := drv.Remove(domain); err != nil {
 		return fmt.Errorf("failed to remove vhost: %w", err)
 	}
@@ -81,6 +93,7 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	}
 
 	// Remove from config
+	logger.Debug("Dropping %s from config", domain)
 	delete(cfg.VHosts, domain)
 	if err := saveConfig(cfg); err != nil {
 		output.Warn("VHost removed but config save failed: %v", err)
@@ -97,12 +110,9 @@ func runRemove(cmd *cobra.Command, args []string) error {
 }
 
 // outputRemoveDryRun outputs what remove command would do in dry-run mode
-func outputRemoveDryRun(domain string, drvName string, drvPaths struct{ Available, Enabled string }) error {
-	// Determine config file name (apache uses .conf extension)
-	configFileName := domain
-	if drvName == "apache" {
-		configFileName = domain + ".conf"
-	}
+func outputRemoveDryRun(domain string, drvName string, drvPaths driver.Paths) error {
+	// Determine config file name (some drivers use an extension)
+	configFileName := driverConfigFileName(drvName, domain)
 
 	configPath := filepath.Join(drvPaths.Available, configFileName)
 	enabledPath := filepath.Join(drvPaths.Enabled, configFileName)
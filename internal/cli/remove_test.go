@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"path/filepath"
 	"strings"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/spf13/cobra"
 )
 
 func TestRunRemove(t *testing.T) {
@@ -365,3 +367,34 @@ func TestRunRemoveDryRun(t *testing.T) {
 		})
 	}
 }
+
+func TestRunRemoveInterrupted(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+	cfg := config.New()
+	cfg.VHosts["test.com"] = &config.VHost{Domain: "test.com", Type: "static", Enabled: true}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	forceRemove = true
+	defer func() {
+		deps = oldDeps
+		forceRemove = false
+	}()
+
+	// Ctrl-C arrived before anything was removed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cmd := &cobra.Command{}
+	cmd.SetContext(ctx)
+
+	if err := runRemove(cmd, []string{"test.com"}); !errors.Is(err, errInterrupted) {
+		t.Fatalf("expected interrupted error, got %v", err)
+	}
+	if len(mockDrv.RemoveCalls) != 0 {
+		t.Errorf("expected nothing removed, got %v", mockDrv.RemoveCalls)
+	}
+	if _, kept := cfg.VHosts["test.com"]; !kept {
+		t.Error("interrupted remove should keep the vhost in config")
+	}
+}
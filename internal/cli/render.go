@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksyq12/vhost/internal/config"
+	vherrors "github.com/ksyq12/vhost/internal/errors"
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/ksyq12/vhost/internal/ssl"
+	"github.com/ksyq12/vhost/internal/template"
+	"github.com/spf13/cobra"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render <domain>",
+	Short: "Print the server config vhost would write for a domain",
+	Long: `Render a vhost's server configuration and print it to stdout.
+
+Nothing is written, tested or reloaded, and the web server is never
+touched, so render needs no root access. Unlike add --dry-run it prints
+only the raw configuration, ready to pipe into a file.
+
+A domain in config.yaml renders from its stored settings. --type, --root,
+--proxy and the other flags override those settings for this render only,
+or describe a vhost that isn't stored at all. --driver renders for a
+server other than the configured one.
+
+Examples:
+  vhost render example.com
+  vhost render example.com --driver caddy
+  vhost render new.example.com --type static --root /var/www/new
+  vhost render old.example.com --redirect https://example.com > old.conf`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runRender,
+}
+
+var (
+	renderDriver   string
+	renderType     string
+	renderTemplate string
+	renderRoot     string
+	renderProxy    string
+	renderRedirect string
+	renderRedirCd  int
+	renderPHP      string
+	renderSPA      bool
+	renderSSL      bool
+)
+
+func init() {
+	renderCmd.Flags().StringVar(&renderDriver, "driver", "", "Render for this web server instead of the configured one")
+	renderCmd.Flags().StringVarP(&renderType, "type", "t", "", "VHost type (static, php, proxy, laravel, wordpress, redirect)")
+	renderCmd.Flags().StringVar(&renderTemplate, "template", "", "Template variant")
+	renderCmd.Flags().StringVarP(&renderRoot, "root", "r", "", "Document root path")
+	renderCmd.Flags().StringVarP(&renderProxy, "proxy", "p", "", "Proxy pass URL (for proxy type)")
+	renderCmd.Flags().StringVar(&renderRedirect, "redirect", "", "Redirect target URL (implies --type redirect)")
+	renderCmd.Flags().IntVar(&renderRedirCd, "redirect-code", template.DefaultRedirectCode, "Redirect status: 301 or 302")
+	renderCmd.Flags().StringVar(&renderPHP, "php", "", "PHP version (e.g., 8.2)")
+	renderCmd.Flags().BoolVar(&renderSPA, "spa", false, "Serve /index.html for unknown paths (static type)")
+	renderCmd.Flags().BoolVar(&renderSSL, "ssl", false, "Render the HTTPS configuration with Let's Encrypt certificate paths")
+
+	rootCmd.AddCommand(renderCmd)
+}
+
+// renderResult is the JSON output of the render command
+type renderResult struct {
+	Domain string `json:"domain"`
+	Driver string `json:"driver"`
+	Config string `json:"config"`
+}
+
+func runRender(cmd *cobra.Command, args []string) error {
+	domain := config.NormalizeDomain(args[0])
+	if err := validateDomain(domain); err != nil {
+		return err
+	}
+
+	cfg, err := deps.ConfigLoader.Load()
+	if err != nil {
+		return vherrors.Wrap(vherrors.ErrCodeConfig, "failed to load config", err)
+	}
+	template.SetTemplateDir(cfg.TemplateDir)
+
+	drvName := renderDriver
+	if drvName == "" {
+		active, err := cfg.WithProfile(profileName)
+		if err != nil {
+			return err
+		}
+		drvName = active.Driver
+	}
+
+	vhost, err := renderVHost(cmd, cfg, domain)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := template.Render(drvName, vhost)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	if jsonOutput {
+		return output.JSON(renderResult{Domain: domain, Driver: drvName, Config: rendered})
+	}
+	fmt.Print(rendered)
+	return nil
+}
+
+// renderVHost returns the vhost to render: a copy of the stored one with
+// the given flags applied, or one built from the flags alone. The config
+// is never modified.
+func renderVHost(cmd *cobra.Command, cfg *config.Config, domain string) (*config.VHost, error) {
+	flags := cmd.Flags()
+	adHoc := flags.Changed("type") || flags.Changed("root") || flags.Changed("proxy") || flags.Changed("redirect")
+
+	vhost := &config.VHost{Domain: domain, Type: config.TypeStatic}
+	if stored, exists := cfg.VHosts[domain]; exists {
+		copied := *stored
+		vhost = &copied
+	} else if !adHoc {
+		return nil, fmt.Errorf("vhost %s not found (pass --type and its options to render it ad hoc)", domain)
+	}
+
+	// Apply only the flags that were given
+	if flags.Changed("redirect") {
+		vhost.Type = config.TypeRedirect
+		vhost.RedirectURL = renderRedirect
+	}
+	if flags.Changed("type") {
+		if !config.IsValidType(renderType) {
+			return nil, fmt.Errorf("invalid type: %s. Valid types: %s", renderType, strings.Join(config.ValidTypes(), ", "))
+		}
+		vhost.Type = renderType
+	}
+	if flags.Changed("template") {
+		vhost.Template = renderTemplate
+	}
+	if flags.Changed("root") {
+		vhost.Root = renderRoot
+	}
+	if flags.Changed("proxy") {
+		vhost.ProxyPass = renderProxy
+	}
+	if flags.Changed("redirect-code") {
+		vhost.RedirectCode = renderRedirCd
+	}
+	if flags.Changed("php") {
+		vhost.PHPVersion = renderPHP
+	}
+	if flags.Changed("spa") {
+		vhost.SPA = renderSPA
+	}
+	if flags.Changed("ssl") {
+		vhost.SSL = renderSSL
+		switch {
+		case renderSSL && vhost.SSLCert == "":
+			cert := ssl.GetCertPaths(domain)
+			vhost.SSLCert, vhost.SSLKey, vhost.HTTP2 = cert.CertPath, cert.KeyPath, true
+		case !renderSSL:
+			// The HTTP rendering of a stored SSL vhost keeps no TLS settings
+			vhost.SSLCert, vhost.SSLKey, vhost.HTTP2, vhost.HTTP3 = "", "", false, false
+		}
+	}
+
+	if err := validateTypeOptions(vhost.Type, vhost.Root, vhost.ProxyPass, vhost.RedirectURL); err != nil {
+		return nil, err
+	}
+	if vhost.PHPVersion == "" && (vhost.Type == config.TypePHP || vhost.Type == config.TypeLaravel || vhost.Type == config.TypeWordPress) {
+		vhost.PHPVersion = cfg.DefaultPHP
+	}
+	return vhost, nil
+}
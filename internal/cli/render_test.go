@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/template"
+)
+
+func TestRunRender(t *testing.T) {
+	stored := &config.VHost{Domain: "test.com", Type: "static", Root: "/var/www/test", Aliases: []string{"www.test.com"}}
+
+	tests := []struct {
+		name   string
+		domain string
+		flags  map[string]string
+		driver string
+		want   *config.VHost
+	}{
+		{
+			name:   "stored vhost",
+			domain: "test.com",
+			driver: "nginx",
+			want:   stored,
+		},
+		{
+			name:   "driver override",
+			domain: "test.com",
+			flags:  map[string]string{"driver": "caddy"},
+			driver: "caddy",
+			want:   stored,
+		},
+		{
+			name:   "flags override stored settings",
+			domain: "test.com",
+			flags:  map[string]string{"type": "proxy", "proxy": "http://localhost:3000"},
+			driver: "nginx",
+			want:   &config.VHost{Domain: "test.com", Type: "proxy", Root: "/var/www/test", Aliases: []string{"www.test.com"}, ProxyPass: "http://localhost:3000"},
+		},
+		{
+			name:   "ad hoc vhost",
+			domain: "new.test.com",
+			flags:  map[string]string{"type": "php", "root": "/var/www/new", "php": "8.3"},
+			driver: "nginx",
+			want:   &config.VHost{Domain: "new.test.com", Type: "php", Root: "/var/www/new", PHPVersion: "8.3"},
+		},
+		{
+			name:   "ad hoc redirect",
+			domain: "old.test.com",
+			flags:  map[string]string{"redirect": "https://test.com", "redirect-code": "302", "driver": "apache"},
+			driver: "apache",
+			want:   &config.VHost{Domain: "old.test.com", Type: "redirect", RedirectURL: "https://test.com", RedirectCode: 302},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.Driver = "nginx"
+			copied := *stored
+			cfg.VHosts["test.com"] = &copied
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).Build()
+			defer func() { deps = oldDeps }()
+
+			for name, value := range tt.flags {
+				if err := renderCmd.Flags().Set(name, value); err != nil {
+					t.Fatalf("failed to set flag %s: %v", name, err)
+				}
+			}
+			defer resetRenderFlags()
+
+			want, err := template.Render(tt.driver, tt.want)
+			if err != nil {
+				t.Fatalf("failed to render: %v", err)
+			}
+
+			var runErr error
+			out := captureStdout(func() { runErr = runRender(renderCmd, []string{tt.domain}) })
+			if runErr != nil {
+				t.Fatalf("unexpected error: %v", runErr)
+			}
+			if out != want {
+				t.Errorf("output differs from template.Render:\ngot:\n%s\nwant:\n%s", out, want)
+			}
+
+			// Rendering never persists anything
+			saved, _ := deps.ConfigLoader.Load()
+			if len(saved.VHosts) != 1 || saved.VHosts["test.com"].Type != "static" {
+				t.Errorf("config was modified: %+v", saved.VHosts)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Driver = "nginx"
+		cfg.VHosts["test.com"] = stored
+
+		oldDeps, oldJSON := deps, jsonOutput
+		deps = NewMockDeps().WithConfig(cfg).Build()
+		jsonOutput = true
+		defer func() { deps, jsonOutput = oldDeps, oldJSON }()
+
+		var runErr error
+		out := captureStdout(func() { runErr = runRender(renderCmd, []string{"test.com"}) })
+		if runErr != nil {
+			t.Fatalf("unexpected error: %v", runErr)
+		}
+		var result renderResult
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("invalid JSON %q: %v", out, err)
+		}
+		want, _ := template.Render("nginx", stored)
+		if result.Driver != "nginx" || result.Config != want {
+			t.Errorf("unexpected result %+v", result)
+		}
+	})
+
+	t.Run("ssl=false drops the stored TLS settings", func(t *testing.T) {
+		secure := *stored
+		secure.SSL, secure.SSLCert, secure.SSLKey = true, "/etc/ssl/test.pem", "/etc/ssl/test.key"
+		secure.HTTP2, secure.HTTP3 = true, true
+		cfg := config.New()
+		cfg.Driver = "nginx"
+		cfg.VHosts["test.com"] = &secure
+
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).Build()
+		defer func() { deps = oldDeps }()
+
+		if err := renderCmd.Flags().Set("ssl", "false"); err != nil {
+			t.Fatal(err)
+		}
+		defer resetRenderFlags()
+
+		out := captureStdout(func() { _ = runRender(renderCmd, []string{"test.com"}) })
+		want, _ := template.Render("nginx", stored)
+		if out != want {
+			t.Errorf("expected the plain HTTP rendering, got:\n%s", out)
+		}
+		if strings.Contains(out, "ssl_certificate") || strings.Contains(out, "quic") {
+			t.Errorf("TLS settings leaked into the HTTP rendering:\n%s", out)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			domain      string
+			flags       map[string]string
+			errContains string
+		}{
+			{"unknown vhost", "missing.test.com", nil, "vhost missing.test.com not found"},
+			{"missing root", "new.test.com", map[string]string{"type": "static"}, "--root is required"},
+			{"invalid type", "new.test.com", map[string]string{"type": "ftp"}, "invalid type"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				oldDeps := deps
+				deps = NewMockDeps().WithConfig(config.New()).Build()
+				defer func() { deps = oldDeps }()
+
+				for name, value := range tt.flags {
+					if err := renderCmd.Flags().Set(name, value); err != nil {
+						t.Fatalf("failed to set flag %s: %v", name, err)
+					}
+				}
+				defer resetRenderFlags()
+
+				err := runRender(renderCmd, []string{tt.domain})
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error containing %q, got %v", tt.errContains, err)
+				}
+			})
+		}
+	})
+}
+
+func resetRenderFlags() {
+	for _, name := range []string{"driver", "type", "template", "root", "proxy", "redirect", "php"} {
+		flag := renderCmd.Flags().Lookup(name)
+		_ = flag.Value.Set("")
+		flag.Changed = false
+	}
+	for name, value := range map[string]string{"redirect-code": "301", "spa": "false", "ssl": "false"} {
+		flag := renderCmd.Flags().Lookup(name)
+		_ = flag.Value.Set(value)
+		flag.Changed = false
+	}
+}
@@ -1,9 +1,17 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	vherrors "github.com/ksyq12/vhost/internal/errors"
 	"github.com/ksyq12/vhost/internal/logger"
+	"github.com/ksyq12/vhost/internal/output"
 	"github.com/spf13/cobra"
 )
 
@@ -11,6 +19,13 @@ var (
 	jsonOutput bool
 	verbose    bool
 	dryRun     bool
+	assumeYes  bool
+	noColor    bool
+	colorMode  string
+	// commandTimeout is the --timeout deadline; zero means none
+	commandTimeout time.Duration
+	// profileName selects a config profile's driver and paths
+	profileName string
 )
 
 // rootCmd represents the base command
@@ -21,22 +36,186 @@ var rootCmd = &cobra.Command{
 
 It provides commands to add, remove, enable, disable, and list virtual hosts,
 as well as SSL certificate management through Let's Encrypt.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// verbose enables the Debug and Info logs on stderr
+		logger.Init(verbose)
+
+		if commandTimeout < 0 {
+			return fmt.Errorf("--timeout must not be negative (got %s)", commandTimeout)
+		}
+		return setupOutput()
+	},
+}
+
+// setupOutput applies the color flags and, with --json, moves progress
+// messages to stderr so stdout holds only the JSON result
+func setupOutput() error {
+	if jsonOutput {
+		output.SetMessageOutput(os.Stderr)
+	} else {
+		output.SetMessageOutput(nil)
+	}
+	// --no-color wins over --color; NO_COLOR is honored in auto mode
+	if noColor {
+		return output.SetColorMode(output.ColorNever)
+	}
+	return output.SetColorMode(colorMode)
 }
 
 // Execute runs the root command
 func Execute() {
-	// Initialize logger based on verbose flag (parsed by cobra)
-	cobra.OnInitialize(func() {
-		logger.Init(verbose)
-	})
-
+	applyTimeout(rootCmd)
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		printJSONError(err)
+		code := 1
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			code = exitErr.code
+		}
+		os.Exit(code)
+	}
+}
+
+// exitCodeError is an error that exits with a specific status instead of 1
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+
+func (e *exitCodeError) Unwrap() error { return e.err }
+
+// reportedError is a failure the command already printed as its JSON
+// result, so Execute adds no error envelope
+type reportedError struct {
+	err error
+}
+
+func (e *reportedError) Error() string { return e.err.Error() }
+
+func (e *reportedError) Unwrap() error { return e.err }
+
+// reported marks err as printed when JSON output is on. Commands whose JSON
+// result already says what failed return their error through it.
+func reported(err error) error {
+	if err == nil || !jsonOutput {
+		return err
+	}
+	return &reportedError{err: err}
+}
+
+// jsonError is the envelope a failed command prints with --json
+type jsonError struct {
+	Success bool               `json:"success"`
+	Error   string             `json:"error"`
+	Code    vherrors.ErrorCode `json:"code"`
+}
+
+// printJSONError prints err as a JSON object on stdout when JSON output is
+// on, so consumers get a parseable failure instead of only the stderr text
+func printJSONError(err error) {
+	var reportedErr *reportedError
+	if !jsonOutput || errors.As(err, &reportedErr) {
+		return
+	}
+	_ = output.JSON(jsonError{Error: err.Error(), Code: vherrors.CodeOf(err)})
+}
+
+// annotationInterruptible marks a command that checks its context between
+// steps and rolls back when it is cancelled. Only these commands trap the
+// first SIGINT or SIGTERM; the rest keep the default and stop at once.
+const annotationInterruptible = "interruptible"
+
+// interruptible marks cmd as honouring cancellation; see
+// annotationInterruptible
+func interruptible(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[annotationInterruptible] = "true"
+}
+
+// interruptContext returns a context cancelled by the first SIGINT or
+// SIGTERM, so commands can stop between steps and roll back. Later signals
+// get the default behavior and terminate the process. stop removes the
+// handler.
+func interruptContext(parent context.Context) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigs:
+			signal.Stop(sigs)
+			output.Warn("Interrupted; cleaning up (press Ctrl-C again to quit immediately)")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigs)
+		cancel()
+	}
+}
+
+// applyTimeout wraps the RunE of cmd and all its subcommands with
+// withTimeout
+func applyTimeout(cmd *cobra.Command) {
+	if cmd.RunE != nil {
+		cmd.RunE = withTimeout(cmd.RunE)
+	}
+	for _, sub := range cmd.Commands() {
+		applyTimeout(sub)
+	}
+}
+
+// withTimeout runs run under the --timeout deadline. The deadline is set on
+// the command's context, so steps that check it stop early and the server
+// and certbot commands in flight are killed. The command is always waited
+// for, so it can roll back before the process exits. Interruptible
+// commands also get a context cancelled by Ctrl-C.
+func withTimeout(run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := commandContext(cmd)
+		if cmd.Annotations[annotationInterruptible] != "" {
+			var stop func()
+			ctx, stop = interruptContext(ctx)
+			defer stop()
+		}
+		setRunContext(ctx)
+		defer setRunContext(context.Background())
+		cmd.SetContext(ctx)
+
+		if commandTimeout <= 0 {
+			return run(cmd, args)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+		defer cancel()
+		setRunContext(ctx)
+		cmd.SetContext(ctx)
+
+		stopWarning := context.AfterFunc(ctx, func() {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				output.Warn("Timed out after %s; cleaning up", commandTimeout)
+			}
+		})
+		defer stopWarning()
+
+		err := run(cmd, args)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%s timed out after %s: %w", cmd.CommandPath(), commandTimeout, err)
+		}
+		return err
 	}
 }
 
 // SetVersion sets the version string for the CLI
 func SetVersion(v string) {
+	appVersion = v
 	rootCmd.Version = v
 }
 
@@ -44,4 +223,10 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging for debugging")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Show what would be done without making changes")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Answer yes to all confirmation prompts")
+	rootCmd.PersistentFlags().BoolVar(&assumeYes, "assume-yes", false, "Alias for --yes")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (same as --color=never)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Use the driver and paths of this config profile")
+	rootCmd.PersistentFlags().DurationVar(&commandTimeout, "timeout", 0, "Fail the command if it runs longer than this (e.g. 30s, 5m)")
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", output.ColorAuto, "Colored output: auto, always or never")
 }
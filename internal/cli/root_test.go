@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	vherrors "github.com/ksyq12/vhost/internal/errors"
+	"github.com/ksyq12/vhost/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+func TestWithTimeout(t *testing.T) {
+	oldTimeout := commandTimeout
+	defer func() { commandTimeout = oldTimeout }()
+
+	t.Run("blocking reload times out and rolls back", func(t *testing.T) {
+		cfg := config.New()
+		cfg.VHosts["test.com"] = &config.VHost{Domain: "test.com", Type: "static"}
+
+		// Reload blocks like a hung systemctl until its command is killed
+		mockDrv := driver.NewMockDriver("nginx", "/tmp/available", "/tmp/enabled")
+		mockDrv.ReloadFunc = func() error {
+			<-runCtx.Done()
+			return errors.New("signal: killed")
+		}
+
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+		defer func() { deps = oldDeps }()
+		noReload = false
+
+		commandTimeout = 50 * time.Millisecond
+		cmd := &cobra.Command{Use: "enable"}
+		start := time.Now()
+		err := withTimeout(runEnable)(cmd, []string{"test.com"})
+
+		if err == nil || !strings.Contains(err.Error(), "enable timed out after 50ms") {
+			t.Fatalf("expected timeout error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("timeout took %s, want it to return promptly", elapsed)
+		}
+		if len(mockDrv.DisableCalls) != 1 {
+			t.Errorf("expected the enable to be rolled back, got Disable calls %v", mockDrv.DisableCalls)
+		}
+		if cfg.VHosts["test.com"].Enabled {
+			t.Error("timed out enable should not be recorded in config")
+		}
+	})
+
+	t.Run("finishes before deadline", func(t *testing.T) {
+		commandTimeout = time.Second
+		want := errors.New("command failed")
+		run := withTimeout(func(cmd *cobra.Command, args []string) error {
+			if _, ok := cmd.Context().Deadline(); !ok {
+				t.Error("expected the command context to carry the deadline")
+			}
+			return want
+		})
+		if err := run(&cobra.Command{Use: "list"}, nil); !errors.Is(err, want) {
+			t.Errorf("expected the command's own error, got %v", err)
+		}
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		commandTimeout = 0
+		called := false
+		run := withTimeout(func(cmd *cobra.Command, args []string) error {
+			called = true
+			return nil
+		})
+		if err := run(&cobra.Command{Use: "list"}, nil); err != nil || !called {
+			t.Errorf("expected a direct call, got called=%v err=%v", called, err)
+		}
+	})
+}
+
+func TestInterruptibleCommands(t *testing.T) {
+	for _, cmd := range []*cobra.Command{addCmd, removeCmd, enableCmd, disableCmd, applyCmd, sslInstallCmd} {
+		if cmd.Annotations[annotationInterruptible] == "" {
+			t.Errorf("expected %s to trap Ctrl-C and roll back", cmd.CommandPath())
+		}
+	}
+	// Commands that never check their context keep the default signal handling
+	for _, cmd := range []*cobra.Command{listCmd, showCmd} {
+		if cmd.Annotations[annotationInterruptible] != "" {
+			t.Errorf("expected %s to keep the default Ctrl-C behavior", cmd.CommandPath())
+		}
+	}
+}
+
+func TestVerboseLogging(t *testing.T) {
+	oldVerbose := verbose
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	defer func() {
+		verbose = oldVerbose
+		logger.Init(false)
+		logger.SetOutput(os.Stderr)
+	}()
+
+	run := func(t *testing.T, v bool) string {
+		t.Helper()
+		buf.Reset()
+		cfg := config.New()
+		cfg.VHosts["test.com"] = &config.VHost{Domain: "test.com", Type: "static"}
+		mockDrv := driver.NewMockDriver("nginx", "/tmp/available", "/tmp/enabled")
+
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+		defer func() { deps = oldDeps }()
+
+		verbose = v
+		cmd := &cobra.Command{Use: "enable"}
+		if err := rootCmd.PersistentPreRunE(cmd, nil); err != nil {
+			t.Fatalf("PersistentPreRunE failed: %v", err)
+		}
+		captureStdout(func() {
+			if err := runEnable(cmd, []string{"test.com"}); err != nil {
+				t.Errorf("enable failed: %v", err)
+			}
+		})
+		return buf.String()
+	}
+
+	t.Run("verbose", func(t *testing.T) {
+		out := run(t, true)
+		for _, want := range []string{"[DEBUG]", "Resolved driver", "Enabling test.com in /tmp/enabled", "nginx config test duration_ms="} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected debug output to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("quiet by default", func(t *testing.T) {
+		if out := run(t, false); out != "" {
+			t.Errorf("expected no log output without --verbose, got:\n%s", out)
+		}
+	})
+}
+
+func TestPrintJSONError(t *testing.T) {
+	oldJSON := jsonOutput
+	// cobra prints the error and usage to stderr as well
+	rootCmd.SetErr(io.Discard)
+	defer func() {
+		jsonOutput = oldJSON
+		rootCmd.SetArgs(nil)
+		rootCmd.SetErr(nil)
+	}()
+
+	cfg := config.New()
+	cfg.VHosts["example.com"] = &config.VHost{Domain: "example.com", Type: "static"}
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).Build()
+	defer func() { deps = oldDeps }()
+
+	// execute runs vhost with args the way Execute does and returns stdout
+	execute := func(args ...string) string {
+		jsonOutput = false
+		rootCmd.SetArgs(args)
+		return captureStdout(func() {
+			if err := rootCmd.Execute(); err != nil {
+				printJSONError(err)
+			}
+		})
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		code vherrors.ErrorCode
+	}{
+		{"invalid domain", []string{"--json", "show", "bad;domain"}, vherrors.ErrCodeValidation},
+		{"unknown vhost", []string{"--json", "show", "missing.com"}, vherrors.ErrCodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := execute(tt.args...)
+
+			var envelope map[string]interface{}
+			if err := json.Unmarshal([]byte(out), &envelope); err != nil {
+				t.Fatalf("expected a JSON error object, got %q: %v", out, err)
+			}
+			if envelope["success"] != false || envelope["code"] != string(tt.code) || envelope["error"] == "" {
+				t.Errorf("unexpected envelope %v", envelope)
+			}
+		})
+	}
+
+	t.Run("plain error", func(t *testing.T) {
+		jsonOutput = true
+		out := captureStdout(func() { printJSONError(fmt.Errorf("boom")) })
+		var envelope jsonError
+		if err := json.Unmarshal([]byte(out), &envelope); err != nil {
+			t.Fatalf("invalid JSON %q: %v", out, err)
+		}
+		if envelope != (jsonError{Error: "boom", Code: vherrors.ErrCodeInternal}) {
+			t.Errorf("unexpected envelope %+v", envelope)
+		}
+	})
+
+	t.Run("already reported", func(t *testing.T) {
+		jsonOutput = true
+		if out := captureStdout(func() { printJSONError(reported(fmt.Errorf("validation failed"))) }); out != "" {
+			t.Errorf("expected no envelope after the command's own JSON, got %q", out)
+		}
+	})
+
+	t.Run("text output", func(t *testing.T) {
+		if out := execute("show", "bad;domain"); out != "" {
+			t.Errorf("expected nothing on stdout without --json, got %q", out)
+		}
+	})
+}
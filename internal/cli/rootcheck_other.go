@@ -0,0 +1,12 @@
+//go:build !windows
+
+package cli
+
+import "os"
+
+func (r *realRootChecker) RequireRoot() error {
+	if os.Geteuid() != 0 {
+		return errRootRequired
+	}
+	return nil
+}
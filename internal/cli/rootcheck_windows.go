@@ -0,0 +1,19 @@
+//go:build windows
+
+package cli
+
+import (
+	vherrors "github.com/ksyq12/vhost/internal/errors"
+	"golang.org/x/sys/windows"
+)
+
+// errAdminRequired is errRootRequired for Windows, where os.Geteuid is
+// always -1 and elevation is what counts
+var errAdminRequired = vherrors.Newf(vherrors.ErrCodePermission, "this operation requires administrator privileges. Please run from an elevated prompt")
+
+func (r *realRootChecker) RequireRoot() error {
+	if !windows.GetCurrentProcessToken().IsElevated() {
+		return errAdminRequired
+	}
+	return nil
+}
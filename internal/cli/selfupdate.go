@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/spf13/cobra"
+)
+
+const (
+	releaseDownloadURL     = "https://github.com/ksyq12/vhost/releases/download"
+	releaseDownloadTimeout = 2 * time.Minute
+
+	// checksumsFile is the release asset listing the archives' SHA-256 sums
+	checksumsFile = "checksums.txt"
+)
+
+var selfUpdateForce bool
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Replace vhost with the latest release",
+	Long: `Download the latest vhost release for this OS and architecture from
+GitHub and replace the running binary with it.
+
+The release archive is verified against the release's checksums.txt before
+anything is written, and the binary is swapped in with a single rename, so
+an interrupted update leaves the old binary in place. The directory holding
+the binary must be writable; for /usr/local/bin that usually means sudo.
+
+Development builds are never considered out of date; pass --force to
+install the latest release over one.
+
+Examples:
+  sudo vhost self-update
+  vhost self-update --dry-run
+  vhost self-update --force`,
+	Args: cobra.NoArgs,
+	RunE: runSelfUpdate,
+}
+
+func init() {
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateForce, "force", false, "Install the latest release even if this version is not older")
+
+	rootCmd.AddCommand(selfUpdateCmd)
+}
+
+func runSelfUpdate(cmd *cobra.Command, args []string) error {
+	if updateCheckDisabled() {
+		return fmt.Errorf("release lookups are disabled by %s", noUpdateCheckEnv)
+	}
+
+	latest, err := deps.ReleaseChecker.LatestVersion()
+	if err != nil {
+		return fmt.Errorf("failed to check for updates: %w", err)
+	}
+
+	if !selfUpdateForce && !isNewerVersion(latest, appVersion) {
+		return outputResult(
+			map[string]interface{}{
+				"success": true,
+				"version": appVersion,
+				"latest":  latest,
+				"updated": false,
+			},
+			"vhost is up to date (latest: %s)", latest,
+		)
+	}
+
+	binPath, err := deps.BinaryInstaller.Executable()
+	if err != nil {
+		return err
+	}
+	if err := deps.BinaryInstaller.CheckWritable(binPath); err != nil {
+		return fmt.Errorf("cannot replace %s: %w (re-run with sudo)", binPath, err)
+	}
+
+	archiveName := releaseArchiveName(latest, runtime.GOOS, runtime.GOARCH)
+	baseURL := releaseDownloadURL + "/" + latest
+
+	if !jsonOutput {
+		output.Info("Downloading %s...", archiveName)
+	}
+	checksums, err := deps.Downloader.Download(baseURL + "/" + checksumsFile)
+	if err != nil {
+		return err
+	}
+	archive, err := deps.Downloader.Download(baseURL + "/" + archiveName)
+	if err != nil {
+		return err
+	}
+	if err := verifyChecksum(archiveName, archive, checksums); err != nil {
+		return err
+	}
+
+	binary, err := extractBinary(archive, "vhost")
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", archiveName, err)
+	}
+
+	// Dry-run mode: the download and checksum are real, the replace is not
+	if dryRun {
+		return outputDryRun(&DryRunResult{
+			Operations: []DryRunOperation{{
+				Action:  "update_file",
+				Target:  binPath,
+				Details: fmt.Sprintf("Replace vhost %s with %s (%s, checksum verified)", appVersion, latest, archiveName),
+			}},
+		})
+	}
+
+	if err := deps.BinaryInstaller.Replace(binPath, binary); err != nil {
+		return err
+	}
+
+	return outputResult(
+		map[string]interface{}{
+			"success":  true,
+			"version":  latest,
+			"previous": appVersion,
+			"path":     binPath,
+			"updated":  true,
+		},
+		"Updated vhost %s to %s", appVersion, latest,
+	)
+}
+
+// releaseArchiveName returns the archive name goreleaser publishes for a
+// release, e.g. vhost-1.4.0-linux-amd64.tar.gz for tag v1.4.0
+func releaseArchiveName(tag, goos, goarch string) string {
+	return fmt.Sprintf("vhost-%s-%s-%s.tar.gz", strings.TrimPrefix(tag, "v"), goos, goarch)
+}
+
+// verifyChecksum checks data against name's entry in a sha256sum-style
+// checksums file
+func verifyChecksum(name string, data, checksums []byte) error {
+	want := ""
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			want = strings.ToLower(fields[0])
+			break
+		}
+	}
+	if want == "" {
+		return fmt.Errorf("no checksum published for %s", name)
+	}
+
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+	return nil
+}
+
+// extractBinary returns the content of the file called name in a .tar.gz
+func extractBinary(archive []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = gz.Close() }()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s not found in archive", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == name {
+			return io.ReadAll(tr)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// releaseArchive builds a goreleaser-style .tar.gz holding a vhost binary
+func releaseArchive(t *testing.T, binary []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"README.md", []byte("# vhost\n")},
+		{"vhost", binary},
+	}
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0755, Size: int64(len(f.data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRunSelfUpdate(t *testing.T) {
+	binary := []byte("new vhost binary")
+	archive := releaseArchive(t, binary)
+	archiveName := releaseArchiveName("v1.4.0", runtime.GOOS, runtime.GOARCH)
+	sum := sha256.Sum256(archive)
+	baseURL := releaseDownloadURL + "/v1.4.0/"
+
+	validChecksums := []byte(hex.EncodeToString(sum[:]) + "  " + archiveName + "\n")
+	badChecksums := []byte(strings.Repeat("0", 64) + "  " + archiveName + "\n")
+
+	tests := []struct {
+		name        string
+		current     string
+		checksums   []byte
+		writableErr error
+		wantErr     string
+		wantReplace bool
+	}{
+		{
+			name:        "happy path",
+			current:     "v1.3.0",
+			checksums:   validChecksums,
+			wantReplace: true,
+		},
+		{
+			name:      "checksum mismatch aborts",
+			current:   "v1.3.0",
+			checksums: badChecksums,
+			wantErr:   "checksum mismatch for " + archiveName,
+		},
+		{
+			name:      "missing checksum aborts",
+			current:   "v1.3.0",
+			checksums: []byte("abc  other.tar.gz\n"),
+			wantErr:   "no checksum published",
+		},
+		{
+			name:        "binary not writable",
+			current:     "v1.3.0",
+			checksums:   validChecksums,
+			writableErr: os.ErrPermission,
+			wantErr:     "re-run with sudo",
+		},
+		{
+			name:      "already up to date",
+			current:   "v1.4.0",
+			checksums: validChecksums,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			downloader := &MockReleaseDownloader{Files: map[string][]byte{
+				baseURL + checksumsFile: tt.checksums,
+				baseURL + archiveName:   archive,
+			}}
+			installer := &MockBinaryInstaller{Path: "/usr/local/bin/vhost", WritableErr: tt.writableErr}
+
+			oldDeps, oldVersion := deps, appVersion
+			deps = NewMockDeps().
+				WithReleaseChecker(&MockReleaseChecker{Version: "v1.4.0"}).
+				WithDownloader(downloader).
+				WithBinaryInstaller(installer).
+				Build()
+			appVersion = tt.current
+			defer func() { deps, appVersion = oldDeps, oldVersion }()
+
+			err := runSelfUpdate(nil, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantReplace {
+				if !bytes.Equal(installer.Replaced, binary) {
+					t.Errorf("replaced with %q, want %q", installer.Replaced, binary)
+				}
+			} else if installer.Calls != 0 {
+				t.Errorf("expected the binary to be left alone, got %d Replace calls", installer.Calls)
+			}
+		})
+	}
+}
+
+func TestRealBinaryInstallerReplace(t *testing.T) {
+	path := t.TempDir() + "/vhost"
+	if err := os.WriteFile(path, []byte("old"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	installer := &realBinaryInstaller{}
+	if err := installer.CheckWritable(path); err != nil {
+		t.Fatalf("CheckWritable() error = %v", err)
+	}
+	if err := installer.Replace(path, []byte("new")); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Errorf("binary = %q (%v), want %q", data, err, "new")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("expected mode 0750 to be kept, got %v", info.Mode().Perm())
+	}
+}
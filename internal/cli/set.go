@@ -0,0 +1,406 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	vherrors "github.com/ksyq12/vhost/internal/errors"
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/ksyq12/vhost/internal/template"
+	"github.com/spf13/cobra"
+)
+
+var setCmd = &cobra.Command{
+	Use:   "set <domain>",
+	Short: "Modify a virtual host in place",
+	Long: `Modify the fields of an existing virtual host without remove + add.
+
+Only the flags given are changed; everything else is preserved. Changing
+--type, --template, --root, --php, --proxy, --redirect, --redirect-code,
+--spa, --wp-multisite or a --fastcgi-* option re-renders the server configuration, tests it and
+reloads the web server, restoring the previous configuration if the test
+fails.
+
+If the current file was edited by hand, the edits are shown and set asks
+before replacing them; --force (or --yes) replaces them without asking.
+
+--maintenance on swaps the configuration for a 503 maintenance page and
+keeps a copy of the current file; --maintenance off puts that copy back.
+
+--owner-email and --notes are stored in config.yaml only and never touch
+the server configuration. Pass an empty value to clear them.
+
+Examples:
+  vhost set example.com --php 8.3
+  vhost set example.com --spa
+  vhost set example.com --template spa
+  vhost set example.com --fastcgi-read-timeout 300s
+  vhost set example.com --type proxy --proxy http://localhost:3000
+  vhost set example.com --type redirect --redirect https://example.org
+  vhost set example.com --root /var/www/new
+  vhost set example.com --maintenance on
+  vhost set example.com --owner-email ops@example.com
+  vhost set example.com --notes ""`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runSet,
+}
+
+var (
+	setType       string
+	setTemplate   string
+	setRoot       string
+	setPHP        string
+	setProxy      string
+	setRedirect   string
+	setRedirCode  int
+	setSPA        bool
+	setOwnerEmail string
+	setNotes      string
+	setMaint      string
+	setMultisite  string
+	setFCGITime   string
+	setFCGIBufs   string
+	setFCGIBufSz  string
+	setForce      bool
+)
+
+func init() {
+	setCmd.Flags().StringVarP(&setType, "type", "t", "", "VHost type (static, php, proxy, laravel, wordpress, redirect)")
+	setCmd.Flags().StringVar(&setTemplate, "template", "", "Template variant, e.g. spa (empty restores the base template)")
+	setCmd.Flags().StringVarP(&setRoot, "root", "r", "", "Document root path")
+	setCmd.Flags().StringVar(&setPHP, "php", "", "PHP version (e.g., 8.2)")
+	setCmd.Flags().StringVarP(&setProxy, "proxy", "p", "", "Proxy pass URL (for proxy type)")
+	setCmd.Flags().StringVar(&setRedirect, "redirect", "", "URL every request is redirected to (for redirect type)")
+	setCmd.Flags().IntVar(&setRedirCode, "redirect-code", template.DefaultRedirectCode, "Redirect status: 301 (permanent) or 302 (temporary)")
+	setCmd.Flags().BoolVar(&setSPA, "spa", false, "Serve /index.html for unknown paths (--spa=false turns it off; static type)")
+	setCmd.Flags().StringVar(&setMultisite, "wp-multisite", "", "WordPress multisite rewrites: subdir, subdomain, or empty for a single site")
+	setCmd.Flags().StringVar(&setFCGITime, "fastcgi-read-timeout", "", "FastCGI read timeout as a duration, e.g. 300s (empty restores the default)")
+	setCmd.Flags().StringVar(&setFCGIBufs, "fastcgi-buffers", "", "nginx fastcgi_buffers as \"<count> <size>\" (empty restores the default)")
+	setCmd.Flags().StringVar(&setFCGIBufSz, "fastcgi-buffer-size", "", "nginx fastcgi_buffer_size, e.g. 32k (empty restores the default)")
+	setCmd.Flags().StringVar(&setMaint, "maintenance", "", "Serve a 503 maintenance page (on) or restore the site (off)")
+	setCmd.Flags().StringVar(&setOwnerEmail, "owner-email", "", "Contact for the site owner")
+	setCmd.Flags().StringVar(&setNotes, "notes", "", "Free-form notes about the site")
+	setCmd.Flags().BoolVar(&setForce, "force", false, "Overwrite manual edits to the config file without asking")
+	setCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server")
+
+	rootCmd.AddCommand(setCmd)
+}
+
+func runSet(cmd *cobra.Command, args []string) error {
+	domain := args[0]
+
+	// Validate domain
+	if err := validateDomain(domain); err != nil {
+		return err
+	}
+
+	flags := cmd.Flags()
+	fieldsChanged := flags.Changed("type") || flags.Changed("template") || flags.Changed("root") || flags.Changed("php") || flags.Changed("proxy") || flags.Changed("redirect") || flags.Changed("redirect-code") || flags.Changed("spa") || flags.Changed("wp-multisite") ||
+		flags.Changed("fastcgi-read-timeout") || flags.Changed("fastcgi-buffers") || flags.Changed("fastcgi-buffer-size")
+	maintChanged := flags.Changed("maintenance")
+	serverChanged := fieldsChanged || maintChanged
+	if !serverChanged && !flags.Changed("owner-email") && !flags.Changed("notes") {
+		return fmt.Errorf("nothing to set: use --type, --template, --root, --php, --proxy, --redirect, --redirect-code, --spa, --wp-multisite, --fastcgi-*, --maintenance, --owner-email or --notes")
+	}
+
+	if maintChanged && setMaint != "on" && setMaint != "off" {
+		return fmt.Errorf("invalid --maintenance value: %s (use on or off)", setMaint)
+	}
+
+	if flags.Changed("type") && !config.IsValidType(setType) {
+		return fmt.Errorf("invalid type: %s. Valid types: %s", setType, strings.Join(config.ValidTypes(), ", "))
+	}
+	if err := template.ValidateVariant(setTemplate); err != nil {
+		return err
+	}
+	if err := template.ValidateRedirectCode(setRedirCode); err != nil {
+		return err
+	}
+
+	// Metadata-only changes don't need the web server at all
+	var (
+		cfg *config.Config
+		drv driver.Driver
+		err error
+	)
+	if serverChanged {
+		cfg, drv, err = loadConfigAndDriver()
+		if err != nil {
+			return err
+		}
+	} else {
+		cfg, err = deps.ConfigLoader.Load()
+		if err != nil {
+			return vherrors.Wrap(vherrors.ErrCodeConfig, "failed to load config", err)
+		}
+	}
+
+	vhost, exists := cfg.FindByDomainOrAlias(domain)
+	if !exists {
+		return vherrors.Newf(vherrors.ErrCodeNotFound, "vhost %s not found", domain)
+	}
+	domain = vhost.Domain
+	previous := *vhost
+
+	// Apply only the flags that were given
+	if flags.Changed("type") {
+		vhost.Type = setType
+	}
+	if flags.Changed("template") {
+		vhost.Template = setTemplate
+	}
+	if flags.Changed("root") {
+		vhost.Root = setRoot
+	}
+	if flags.Changed("php") {
+		vhost.PHPVersion = setPHP
+	}
+	if flags.Changed("proxy") {
+		vhost.ProxyPass = setProxy
+	}
+	if flags.Changed("redirect") {
+		vhost.RedirectURL = setRedirect
+	}
+	if flags.Changed("redirect-code") {
+		vhost.RedirectCode = setRedirCode
+	}
+	if flags.Changed("spa") {
+		vhost.SPA = setSPA
+	}
+	if flags.Changed("wp-multisite") {
+		vhost.WPMultisite = setMultisite
+	}
+	if flags.Changed("fastcgi-read-timeout") {
+		vhost.FastCGIReadTimeout = setFCGITime
+	}
+	if flags.Changed("fastcgi-buffers") {
+		vhost.FastCGIBuffers = setFCGIBufs
+	}
+	if flags.Changed("fastcgi-buffer-size") {
+		vhost.FastCGIBufferSize = setFCGIBufSz
+	}
+	if maintChanged {
+		vhost.Maintenance = setMaint == "on"
+	}
+	if flags.Changed("owner-email") {
+		vhost.Owner = setOwnerEmail
+	}
+	if flags.Changed("notes") {
+		vhost.Notes = setNotes
+	}
+
+	if serverChanged {
+		if err := validateTypeOptions(vhost.Type, vhost.Root, vhost.ProxyPass, vhost.RedirectURL); err != nil {
+			return err
+		}
+		if err := validateSPA(vhost.Type, vhost.SPA); err != nil {
+			return err
+		}
+		if err := validateWPMultisite(vhost.Type, vhost.WPMultisite); err != nil {
+			return err
+		}
+		if err := validateFastCGIOptions(vhost.Type, vhost.FastCGIReadTimeout, vhost.FastCGIBuffers, vhost.FastCGIBufferSize); err != nil {
+			return err
+		}
+		if vhost.PHPVersion == "" && (vhost.Type == config.TypePHP || vhost.Type == config.TypeLaravel || vhost.Type == config.TypeWordPress) {
+			vhost.PHPVersion = cfg.DefaultPHP
+		}
+
+		configContent, err := template.Render(drv.Name(), vhost)
+		if err != nil {
+			return fmt.Errorf("failed to render template: %w", err)
+		}
+
+		// Dry-run mode: show what would be done without making changes
+		if dryRun {
+			return outputSetDryRun(domain, drv)
+		}
+
+		if err := requireRoot(); err != nil {
+			return err
+		}
+
+		backupPath, err := maintenanceBackupPath(drv.Name(), domain)
+		if err != nil {
+			return err
+		}
+		enteringMaint := vhost.Maintenance && !previous.Maintenance
+		leavingMaint := !vhost.Maintenance && previous.Maintenance
+
+		// Maintenance keeps the live file and restores it verbatim; every
+		// other change replaces it with a fresh rendering
+		restoring := leavingMaint && !fieldsChanged
+		if !enteringMaint && !restoring {
+			if err := confirmOverwriteEdits(drv, &previous, setForce); err != nil {
+				return err
+			}
+		}
+
+		// Keep the live file so leaving maintenance restores it verbatim,
+		// including manual edits the templates don't know about
+		if enteringMaint {
+			if err := backupForMaintenance(drv, domain, backupPath); err != nil {
+				return err
+			}
+		}
+		if restoring {
+			if saved, err := os.ReadFile(backupPath); err == nil {
+				configContent = string(saved)
+			}
+		}
+
+		if err := updateVHostConfig(drv, &previous, vhost, configContent); err != nil {
+			if enteringMaint {
+				_ = os.Remove(backupPath)
+			}
+			return err
+		}
+		// A restored copy may carry manual edits; without a hash the next
+		// change compares against a fresh rendering instead
+		if restoring {
+			vhost.ConfigHash = ""
+		} else {
+			recordConfigHash(vhost, configContent)
+		}
+
+		// The copy is stale once restored or once the site's fields change
+		if !enteringMaint && (leavingMaint || fieldsChanged) {
+			_ = os.Remove(backupPath)
+		}
+	}
+
+	// Dry-run mode: a metadata-only change would only save config.yaml
+	if dryRun {
+		return outputSetDryRun(domain, nil)
+	}
+
+	_ = cfg.TouchVHost(domain)
+	if err := saveConfig(cfg); err != nil {
+		if serverChanged {
+			output.Warn("VHost updated but config save failed: %v", err)
+		} else {
+			return err
+		}
+	}
+
+	return outputResult(
+		map[string]interface{}{
+			"success":     true,
+			"domain":      domain,
+			"type":        vhost.Type,
+			"root":        vhost.Root,
+			"php_version": vhost.PHPVersion,
+			"proxy_pass":  vhost.ProxyPass,
+			"maintenance": vhost.Maintenance,
+			"owner":       vhost.Owner,
+			"notes":       vhost.Notes,
+		},
+		"VHost %s updated", domain,
+	)
+}
+
+// updateVHostConfig replaces the server config of an existing vhost, then
+// tests and reloads. The previous file is restored if any step fails.
+func updateVHostConfig(drv driver.Driver, previous, vhost *config.VHost, configContent string) error {
+	domain := vhost.Domain
+
+	// Back up the current file; fall back to re-rendering it from config
+	configPath := filepath.Join(drv.Paths().Available, driverConfigFileName(drv.Name(), domain))
+	backup, err := os.ReadFile(configPath)
+	if err != nil {
+		rendered, renderErr := template.Render(drv.Name(), previous)
+		if renderErr != nil {
+			return fmt.Errorf("failed to back up current config: %w", err)
+		}
+		backup = []byte(rendered)
+	}
+
+	wasEnabled, _ := drv.IsEnabled(domain)
+
+	rollback := func() error {
+		output.Info("Rolling back changes...")
+		return replaceVHostConfig(drv, previous, string(backup), wasEnabled)
+	}
+
+	output.Info("Updating vhost configuration...")
+	if err := replaceVHostConfig(drv, vhost, configContent, wasEnabled); err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			output.Warn("Rollback failed: %v", rbErr)
+		}
+		return err
+	}
+
+	return testAndReload(drv, !noReload, rollback)
+}
+
+// replaceVHostConfig swaps the driver's config for domain with configContent,
+// disabling it first and re-enabling it afterwards when enable is set
+func replaceVHostConfig(drv driver.Driver, vhost *config.VHost, configContent string, enable bool) error {
+	if enabled, _ := drv.IsEnabled(vhost.Domain); enabled {
+		if err := drv.Disable(vhost.Domain); err != nil {
+			output.Warn("Failed to disable before update: %v", err)
+		}
+	}
+
+	if err := drv.Remove(vhost.Domain); err != nil {
+		// Not fatal - file might not exist
+		output.Warn("Could not remove old config: %v", err)
+	}
+
+	if err := drv.Add(vhost, configContent); err != nil {
+		return fmt.Errorf("failed to update vhost config: %w", err)
+	}
+
+	if enable {
+		if err := drv.Enable(vhost.Domain); err != nil {
+			return fmt.Errorf("failed to enable vhost: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// outputSetDryRun outputs what set command would do in dry-run mode. drv is
+// nil for a metadata-only change, which leaves the server configuration alone.
+func outputSetDryRun(domain string, drv driver.Driver) error {
+	var operations []DryRunOperation
+	if drv != nil {
+		configPath := filepath.Join(drv.Paths().Available, driverConfigFileName(drv.Name(), domain))
+		operations = append(operations,
+			DryRunOperation{
+				Action:  "update_file",
+				Target:  configPath,
+				Details: fmt.Sprintf("Re-render VHost configuration for %s", domain),
+			},
+			DryRunOperation{
+				Action:  "test_config",
+				Target:  drv.Name(),
+				Details: "Validate configuration syntax",
+			},
+		)
+		if !noReload {
+			operations = append(operations, DryRunOperation{
+				Action:  "reload_server",
+				Target:  drv.Name(),
+				Details: "Apply configuration changes",
+			})
+		}
+	}
+
+	cfgPath, _ := deps.ConfigLoader.Path()
+	operations = append(operations, DryRunOperation{
+		Action:  "update_config",
+		Target:  cfgPath,
+		Details: fmt.Sprintf("Save the new fields of %s", domain),
+	})
+
+	return outputDryRun(&DryRunResult{
+		Domain:     domain,
+		Operations: operations,
+	})
+}
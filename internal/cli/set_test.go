@@ -0,0 +1,472 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/template"
+)
+
+func TestRunSet(t *testing.T) {
+	earlier := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		domain      string
+		flags       map[string]string
+		wantErr     bool
+		errContains string
+		wantOwner   string
+		wantNotes   string
+	}{
+		{
+			name:      "set owner and notes",
+			domain:    "test.com",
+			flags:     map[string]string{"owner-email": "new@example.com", "notes": "migrated"},
+			wantOwner: "new@example.com",
+			wantNotes: "migrated",
+		},
+		{
+			name:      "set owner keeps notes",
+			domain:    "test.com",
+			flags:     map[string]string{"owner-email": "new@example.com"},
+			wantOwner: "new@example.com",
+			wantNotes: "old notes",
+		},
+		{
+			name:      "empty value clears field",
+			domain:    "test.com",
+			flags:     map[string]string{"notes": ""},
+			wantOwner: "old@example.com",
+			wantNotes: "",
+		},
+		{
+			name:      "mixed case domain",
+			domain:    "Test.COM",
+			flags:     map[string]string{"notes": "upper"},
+			wantOwner: "old@example.com",
+			wantNotes: "upper",
+		},
+		{
+			name:      "alias resolves to its vhost",
+			domain:    "www.test.com",
+			flags:     map[string]string{"notes": "via alias"},
+			wantOwner: "old@example.com",
+			wantNotes: "via alias",
+		},
+		{
+			name:        "no flags fails",
+			domain:      "test.com",
+			flags:       map[string]string{},
+			wantErr:     true,
+			errContains: "nothing to set",
+		},
+		{
+			name:        "unknown vhost fails",
+			domain:      "missing.com",
+			flags:       map[string]string{"notes": "x"},
+			wantErr:     true,
+			errContains: "not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.VHosts["test.com"] = &config.VHost{
+				Domain:    "test.com",
+				Type:      "static",
+				Aliases:   []string{"www.test.com"},
+				Owner:     "old@example.com",
+				Notes:     "old notes",
+				UpdatedAt: earlier,
+			}
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).Build()
+			defer func() { deps = oldDeps }()
+
+			for name, value := range tt.flags {
+				if err := setCmd.Flags().Set(name, value); err != nil {
+					t.Fatalf("failed to set flag %s: %v", name, err)
+				}
+			}
+			defer func() {
+				resetSetFlags()
+			}()
+
+			err := runSet(setCmd, []string{tt.domain})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error %q does not contain %q", err.Error(), tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			saved, _ := deps.ConfigLoader.Load()
+			vhost := saved.VHosts["test.com"]
+			if vhost.Owner != tt.wantOwner {
+				t.Errorf("expected owner %q, got %q", tt.wantOwner, vhost.Owner)
+			}
+			if vhost.Notes != tt.wantNotes {
+				t.Errorf("expected notes %q, got %q", tt.wantNotes, vhost.Notes)
+			}
+			if !vhost.UpdatedAt.After(earlier) {
+				t.Errorf("expected UpdatedAt to move past %v, got %v", earlier, vhost.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestRunSetMetadataDryRun(t *testing.T) {
+	updated := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.New()
+	cfg.VHosts["test.com"] = &config.VHost{Domain: "test.com", Type: "static", Owner: "old@example.com", UpdatedAt: updated}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithConfigDir(t.TempDir()).Build()
+	defer func() { deps = oldDeps }()
+
+	dryRun = true
+	defer func() { dryRun = false }()
+	if err := setCmd.Flags().Set("owner-email", "new@example.com"); err != nil {
+		t.Fatalf("failed to set flag: %v", err)
+	}
+	defer resetSetFlags()
+
+	var err error
+	out := captureOutput(func() {
+		err = runSet(setCmd, []string{"test.com"})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "update_config") {
+		t.Errorf("expected update_config in dry-run plan, got %q", out)
+	}
+
+	loader := deps.ConfigLoader.(*MockConfigLoader)
+	if loader.SaveCalls != 0 {
+		t.Errorf("expected config.yaml left alone, got %d save(s)", loader.SaveCalls)
+	}
+	if got := loader.Cfg.VHosts["test.com"].UpdatedAt; !got.Equal(updated) {
+		t.Errorf("expected UpdatedAt to stay %v, got %v", updated, got)
+	}
+}
+
+func TestRunSetServerFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		flags       map[string]string
+		testErr     error
+		wantErr     bool
+		errContains string
+		validate    func(*testing.T, *config.VHost, *driver.MockDriver)
+	}{
+		{
+			name:  "change php version re-renders config",
+			flags: map[string]string{"php": "8.3"},
+			validate: func(t *testing.T, vhost *config.VHost, mockDrv *driver.MockDriver) {
+				if vhost.PHPVersion != "8.3" {
+					t.Errorf("expected PHP 8.3 in config, got %s", vhost.PHPVersion)
+				}
+				if vhost.Root != "/var/www/test" {
+					t.Errorf("root should be preserved, got %s", vhost.Root)
+				}
+				if len(mockDrv.AddCalls) != 1 {
+					t.Fatalf("expected 1 Add call, got %d", len(mockDrv.AddCalls))
+				}
+				if !strings.Contains(mockDrv.AddCalls[0].Content, "php8.3-fpm.sock") {
+					t.Errorf("rendered config should use PHP 8.3:\n%s", mockDrv.AddCalls[0].Content)
+				}
+				if len(mockDrv.DisableCalls) != 1 || len(mockDrv.RemoveCalls) != 1 || len(mockDrv.EnableCalls) != 1 {
+					t.Errorf("expected disable, remove and enable once, got %d/%d/%d",
+						len(mockDrv.DisableCalls), len(mockDrv.RemoveCalls), len(mockDrv.EnableCalls))
+				}
+				if mockDrv.ReloadCalls != 1 {
+					t.Errorf("expected 1 Reload call, got %d", mockDrv.ReloadCalls)
+				}
+			},
+		},
+		{
+			name:  "switch to proxy type",
+			flags: map[string]string{"type": "proxy", "proxy": "http://localhost:3000"},
+			validate: func(t *testing.T, vhost *config.VHost, mockDrv *driver.MockDriver) {
+				if vhost.Type != "proxy" || vhost.ProxyPass != "http://localhost:3000" {
+					t.Errorf("expected proxy type with target, got %s %s", vhost.Type, vhost.ProxyPass)
+				}
+				if len(mockDrv.AddCalls) != 1 || !strings.Contains(mockDrv.AddCalls[0].Content, "proxy_pass") {
+					t.Error("expected proxy config to be rendered")
+				}
+			},
+		},
+		{
+			name:        "proxy type requires proxy url",
+			flags:       map[string]string{"type": "proxy"},
+			wantErr:     true,
+			errContains: "--proxy is required",
+			validate: func(t *testing.T, vhost *config.VHost, mockDrv *driver.MockDriver) {
+				if len(mockDrv.AddCalls) != 0 {
+					t.Errorf("expected no Add calls, got %d", len(mockDrv.AddCalls))
+				}
+			},
+		},
+		{
+			name:        "invalid type fails",
+			flags:       map[string]string{"type": "cgi"},
+			wantErr:     true,
+			errContains: "invalid type",
+		},
+		{
+			name:        "test failure restores previous config",
+			flags:       map[string]string{"php": "8.3"},
+			testErr:     errors.New("syntax error"),
+			wantErr:     true,
+			errContains: "configuration test failed",
+			validate: func(t *testing.T, vhost *config.VHost, mockDrv *driver.MockDriver) {
+				if len(mockDrv.AddCalls) != 2 {
+					t.Fatalf("expected 2 Add calls (update + rollback), got %d", len(mockDrv.AddCalls))
+				}
+				if mockDrv.AddCalls[1].Content != "original config" {
+					t.Errorf("rollback should restore the backed up file, got %q", mockDrv.AddCalls[1].Content)
+				}
+				if mockDrv.ReloadCalls != 0 {
+					t.Errorf("expected no reload after failed test, got %d", mockDrv.ReloadCalls)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			availableDir := filepath.Join(tempDir, "sites-available")
+			if err := os.MkdirAll(availableDir, 0755); err != nil {
+				t.Fatalf("failed to create available dir: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(availableDir, "test.com"), []byte("original config"), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			mockDrv := driver.NewMockDriver("nginx", availableDir, filepath.Join(tempDir, "sites-enabled"))
+			enabled := true
+			mockDrv.IsEnabledFunc = func(domain string) (bool, error) { return enabled, nil }
+			mockDrv.DisableFunc = func(domain string) error { enabled = false; return nil }
+			mockDrv.EnableFunc = func(domain string) error { enabled = true; return nil }
+			if tt.testErr != nil {
+				mockDrv.TestFunc = func() error { return tt.testErr }
+			}
+
+			cfg := config.New()
+			cfg.VHosts["test.com"] = &config.VHost{
+				Domain:     "test.com",
+				Type:       "php",
+				Root:       "/var/www/test",
+				PHPVersion: "8.2",
+				Enabled:    true,
+			}
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithConfigDir(filepath.Join(tempDir, "config")).WithDriver(mockDrv).WithRootAccess(true).Build()
+			defer func() { deps = oldDeps }()
+
+			for name, value := range tt.flags {
+				if err := setCmd.Flags().Set(name, value); err != nil {
+					t.Fatalf("failed to set flag %s: %v", name, err)
+				}
+			}
+			defer resetSetFlags()
+
+			err := runSet(setCmd, []string{"test.com"})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error %q does not contain %q", err.Error(), tt.errContains)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.validate != nil {
+				tt.validate(t, cfg.VHosts["test.com"], mockDrv)
+			}
+		})
+	}
+}
+
+func TestRunSetManualEdits(t *testing.T) {
+	stored := config.VHost{Domain: "test.com", Type: "php", Root: "/var/www/test", PHPVersion: "8.2", Enabled: true}
+	rendered, err := template.Render("nginx", &stored)
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	edited := strings.Replace(rendered, "index index.php", "index app.php index.php", 1)
+	if edited == rendered {
+		t.Fatal("template has no index line to edit")
+	}
+
+	// A file an older template wrote differs from today's rendering
+	older := "# older template\n" + rendered
+
+	tests := []struct {
+		name      string
+		onDisk    string
+		hash      string // recorded ConfigHash; "" compares with a rendering
+		stdin     string
+		force     bool
+		wantErr   error
+		wantAdded bool
+	}{
+		{"unchanged file is replaced without asking", rendered, "", "", false, nil, true},
+		{"edited file declined", edited, "", "n\n", false, errEditsKept, false},
+		{"edited file confirmed", edited, "", "y\n", false, nil, true},
+		{"edited file with --force", edited, "", "", true, nil, true},
+		{"file matching its hash is replaced without asking", older, configHash(older), "", false, nil, true},
+		{"file edited since its hash declined", edited, configHash(older), "n\n", false, errEditsKept, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			availableDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(availableDir, "test.com"), []byte(tt.onDisk), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			mockDrv := driver.NewMockDriver("nginx", availableDir, t.TempDir())
+
+			vhost := stored
+			vhost.ConfigHash = tt.hash
+			cfg := config.New()
+			cfg.VHosts["test.com"] = &vhost
+
+			// An empty stdin fails any prompt, so it proves none was shown
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).WithStdinInput(tt.stdin).WithConfigDir(t.TempDir()).Build()
+			defer func() { deps = oldDeps }()
+
+			if err := setCmd.Flags().Set("php", "8.3"); err != nil {
+				t.Fatal(err)
+			}
+			setForce = tt.force
+			defer resetSetFlags()
+
+			err := runSet(setCmd, []string{"test.com"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if added := len(mockDrv.AddCalls) > 0; added != tt.wantAdded {
+				t.Errorf("config replaced = %v, want %v", added, tt.wantAdded)
+			}
+			if saves := deps.ConfigLoader.(*MockConfigLoader).SaveCalls; !tt.wantAdded && saves != 0 {
+				t.Errorf("expected config not to be saved, got %d saves", saves)
+			}
+			if tt.wantAdded && vhost.ConfigHash != configHash(mockDrv.AddCalls[0].Content) {
+				t.Errorf("expected the hash of the new config recorded, got %q", vhost.ConfigHash)
+			}
+		})
+	}
+}
+
+func TestRunSetMaintenance(t *testing.T) {
+	tempDir := t.TempDir()
+
+	availableDir := filepath.Join(tempDir, "sites-available")
+	if err := os.MkdirAll(availableDir, 0755); err != nil {
+		t.Fatalf("failed to create available dir: %v", err)
+	}
+	configPath := filepath.Join(availableDir, "test.com")
+	original := "# hand-tuned config\nserver { listen 80; }\n"
+	if err := os.WriteFile(configPath, []byte(original), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	// Mock Add writes the file so the second step sees the maintenance config
+	mockDrv := driver.NewMockDriver("nginx", availableDir, filepath.Join(tempDir, "sites-enabled"))
+	mockDrv.AddFunc = func(vhost *config.VHost, content string) error {
+		return os.WriteFile(configPath, []byte(content), 0644)
+	}
+	mockDrv.IsEnabledFunc = func(domain string) (bool, error) { return true, nil }
+
+	cfg := config.New()
+	cfg.VHosts["test.com"] = &config.VHost{Domain: "test.com", Type: "static", Root: "/var/www/test", Enabled: true}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithConfigDir(filepath.Join(tempDir, "config")).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+	defer resetSetFlags()
+
+	// Turn maintenance on
+	if err := setCmd.Flags().Set("maintenance", "on"); err != nil {
+		t.Fatalf("failed to set flag: %v", err)
+	}
+	if err := runSet(setCmd, []string{"test.com"}); err != nil {
+		t.Fatalf("maintenance on failed: %v", err)
+	}
+	if !cfg.VHosts["test.com"].Maintenance {
+		t.Error("vhost should be marked in maintenance")
+	}
+	if len(mockDrv.AddCalls) != 1 {
+		t.Fatalf("expected 1 Add call, got %d", len(mockDrv.AddCalls))
+	}
+	if !strings.Contains(mockDrv.AddCalls[0].Content, "return 503") {
+		t.Errorf("expected 503 maintenance config, got:\n%s", mockDrv.AddCalls[0].Content)
+	}
+	backupPath, _ := maintenanceBackupPath("nginx", "test.com")
+	if saved, err := os.ReadFile(backupPath); err != nil || string(saved) != original {
+		t.Errorf("expected original config backed up, got %q (err %v)", saved, err)
+	}
+
+	// Turn maintenance off
+	resetSetFlags()
+	if err := setCmd.Flags().Set("maintenance", "off"); err != nil {
+		t.Fatalf("failed to set flag: %v", err)
+	}
+	if err := runSet(setCmd, []string{"test.com"}); err != nil {
+		t.Fatalf("maintenance off failed: %v", err)
+	}
+	if cfg.VHosts["test.com"].Maintenance {
+		t.Error("vhost should no longer be in maintenance")
+	}
+	if len(mockDrv.AddCalls) != 2 || mockDrv.AddCalls[1].Content != original {
+		t.Errorf("expected original config restored verbatim, got %q", mockDrv.AddCalls[len(mockDrv.AddCalls)-1].Content)
+	}
+	if _, err := os.Stat(backupPath); !os.IsNotExist(err) {
+		t.Error("backup should be removed after restoring")
+	}
+
+	// Invalid value
+	resetSetFlags()
+	if err := setCmd.Flags().Set("maintenance", "maybe"); err != nil {
+		t.Fatalf("failed to set flag: %v", err)
+	}
+	if err := runSet(setCmd, []string{"test.com"}); err == nil || !strings.Contains(err.Error(), "invalid --maintenance") {
+		t.Errorf("expected invalid value error, got %v", err)
+	}
+}
+
+// resetSetFlags clears the set command flags between test cases
+func resetSetFlags() {
+	for _, name := range []string{"type", "root", "php", "proxy", "maintenance", "owner-email", "notes"} {
+		flag := setCmd.Flags().Lookup(name)
+		_ = flag.Value.Set("")
+		flag.Changed = false
+	}
+	setForce = false
+}
@@ -1,9 +1,12 @@
 package cli
 
 import (
-	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/ksyq12/vhost/internal/driver"
+	vherrors "github.com/ksyq12/vhost/internal/errors"
 	"github.com/ksyq12/vhost/internal/output"
 	"github.com/spf13/cobra"
 )
@@ -13,30 +16,66 @@ var showCmd = &cobra.Command{
 	Short: "Show details of a virtual host",
 	Long: `Show detailed information about a virtual host.
 
+Use --logs to append the last lines of the access and error logs
+(default 10, or --logs=N).
+
 Examples:
   vhost show example.com
+  vhost show example.com --logs
+  vhost show example.com --logs=50
   vhost show example.com --json`,
-	Args: cobra.ExactArgs(1),
-	RunE: runShow,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runShow,
 }
 
+var showLogLines int
+
 func init() {
+	showCmd.Flags().IntVar(&showLogLines, "logs", 0, "Include the last N lines of the access and error logs")
+	showCmd.Flags().Lookup("logs").NoOptDefVal = "10"
+
 	rootCmd.AddCommand(showCmd)
 }
 
 // showDetail represents the detailed vhost information for output
 type showDetail struct {
-	Domain     string     `json:"domain"`
-	Type       string     `json:"type"`
-	Root       string     `json:"root,omitempty"`
-	ProxyPass  string     `json:"proxy_pass,omitempty"`
-	PHPVersion string     `json:"php_version,omitempty"`
-	SSL        bool       `json:"ssl"`
-	SSLCert    string     `json:"ssl_cert,omitempty"`
-	SSLKey     string     `json:"ssl_key,omitempty"`
-	SSLExpires *time.Time `json:"ssl_expires,omitempty"`
-	Enabled    bool       `json:"enabled"`
-	CreatedAt  time.Time  `json:"created_at"`
+	Domain      string            `json:"domain"`
+	Type        string            `json:"type"`
+	Template    string            `json:"template,omitempty"`
+	Aliases     []string          `json:"aliases,omitempty"`
+	Root        string            `json:"root,omitempty"`
+	ProxyPass   string            `json:"proxy_pass,omitempty"`
+	RedirectURL string            `json:"redirect_url,omitempty"`
+	PHPVersion  string            `json:"php_version,omitempty"`
+	SPA         bool              `json:"spa,omitempty"`
+	SSL         bool              `json:"ssl"`
+	SSLCert     string            `json:"ssl_cert,omitempty"`
+	SSLKey      string            `json:"ssl_key,omitempty"`
+	SSLExpires  *time.Time        `json:"ssl_expires,omitempty"`
+	EnvVars     map[string]string `json:"env_vars,omitempty"`
+	Locations   []showLocation    `json:"locations,omitempty"`
+	Enabled     bool              `json:"enabled"`
+	Maintenance bool              `json:"maintenance"`
+	Owner       string            `json:"owner,omitempty"`
+	Notes       string            `json:"notes,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Logs        []logTail         `json:"logs,omitempty"`
+}
+
+// showLocation is an extra path served from another directory
+type showLocation struct {
+	Path string `json:"path"`
+	Root string `json:"root"`
+}
+
+// logTail holds the last lines of one log file
+type logTail struct {
+	Type  string   `json:"type"` // "access" or "error"
+	Path  string   `json:"path"`
+	Found bool     `json:"found"`
+	Lines []string `json:"lines"`
 }
 
 func runShow(cmd *cobra.Command, args []string) error {
@@ -54,10 +93,11 @@ func runShow(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get vhost from config
-	vhost, exists := cfg.VHosts[domain]
+	vhost, exists := cfg.FindByDomainOrAlias(domain)
 	if !exists {
-		return fmt.Errorf("vhost %s not found", domain)
+		return vherrors.Newf(vherrors.ErrCodeNotFound, "vhost %s not found", domain)
 	}
+	domain = vhost.Domain
 
 	// Check enabled status from driver
 	enabled, err := drv.IsEnabled(domain)
@@ -67,16 +107,29 @@ func runShow(cmd *cobra.Command, args []string) error {
 
 	// Build detail struct
 	detail := showDetail{
-		Domain:     vhost.Domain,
-		Type:       vhost.Type,
-		Root:       vhost.Root,
-		ProxyPass:  vhost.ProxyPass,
-		PHPVersion: vhost.PHPVersion,
-		SSL:        vhost.SSL,
-		SSLCert:    vhost.SSLCert,
-		SSLKey:     vhost.SSLKey,
-		Enabled:    enabled,
-		CreatedAt:  vhost.CreatedAt,
+		Domain:      vhost.Domain,
+		Type:        vhost.Type,
+		Template:    vhost.Template,
+		SPA:         vhost.SPA,
+		Aliases:     vhost.Aliases,
+		Root:        vhost.Root,
+		ProxyPass:   vhost.ProxyPass,
+		RedirectURL: vhost.RedirectURL,
+		PHPVersion:  vhost.PHPVersion,
+		SSL:         vhost.SSL,
+		SSLCert:     vhost.SSLCert,
+		SSLKey:      vhost.SSLKey,
+		EnvVars:     vhost.EnvVars,
+		Enabled:     enabled,
+		Maintenance: vhost.Maintenance,
+		Owner:       vhost.Owner,
+		Notes:       vhost.Notes,
+		CreatedAt:   vhost.CreatedAt,
+		UpdatedAt:   vhost.UpdatedAt,
+	}
+
+	for _, location := range vhost.Locations {
+		detail.Locations = append(detail.Locations, showLocation{Path: location.Path, Root: location.Root})
 	}
 
 	// Get SSL expiry if SSL is enabled
@@ -86,6 +139,11 @@ func runShow(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Include recent log lines if requested
+	if showLogLines > 0 {
+		detail.Logs = collectLogTails(drv, domain, showLogLines)
+	}
+
 	// Output JSON if requested
 	if jsonOutput {
 		return output.JSON(detail)
@@ -95,6 +153,12 @@ func runShow(cmd *cobra.Command, args []string) error {
 	output.Print("")
 	output.Print("Domain:     %s", detail.Domain)
 	output.Print("Type:       %s", detail.Type)
+	if detail.Template != "" {
+		output.Print("Template:   %s", detail.Template)
+	}
+	if len(detail.Aliases) > 0 {
+		output.Print("Aliases:    %s", strings.Join(detail.Aliases, ", "))
+	}
 
 	if detail.Root != "" {
 		output.Print("Root:       %s", detail.Root)
@@ -102,9 +166,34 @@ func runShow(cmd *cobra.Command, args []string) error {
 	if detail.ProxyPass != "" {
 		output.Print("ProxyPass:  %s", detail.ProxyPass)
 	}
+	if detail.RedirectURL != "" {
+		output.Print("Redirect:   %s", detail.RedirectURL)
+	}
 	if detail.PHPVersion != "" {
 		output.Print("PHP:        %s", detail.PHPVersion)
 	}
+	if detail.SPA {
+		output.Print("SPA:        yes (unknown paths serve /index.html)")
+	}
+
+	if len(detail.Locations) > 0 {
+		output.Print("Locations:")
+		for _, location := range detail.Locations {
+			output.Print("  %s -> %s", location.Path, location.Root)
+		}
+	}
+
+	if len(detail.EnvVars) > 0 {
+		keys := make([]string, 0, len(detail.EnvVars))
+		for key := range detail.EnvVars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		output.Print("Env:")
+		for _, key := range keys {
+			output.Print("  %s=%s", key, detail.EnvVars[key])
+		}
+	}
 
 	if detail.SSL {
 		output.Print("SSL:        enabled")
@@ -127,8 +216,65 @@ func runShow(cmd *cobra.Command, args []string) error {
 		output.Print("Enabled:    no")
 	}
 
+	if detail.Owner != "" {
+		output.Print("Owner:      %s", detail.Owner)
+	}
+	if detail.Notes != "" {
+		output.Print("Notes:      %s", detail.Notes)
+	}
+
+	if detail.Maintenance {
+		output.Print("Mode:       maintenance (serving 503 page)")
+	}
+
 	output.Print("Created:    %s", detail.CreatedAt.Format("2006-01-02 15:04:05"))
+	if !detail.UpdatedAt.IsZero() {
+		output.Print("Updated:    %s", detail.UpdatedAt.Format("2006-01-02 15:04:05"))
+	}
 	output.Print("")
 
+	for _, tail := range detail.Logs {
+		output.Print("%s log (%s):", capitalize(tail.Type), tail.Path)
+		if !tail.Found {
+			output.Print("  (not found)")
+		} else if len(tail.Lines) == 0 {
+			output.Print("  (empty)")
+		}
+		for _, line := range tail.Lines {
+			output.Print("  %s", line)
+		}
+		output.Print("")
+	}
+
 	return nil
 }
+
+// collectLogTails reads the last n lines of the vhost's access and error logs.
+// Missing logs are reported as not found rather than failing.
+func collectLogTails(drv driver.Driver, domain string, n int) []logTail {
+	accessLog, errorLog, err := parseLogPaths(drv, domain)
+	if err != nil {
+		accessLog = getDefaultLogPath(drv.Name(), domain, "access")
+		errorLog = getDefaultLogPath(drv.Name(), domain, "error")
+	}
+
+	var tails []logTail
+	for _, entry := range []struct{ logType, path string }{{"access", accessLog}, {"error", errorLog}} {
+		if entry.path == "" {
+			continue
+		}
+		// Caddy writes both to a single file
+		if entry.logType == "error" && entry.path == accessLog {
+			continue
+		}
+
+		tail := logTail{Type: entry.logType, Path: entry.path, Lines: []string{}}
+		if lines, err := tailLines(entry.path, n); err == nil {
+			tail.Found = true
+			tail.Lines = lines
+		}
+		tails = append(tails, tail)
+	}
+
+	return tails
+}
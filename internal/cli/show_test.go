@@ -1,11 +1,17 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/ksyq12/vhost/internal/config"
 	"github.com/ksyq12/vhost/internal/driver"
 )
@@ -188,3 +194,245 @@ func TestRunShow(t *testing.T) {
 		})
 	}
 }
+
+// captureStdout captures stdout during function execution
+func captureStdout(f func()) string {
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	f()
+
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	return buf.String()
+}
+
+// captureOutput captures stdout like captureStdout, plus the colored
+// message helpers, which write through color.Output
+func captureOutput(f func()) string {
+	oldColor := color.Output
+	defer func() { color.Output = oldColor }()
+	return captureStdout(func() {
+		color.Output = os.Stdout
+		f()
+	})
+}
+
+func TestRunShowJSONTimestamps(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+	created := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	updated := time.Date(2026, 2, 1, 12, 30, 0, 0, time.UTC)
+	cfg := config.New()
+	cfg.VHosts["test.com"] = &config.VHost{
+		Domain:    "test.com",
+		Type:      "static",
+		Root:      "/var/www/test",
+		CreatedAt: created,
+		UpdatedAt: updated,
+	}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+	defer func() { deps = oldDeps }()
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+
+	var runErr error
+	out := captureStdout(func() {
+		runErr = runShow(nil, []string{"test.com"})
+	})
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if result["created_at"] != created.Format(time.RFC3339) {
+		t.Errorf("expected created_at %s, got %v", created.Format(time.RFC3339), result["created_at"])
+	}
+	if result["updated_at"] != updated.Format(time.RFC3339) {
+		t.Errorf("expected updated_at %s, got %v", updated.Format(time.RFC3339), result["updated_at"])
+	}
+}
+
+func TestRunShowMetadata(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+	cfg := config.New()
+	cfg.VHosts["test.com"] = &config.VHost{
+		Domain: "test.com",
+		Type:   "static",
+		Root:   "/var/www/test",
+		Owner:  "ops@example.com",
+		Notes:  "Customer #42",
+	}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+	defer func() { deps = oldDeps }()
+
+	var runErr error
+	out := captureStdout(func() {
+		runErr = runShow(nil, []string{"test.com"})
+	})
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+	for _, want := range []string{"Owner:      ops@example.com", "Notes:      Customer #42"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+
+	out = captureStdout(func() {
+		runErr = runShow(nil, []string{"test.com"})
+	})
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if result["owner"] != "ops@example.com" || result["notes"] != "Customer #42" {
+		t.Errorf("expected owner and notes in JSON, got %v", result)
+	}
+}
+
+func TestRunShowAlias(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+	cfg := config.New()
+	cfg.VHosts["test.com"] = &config.VHost{
+		Domain:  "test.com",
+		Type:    "static",
+		Root:    "/var/www/test",
+		Aliases: []string{"www.test.com"},
+	}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+	defer func() { deps = oldDeps }()
+
+	var runErr error
+	out := captureStdout(func() {
+		runErr = runShow(nil, []string{"www.test.com"})
+	})
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+	if !strings.Contains(out, "test.com") {
+		t.Errorf("expected primary domain in output:\n%s", out)
+	}
+	if len(mockDrv.IsEnabledCalls) == 0 || mockDrv.IsEnabledCalls[0] != "test.com" {
+		t.Errorf("expected driver lookup by primary domain, got %v", mockDrv.IsEnabledCalls)
+	}
+
+	if err := runShow(nil, []string{"unknown.com"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestRunShowLogs(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	mockDrv := driver.NewMockDriver("nginx", availableDir, filepath.Join(tempDir, "sites-enabled"))
+
+	accessLog := filepath.Join(tempDir, "test.com-access.log")
+	errorLog := filepath.Join(tempDir, "test.com-error.log")
+
+	var access strings.Builder
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&access, "GET /page/%d 200\n", i)
+	}
+	if err := os.WriteFile(accessLog, []byte(access.String()), 0644); err != nil {
+		t.Fatalf("failed to write access log: %v", err)
+	}
+
+	if err := os.MkdirAll(availableDir, 0755); err != nil {
+		t.Fatalf("failed to create available dir: %v", err)
+	}
+	siteConfig := fmt.Sprintf("server {\n    access_log %s;\n    error_log %s;\n}\n", accessLog, errorLog)
+	if err := os.WriteFile(filepath.Join(availableDir, "test.com"), []byte(siteConfig), 0644); err != nil {
+		t.Fatalf("failed to write site config: %v", err)
+	}
+
+	cfg := config.New()
+	cfg.VHosts["test.com"] = &config.VHost{Domain: "test.com", Type: "static", Root: "/var/www/test"}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+	defer func() { deps = oldDeps }()
+
+	showLogLines = 3
+	defer func() { showLogLines = 0 }()
+
+	var runErr error
+	out := captureStdout(func() {
+		runErr = runShow(nil, []string{"test.com"})
+	})
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+
+	for _, want := range []string{"GET /page/28 200", "GET /page/29 200", "GET /page/30 200"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "GET /page/27 200") {
+		t.Errorf("expected only the last 3 lines, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Error log ("+errorLog+"):\n  (not found)") {
+		t.Errorf("expected missing error log note, got:\n%s", out)
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	dir := t.TempDir()
+
+	// Longer than one read chunk to exercise the backwards scan
+	var content strings.Builder
+	for i := 1; i <= 2000; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	path := filepath.Join(dir, "big.log")
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	lines, err := tailLines(path, 2)
+	if err != nil {
+		t.Fatalf("tailLines failed: %v", err)
+	}
+	if len(lines) != 2 || lines[0] != "line 1999" || lines[1] != "line 2000" {
+		t.Errorf("unexpected tail: %v", lines)
+	}
+
+	empty := filepath.Join(dir, "empty.log")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	lines, err = tailLines(empty, 5)
+	if err != nil || len(lines) != 0 {
+		t.Errorf("expected no lines for empty file, got %v (%v)", lines, err)
+	}
+
+	if _, err := tailLines(filepath.Join(dir, "missing.log"), 5); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -1,8 +1,17 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/logger"
 	"github.com/ksyq12/vhost/internal/output"
 	"github.com/ksyq12/vhost/internal/ssl"
 	"github.com/ksyq12/vhost/internal/template"
@@ -10,7 +19,30 @@ import (
 )
 
 var (
-	sslEmail string
+	sslEmail      string
+	sslStaging    bool
+	sslInstallAll bool
+	sslHTTP2      bool
+	sslHTTP3      bool
+	sslForce      bool
+	sslMethod     string
+	sslDNSPlugin  string
+	sslDNSCreds   string
+	sslCopyTo     string
+	sslNoAliases  bool
+)
+
+// Certificate issuing methods for ssl install --method
+const (
+	sslMethodWebroot    = "webroot"
+	sslMethodStandalone = "standalone"
+	sslMethodNginx      = "nginx"
+	sslMethodApache     = "apache"
+	sslMethodDNS        = "dns"
+
+	// sslMethodCaddy is caddy's automatic HTTPS: no certbot run, caddy
+	// obtains the certificate once the site is served over HTTPS
+	sslMethodCaddy = "caddy"
 )
 
 var sslCmd = &cobra.Command{
@@ -20,14 +52,56 @@ var sslCmd = &cobra.Command{
 }
 
 var sslInstallCmd = &cobra.Command{
-	Use:   "install <domain>",
+	Use:   "install <domain> | --all",
 	Short: "Install SSL certificate for a domain",
 	Long: `Install a Let's Encrypt SSL certificate for a domain.
 
+--method picks how certbot proves control of the domain: webroot (serves
+the challenge from the document root), standalone (certbot listens on
+port 80 itself), nginx or apache (the web server plugin), or dns (a certbot
+DNS plugin named by --dns-plugin). The default follows the driver: the
+nginx or apache plugin, webroot for traefik.
+
+Caddy obtains and renews certificates itself, so with the caddy driver
+install runs no certbot: it switches the site to HTTPS and reloads, and
+caddy fetches the certificate on its own. --email and --method are not
+needed there.
+
+--all issues certificates for every enabled vhost without SSL, using
+webroot validation against each document root unless --method is given;
+vhosts without a document root are skipped for webroot.
+A failure on one domain doesn't stop the others, and the web server is
+reloaded once at the end.
+
+The SSL listener negotiates HTTP/2 unless --http2=false; --http3 also
+listens for HTTP/3 over QUIC (nginx 1.25 or newer).
+
+The certificate also covers the vhost's aliases, so example.com with the
+alias www.example.com gets one certificate for both; --no-aliases issues it
+for the primary domain only.
+
+--copy-to copies the issued fullchain.pem and privkey.pem into
+<dir>/<domain>/ and points the vhost at the copies instead of the
+/etc/letsencrypt/live symlinks, for chrooted or containerized servers. The
+key keeps mode 0600. vhost ssl renew refreshes the copies and reloads the
+server; certbot's own renewal timer updates only the live files, so run
+vhost ssl renew --all from cron for copied certificates.
+
+The config file is re-rendered with SSL. If it was edited by hand, the
+edits are shown and install asks before discarding them (--all skips the
+vhosts where the answer is no); --force replaces them without asking.
+
 Examples:
-  vhost ssl install example.com --email admin@example.com`,
-	Args: cobra.ExactArgs(1),
-	RunE: runSSLInstall,
+  vhost ssl install example.com --email admin@example.com
+  vhost ssl install --all --email admin@example.com
+  vhost ssl install --all --email admin@example.com --staging
+  vhost ssl install example.com --email admin@example.com --http3
+  vhost ssl install example.com --email admin@example.com --method standalone
+  vhost ssl install example.com --email admin@example.com --copy-to /srv/chroot/etc/ssl
+  vhost ssl install example.com --email admin@example.com --method dns --dns-plugin cloudflare --dns-credentials /root/.secrets/cloudflare.ini`,
+	Args:              domainOrAllArgs(&sslInstallAll),
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runSSLInstall,
 }
 
 var sslRenewCmd = &cobra.Command{
@@ -35,32 +109,67 @@ var sslRenewCmd = &cobra.Command{
 	Short: "Renew SSL certificate(s)",
 	Long: `Renew SSL certificates.
 
+certbot skips certificates that are not close to expiry; --force renews
+one anyway, e.g. after its names changed. Forced renewals count against
+Let's Encrypt's rate limits.
+
+Vhosts installed with --copy-to get fresh copies of the renewed
+certificate, and the server is reloaded to pick them up.
+
 Examples:
-  vhost ssl renew example.com    # Renew specific domain
-  vhost ssl renew --all          # Renew all certificates`,
-	RunE: runSSLRenew,
+  vhost ssl renew example.com          # Renew specific domain
+  vhost ssl renew --all                # Renew all certificates
+  vhost ssl renew example.com --force  # Renew even if not due`,
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runSSLRenew,
 }
 
 var sslStatusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [domain]",
 	Short: "Show SSL certificate status",
 	Long: `Show the status of all SSL certificates.
 
+Without a domain, list every certificate certbot manages with its names,
+expiry date and days of validity left; --json emits them as objects.
+
+With a domain, read the certificate its vhost uses (the Let's Encrypt one
+unless config.yaml points elsewhere, e.g. a --copy-to copy) and show its
+subject, issuer, names, validity period, serial number and days remaining,
+and whether the private key file is present.
+
 Examples:
-  vhost ssl status`,
-	RunE: runSSLStatus,
+  vhost ssl status
+  vhost ssl status example.com
+  vhost ssl status example.com --json`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: validDomainsForCompletion,
+	RunE:              runSSLStatus,
 }
 
 var (
-	renewAll bool
+	renewAll   bool
+	renewForce bool
 )
 
 func init() {
-	sslInstallCmd.Flags().StringVarP(&sslEmail, "email", "e", "", "Email address for Let's Encrypt (required)")
-	_ = sslInstallCmd.MarkFlagRequired("email")
+	sslInstallCmd.Flags().StringVarP(&sslEmail, "email", "e", "", "Email address for Let's Encrypt (required unless the driver is caddy)")
+	sslInstallCmd.Flags().BoolVar(&sslStaging, "staging", false, "Use the Let's Encrypt staging CA (untrusted test certificates)")
+	sslInstallCmd.Flags().BoolVar(&sslInstallAll, "all", false, "Install certificates for every enabled vhost without SSL")
+	sslInstallCmd.Flags().BoolVar(&sslHTTP2, "http2", true, "Negotiate HTTP/2 on the SSL listener")
+	sslInstallCmd.Flags().BoolVar(&sslHTTP3, "http3", false, "Also serve HTTP/3 over QUIC (nginx 1.25+)")
+	sslInstallCmd.Flags().BoolVar(&sslForce, "force", false, "Overwrite manual edits to the config file without asking")
+	sslInstallCmd.Flags().StringVar(&sslMethod, "method", "", "How to validate the domain: webroot, standalone, nginx, apache or dns (default depends on the driver)")
+	sslInstallCmd.Flags().StringVar(&sslDNSPlugin, "dns-plugin", "", "certbot DNS plugin for --method dns, e.g. cloudflare or route53")
+	sslInstallCmd.Flags().StringVar(&sslDNSCreds, "dns-credentials", "", "Credentials file for the DNS plugin")
+	sslInstallCmd.Flags().BoolVar(&sslNoAliases, "no-aliases", false, "Issue the certificate for the primary domain only, not the vhost's aliases")
+	sslInstallCmd.Flags().StringVar(&sslCopyTo, "copy-to", "", "Copy the certificate and key into <dir>/<domain>/ and use the copies")
+	sslInstallCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server")
 
 	sslRenewCmd.Flags().BoolVar(&renewAll, "all", false, "Renew all certificates")
+	sslRenewCmd.Flags().BoolVar(&renewForce, "force", false, "Renew even if the certificate is not due (passes --force-renewal to certbot)")
+	sslRenewCmd.Flags().BoolVar(&noReload, "no-reload", false, "Don't reload web server after refreshing --copy-to copies")
 
+	interruptible(sslInstallCmd)
 	sslCmd.AddCommand(sslInstallCmd)
 	sslCmd.AddCommand(sslRenewCmd)
 	sslCmd.AddCommand(sslStatusCmd)
@@ -69,6 +178,11 @@ func init() {
 }
 
 func runSSLInstall(cmd *cobra.Command, args []string) error {
+	ssl.SetStaging(sslStaging)
+	if sslInstallAll {
+		return runSSLInstallAll()
+	}
+
 	domain := args[0]
 
 	// Validate domain
@@ -76,66 +190,87 @@ func runSSLInstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Check if certbot is installed
-	if !ssl.IsInstalled() {
-		return fmt.Errorf("certbot is not installed. Install it with: apt install certbot python3-certbot-nginx")
-	}
-
 	// Load config and driver
 	cfg, drv, err := loadConfigAndDriver()
 	if err != nil {
 		return err
 	}
 
+	method, err := resolveSSLMethod(drv.Name(), defaultSSLMethod(drv.Name()))
+	if err != nil {
+		return err
+	}
+	logger.Debug("Using SSL method %s", method)
+	if err := checkCertbot(method); err != nil {
+		return err
+	}
+	if err := validateSSLCopyTo(method); err != nil {
+		return err
+	}
+
 	// Get vhost
-	vhost, exists := cfg.VHosts[domain]
+	vhost, exists := cfg.FindByDomainOrAlias(domain)
 	if !exists {
 		return fmt.Errorf("vhost %s not found. Create it first with: vhost add %s", domain, domain)
 	}
+	domain = vhost.Domain
 
-	// Issue certificate
-	output.Info("Issuing SSL certificate for %s...", domain)
-	cert, err := ssl.IssueNginx(domain, sslEmail)
-	if err != nil {
-		return fmt.Errorf("failed to issue certificate: %w", err)
+	// Dry-run mode: show what would be done without issuing anything
+	if dryRun {
+		return outputSSLInstallDryRun(cfg, []string{domain}, method, drv.Name(), drv.Paths())
 	}
 
-	// Update vhost config
-	vhost.SSL = true
-	vhost.SSLCert = cert.CertPath
-	vhost.SSLKey = cert.KeyPath
+	// Ask before issuing, so declining leaves nothing half done
+	if err := confirmOverwriteEdits(drv, vhost, sslForce); err != nil {
+		return err
+	}
 
-	// Re-render template with SSL
-	configContent, err := template.Render(drv.Name(), vhost)
+	// Ctrl-C before issuing changes nothing; after it, the certificate is
+	// kept and the config left on HTTP
+	ctx := commandContext(cmd)
+	if err := checkInterrupted(ctx); err != nil {
+		return err
+	}
+
+	// Back up the config before certbot runs, so restore puts back what
+	// was there before the install rather than anything certbot touched
+	backup, err := backupVHostConfig(drv, vhost)
 	if err != nil {
-		return fmt.Errorf("failed to render template: %w", err)
+		return err
 	}
 
-	// Update config file - disable first, then remove and re-add
-	output.Info("Updating vhost configuration with SSL...")
-	if enabled, _ := drv.IsEnabled(domain); enabled {
-		if err := drv.Disable(domain); err != nil {
-			output.Warn("Failed to disable before update: %v", err)
+	// Issue certificate
+	stopSpinner := func() {}
+	if method != sslMethodCaddy {
+		output.Info("Issuing SSL certificate for %s...", domain)
+		if !jsonOutput {
+			stopSpinner = output.StartSpinner("Waiting for certbot...")
 		}
 	}
-
-	if err := drv.Remove(domain); err != nil {
-		// Not fatal - file might not exist
-		output.Warn("Could not remove old config: %v", err)
+	cert, err := issueSSLCert(method, vhost)
+	stopSpinner()
+	if err != nil {
+		return fmt.Errorf("failed to issue certificate: %w", err)
 	}
-
-	if err := drv.Add(vhost, configContent); err != nil {
-		return fmt.Errorf("failed to update vhost config: %w", err)
+	if cert, err = copySSLCert(cert); err != nil {
+		return err
+	}
+	if err := checkInterrupted(ctx); err != nil {
+		return err
 	}
 
-	if err := drv.Enable(domain); err != nil {
-		return fmt.Errorf("failed to enable vhost: %w", err)
+	// Switch the config to SSL; any later failure puts the HTTP config back.
+	// The issued certificate stays, it is valid and reusable.
+	output.Info("Updating vhost configuration with SSL...")
+	restore, err := applySSLCert(drv, vhost, cert, backup)
+	if err != nil {
+		return err
 	}
 
-	// Test and reload
-	if err := testAndReload(drv, true, nil); err != nil {
+	if err := testReloadOrRestore(drv, restore); err != nil {
 		return err
 	}
+	_ = cfg.TouchVHost(domain)
 
 	// Save config
 	if err := saveConfig(cfg); err != nil {
@@ -146,11 +281,18 @@ func runSSLInstall(cmd *cobra.Command, args []string) error {
 		return output.JSON(map[string]interface{}{
 			"success":   true,
 			"domain":    domain,
+			"method":    method,
 			"cert_path": cert.CertPath,
 			"key_path":  cert.KeyPath,
 		})
 	}
 
+	if method == sslMethodCaddy {
+		output.Success("HTTPS enabled for %s", domain)
+		output.Print("  Caddy obtains the certificate from Let's Encrypt on its own; ports 80 and")
+		output.Print("  443 must be reachable and the domain's DNS must point at this server.")
+		return nil
+	}
 	output.Success("SSL certificate installed for %s", domain)
 	output.Print("  Certificate: %s", cert.CertPath)
 	output.Print("  Private Key: %s", cert.KeyPath)
@@ -158,20 +300,527 @@ func runSSLInstall(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// sslInstallSkip records a vhost ssl install --all left alone, and why
+type sslInstallSkip struct {
+	Domain string `json:"domain"`
+	Reason string `json:"reason"`
+}
+
+// sslInstallFailure records a vhost whose certificate or config update failed
+type sslInstallFailure struct {
+	Domain string `json:"domain"`
+	Error  string `json:"error"`
+}
+
+// runSSLInstallAll issues certificates for every eligible vhost, updates
+// their configs and reloads once. Per-domain failures are collected and
+// reported together; a failed config test restores every updated file.
+func runSSLInstallAll() error {
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return err
+	}
+
+	method, err := resolveSSLMethod(drv.Name(), sslMethodWebroot)
+	if err != nil {
+		return err
+	}
+	if err := checkCertbot(method); err != nil {
+		return err
+	}
+	if err := validateSSLCopyTo(method); err != nil {
+		return err
+	}
+
+	var eligible []string
+	skipped := []sslInstallSkip{}
+	for _, domain := range sortedDomains(cfg) {
+		if reason := sslIneligibleReason(drv, cfg.VHosts[domain], method); reason != "" {
+			skipped = append(skipped, sslInstallSkip{Domain: domain, Reason: reason})
+			continue
+		}
+		eligible = append(eligible, domain)
+	}
+
+	if dryRun {
+		return outputSSLInstallDryRun(cfg, eligible, method, drv.Name(), drv.Paths())
+	}
+
+	if len(eligible) == 0 {
+		return outputResult(
+			map[string]interface{}{
+				"success":   true,
+				"installed": []string{},
+				"skipped":   skipped,
+			},
+			"No vhosts need an SSL certificate",
+		)
+	}
+
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	installed := []string{}
+	failed := []sslInstallFailure{}
+	var restores []func() error
+	for _, domain := range eligible {
+		vhost := cfg.VHosts[domain]
+		if err := confirmOverwriteEdits(drv, vhost, sslForce); err != nil {
+			if !errors.Is(err, errEditsKept) {
+				return err
+			}
+			skipped = append(skipped, sslInstallSkip{Domain: domain, Reason: "manual edits kept"})
+			continue
+		}
+		restore, err := installSSLForVHost(drv, vhost, method)
+		if restore != nil {
+			restores = append(restores, restore)
+		}
+		if err != nil {
+			output.Warn("SSL install failed for %s: %v", domain, err)
+			failed = append(failed, sslInstallFailure{Domain: domain, Error: err.Error()})
+			continue
+		}
+		_ = cfg.TouchVHost(domain)
+		installed = append(installed, domain)
+	}
+
+	if len(installed) > 0 {
+		restoreAll := func() error {
+			var errs []error
+			for _, restore := range restores {
+				if err := restore(); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return errors.Join(errs...)
+		}
+		if err := testReloadOrRestore(drv, restoreAll); err != nil {
+			return err
+		}
+
+		if err := saveConfig(cfg); err != nil {
+			output.Warn("SSL installed but config save failed: %v", err)
+		}
+	}
+
+	if jsonOutput {
+		if err := output.JSON(map[string]interface{}{
+			"success":   len(failed) == 0,
+			"installed": installed,
+			"skipped":   skipped,
+			"failed":    failed,
+		}); err != nil {
+			return err
+		}
+	} else {
+		output.Success("SSL certificates installed for %d vhost(s)", len(installed))
+		for _, domain := range installed {
+			output.Print("  - %s", domain)
+		}
+		for _, skip := range skipped {
+			output.Print("  - %s (skipped: %s)", skip.Domain, skip.Reason)
+		}
+		for _, failure := range failed {
+			output.Print("  - %s (failed: %s)", failure.Domain, failure.Error)
+		}
+	}
+
+	if len(failed) > 0 {
+		return reported(fmt.Errorf("SSL install failed for %d vhost(s)", len(failed)))
+	}
+	return nil
+}
+
+// sslIneligibleReason explains why ssl install --all skips a vhost, or
+// returns "" when a certificate should be issued for it
+func sslIneligibleReason(drv driver.Driver, vhost *config.VHost, method string) string {
+	if vhost.SSL {
+		return "SSL already enabled"
+	}
+	if method == sslMethodWebroot && sslWebroot(vhost) == "" {
+		return "no document root for webroot validation"
+	}
+	if enabled, _ := drv.IsEnabled(vhost.Domain); !enabled {
+		return "not enabled"
+	}
+	return ""
+}
+
+// sslWebroot returns the directory the vhost serves for ACME webroot
+// challenges: the document root, or its public/ directory for laravel
+func sslWebroot(vhost *config.VHost) string {
+	switch vhost.Type {
+	case config.TypeProxy:
+		return ""
+	case config.TypeLaravel:
+		if vhost.Root == "" {
+			return ""
+		}
+		return filepath.Join(vhost.Root, "public")
+	default:
+		return vhost.Root
+	}
+}
+
+// errCertbotMissing explains how to install certbot, with the plugin the
+// issuing method needs
+func errCertbotMissing(method string) error {
+	packages := "certbot"
+	switch method {
+	case sslMethodNginx, sslMethodApache:
+		packages += " python3-certbot-" + method
+	case sslMethodDNS:
+		packages += " python3-certbot-dns-" + sslDNSPlugin
+	}
+	return fmt.Errorf("certbot is not installed. Install it with: apt install %s", packages)
+}
+
+// checkCertbot makes sure certbot and an email are available when method
+// runs certbot
+func checkCertbot(method string) error {
+	if method == sslMethodCaddy {
+		return nil
+	}
+	if sslEmail == "" {
+		return fmt.Errorf("--email is required to issue a Let's Encrypt certificate")
+	}
+	if !ssl.IsInstalled() {
+		return errCertbotMissing(method)
+	}
+	return nil
+}
+
+// defaultSSLMethod returns the issuing method used without --method: the
+// certbot plugin for the driver's server, webroot for traefik, and caddy's
+// own automatic HTTPS for caddy
+func defaultSSLMethod(drvName string) string {
+	switch drvName {
+	case "nginx":
+		return sslMethodNginx
+	case "apache":
+		return sslMethodApache
+	case "caddy":
+		return sslMethodCaddy
+	default:
+		return sslMethodWebroot
+	}
+}
+
+// resolveSSLMethod validates --method for the driver, returning fallback
+// when it isn't set. Caddy always manages its own certificates.
+func resolveSSLMethod(drvName, fallback string) (string, error) {
+	if drvName == "caddy" {
+		if sslMethod != "" {
+			return "", fmt.Errorf("--method is not used with the caddy driver: caddy obtains certificates itself")
+		}
+		return sslMethodCaddy, nil
+	}
+	if sslMethod == "" {
+		return fallback, nil
+	}
+	switch sslMethod {
+	case sslMethodWebroot, sslMethodStandalone:
+	case sslMethodNginx, sslMethodApache:
+		if drvName != sslMethod {
+			return "", fmt.Errorf("--method %s requires the %s driver (current driver: %s)", sslMethod, sslMethod, drvName)
+		}
+	case sslMethodDNS:
+		if sslDNSPlugin == "" {
+			return "", fmt.Errorf("--method dns requires --dns-plugin, e.g. cloudflare or route53")
+		}
+	default:
+		return "", fmt.Errorf("invalid --method: %s (use %s, %s, %s, %s or %s)", sslMethod, sslMethodWebroot, sslMethodStandalone, sslMethodNginx, sslMethodApache, sslMethodDNS)
+	}
+	return sslMethod, nil
+}
+
+// sslSANs returns the extra names vhost's certificate covers: its aliases,
+// unless --no-aliases
+func sslSANs(vhost *config.VHost) ([]string, error) {
+	if sslNoAliases {
+		return nil, nil
+	}
+	for _, alias := range vhost.Aliases {
+		if err := validateDomain(alias); err != nil {
+			return nil, fmt.Errorf("invalid alias %s: %w", alias, err)
+		}
+	}
+	return vhost.Aliases, nil
+}
+
+// issueSSLCert obtains a certificate for vhost with method. For caddy it
+// runs no certbot: the returned cert has no paths and caddy manages TLS.
+func issueSSLCert(method string, vhost *config.VHost) (*ssl.Cert, error) {
+	if method == sslMethodCaddy {
+		return &ssl.Cert{Domain: vhost.Domain}, nil
+	}
+
+	sans, err := sslSANs(vhost)
+	if err != nil {
+		return nil, err
+	}
+	logger.DebugFields("Issuing certificate", map[string]interface{}{
+		"domain": vhost.Domain,
+		"method": method,
+		"sans":   sans,
+	})
+
+	var cert *ssl.Cert
+	err = timed("certbot "+method, func() error {
+		var err error
+		cert, err = requestSSLCert(method, vhost, sans)
+		return err
+	})
+	return cert, err
+}
+
+// requestSSLCert runs certbot with method for vhost's domain and sans
+func requestSSLCert(method string, vhost *config.VHost, sans []string) (*ssl.Cert, error) {
+	switch method {
+	case sslMethodWebroot:
+		webroot := sslWebroot(vhost)
+		if webroot == "" {
+			return nil, fmt.Errorf("%s has no document root for webroot validation (use --method standalone or dns)", vhost.Domain)
+		}
+		return ssl.Issue(vhost.Domain, sslEmail, webroot, sans...)
+	case sslMethodStandalone:
+		return ssl.IssueStandalone(vhost.Domain, sslEmail, sans...)
+	case sslMethodNginx:
+		return ssl.IssueNginx(vhost.Domain, sslEmail, sans...)
+	case sslMethodApache:
+		return ssl.IssueApache(vhost.Domain, sslEmail, sans...)
+	case sslMethodDNS:
+		return ssl.IssueDNS(vhost.Domain, sslEmail, sslDNSPlugin, sslDNSCreds, sans...)
+	default:
+		return nil, fmt.Errorf("unknown SSL method: %s", method)
+	}
+}
+
+// validateSSLCopyTo checks --copy-to; caddy keeps its certificates itself,
+// so there is nothing to copy
+func validateSSLCopyTo(method string) error {
+	if sslCopyTo == "" {
+		return nil
+	}
+	if method == sslMethodCaddy {
+		return fmt.Errorf("--copy-to is not used with the caddy driver: caddy manages its own certificates")
+	}
+	if !filepath.IsAbs(sslCopyTo) {
+		return fmt.Errorf("--copy-to must be an absolute path: %s", sslCopyTo)
+	}
+	return nil
+}
+
+// copySSLCert copies cert into --copy-to and returns the copy, or cert
+// itself when --copy-to isn't set
+func copySSLCert(cert *ssl.Cert) (*ssl.Cert, error) {
+	if sslCopyTo == "" {
+		return cert, nil
+	}
+	copied, err := ssl.CopyCert(cert, sslCopyTo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy certificate: %w", err)
+	}
+	logger.Debug("Copied certificate for %s to %s", cert.Domain, sslCopyTo)
+	return copied, nil
+}
+
+// sslMethodDetails describes how a certificate is obtained, for dry-run
+func sslMethodDetails(method string, vhost *config.VHost) string {
+	switch method {
+	case sslMethodCaddy:
+		return "caddy obtains the certificate automatically"
+	case sslMethodWebroot:
+		return fmt.Sprintf("certbot webroot validation in %s", sslWebroot(vhost))
+	case sslMethodDNS:
+		return fmt.Sprintf("certbot dns-%s validation", sslDNSPlugin)
+	default:
+		return fmt.Sprintf("certbot %s validation", method)
+	}
+}
+
+// installSSLForVHost issues a certificate for vhost with method and swaps
+// its config for the SSL variant. The returned restore puts the previous
+// config back; it is nil when nothing was changed yet.
+func installSSLForVHost(drv driver.Driver, vhost *config.VHost, method string) (func() error, error) {
+	backup, err := backupVHostConfig(drv, vhost)
+	if err != nil {
+		return nil, err
+	}
+	if method != sslMethodCaddy {
+		output.Info("Issuing SSL certificate for %s...", vhost.Domain)
+	}
+	cert, err := issueSSLCert(method, vhost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue certificate: %w", err)
+	}
+	if cert, err = copySSLCert(cert); err != nil {
+		return nil, err
+	}
+
+	return applySSLCert(drv, vhost, cert, backup)
+}
+
+// backupVHostConfig reads vhost's current config file, falling back to a
+// fresh rendering from config when the file can't be read. Take it before
+// issuing: certbot plugins may touch the file while validating.
+func backupVHostConfig(drv driver.Driver, vhost *config.VHost) ([]byte, error) {
+	configPath := filepath.Join(drv.Paths().Available, driverConfigFileName(drv.Name(), vhost.Domain))
+	backup, err := os.ReadFile(configPath)
+	if err == nil {
+		return backup, nil
+	}
+	rendered, renderErr := template.Render(drv.Name(), vhost)
+	if renderErr != nil {
+		return nil, fmt.Errorf("failed to back up current config: %w", err)
+	}
+	logger.Debug("Can't read %s (%v); backing up a fresh rendering instead", configPath, err)
+	return []byte(rendered), nil
+}
+
+// applySSLCert sets the certificate on vhost and replaces its config with
+// the SSL rendering. The returned restore resets the SSL fields and puts
+// backup back; on error it has already been applied.
+func applySSLCert(drv driver.Driver, vhost *config.VHost, cert *ssl.Cert, backup []byte) (func() error, error) {
+	previous := *vhost
+	// A disabled vhost gets its SSL config but stays disabled
+	enabled, _ := drv.IsEnabled(vhost.Domain)
+
+	logger.Debug("Switching %s to SSL with certificate %s", vhost.Domain, cert.CertPath)
+
+	vhost.SSL = true
+	vhost.SSLCert = cert.CertPath
+	vhost.SSLKey = cert.KeyPath
+	vhost.HTTP2 = sslHTTP2
+	vhost.HTTP3 = sslHTTP3
+
+	restore := func() error {
+		*vhost = previous
+		return replaceVHostConfig(drv, vhost, string(backup), enabled)
+	}
+
+	configContent, err := template.Render(drv.Name(), vhost)
+	if err != nil {
+		*vhost = previous
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+
+	if err := replaceVHostConfig(drv, vhost, configContent, enabled); err != nil {
+		if rbErr := restore(); rbErr != nil {
+			output.Warn("Rollback failed: %v", rbErr)
+		}
+		return nil, err
+	}
+	recordConfigHash(vhost, configContent)
+
+	return restore, nil
+}
+
+// testReloadOrRestore tests and reloads after an SSL config change. Unlike
+// testAndReload it also restores on a failed reload, then reloads again so
+// the server ends up running the previous HTTP config. With --no-reload it
+// only tests.
+func testReloadOrRestore(drv driver.Driver, restore func() error) error {
+	rollback := func() error {
+		output.Info("Rolling back SSL changes...")
+		return restore()
+	}
+
+	if err := testAndReload(drv, false, rollback); err != nil {
+		return err
+	}
+	if noReload {
+		return nil
+	}
+
+	output.Info("Reloading %s...", drv.Name())
+	if err := reloadServer(drv); err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			output.Warn("Rollback failed: %v", rbErr)
+		} else if rlErr := reloadServer(drv); rlErr != nil {
+			output.Warn("Reload after rollback failed: %v", rlErr)
+		}
+		return fmt.Errorf("failed to reload %s: %w", drv.Name(), err)
+	}
+
+	return nil
+}
+
+// outputSSLInstallDryRun outputs what ssl install would do for domains in
+// dry-run mode
+func outputSSLInstallDryRun(cfg *config.Config, domains []string, method, drvName string, drvPaths driver.Paths) error {
+	operations := make([]DryRunOperation, 0, 2*len(domains)+2)
+	for _, domain := range domains {
+		details := sslMethodDetails(method, cfg.VHosts[domain])
+		if sslStaging && method != sslMethodCaddy {
+			details += " (staging)"
+		}
+		operations = append(operations, DryRunOperation{
+			Action:  "issue_certificate",
+			Target:  domain,
+			Details: details,
+		})
+		if sslCopyTo != "" {
+			operations = append(operations, DryRunOperation{
+				Action:  "copy_files",
+				Target:  filepath.Join(sslCopyTo, domain),
+				Details: "Copy fullchain.pem and privkey.pem (mode 0600)",
+			})
+		}
+		operations = append(operations,
+			DryRunOperation{
+				Action:  "update_file",
+				Target:  filepath.Join(drvPaths.Available, driverConfigFileName(drvName, domain)),
+				Details: fmt.Sprintf("Re-render VHost configuration for %s with SSL", domain),
+			},
+		)
+	}
+
+	if len(domains) > 0 {
+		operations = append(operations, DryRunOperation{
+			Action:  "test_config",
+			Target:  drvName,
+			Details: "Validate configuration syntax",
+		})
+		if !noReload {
+			operations = append(operations, DryRunOperation{
+				Action:  "reload_server",
+				Target:  drvName,
+				Details: "Apply configuration changes",
+			})
+		}
+	}
+
+	return outputDryRun(&DryRunResult{
+		Domain:     strings.Join(domains, ", "),
+		Operations: operations,
+	})
+}
+
 func runSSLRenew(cmd *cobra.Command, args []string) error {
 	if !ssl.IsInstalled() {
 		return fmt.Errorf("certbot is not installed")
 	}
 
 	if renewAll {
+		if renewForce {
+			return fmt.Errorf("--force renews one certificate at a time; pass a domain instead of --all")
+		}
 		output.Info("Renewing all certificates...")
-		if err := ssl.RenewAll(); err != nil {
+		if err := timed("certbot renew", ssl.RenewAll); err != nil {
+			return err
+		}
+		refreshed, err := refreshSSLCopies("")
+		if err != nil {
 			return err
 		}
 		return outputResult(
 			map[string]interface{}{
-				"success": true,
-				"renewed": "all",
+				"success":   true,
+				"renewed":   "all",
+				"refreshed": refreshed,
 			},
 			"All certificates renewed",
 		)
@@ -186,44 +835,191 @@ func runSSLRenew(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	renew := ssl.Renew
+	if renewForce {
+		output.Warn("Forcing renewal of %s: Let's Encrypt allows 5 certificates for the same names per week", domain)
+		renew = ssl.RenewForce
+	}
+
 	output.Info("Renewing certificate for %s...", domain)
-	if err := ssl.Renew(domain); err != nil {
+	if err := timed("certbot renew "+domain, func() error { return renew(domain) }); err != nil {
+		return err
+	}
+	refreshed, err := refreshSSLCopies(domain)
+	if err != nil {
 		return err
 	}
 
 	return outputResult(
 		map[string]interface{}{
-			"success": true,
-			"domain":  domain,
-			"renewed": true,
+			"success":   true,
+			"domain":    domain,
+			"renewed":   true,
+			"refreshed": refreshed,
 		},
 		"Certificate renewed for %s", domain,
 	)
 }
 
+// copiedSSLCertDir returns the --copy-to directory vhost's certificate was
+// copied into, or "" when it uses certbot's live files
+func copiedSSLCertDir(vhost *config.VHost) string {
+	if !vhost.SSL || vhost.SSLCert == "" || vhost.SSLCert == ssl.GetCertPaths(vhost.Domain).CertPath {
+		return ""
+	}
+	// CopyCert lays copies out as <dir>/<domain>/fullchain.pem
+	if filepath.Base(vhost.SSLCert) != "fullchain.pem" || filepath.Base(filepath.Dir(vhost.SSLCert)) != vhost.Domain {
+		return ""
+	}
+	return filepath.Dir(filepath.Dir(vhost.SSLCert))
+}
+
+// refreshSSLCopies copies the renewed live certificate of domain, or of
+// every vhost when domain is "", over the --copy-to copies and reloads the
+// server when any changed. It returns the refreshed domains.
+func refreshSSLCopies(domain string) ([]string, error) {
+	refreshed := []string{}
+	cfg, drv, err := loadConfigAndDriver()
+	if err != nil {
+		warn("Could not check for copied certificates: %v", err)
+		return refreshed, nil
+	}
+
+	for _, name := range sortedDomains(cfg) {
+		vhost := cfg.VHosts[name]
+		dir := copiedSSLCertDir(vhost)
+		if dir == "" || (domain != "" && vhost.Domain != domain) {
+			continue
+		}
+		if _, err := ssl.CopyCert(ssl.GetCertPaths(vhost.Domain), dir); err != nil {
+			return refreshed, fmt.Errorf("failed to refresh the certificate copy of %s: %w", vhost.Domain, err)
+		}
+		logger.Debug("Refreshed the certificate copy of %s in %s", vhost.Domain, dir)
+		refreshed = append(refreshed, vhost.Domain)
+	}
+
+	if len(refreshed) == 0 || noReload {
+		return refreshed, nil
+	}
+	output.Info("Reloading %s to use the refreshed certificate copies...", drv.Name())
+	if err := reloadServer(drv); err != nil {
+		return refreshed, fmt.Errorf("failed to reload %s: %w", drv.Name(), err)
+	}
+	return refreshed, nil
+}
+
 func runSSLStatus(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		return runSSLStatusDomain(args[0])
+	}
+
 	if !ssl.IsInstalled() {
 		return fmt.Errorf("certbot is not installed")
 	}
 
-	domains, err := ssl.List()
+	certs, err := ssl.ListDetailed()
 	if err != nil {
 		return err
 	}
 
-	if len(domains) == 0 {
+	if jsonOutput {
+		return output.JSON(certs)
+	}
+
+	if len(certs) == 0 {
 		output.Info("No SSL certificates found")
 		return nil
 	}
 
-	if jsonOutput {
-		return output.JSON(domains)
+	output.Print("Managed SSL certificates:")
+	for _, cert := range certs {
+		status := fmt.Sprintf("%d day(s) left", cert.ValidDays)
+		if cert.ValidDays == 0 {
+			status = "invalid"
+		}
+		output.Print("  - %s (%s; expires %s, %s)", cert.Name, strings.Join(cert.Domains, ", "), cert.ExpiryDate.Format("2006-01-02"), status)
 	}
 
-	output.Print("Managed SSL certificates:")
-	for _, domain := range domains {
-		output.Print("  - %s", domain)
+	return nil
+}
+
+// sslCertDetails is what ssl status <domain> reports about one certificate
+type sslCertDetails struct {
+	Domain        string    `json:"domain"`
+	CertPath      string    `json:"cert_path"`
+	KeyPath       string    `json:"key_path"`
+	KeyExists     bool      `json:"key_exists"`
+	Subject       string    `json:"subject"`
+	Issuer        string    `json:"issuer"`
+	DNSNames      []string  `json:"dns_names"`
+	NotBefore     time.Time `json:"not_before"`
+	NotAfter      time.Time `json:"not_after"`
+	Serial        string    `json:"serial"`
+	DaysRemaining int       `json:"days_remaining"`
+}
+
+// runSSLStatusDomain shows the details of one domain's certificate. It
+// reads the files certbot writes and doesn't need certbot itself.
+func runSSLStatusDomain(domain string) error {
+	domain = config.NormalizeDomain(domain)
+	if err := validateDomain(domain); err != nil {
+		return err
+	}
+
+	// A vhost may use copies or other files than certbot's live ones
+	paths := ssl.GetCertPaths(domain)
+	if cfg, err := deps.ConfigLoader.Load(); err == nil {
+		if vhost, ok := cfg.FindByDomainOrAlias(domain); ok && vhost.SSLCert != "" {
+			paths = &ssl.Cert{Domain: vhost.Domain, CertPath: vhost.SSLCert, KeyPath: vhost.SSLKey}
+		}
+	}
+	if _, err := os.Stat(paths.CertPath); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no certificate found for %s (looked for %s)", domain, paths.CertPath)
+	}
+	cert, err := readCertificate(paths.CertPath)
+	if err != nil {
+		return err
 	}
 
+	_, keyErr := os.Stat(paths.KeyPath)
+	details := sslCertDetails{
+		Domain:        domain,
+		CertPath:      paths.CertPath,
+		KeyPath:       paths.KeyPath,
+		KeyExists:     keyErr == nil,
+		Subject:       cert.Subject.String(),
+		Issuer:        cert.Issuer.String(),
+		DNSNames:      cert.DNSNames,
+		NotBefore:     cert.NotBefore,
+		NotAfter:      cert.NotAfter,
+		Serial:        fmt.Sprintf("%X", cert.SerialNumber),
+		DaysRemaining: int(math.Floor(time.Until(cert.NotAfter).Hours() / 24)),
+	}
+	if details.DNSNames == nil {
+		details.DNSNames = []string{}
+	}
+
+	if jsonOutput {
+		return output.JSON(details)
+	}
+
+	output.Print("Domain:      %s", details.Domain)
+	output.Print("Subject:     %s", details.Subject)
+	output.Print("Issuer:      %s", details.Issuer)
+	output.Print("Names:       %s", strings.Join(details.DNSNames, ", "))
+	output.Print("Valid from:  %s", details.NotBefore.Format(time.RFC3339))
+	output.Print("Valid until: %s", details.NotAfter.Format(time.RFC3339))
+	if details.DaysRemaining < 0 {
+		output.Print("Remaining:   expired %d day(s) ago", -details.DaysRemaining)
+	} else {
+		output.Print("Remaining:   %d day(s)", details.DaysRemaining)
+	}
+	output.Print("Serial:      %s", details.Serial)
+	output.Print("Certificate: %s", details.CertPath)
+	if details.KeyExists {
+		output.Print("Key:         %s", details.KeyPath)
+	} else {
+		output.Print("Key:         %s (missing)", details.KeyPath)
+	}
 	return nil
 }
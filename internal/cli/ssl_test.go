@@ -0,0 +1,853 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/executor"
+	"github.com/ksyq12/vhost/internal/ssl"
+)
+
+// certbotDomain returns the -d argument of a certbot call
+func certbotDomain(args []string) string {
+	for i, arg := range args {
+		if arg == "-d" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func setupSSLInstallAll(t *testing.T) (*config.Config, *driver.MockDriver, *executor.MockExecutor) {
+	t.Helper()
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+	mockDrv.IsEnabledFunc = func(domain string) (bool, error) {
+		return domain != "disabled.com", nil
+	}
+
+	cfg := config.New()
+	cfg.VHosts["static.com"] = &config.VHost{Domain: "static.com", Type: "static", Root: "/var/www/static", Enabled: true}
+	cfg.VHosts["laravel.com"] = &config.VHost{Domain: "laravel.com", Type: "laravel", Root: "/var/www/laravel", PHPVersion: "8.2", Enabled: true}
+	cfg.VHosts["proxy.com"] = &config.VHost{Domain: "proxy.com", Type: "proxy", ProxyPass: "http://localhost:3000", Enabled: true}
+	cfg.VHosts["secure.com"] = &config.VHost{Domain: "secure.com", Type: "static", Root: "/var/www/secure", SSL: true, Enabled: true}
+	cfg.VHosts["disabled.com"] = &config.VHost{Domain: "disabled.com", Type: "static", Root: "/var/www/disabled"}
+	cfg.VHosts["broken.com"] = &config.VHost{Domain: "broken.com", Type: "static", Root: "/var/www/broken", Enabled: true}
+
+	mock := &executor.MockExecutor{
+		LookPathFunc: func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		},
+		ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+			if certbotDomain(args) == "broken.com" {
+				return []byte("Challenge failed"), errors.New("exit status 1")
+			}
+			return nil, nil
+		},
+	}
+	ssl.SetExecutor(mock)
+	t.Cleanup(ssl.ResetExecutor)
+
+	return cfg, mockDrv, mock
+}
+
+func TestRunSSLInstallAll(t *testing.T) {
+	cfg, mockDrv, mock := setupSSLInstallAll(t)
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	sslInstallAll = true
+	sslEmail = "admin@example.com"
+	defer func() {
+		sslInstallAll = false
+		sslEmail = ""
+	}()
+
+	err := runSSLInstall(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed for 1 vhost") {
+		t.Fatalf("expected summary error for the failed domain, got %v", err)
+	}
+
+	// One certbot run per eligible domain, using the served directory as webroot
+	webroots := map[string]string{}
+	for _, call := range mock.Calls {
+		if call.Name != "certbot" {
+			t.Errorf("unexpected command %s", call.Name)
+			continue
+		}
+		domain := certbotDomain(call.Args)
+		if _, dup := webroots[domain]; dup {
+			t.Errorf("certbot invoked more than once for %s", domain)
+		}
+		for i, arg := range call.Args {
+			if arg == "-w" {
+				webroots[domain] = call.Args[i+1]
+			}
+		}
+	}
+	want := map[string]string{
+		"broken.com":  "/var/www/broken",
+		"laravel.com": "/var/www/laravel/public",
+		"static.com":  "/var/www/static",
+	}
+	if len(webroots) != len(want) {
+		t.Errorf("expected certbot for %v, got %v", want, webroots)
+	}
+	for domain, root := range want {
+		if webroots[domain] != root {
+			t.Errorf("expected webroot %s for %s, got %q", root, domain, webroots[domain])
+		}
+	}
+
+	for _, domain := range []string{"static.com", "laravel.com"} {
+		if !cfg.VHosts[domain].SSL {
+			t.Errorf("%s should have SSL enabled", domain)
+		}
+	}
+	for _, domain := range []string{"broken.com", "proxy.com", "disabled.com"} {
+		if cfg.VHosts[domain].SSL {
+			t.Errorf("%s should not have SSL enabled", domain)
+		}
+	}
+
+	if len(mockDrv.AddCalls) != 2 {
+		t.Errorf("expected 2 config updates, got %d", len(mockDrv.AddCalls))
+	}
+	if mockDrv.TestCalls != 1 || mockDrv.ReloadCalls != 1 {
+		t.Errorf("expected a single test and reload, got %d and %d", mockDrv.TestCalls, mockDrv.ReloadCalls)
+	}
+}
+
+func TestRunSSLInstallAllNoReload(t *testing.T) {
+	cfg, mockDrv, _ := setupSSLInstallAll(t)
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	sslInstallAll = true
+	sslEmail = "admin@example.com"
+	noReload = true
+	defer func() {
+		sslInstallAll = false
+		sslEmail = ""
+		noReload = false
+	}()
+
+	_ = runSSLInstall(nil, nil)
+
+	if !cfg.VHosts["static.com"].SSL {
+		t.Error("static.com should have SSL enabled")
+	}
+	if mockDrv.TestCalls != 1 || mockDrv.ReloadCalls != 0 {
+		t.Errorf("expected a test and no reload, got %d and %d", mockDrv.TestCalls, mockDrv.ReloadCalls)
+	}
+}
+
+func TestRunSSLInstallAllDryRun(t *testing.T) {
+	cfg, mockDrv, mock := setupSSLInstallAll(t)
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	sslInstallAll = true
+	sslStaging = true
+	sslEmail = "admin@example.com"
+	dryRun = true
+	jsonOutput = true
+	defer func() {
+		sslInstallAll = false
+		sslStaging = false
+		sslEmail = ""
+		dryRun = false
+		jsonOutput = false
+		ssl.SetStaging(false)
+	}()
+
+	var err error
+	out := captureStdout(func() {
+		err = runSSLInstall(nil, nil)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.Calls) != 0 {
+		t.Errorf("dry-run should not run certbot, got %v", mock.Calls)
+	}
+	if len(mockDrv.AddCalls) != 0 {
+		t.Errorf("dry-run should not change configs, got %d Add calls", len(mockDrv.AddCalls))
+	}
+	for _, want := range []string{"static.com", "laravel.com", "(staging)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in dry-run output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "proxy.com") {
+		t.Errorf("proxy vhost should not be in dry-run output:\n%s", out)
+	}
+}
+
+func TestRunSSLInstallDryRun(t *testing.T) {
+	cfg, mockDrv, mock := setupSSLInstallAll(t)
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	sslEmail = "admin@example.com"
+	dryRun = true
+	jsonOutput = true
+	defer func() {
+		sslEmail = ""
+		dryRun = false
+		jsonOutput = false
+	}()
+
+	var err error
+	out := captureStdout(func() {
+		err = runSSLInstall(nil, []string{"static.com"})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.Calls) != 0 {
+		t.Errorf("dry-run should not run certbot, got %v", mock.Calls)
+	}
+	if len(mockDrv.AddCalls) != 0 || mockDrv.ReloadCalls != 0 {
+		t.Errorf("dry-run should not change configs, got %d Add and %d Reload calls", len(mockDrv.AddCalls), mockDrv.ReloadCalls)
+	}
+	var result DryRunResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if !result.DryRun || result.Domain != "static.com" || len(result.Operations) == 0 || result.Operations[0].Action != "issue_certificate" {
+		t.Errorf("expected a certificate plan for static.com, got %+v", result)
+	}
+}
+
+func TestRunSSLInstallRollback(t *testing.T) {
+	tests := []struct {
+		name       string
+		disabled   bool
+		testErr    error
+		reloadErr  error
+		wantReload int
+	}{
+		{"config test fails", false, errors.New("invalid ssl_certificate"), nil, 0},
+		{"reload fails", false, nil, errors.New("reload failed"), 2},
+		{"disabled vhost stays disabled", true, errors.New("invalid ssl_certificate"), nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			availableDir := filepath.Join(tempDir, "sites-available")
+			if err := os.MkdirAll(availableDir, 0755); err != nil {
+				t.Fatalf("failed to create dir: %v", err)
+			}
+			original := "server { listen 80; }"
+			if err := os.WriteFile(filepath.Join(availableDir, "test.com"), []byte(original), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			mockDrv := driver.NewMockDriver("nginx", availableDir, filepath.Join(tempDir, "sites-enabled"))
+			mockDrv.IsEnabledFunc = func(domain string) (bool, error) { return !tt.disabled, nil }
+			mockDrv.TestFunc = func() error { return tt.testErr }
+			reloads := 0
+			mockDrv.ReloadFunc = func() error {
+				reloads++
+				if reloads == 1 {
+					return tt.reloadErr
+				}
+				return nil
+			}
+
+			cfg := config.New()
+			cfg.VHosts["test.com"] = &config.VHost{Domain: "test.com", Type: "static", Root: "/var/www/test", Enabled: true}
+
+			ssl.SetExecutor(&executor.MockExecutor{
+				LookPathFunc: func(file string) (string, error) {
+					return "/usr/bin/" + file, nil
+				},
+				// A plugin that edits the file while validating must not
+				// end up in the backup
+				ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+					if name == "certbot" {
+						_ = os.WriteFile(filepath.Join(availableDir, "test.com"), []byte("# certbot edit"), 0644)
+					}
+					return nil, nil
+				},
+			})
+			defer ssl.ResetExecutor()
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+			defer func() { deps = oldDeps }()
+
+			sslEmail = "admin@example.com"
+			defer func() { sslEmail = "" }()
+
+			if err := runSSLInstall(nil, []string{"test.com"}); err == nil {
+				t.Fatal("expected error")
+			}
+
+			vhost := cfg.VHosts["test.com"]
+			if vhost.SSL || vhost.SSLCert != "" || vhost.SSLKey != "" {
+				t.Errorf("SSL fields should be reset, got SSL=%v cert=%q key=%q", vhost.SSL, vhost.SSLCert, vhost.SSLKey)
+			}
+
+			// The SSL config is written, then the original is put back
+			if len(mockDrv.AddCalls) != 2 {
+				t.Fatalf("expected 2 Add calls, got %d", len(mockDrv.AddCalls))
+			}
+			if !strings.Contains(mockDrv.AddCalls[0].Content, "ssl") {
+				t.Error("first Add should write the SSL config")
+			}
+			if got := mockDrv.AddCalls[1].Content; got != original {
+				t.Errorf("expected original config restored, got %q", got)
+			}
+			if reloads != tt.wantReload {
+				t.Errorf("expected %d reloads, got %d", tt.wantReload, reloads)
+			}
+
+			// Both the SSL config and the restored one keep the enabled state
+			wantEnables := 2
+			if tt.disabled {
+				wantEnables = 0
+			}
+			if len(mockDrv.EnableCalls) != wantEnables {
+				t.Errorf("expected %d Enable calls, got %v", wantEnables, mockDrv.EnableCalls)
+			}
+		})
+	}
+}
+
+func TestRunSSLInstallCopyTo(t *testing.T) {
+	tempDir := t.TempDir()
+	liveDir := filepath.Join(tempDir, "live")
+	if err := os.MkdirAll(filepath.Join(liveDir, "test.com"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, perm := range map[string]os.FileMode{"fullchain.pem": 0644, "privkey.pem": 0600} {
+		if err := os.WriteFile(filepath.Join(liveDir, "test.com", name), []byte(name), perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ssl.SetLiveDir(liveDir)
+	defer ssl.ResetLiveDir()
+
+	ssl.SetExecutor(&executor.MockExecutor{
+		LookPathFunc: func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		},
+	})
+	defer ssl.ResetExecutor()
+
+	mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+	cfg := config.New()
+	cfg.VHosts["test.com"] = &config.VHost{Domain: "test.com", Type: "static", Root: "/var/www/test", Enabled: true}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	copyDir := filepath.Join(tempDir, "certs")
+	sslEmail, sslCopyTo = "admin@example.com", copyDir
+	defer func() { sslEmail, sslCopyTo = "", "" }()
+
+	if err := runSSLInstall(nil, []string{"test.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	vhost := cfg.VHosts["test.com"]
+	wantCert := filepath.Join(copyDir, "test.com", "fullchain.pem")
+	wantKey := filepath.Join(copyDir, "test.com", "privkey.pem")
+	if vhost.SSLCert != wantCert || vhost.SSLKey != wantKey {
+		t.Errorf("expected vhost to use the copies, got cert=%s key=%s", vhost.SSLCert, vhost.SSLKey)
+	}
+	if len(mockDrv.AddCalls) != 1 || !strings.Contains(mockDrv.AddCalls[0].Content, wantKey) {
+		t.Errorf("expected rendered config to reference %s", wantKey)
+	}
+	for path, perm := range map[string]os.FileMode{wantCert: 0644, wantKey: 0600} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("expected %s to be copied: %v", path, err)
+		}
+		if info.Mode().Perm() != perm {
+			t.Errorf("%s: expected mode %o, got %o", path, perm, info.Mode().Perm())
+		}
+	}
+
+	t.Run("relative dir", func(t *testing.T) {
+		sslCopyTo = "certs"
+		if err := runSSLInstall(nil, []string{"test.com"}); err == nil || !strings.Contains(err.Error(), "--copy-to must be an absolute path") {
+			t.Errorf("expected absolute path error, got %v", err)
+		}
+	})
+}
+
+func TestRunSSLInstallAliases(t *testing.T) {
+	tests := []struct {
+		name      string
+		noAliases bool
+		want      []string
+	}{
+		{"aliases included", false, []string{"test.com", "www.test.com", "api.test.com"}},
+		{"--no-aliases", true, []string{"test.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+			cfg := config.New()
+			cfg.VHosts["test.com"] = &config.VHost{Domain: "test.com", Type: "static", Root: "/var/www/test", Aliases: []string{"www.test.com", "api.test.com"}, Enabled: true}
+
+			mock := &executor.MockExecutor{
+				LookPathFunc: func(file string) (string, error) {
+					return "/usr/bin/" + file, nil
+				},
+			}
+			ssl.SetExecutor(mock)
+			defer ssl.ResetExecutor()
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+			defer func() { deps = oldDeps }()
+
+			sslEmail, sslNoAliases = "admin@example.com", tt.noAliases
+			defer func() { sslEmail, sslNoAliases = "", false }()
+
+			if err := runSSLInstall(nil, []string{"test.com"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(mock.Calls) != 1 {
+				t.Fatalf("expected one certbot call, got %v", mock.Calls)
+			}
+			var got []string
+			args := mock.Calls[0].Args
+			for i, arg := range args {
+				if arg == "-d" && i+1 < len(args) {
+					got = append(got, args[i+1])
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected -d %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunSSLRenewForce(t *testing.T) {
+	for _, force := range []bool{true, false} {
+		t.Run(fmt.Sprintf("force=%v", force), func(t *testing.T) {
+			mock := &executor.MockExecutor{
+				LookPathFunc: func(file string) (string, error) {
+					return "/usr/bin/" + file, nil
+				},
+			}
+			ssl.SetExecutor(mock)
+			defer ssl.ResetExecutor()
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(config.New()).Build()
+			defer func() { deps = oldDeps }()
+
+			renewForce = force
+			defer func() { renewForce = false }()
+
+			if err := runSSLRenew(nil, []string{"example.com"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(mock.Calls) != 1 {
+				t.Fatalf("expected one certbot call, got %v", mock.Calls)
+			}
+			if got := slices.Contains(mock.Calls[0].Args, "--force-renewal"); got != force {
+				t.Errorf("expected --force-renewal present=%v, got args %v", force, mock.Calls[0].Args)
+			}
+		})
+	}
+
+	t.Run("with --all", func(t *testing.T) {
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(config.New()).Build()
+		defer func() { deps = oldDeps }()
+
+		ssl.SetExecutor(&executor.MockExecutor{
+			LookPathFunc: func(file string) (string, error) {
+				return "/usr/bin/" + file, nil
+			},
+		})
+		defer ssl.ResetExecutor()
+
+		renewAll, renewForce = true, true
+		defer func() { renewAll, renewForce = false, false }()
+
+		if err := runSSLRenew(nil, nil); err == nil || !strings.Contains(err.Error(), "pass a domain") {
+			t.Errorf("expected --force to be refused with --all, got %v", err)
+		}
+	})
+}
+
+func TestRunSSLRenewCopies(t *testing.T) {
+	tempDir := t.TempDir()
+	liveDir := filepath.Join(tempDir, "live")
+	copyDir := filepath.Join(tempDir, "certs")
+	for _, domain := range []string{"copied.com", "live.com"} {
+		if err := os.MkdirAll(filepath.Join(liveDir, domain), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"fullchain.pem", "privkey.pem"} {
+			if err := os.WriteFile(filepath.Join(liveDir, domain, name), []byte("renewed "+name), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	ssl.SetLiveDir(liveDir)
+	defer ssl.ResetLiveDir()
+
+	ssl.SetExecutor(&executor.MockExecutor{
+		LookPathFunc: func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		},
+	})
+	defer ssl.ResetExecutor()
+
+	newDeps := func() (*driver.MockDriver, *config.Config) {
+		mockDrv := driver.NewMockDriver("nginx", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+		live := ssl.GetCertPaths("live.com")
+		cfg := config.New()
+		cfg.VHosts["copied.com"] = &config.VHost{Domain: "copied.com", Type: "static", SSL: true,
+			SSLCert: filepath.Join(copyDir, "copied.com", "fullchain.pem"), SSLKey: filepath.Join(copyDir, "copied.com", "privkey.pem")}
+		cfg.VHosts["live.com"] = &config.VHost{Domain: "live.com", Type: "static", SSL: true, SSLCert: live.CertPath, SSLKey: live.KeyPath}
+		deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).Build()
+		return mockDrv, cfg
+	}
+	oldDeps := deps
+	defer func() { deps = oldDeps }()
+
+	t.Run("copies refreshed and server reloaded", func(t *testing.T) {
+		mockDrv, _ := newDeps()
+		_ = os.RemoveAll(copyDir)
+
+		if err := runSSLRenew(nil, []string{"copied.com"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := os.ReadFile(filepath.Join(copyDir, "copied.com", "fullchain.pem"))
+		if err != nil || string(got) != "renewed fullchain.pem" {
+			t.Errorf("expected the renewed chain copied, got %q (%v)", got, err)
+		}
+		if mockDrv.ReloadCalls != 1 {
+			t.Errorf("expected one reload, got %d", mockDrv.ReloadCalls)
+		}
+	})
+
+	t.Run("live certificates need no copy", func(t *testing.T) {
+		mockDrv, _ := newDeps()
+		if err := runSSLRenew(nil, []string{"live.com"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(copyDir, "live.com")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected no copy for live.com, got %v", err)
+		}
+		if mockDrv.ReloadCalls != 0 {
+			t.Errorf("expected no reload, got %d", mockDrv.ReloadCalls)
+		}
+	})
+
+	t.Run("--all with --no-reload", func(t *testing.T) {
+		mockDrv, _ := newDeps()
+		_ = os.RemoveAll(copyDir)
+		renewAll, noReload = true, true
+		defer func() { renewAll, noReload = false, false }()
+
+		if err := runSSLRenew(nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(copyDir, "copied.com", "privkey.pem")); err != nil {
+			t.Errorf("expected the key copied: %v", err)
+		}
+		if mockDrv.ReloadCalls != 0 {
+			t.Errorf("expected --no-reload to skip the reload, got %d", mockDrv.ReloadCalls)
+		}
+	})
+}
+
+func TestRunSSLInstallMethod(t *testing.T) {
+	tests := []struct {
+		name     string
+		driver   string
+		method   string
+		vhost    *config.VHost
+		wantArgs string // prefix of the certbot arguments; "" expects no certbot run
+		wantErr  string
+	}{
+		{name: "nginx default", driver: "nginx", wantArgs: "certonly --nginx -d test.com"},
+		{name: "apache default", driver: "apache", wantArgs: "certonly --apache -d test.com"},
+		{name: "traefik default", driver: "traefik", wantArgs: "certonly --webroot -w /var/www/test -d test.com"},
+		{name: "caddy default", driver: "caddy"},
+		{name: "webroot", driver: "nginx", method: "webroot", wantArgs: "certonly --webroot -w /var/www/test -d test.com"},
+		{name: "webroot laravel", driver: "nginx", method: "webroot", vhost: &config.VHost{Domain: "test.com", Type: "laravel", Root: "/var/www/test"}, wantArgs: "certonly --webroot -w /var/www/test/public"},
+		{name: "standalone", driver: "apache", method: "standalone", wantArgs: "certonly --standalone -d test.com"},
+		{name: "dns", driver: "nginx", method: "dns", wantArgs: "certonly --dns-cloudflare --dns-cloudflare-credentials /root/cf.ini -d test.com"},
+		{name: "method on caddy", driver: "caddy", method: "dns", wantErr: "caddy obtains certificates itself"},
+		{name: "plugin for other driver", driver: "apache", method: "nginx", wantErr: "requires the nginx driver"},
+		{name: "unknown method", driver: "nginx", method: "http", wantErr: "invalid --method"},
+		{name: "webroot without root", driver: "nginx", method: "webroot", vhost: &config.VHost{Domain: "test.com", Type: "proxy", ProxyPass: "http://localhost:3000"}, wantErr: "no document root"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			mockDrv := driver.NewMockDriver(tt.driver, filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+			vhost := tt.vhost
+			if vhost == nil {
+				vhost = &config.VHost{Domain: "test.com", Type: "static", Root: "/var/www/test"}
+			}
+			vhost.Enabled = true
+			cfg := config.New()
+			cfg.VHosts["test.com"] = vhost
+
+			var calls [][]string
+			ssl.SetExecutor(&executor.MockExecutor{
+				LookPathFunc: func(file string) (string, error) {
+					return "/usr/bin/" + file, nil
+				},
+				ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+					calls = append(calls, args)
+					return nil, nil
+				},
+			})
+			defer ssl.ResetExecutor()
+
+			oldDeps := deps
+			deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+			defer func() { deps = oldDeps }()
+
+			sslEmail, sslMethod, sslDNSPlugin, sslDNSCreds = "admin@example.com", tt.method, "cloudflare", "/root/cf.ini"
+			defer func() { sslEmail, sslMethod, sslDNSPlugin, sslDNSCreds = "", "", "", "" }()
+
+			err := runSSLInstall(nil, []string{"test.com"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if len(calls) != 0 {
+					t.Errorf("certbot should not run, got %v", calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantArgs == "" {
+				if len(calls) != 0 {
+					t.Errorf("expected no certbot run, got %v", calls)
+				}
+			} else if len(calls) != 1 || !strings.HasPrefix(strings.Join(calls[0], " "), tt.wantArgs) {
+				t.Errorf("expected certbot %q..., got %v", tt.wantArgs, calls)
+			}
+			if !vhost.SSL {
+				t.Error("expected SSL enabled on the vhost")
+			}
+		})
+	}
+}
+
+func TestRunSSLInstallCaddy(t *testing.T) {
+	tempDir := t.TempDir()
+	mockDrv := driver.NewMockDriver("caddy", filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+	cfg := config.New()
+	cfg.VHosts["test.com"] = &config.VHost{Domain: "test.com", Type: "static", Root: "/var/www/test", Enabled: true}
+
+	// Neither a certbot lookup nor any command may run
+	mock := &executor.MockExecutor{
+		LookPathFunc: func(file string) (string, error) {
+			t.Errorf("unexpected lookup of %s", file)
+			return "", errors.New("not found")
+		},
+		ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+			t.Errorf("unexpected command: %s %v", name, args)
+			return nil, errors.New("unexpected command")
+		},
+	}
+	ssl.SetExecutor(mock)
+	defer ssl.ResetExecutor()
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(cfg).WithDriver(mockDrv).WithRootAccess(true).Build()
+	defer func() { deps = oldDeps }()
+
+	// No --email: caddy registers with Let's Encrypt itself
+	sslEmail = ""
+
+	if err := runSSLInstall(nil, []string{"test.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	vhost := cfg.VHosts["test.com"]
+	if !vhost.SSL || vhost.SSLCert != "" || vhost.SSLKey != "" {
+		t.Errorf("expected SSL without certbot paths, got SSL=%v cert=%q key=%q", vhost.SSL, vhost.SSLCert, vhost.SSLKey)
+	}
+	if len(mockDrv.AddCalls) != 1 {
+		t.Fatalf("expected the caddy config re-rendered once, got %d Add calls", len(mockDrv.AddCalls))
+	}
+	if content := mockDrv.AddCalls[0].Content; !strings.HasPrefix(content, "test.com {") {
+		t.Errorf("expected an HTTPS site address, got:\n%s", content)
+	}
+	if mockDrv.ReloadCalls != 1 {
+		t.Errorf("expected 1 reload, got %d", mockDrv.ReloadCalls)
+	}
+}
+
+func TestRunSSLStatusJSON(t *testing.T) {
+	ssl.SetExecutor(&executor.MockExecutor{
+		LookPathFunc: func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		},
+		ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+			return []byte("  Certificate Name: example.com\n    Domains: example.com www.example.com\n    Expiry Date: 2024-05-15 (VALID: 89 days)"), nil
+		},
+	})
+	defer ssl.ResetExecutor()
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+
+	var runErr error
+	out := captureStdout(func() { runErr = runSSLStatus(nil, nil) })
+	if runErr != nil {
+		t.Fatalf("unexpected error: %v", runErr)
+	}
+
+	var certs []ssl.CertInfo
+	if err := json.Unmarshal([]byte(out), &certs); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if len(certs) != 1 || certs[0].Name != "example.com" || len(certs[0].Domains) != 2 || certs[0].ValidDays != 89 || certs[0].ExpiryDate.IsZero() {
+		t.Errorf("unexpected certificates %+v", certs)
+	}
+}
+
+func TestRunSSLStatusDomain(t *testing.T) {
+	liveDir := t.TempDir()
+	ssl.SetLiveDir(liveDir)
+	defer ssl.ResetLiveDir()
+
+	// Lay the test certificate out the way certbot does
+	certDir := filepath.Join(liveDir, "example.com")
+	if err := os.MkdirAll(certDir, 0755); err != nil {
+		t.Fatalf("failed to create cert dir: %v", err)
+	}
+	certPath, keyPath := writeTestCert(t, certDir, "site", "example.com", "www.example.com")
+	if err := os.Rename(certPath, filepath.Join(certDir, "fullchain.pem")); err != nil {
+		t.Fatalf("failed to move cert: %v", err)
+	}
+	if err := os.Rename(keyPath, filepath.Join(certDir, "privkey.pem")); err != nil {
+		t.Fatalf("failed to move key: %v", err)
+	}
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(config.New()).Build()
+	defer func() { deps = oldDeps }()
+
+	t.Run("vhost certificate", func(t *testing.T) {
+		copyDir := t.TempDir()
+		copyCert, copyKey := writeTestCert(t, copyDir, "copy", "app.example.com")
+		cfg := config.New()
+		cfg.VHosts["app.example.com"] = &config.VHost{Domain: "app.example.com", Type: "static", SSL: true, SSLCert: copyCert, SSLKey: copyKey}
+		deps = NewMockDeps().WithConfig(cfg).Build()
+		defer func() { deps = NewMockDeps().WithConfig(config.New()).Build() }()
+
+		out := captureStdout(func() {
+			if err := runSSLStatus(nil, []string{"app.example.com"}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+		if !strings.Contains(out, "Certificate: "+copyCert) || !strings.Contains(out, "Names:       app.example.com") {
+			t.Errorf("expected the vhost's certificate, got:\n%s", out)
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		var err error
+		out := captureStdout(func() {
+			err = runSSLStatus(nil, []string{"example.com"})
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, want := range []string{
+			"Subject:     CN=example.com",
+			"Issuer:      CN=example.com",
+			"Names:       example.com, www.example.com",
+			"Remaining:   0 day(s)",
+			"Serial:      1",
+			"Key:         " + filepath.Join(certDir, "privkey.pem") + "\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected %q in output:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		jsonOutput = true
+		defer func() { jsonOutput = false }()
+
+		var err error
+		out := captureStdout(func() {
+			err = runSSLStatus(nil, []string{"example.com"})
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var details sslCertDetails
+		if err := json.Unmarshal([]byte(out), &details); err != nil {
+			t.Fatalf("invalid JSON %q: %v", out, err)
+		}
+		if details.Subject != "CN=example.com" || len(details.DNSNames) != 2 || !details.KeyExists || details.Serial != "1" {
+			t.Errorf("unexpected details: %+v", details)
+		}
+		if details.NotAfter.Before(details.NotBefore) {
+			t.Errorf("expected NotAfter after NotBefore, got %+v", details)
+		}
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		if err := os.Remove(filepath.Join(certDir, "privkey.pem")); err != nil {
+			t.Fatalf("failed to remove key: %v", err)
+		}
+		out := captureStdout(func() {
+			_ = runSSLStatus(nil, []string{"example.com"})
+		})
+		if !strings.Contains(out, "(missing)") {
+			t.Errorf("expected missing key in output:\n%s", out)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		err := runSSLStatus(nil, []string{"other.com"})
+		if err == nil || !strings.Contains(err.Error(), "no certificate found for other.com") {
+			t.Errorf("expected not-found error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+
+	vherrors "github.com/ksyq12/vhost/internal/errors"
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/ksyq12/vhost/internal/template"
+	"github.com/spf13/cobra"
+)
+
+var (
+	templateDirFlag string
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Configuration template management",
+	Long:  `Inspect and validate the templates used to render vhost configurations.`,
+}
+
+var templateValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate embedded and custom templates",
+	Long: `Parse every embedded template and every override in the custom template
+directory, and render each against a sample vhost to catch errors early.
+
+Override templates are laid out as <dir>/<driver>/<type>.tmpl. The directory
+defaults to template_dir from the config file.
+
+Examples:
+  vhost template validate
+  vhost template validate --template-dir /etc/vhost/templates`,
+	Args: cobra.NoArgs,
+	RunE: runTemplateValidate,
+}
+
+func init() {
+	templateValidateCmd.Flags().StringVar(&templateDirFlag, "template-dir", "", "Directory containing override templates")
+
+	templateCmd.AddCommand(templateValidateCmd)
+
+	rootCmd.AddCommand(templateCmd)
+}
+
+// templateValidateResult is the JSON result of template validation
+type templateValidateResult struct {
+	Success bool     `json:"success"`
+	Errors  []string `json:"errors"`
+}
+
+func runTemplateValidate(cmd *cobra.Command, args []string) error {
+	dir := templateDirFlag
+	if dir == "" {
+		cfg, err := deps.ConfigLoader.Load()
+		if err != nil {
+			return vherrors.Wrap(vherrors.ErrCodeConfig, "failed to load config", err)
+		}
+		dir = cfg.TemplateDir
+	}
+
+	template.SetTemplateDir(dir)
+	errs := template.Validate()
+
+	result := templateValidateResult{
+		Success: len(errs) == 0,
+		Errors:  make([]string, 0, len(errs)),
+	}
+	for _, err := range errs {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	if jsonOutput {
+		if err := output.JSON(result); err != nil {
+			return err
+		}
+	} else {
+		for _, msg := range result.Errors {
+			output.Error("%s", msg)
+		}
+	}
+
+	if len(errs) > 0 {
+		return reported(fmt.Errorf("%d template(s) failed validation", len(errs)))
+	}
+
+	if !jsonOutput {
+		output.Success("All templates are valid")
+	}
+	return nil
+}
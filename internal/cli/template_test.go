@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/template"
+)
+
+func TestRunTemplateValidate(t *testing.T) {
+	defer template.SetTemplateDir("")
+
+	t.Run("embedded templates pass", func(t *testing.T) {
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(config.New()).Build()
+		defer func() { deps = oldDeps }()
+
+		templateDirFlag = ""
+		if err := runTemplateValidate(nil, nil); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("broken template from config dir fails", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "apache"), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "apache", "static.tmpl"), []byte("{{ if .SSL }}"), 0644); err != nil {
+			t.Fatalf("failed to write template: %v", err)
+		}
+
+		cfg := config.New()
+		cfg.TemplateDir = dir
+
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).Build()
+		defer func() { deps = oldDeps }()
+
+		templateDirFlag = ""
+		err := runTemplateValidate(nil, nil)
+		if err == nil {
+			t.Fatal("expected validation error")
+		}
+		if !strings.Contains(err.Error(), "1 template(s)") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
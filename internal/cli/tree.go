@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ksyq12/vhost/internal/driver"
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var treeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Show how available configs map to enabled symlinks",
+	Long: `Print the web server's config directories as a tree.
+
+Each config in the available directory is listed with its state:
+
+  enabled        a symlink in the enabled directory points at it
+  disabled       nothing in the enabled directory links to it
+  dangling       its symlink points at a file that doesn't exist
+  elsewhere      its symlink points at a different file
+  copy           the enabled entry is an identical copy (activation_mode: copy)
+  stale copy     the enabled entry is a copy that differs from the config
+  not a symlink  the enabled entry is some other file, not a link
+
+Entries in the enabled directory with no matching config are listed
+after it, so stale or hand-made links stand out. Only the filesystem is
+read; config.yaml is not consulted.
+
+Examples:
+  vhost tree
+  vhost tree --json`,
+	Args: cobra.NoArgs,
+	RunE: runTree,
+}
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+}
+
+// Tree entry states
+const (
+	treeEnabled   = "enabled"
+	treeDisabled  = "disabled"
+	treeDangling  = "dangling"
+	treeElsewhere = "elsewhere"
+	treeCopy      = "copy"
+	treeStale     = "stale copy"
+	treeNotLink   = "not a symlink"
+)
+
+// treeEntry is one config file or enabled entry in the tree
+type treeEntry struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Available bool   `json:"available"`        // the name exists in the available directory
+	Link      string `json:"link,omitempty"`   // path of the enabled entry
+	Target    string `json:"target,omitempty"` // where the link points, as stored
+}
+
+// treeResult is the JSON output of the tree command
+type treeResult struct {
+	AvailableDir string      `json:"available_dir"`
+	EnabledDir   string      `json:"enabled_dir"`
+	Entries      []treeEntry `json:"entries"`
+}
+
+func runTree(cmd *cobra.Command, args []string) error {
+	_, drv, err := loadConfigAndDriver()
+	if err != nil {
+		return err
+	}
+
+	result, err := buildTree(drv.Paths())
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return output.JSON(result)
+	}
+	printTree(result)
+	return nil
+}
+
+// buildTree lists both config directories and correlates each available
+// config with the entry of the same name in the enabled directory
+func buildTree(paths driver.Paths) (*treeResult, error) {
+	result := &treeResult{AvailableDir: paths.Available, EnabledDir: paths.Enabled, Entries: []treeEntry{}}
+
+	available, err := treeDirNames(paths.Available)
+	if err != nil {
+		return nil, err
+	}
+
+	// Configs are enabled in place when both directories are the same
+	if filepath.Clean(paths.Available) == filepath.Clean(paths.Enabled) {
+		for _, name := range available {
+			result.Entries = append(result.Entries, treeEntry{Name: name, Status: treeEnabled, Available: true})
+		}
+		return result, nil
+	}
+
+	enabled, err := treeDirNames(paths.Enabled)
+	if err != nil {
+		return nil, err
+	}
+	isAvailable := make(map[string]bool, len(available))
+	for _, name := range available {
+		isAvailable[name] = true
+	}
+	isEnabled := make(map[string]bool, len(enabled))
+	for _, name := range enabled {
+		isEnabled[name] = true
+	}
+
+	for _, name := range available {
+		entry := treeEntry{Name: name, Status: treeDisabled, Available: true}
+		if isEnabled[name] {
+			inspectEnabledEntry(&entry, filepath.Join(paths.Enabled, name), filepath.Join(paths.Available, name))
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	for _, name := range enabled {
+		if isAvailable[name] {
+			continue
+		}
+		entry := treeEntry{Name: name}
+		inspectEnabledEntry(&entry, filepath.Join(paths.Enabled, name), "")
+		result.Entries = append(result.Entries, entry)
+	}
+	return result, nil
+}
+
+// inspectEnabledEntry sets entry's link, target and status from the
+// enabled entry at link. source is the available config it should point
+// at, or empty when there is none.
+func inspectEnabledEntry(entry *treeEntry, link, source string) {
+	entry.Link = link
+
+	info, err := os.Lstat(link)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		entry.Status = copyStatus(info, link, source)
+		return
+	}
+	entry.Target, _ = os.Readlink(link)
+
+	resolved, err := os.Stat(link)
+	if err != nil {
+		entry.Status = treeDangling
+		return
+	}
+	entry.Status = treeEnabled
+	if source == "" {
+		entry.Status = treeElsewhere
+		return
+	}
+	if sourceInfo, err := os.Stat(source); err != nil || !os.SameFile(resolved, sourceInfo) {
+		entry.Status = treeElsewhere
+	}
+}
+
+// copyStatus describes an enabled entry that isn't a symlink: a copy of
+// source, identical or stale, or some other file
+func copyStatus(info os.FileInfo, link, source string) string {
+	if info == nil || !info.Mode().IsRegular() || source == "" {
+		return treeNotLink
+	}
+	enabled, err := os.ReadFile(link)
+	if err != nil {
+		return treeNotLink
+	}
+	available, err := os.ReadFile(source)
+	if err != nil {
+		return treeNotLink
+	}
+	if bytes.Equal(enabled, available) {
+		return treeCopy
+	}
+	return treeStale
+}
+
+// treeDirNames returns the sorted names of the files and links in dir,
+// skipping hidden files and subdirectories. A missing dir has no entries.
+func treeDirNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// printTree prints the available configs, then any enabled entries that
+// don't match one
+func printTree(result *treeResult) {
+	var configs, unmatched []treeEntry
+	for _, entry := range result.Entries {
+		if entry.Available {
+			configs = append(configs, entry)
+		} else {
+			unmatched = append(unmatched, entry)
+		}
+	}
+
+	output.Print("%s", result.AvailableDir)
+	if len(configs) == 0 {
+		output.Print("└── (empty)")
+	}
+	printTreeEntries(configs)
+
+	if len(unmatched) > 0 {
+		output.Print("%s (no matching config)", result.EnabledDir)
+		printTreeEntries(unmatched)
+	}
+}
+
+// printTreeEntries prints entries as branches, each enabled entry with
+// its link as a child
+func printTreeEntries(entries []treeEntry) {
+	for i, entry := range entries {
+		branch, indent := "├── ", "│   "
+		if i == len(entries)-1 {
+			branch, indent = "└── ", "    "
+		}
+		output.Print("%s%s (%s)", branch, entry.Name, entry.Status)
+
+		switch {
+		case entry.Target != "":
+			output.Print("%s└── %s → %s", indent, entry.Link, entry.Target)
+		case entry.Link != "":
+			output.Print("%s└── %s", indent, entry.Link)
+		}
+	}
+}
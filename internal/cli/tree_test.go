@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/driver"
+)
+
+// setupTree creates config directories holding an enabled, a disabled and
+// a dangling vhost, plus a stale link with no config
+func setupTree(t *testing.T) *driver.MockDriver {
+	t.Helper()
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	enabledDir := filepath.Join(tempDir, "sites-enabled")
+	for _, dir := range []string{availableDir, enabledDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+
+	for _, name := range []string{"on.com", "off.com", "broken.com"} {
+		if err := os.WriteFile(filepath.Join(availableDir, name), []byte("server {}"), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+	links := map[string]string{
+		"on.com":     filepath.Join(availableDir, "on.com"),
+		"broken.com": filepath.Join(tempDir, "moved", "broken.com"),
+		"stale.com":  filepath.Join(availableDir, "stale.com"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(enabledDir, name)); err != nil {
+			t.Fatalf("failed to create symlink: %v", err)
+		}
+	}
+
+	return driver.NewMockDriver("nginx", availableDir, enabledDir)
+}
+
+func TestRunTree(t *testing.T) {
+	mockDrv := setupTree(t)
+	paths := mockDrv.Paths()
+
+	oldDeps := deps
+	deps = NewMockDeps().WithConfig(config.New()).WithDriver(mockDrv).Build()
+	defer func() { deps = oldDeps }()
+
+	t.Run("text", func(t *testing.T) {
+		var err error
+		out := captureStdout(func() { err = runTree(nil, nil) })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := strings.Join([]string{
+			paths.Available,
+			"├── broken.com (dangling)",
+			"│   └── " + filepath.Join(paths.Enabled, "broken.com") + " → " + filepath.Join(filepath.Dir(paths.Available), "moved", "broken.com"),
+			"├── off.com (disabled)",
+			"└── on.com (enabled)",
+			"    └── " + filepath.Join(paths.Enabled, "on.com") + " → " + filepath.Join(paths.Available, "on.com"),
+			paths.Enabled + " (no matching config)",
+			"└── stale.com (dangling)",
+			"    └── " + filepath.Join(paths.Enabled, "stale.com") + " → " + filepath.Join(paths.Available, "stale.com"),
+		}, "\n") + "\n"
+		if out != want {
+			t.Errorf("unexpected tree:\ngot:\n%s\nwant:\n%s", out, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		jsonOutput = true
+		defer func() { jsonOutput = false }()
+
+		var err error
+		out := captureStdout(func() { err = runTree(nil, nil) })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var result treeResult
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("invalid JSON %q: %v", out, err)
+		}
+		states := make(map[string]string)
+		for _, entry := range result.Entries {
+			states[entry.Name] = entry.Status
+		}
+		want := map[string]string{
+			"on.com":     treeEnabled,
+			"off.com":    treeDisabled,
+			"broken.com": treeDangling,
+			"stale.com":  treeDangling,
+		}
+		for name, status := range want {
+			if states[name] != status {
+				t.Errorf("%s: expected status %q, got %q", name, status, states[name])
+			}
+		}
+	})
+}
+
+func TestBuildTree(t *testing.T) {
+	t.Run("link to another file", func(t *testing.T) {
+		mockDrv := setupTree(t)
+		paths := mockDrv.Paths()
+		link := filepath.Join(paths.Enabled, "off.com")
+		if err := os.Symlink(filepath.Join(paths.Available, "on.com"), link); err != nil {
+			t.Fatalf("failed to create symlink: %v", err)
+		}
+
+		result, err := buildTree(paths)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, entry := range result.Entries {
+			if entry.Name == "off.com" && entry.Status != treeElsewhere {
+				t.Errorf("expected off.com to be %q, got %q", treeElsewhere, entry.Status)
+			}
+		}
+	})
+
+	t.Run("regular file in enabled directory", func(t *testing.T) {
+		tests := []struct {
+			content string
+			want    string
+		}{
+			{"server {}", treeCopy},
+			{"server { listen 8080; }", treeStale},
+		}
+		for _, tt := range tests {
+			mockDrv := setupTree(t)
+			paths := mockDrv.Paths()
+			if err := os.WriteFile(filepath.Join(paths.Enabled, "off.com"), []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(paths.Enabled, "extra.com"), []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			result, err := buildTree(paths)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, entry := range result.Entries {
+				if entry.Name == "off.com" && entry.Status != tt.want {
+					t.Errorf("expected off.com to be %q, got %q", tt.want, entry.Status)
+				}
+				// Without a config there is nothing to be a copy of
+				if entry.Name == "extra.com" && entry.Status != treeNotLink {
+					t.Errorf("expected extra.com to be %q, got %q", treeNotLink, entry.Status)
+				}
+			}
+		}
+	})
+
+	t.Run("same directory", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "a.com.conf"), []byte("server {}"), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+
+		result, err := buildTree(driver.Paths{Available: dir, Enabled: dir})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.Entries) != 1 || result.Entries[0].Status != treeEnabled {
+			t.Errorf("expected a.com.conf enabled in place, got %+v", result.Entries)
+		}
+	})
+}
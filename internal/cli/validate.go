@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ksyq12/vhost/internal/config"
+	vherrors "github.com/ksyq12/vhost/internal/errors"
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check config.yaml for broken references",
+	Long: `Statically check config.yaml without touching the web server.
+
+Unlike the web server's own config test, this checks what vhost records:
+  - SSL certificate and key files exist and the certificate is readable
+  - Document roots exist for static, php, laravel, and wordpress vhosts
+  - Proxy URLs parse
+  - No two vhosts claim the same domain or alias
+  - No two vhosts share an SSL certificate or key, unless it is one
+    certificate covering all of their names
+
+All problems are reported at once. The command exits non-zero if any
+error is found; warnings alone do not fail it.
+
+--fix re-keys entries whose key drifted from their domain field (e.g.
+after a manual edit), lowercasing the domain, and saves config.yaml. It
+changes nothing if two entries would end up with the same domain. Server
+config files are named after the domain, so rename them by hand if they
+follow the old key.
+
+Examples:
+  vhost validate
+  vhost validate --json
+  vhost validate --fix`,
+	Args: cobra.NoArgs,
+	RunE: runValidate,
+}
+
+var validateFix bool
+
+func init() {
+	validateCmd.Flags().BoolVar(&validateFix, "fix", false, "Re-key entries to match their domain field and save config.yaml")
+
+	rootCmd.AddCommand(validateCmd)
+}
+
+// ValidationIssue is a single problem found in config.yaml
+type ValidationIssue struct {
+	Domain   string `json:"domain"`
+	Severity string `json:"severity"` // "error" or "warning"
+	Message  string `json:"message"`
+}
+
+// validateResult represents the validate command output
+type validateResult struct {
+	Valid  bool              `json:"valid"`
+	Issues []ValidationIssue `json:"issues"`
+	Fixed  map[string]string `json:"fixed,omitempty"` // old key -> new key, with --fix
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	// Report structural problems as issues instead of failing to load
+	cfg, err := deps.ConfigLoader.LoadUnchecked()
+	if err != nil {
+		return vherrors.Wrap(vherrors.ErrCodeConfig, "failed to load config", err)
+	}
+
+	var fixed map[string]string
+	if validateFix {
+		fixed, err = cfg.Rekey()
+		if err != nil {
+			return fmt.Errorf("cannot fix config: %w", err)
+		}
+		if len(fixed) > 0 && !dryRun {
+			if err := saveConfig(cfg); err != nil {
+				return err
+			}
+		}
+		if !jsonOutput {
+			oldKeys := make([]string, 0, len(fixed))
+			for oldKey := range fixed {
+				oldKeys = append(oldKeys, oldKey)
+			}
+			sort.Strings(oldKeys)
+			for _, oldKey := range oldKeys {
+				output.Success("Re-keyed %s to %s", oldKey, fixed[oldKey])
+			}
+		}
+	}
+
+	issues := validateConfig(cfg)
+
+	errorCount := 0
+	for _, issue := range issues {
+		if issue.Severity == statusError {
+			errorCount++
+		}
+	}
+
+	if jsonOutput {
+		if err := output.JSON(validateResult{Valid: errorCount == 0, Issues: issues, Fixed: fixed}); err != nil {
+			return err
+		}
+	} else {
+		for _, issue := range issues {
+			if issue.Severity == statusError {
+				output.Error("%s: %s", issue.Domain, issue.Message)
+			} else {
+				output.Warn("%s: %s", issue.Domain, issue.Message)
+			}
+		}
+		if len(issues) == 0 {
+			output.Success("Config is valid (%d vhost(s) checked)", len(cfg.VHosts))
+		}
+	}
+
+	if errorCount > 0 {
+		return reported(fmt.Errorf("config validation found %d error(s)", errorCount))
+	}
+	return nil
+}
+
+// validateConfig checks every vhost in cfg and returns all issues, sorted by domain
+func validateConfig(cfg *config.Config) []ValidationIssue {
+	issues := []ValidationIssue{}
+
+	add := func(domain, severity, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{
+			Domain:   domain,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	keys := make([]string, 0, len(cfg.VHosts))
+	for key := range cfg.VHosts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// claimedBy maps each normalized domain or alias to the first config entry using it
+	claimedBy := make(map[string]string)
+
+	shared := findSharedSSLFiles(cfg)
+
+	for _, key := range keys {
+		vhost := cfg.VHosts[key]
+		if vhost == nil {
+			add(key, statusError, "entry is empty")
+			continue
+		}
+
+		if vhost.Domain != key {
+			add(key, statusError, "entry key does not match domain %q", vhost.Domain)
+		}
+		if err := validateDomain(vhost.Domain); err != nil {
+			add(key, statusError, "%v", err)
+		}
+
+		for i, name := range append([]string{vhost.Domain}, vhost.Aliases...) {
+			kind := "domain"
+			if i > 0 {
+				kind = "alias"
+			}
+			normalized := strings.ToLower(name)
+			if other, exists := claimedBy[normalized]; exists {
+				if other != key {
+					add(key, statusError, "%s %s is also claimed by %s", kind, name, other)
+				}
+				continue
+			}
+			claimedBy[normalized] = key
+		}
+
+		if !config.IsValidType(vhost.Type) && vhost.Type != config.TypeDefault {
+			add(key, statusError, "invalid type %q", vhost.Type)
+		}
+
+		switch vhost.Type {
+		case config.TypeStatic, config.TypePHP, config.TypeLaravel, config.TypeWordPress:
+			if vhost.Root == "" {
+				add(key, statusError, "root is required for type %s", vhost.Type)
+			} else if info, err := os.Stat(vhost.Root); err != nil {
+				add(key, statusError, "root directory missing: %s", vhost.Root)
+			} else if !info.IsDir() {
+				add(key, statusError, "root is not a directory: %s", vhost.Root)
+			}
+		case config.TypeProxy:
+			if vhost.ProxyPass == "" {
+				add(key, statusError, "proxy_pass is required for type proxy")
+			} else if err := validateProxyURL(vhost.ProxyPass); err != nil {
+				add(key, statusError, "%v", err)
+			}
+		case config.TypeRedirect:
+			if vhost.RedirectURL == "" {
+				add(key, statusError, "redirect_url is required for type redirect")
+			} else if err := validateRedirectURL(vhost.RedirectURL); err != nil {
+				add(key, statusError, "%v", err)
+			}
+		}
+
+		if vhost.SSL {
+			issues = append(issues, validateSSLFiles(key, vhost)...)
+
+			for _, file := range shared {
+				if others := slices.DeleteFunc(slices.Clone(file.Domains), func(d string) bool { return d == key }); len(others) < len(file.Domains) {
+					add(key, statusWarning, "SSL %s %s is also used by %s", file.Kind, file.Path, strings.Join(others, ", "))
+				}
+			}
+		}
+	}
+
+	return issues
+}
+
+// validateSSLFiles checks that an SSL vhost's certificate and key are usable
+func validateSSLFiles(domain string, vhost *config.VHost) []ValidationIssue {
+	var (
+		issues []ValidationIssue
+		certOK bool
+	)
+
+	add := func(severity, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{
+			Domain:   domain,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if vhost.SSLCert == "" {
+		add(statusError, "ssl is enabled but ssl_cert is not set")
+	} else if _, err := os.Stat(vhost.SSLCert); err != nil {
+		add(statusError, "SSL certificate missing: %s", vhost.SSLCert)
+	} else if cert, err := readCertificate(vhost.SSLCert); err != nil {
+		add(statusError, "SSL certificate unreadable: %v", err)
+	} else {
+		certOK = true
+		if time.Now().After(cert.NotAfter) {
+			add(statusWarning, "SSL certificate expired on %s", cert.NotAfter.Format("2006-01-02"))
+		}
+		if missing := uncoveredNames(cert, vhost); len(missing) > 0 {
+			add(statusError, "SSL certificate does not cover %s", strings.Join(missing, ", "))
+		}
+	}
+
+	if vhost.SSLKey == "" {
+		add(statusError, "ssl is enabled but ssl_key is not set")
+	} else if _, err := os.Stat(vhost.SSLKey); err != nil {
+		add(statusError, "SSL key missing: %s", vhost.SSLKey)
+	} else if certOK {
+		if err := checkKeyPair(vhost.SSLCert, vhost.SSLKey); err != nil {
+			add(statusError, "%v", err)
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	existingRoot := filepath.Join(tempDir, "www")
+	if err := os.MkdirAll(existingRoot, 0755); err != nil {
+		t.Fatalf("failed to create root: %v", err)
+	}
+	badCert := filepath.Join(tempDir, "bad.pem")
+	if err := os.WriteFile(badCert, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("failed to write cert: %v", err)
+	}
+
+	cfg := config.New()
+	cfg.VHosts["ok.com"] = &config.VHost{Domain: "ok.com", Type: config.TypeStatic, Root: existingRoot}
+	cfg.VHosts["noroot.com"] = &config.VHost{Domain: "noroot.com", Type: config.TypePHP, Root: filepath.Join(tempDir, "missing")}
+	cfg.VHosts["badproxy.com"] = &config.VHost{Domain: "badproxy.com", Type: config.TypeProxy, ProxyPass: "http://[::1"}
+	cfg.VHosts["nocert.com"] = &config.VHost{
+		Domain:  "nocert.com",
+		Type:    config.TypeStatic,
+		Root:    existingRoot,
+		SSL:     true,
+		SSLCert: filepath.Join(tempDir, "missing-cert.pem"),
+		SSLKey:  filepath.Join(tempDir, "missing-key.pem"),
+	}
+	cfg.VHosts["badcert.com"] = &config.VHost{
+		Domain:  "badcert.com",
+		Type:    config.TypeStatic,
+		Root:    existingRoot,
+		SSL:     true,
+		SSLCert: badCert,
+		SSLKey:  badCert,
+	}
+	certPath, keyPath := writeTestCert(t, tempDir, "pair", "pair.com", "wrongkey.com")
+	_, otherKey := writeTestCert(t, tempDir, "other", "other.com")
+	cfg.VHosts["pair.com"] = &config.VHost{Domain: "pair.com", Type: config.TypeStatic, Root: existingRoot, SSL: true, SSLCert: certPath, SSLKey: keyPath}
+	cfg.VHosts["wrongname.com"] = &config.VHost{Domain: "wrongname.com", Type: config.TypeStatic, Root: existingRoot, SSL: true, SSLCert: certPath, SSLKey: keyPath}
+	cfg.VHosts["wrongkey.com"] = &config.VHost{Domain: "wrongkey.com", Type: config.TypeStatic, Root: existingRoot, SSL: true, SSLCert: certPath, SSLKey: otherKey}
+	cfg.VHosts["dup.com"] = &config.VHost{Domain: "ok.com", Type: config.TypeStatic, Root: existingRoot}
+	cfg.VHosts["alias.com"] = &config.VHost{Domain: "alias.com", Type: config.TypeStatic, Root: existingRoot, Aliases: []string{"www.ok.com"}}
+	cfg.VHosts["www.com"] = &config.VHost{Domain: "www.com", Type: config.TypeStatic, Root: existingRoot, Aliases: []string{"www.ok.com"}}
+
+	issues := validateConfig(cfg)
+
+	expected := []struct {
+		domain  string
+		message string
+	}{
+		{"noroot.com", "root directory missing"},
+		{"badproxy.com", "invalid proxy URL"},
+		{"nocert.com", "SSL certificate missing"},
+		{"nocert.com", "SSL key missing"},
+		{"badcert.com", "SSL certificate unreadable"},
+		{"wrongname.com", "SSL certificate does not cover wrongname.com"},
+		{"wrongkey.com", "SSL key does not match certificate"},
+		{"dup.com", "does not match domain"},
+		{"ok.com", "domain ok.com is also claimed by dup.com"},
+		{"www.com", "alias www.ok.com is also claimed by alias.com"},
+	}
+	for _, want := range expected {
+		found := false
+		for _, issue := range issues {
+			if issue.Domain == want.domain && issue.Severity == statusError && strings.Contains(issue.Message, want.message) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected error for %s containing %q, got %+v", want.domain, want.message, issues)
+		}
+	}
+
+	// The pair certificate is shared by mistake, as it doesn't cover wrongname.com
+	warnings := []struct {
+		domain  string
+		message string
+	}{
+		{"pair.com", "SSL certificate " + certPath + " is also used by wrongkey.com, wrongname.com"},
+		{"pair.com", "SSL key " + keyPath + " is also used by wrongname.com"},
+		{"wrongkey.com", "SSL certificate " + certPath + " is also used by pair.com, wrongname.com"},
+		{"wrongname.com", "SSL certificate " + certPath + " is also used by pair.com, wrongkey.com"},
+		{"wrongname.com", "SSL key " + keyPath + " is also used by pair.com"},
+	}
+	for _, want := range warnings {
+		found := false
+		for _, issue := range issues {
+			if issue.Domain == want.domain && issue.Severity == statusWarning && issue.Message == want.message {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected warning for %s %q, got %+v", want.domain, want.message, issues)
+		}
+	}
+
+	if len(issues) != len(expected)+len(warnings) {
+		t.Errorf("expected %d issues, got %d: %+v", len(expected)+len(warnings), len(issues), issues)
+	}
+}
+
+func TestRunValidate(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Run("valid config", func(t *testing.T) {
+		cfg := config.New()
+		cfg.VHosts["ok.com"] = &config.VHost{Domain: "ok.com", Type: config.TypeStatic, Root: tempDir}
+
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).Build()
+		defer func() { deps = oldDeps }()
+
+		if err := runValidate(nil, nil); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("errors exit non-zero", func(t *testing.T) {
+		cfg := config.New()
+		cfg.VHosts["a.com"] = &config.VHost{Domain: "a.com", Type: config.TypeStatic, Root: filepath.Join(tempDir, "missing")}
+		cfg.VHosts["b.com"] = &config.VHost{Domain: "b.com", Type: config.TypeProxy}
+
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).Build()
+		defer func() { deps = oldDeps }()
+
+		err := runValidate(nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "2 error(s)") {
+			t.Errorf("expected 2 errors, got %v", err)
+		}
+	})
+	t.Run("fix re-keys drifted entries", func(t *testing.T) {
+		cfg := config.New()
+		cfg.VHosts["old.com"] = &config.VHost{Domain: "new.com", Type: config.TypeStatic, Root: tempDir, Notes: "keep me"}
+
+		oldDeps := deps
+		mockDeps := NewMockDeps().WithConfig(cfg).Build()
+		deps = mockDeps
+		validateFix, jsonOutput = true, true
+		defer func() {
+			deps = oldDeps
+			validateFix, jsonOutput = false, false
+		}()
+
+		var err error
+		out := captureStdout(func() { err = runValidate(nil, nil) })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var result validateResult
+		if jsonErr := json.Unmarshal([]byte(out), &result); jsonErr != nil {
+			t.Fatalf("invalid JSON %q: %v", out, jsonErr)
+		}
+		if result.Fixed["old.com"] != "new.com" || !result.Valid {
+			t.Errorf("expected old.com fixed to new.com and a valid result, got %+v", result)
+		}
+
+		saved := mockDeps.ConfigLoader.(*MockConfigLoader).Cfg
+		if vhost := saved.VHosts["new.com"]; vhost == nil || vhost.Notes != "keep me" {
+			t.Errorf("expected re-keyed entry with its data, got %+v", saved.VHosts)
+		}
+		if _, exists := saved.VHosts["old.com"]; exists {
+			t.Error("old key should be gone")
+		}
+	})
+
+	t.Run("fix refuses collisions", func(t *testing.T) {
+		cfg := config.New()
+		cfg.VHosts["a.com"] = &config.VHost{Domain: "same.com", Type: config.TypeStatic, Root: tempDir}
+		cfg.VHosts["b.com"] = &config.VHost{Domain: "same.com", Type: config.TypeStatic, Root: tempDir}
+
+		oldDeps := deps
+		deps = NewMockDeps().WithConfig(cfg).Build()
+		validateFix = true
+		defer func() {
+			deps = oldDeps
+			validateFix = false
+		}()
+
+		err := runValidate(nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "both have domain same.com") {
+			t.Errorf("expected collision error, got %v", err)
+		}
+		if _, exists := cfg.VHosts["a.com"]; !exists {
+			t.Error("config should be unchanged after a collision")
+		}
+	})
+}
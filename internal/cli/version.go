@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ksyq12/vhost/internal/output"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	latestReleaseURL    = "https://api.github.com/repos/ksyq12/vhost/releases/latest"
+	releaseCheckTimeout = 2 * time.Second
+
+	// noUpdateCheckEnv disables the release lookup, e.g. on offline hosts
+	noUpdateCheckEnv = "VHOST_NO_UPDATE_CHECK"
+)
+
+var (
+	appVersion    = "dev"
+	checkUpdate   bool
+	noUpdateCheck bool
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the vhost version",
+	Long: `Show the vhost version.
+
+With --check, query GitHub for the latest release and report whether an
+update is available. The lookup times out after 2 seconds; a failed lookup
+is reported as a warning and never fails the command.
+
+Set --no-update-check or VHOST_NO_UPDATE_CHECK=1 to skip the lookup on
+offline hosts.
+
+Examples:
+  vhost version
+  vhost version --check
+  vhost version --check --json`,
+	Args: cobra.NoArgs,
+	RunE: runVersion,
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&checkUpdate, "check", false, "Check GitHub for a newer release")
+	versionCmd.Flags().BoolVar(&noUpdateCheck, "no-update-check", false, "Never query GitHub for releases")
+
+	rootCmd.AddCommand(versionCmd)
+}
+
+// versionResult represents the version command output
+type versionResult struct {
+	Version         string `json:"version"`
+	Latest          string `json:"latest,omitempty"`
+	UpdateAvailable bool   `json:"update_available"`
+	Checked         bool   `json:"checked"`
+	Error           string `json:"error,omitempty"`
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	result := versionResult{Version: appVersion}
+
+	if checkUpdate && !updateCheckDisabled() {
+		latest, err := deps.ReleaseChecker.LatestVersion()
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Checked = true
+			result.Latest = latest
+			result.UpdateAvailable = isNewerVersion(latest, appVersion)
+		}
+	}
+
+	if jsonOutput {
+		return output.JSON(result)
+	}
+
+	output.Print("vhost %s", result.Version)
+
+	if !checkUpdate {
+		return nil
+	}
+
+	switch {
+	case updateCheckDisabled():
+		output.Info("Update check disabled")
+	case result.Error != "":
+		output.Warn("Could not check for updates: %s", result.Error)
+	case result.UpdateAvailable:
+		output.Warn("Update available: %s (https://github.com/ksyq12/vhost/releases/latest)", result.Latest)
+	default:
+		output.Success("vhost is up to date (latest: %s)", result.Latest)
+	}
+
+	return nil
+}
+
+// updateCheckDisabled reports whether release lookups are turned off
+func updateCheckDisabled() bool {
+	if noUpdateCheck {
+		return true
+	}
+	value := os.Getenv(noUpdateCheckEnv)
+	return value != "" && value != "0" && value != "false"
+}
+
+// isNewerVersion reports whether latest is a newer release than current,
+// by semver precedence: a pre-release such as v1.2.0-rc1 is older than
+// v1.2.0. Non-release builds (e.g. "dev") are never considered out of date.
+func isNewerVersion(latest, current string) bool {
+	latest, current = canonicalVersion(latest), canonicalVersion(current)
+	if !semver.IsValid(latest) || !semver.IsValid(current) {
+		return false
+	}
+	return semver.Compare(latest, current) > 0
+}
+
+// canonicalVersion adds the "v" prefix semver requires, so release tags
+// and ldflags versions with or without it compare alike
+func canonicalVersion(v string) string {
+	v = strings.TrimSpace(v)
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	return v
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsNewerVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		latest  string
+		current string
+		want    bool
+	}{
+		{"newer patch", "v1.2.4", "v1.2.3", true},
+		{"newer minor", "v1.3.0", "1.2.9", true},
+		{"newer major", "v2.0.0", "v1.9.9", true},
+		{"same version", "v1.2.3", "1.2.3", false},
+		{"older version", "v1.2.2", "v1.2.3", false},
+		{"release after its prerelease", "v1.2.0", "v1.2.0-rc1", true},
+		{"prerelease before its release", "v1.2.0-rc1", "v1.2.0", false},
+		{"newer prerelease", "v1.2.0-rc2", "v1.2.0-rc1", true},
+		{"build metadata ignored", "v1.2.3+build.5", "v1.2.3", false},
+		{"short version", "v1.3", "v1.2.9", true},
+		{"dev build", "v1.2.3", "dev", false},
+		{"invalid latest", "latest", "v1.2.3", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNewerVersion(tt.latest, tt.current); got != tt.want {
+				t.Errorf("isNewerVersion(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunVersionCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		current       string
+		checker       *MockReleaseChecker
+		noCheck       bool
+		wantCalls     int
+		wantChecked   bool
+		wantAvailable bool
+		wantError     bool
+	}{
+		{
+			name:          "newer release available",
+			current:       "v1.0.0",
+			checker:       &MockReleaseChecker{Version: "v1.1.0"},
+			wantCalls:     1,
+			wantChecked:   true,
+			wantAvailable: true,
+		},
+		{
+			name:        "same release",
+			current:     "v1.1.0",
+			checker:     &MockReleaseChecker{Version: "v1.1.0"},
+			wantCalls:   1,
+			wantChecked: true,
+		},
+		{
+			name:        "running newer than latest release",
+			current:     "v1.2.0",
+			checker:     &MockReleaseChecker{Version: "v1.1.0"},
+			wantCalls:   1,
+			wantChecked: true,
+		},
+		{
+			name:      "lookup failure is not fatal",
+			current:   "v1.0.0",
+			checker:   &MockReleaseChecker{Err: errors.New("network unreachable")},
+			wantCalls: 1,
+			wantError: true,
+		},
+		{
+			name:      "no-update-check skips lookup",
+			current:   "v1.0.0",
+			checker:   &MockReleaseChecker{Version: "v9.9.9"},
+			noCheck:   true,
+			wantCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldDeps, oldVersion := deps, appVersion
+			deps = NewMockDeps().WithReleaseChecker(tt.checker).Build()
+			appVersion = tt.current
+			checkUpdate, noUpdateCheck, jsonOutput = true, tt.noCheck, true
+			defer func() {
+				deps, appVersion = oldDeps, oldVersion
+				checkUpdate, noUpdateCheck, jsonOutput = false, false, false
+			}()
+
+			var err error
+			out := captureStdout(func() {
+				err = runVersion(nil, nil)
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var result versionResult
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("invalid JSON output %q: %v", out, err)
+			}
+
+			if tt.checker.Calls != tt.wantCalls {
+				t.Errorf("expected %d release lookups, got %d", tt.wantCalls, tt.checker.Calls)
+			}
+			if result.Checked != tt.wantChecked {
+				t.Errorf("checked = %v, want %v", result.Checked, tt.wantChecked)
+			}
+			if result.UpdateAvailable != tt.wantAvailable {
+				t.Errorf("update_available = %v, want %v", result.UpdateAvailable, tt.wantAvailable)
+			}
+			if (result.Error != "") != tt.wantError {
+				t.Errorf("error = %q, wantError %v", result.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestGithubReleaseChecker(t *testing.T) {
+	t.Run("parses tag_name", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"tag_name": "v1.4.0", "name": "v1.4.0"}`))
+		}))
+		defer server.Close()
+
+		checker := &githubReleaseChecker{url: server.URL, timeout: time.Second}
+		got, err := checker.LatestVersion()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "v1.4.0" {
+			t.Errorf("LatestVersion() = %q, want v1.4.0", got)
+		}
+	})
+
+	t.Run("non-200 status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		checker := &githubReleaseChecker{url: server.URL, timeout: time.Second}
+		if _, err := checker.LatestVersion(); err == nil {
+			t.Error("expected error for non-200 status")
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		checker := &githubReleaseChecker{url: server.URL, timeout: 50 * time.Millisecond}
+		if _, err := checker.LatestVersion(); err == nil {
+			t.Error("expected timeout error")
+		}
+	})
+}
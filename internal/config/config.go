@@ -1,9 +1,14 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -14,12 +19,36 @@ type DriverPaths struct {
 	Enabled   string `yaml:"enabled,omitempty"`
 }
 
+// Activation modes accepted by activation_mode
+const (
+	ActivationSymlink = "symlink"
+	ActivationCopy    = "copy"
+)
+
+// Profile overrides the server settings for one environment. Fields left
+// unset keep the top-level values.
+type Profile struct {
+	Driver          string       `yaml:"driver,omitempty"`
+	Paths           *DriverPaths `yaml:"paths,omitempty"`
+	NginxConfigPath string       `yaml:"nginx_config_path,omitempty"`
+	TestCommand     string       `yaml:"test_command,omitempty"`
+	ReloadCommand   string       `yaml:"reload_command,omitempty"`
+	ActivationMode  string       `yaml:"activation_mode,omitempty"`
+}
+
 // Config represents the application configuration
 type Config struct {
-	Driver     string            `yaml:"driver"`
-	DefaultPHP string            `yaml:"default_php"`
-	Paths      *DriverPaths      `yaml:"paths,omitempty"`
-	VHosts     map[string]*VHost `yaml:"vhosts"`
+	Driver          string              `yaml:"driver"`
+	DefaultPHP      string              `yaml:"default_php"`
+	Paths           *DriverPaths        `yaml:"paths,omitempty"`
+	NginxConfigPath string              `yaml:"nginx_config_path,omitempty"`
+	TestCommand     string              `yaml:"test_command,omitempty"`
+	ReloadCommand   string              `yaml:"reload_command,omitempty"`
+	ActivationMode  string              `yaml:"activation_mode,omitempty"`
+	TemplateDir     string              `yaml:"template_dir,omitempty"`
+	RootPattern     string              `yaml:"root_pattern,omitempty"`
+	Profiles        map[string]*Profile `yaml:"profiles,omitempty"`
+	VHosts          map[string]*VHost   `yaml:"vhosts"`
 }
 
 // configDir is the default config directory
@@ -35,8 +64,27 @@ func New() *Config {
 	}
 }
 
-// ConfigDir returns the config directory path
-func ConfigDir() (string, error) {
+// Loader reads and writes config.yaml in one directory. The zero value
+// uses ~/.config/vhost, so tests can point a Loader at a temp dir instead
+// of overriding HOME.
+type Loader struct {
+	dir string
+}
+
+// NewLoader returns a Loader for the config in dir. An empty dir means
+// the default ~/.config/vhost.
+func NewLoader(dir string) *Loader {
+	return &Loader{dir: dir}
+}
+
+// defaultLoader backs the package-level functions
+var defaultLoader = &Loader{}
+
+// Dir returns the config directory path
+func (l *Loader) Dir() (string, error) {
+	if l.dir != "" {
+		return l.dir, nil
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get home directory: %w", err)
@@ -44,18 +92,34 @@ func ConfigDir() (string, error) {
 	return filepath.Join(home, configDir), nil
 }
 
-// ConfigPath returns the config file path
-func ConfigPath() (string, error) {
-	dir, err := ConfigDir()
+// Path returns the config file path
+func (l *Loader) Path() (string, error) {
+	dir, err := l.Dir()
 	if err != nil {
 		return "", err
 	}
 	return filepath.Join(dir, configFile), nil
 }
 
-// Load reads the config from disk
-func Load() (*Config, error) {
-	path, err := ConfigPath()
+// Load reads the config from disk and validates its structure
+func (l *Loader) Load() (*Config, error) {
+	cfg, err := l.LoadUnchecked()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		path, _ := l.Path()
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// LoadUnchecked reads the config from disk without validating it, so
+// tools that report or repair problems can still open a broken file
+func (l *Loader) LoadUnchecked() (*Config, error) {
+	path, err := l.Path()
 	if err != nil {
 		return nil, err
 	}
@@ -83,9 +147,9 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
-// Save writes the config to disk
-func (c *Config) Save() error {
-	dir, err := ConfigDir()
+// Save writes cfg to disk
+func (l *Loader) Save(cfg *Config) error {
+	dir, err := l.Dir()
 	if err != nil {
 		return err
 	}
@@ -95,12 +159,12 @@ func (c *Config) Save() error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	path, err := ConfigPath()
+	path, err := l.Path()
 	if err != nil {
 		return err
 	}
 
-	data, err := yaml.Marshal(c)
+	data, err := yaml.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -112,10 +176,160 @@ func (c *Config) Save() error {
 	return nil
 }
 
-// AddVHost adds a vhost to the config
+// ConfigDir returns the default config directory path
+func ConfigDir() (string, error) {
+	return defaultLoader.Dir()
+}
+
+// ConfigPath returns the default config file path
+func ConfigPath() (string, error) {
+	return defaultLoader.Path()
+}
+
+// Load reads the default config from disk and validates its structure
+func Load() (*Config, error) {
+	return defaultLoader.Load()
+}
+
+// LoadUnchecked reads the default config from disk without validating it
+func LoadUnchecked() (*Config, error) {
+	return defaultLoader.LoadUnchecked()
+}
+
+// validDrivers are the driver names a config may select. The driver
+// package adds every registered driver through RegisterDriver.
+var validDrivers = []string{"nginx", "apache", "caddy", "traefik"}
+
+// ValidDrivers returns the web server drivers a config may select
+func ValidDrivers() []string {
+	return append([]string(nil), validDrivers...)
+}
+
+// RegisterDriver allows name as a config driver. It is called by
+// driver.Register so registered drivers pass Validate.
+func RegisterDriver(name string) {
+	for _, existing := range validDrivers {
+		if existing == name {
+			return
+		}
+	}
+	validDrivers = append(validDrivers, name)
+}
+
+// isValidDriver reports whether name is a driver a config may select
+func isValidDriver(name string) bool {
+	for _, existing := range validDrivers {
+		if existing == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the structure of a loaded config and reports every
+// invalid field, joined
+func (c *Config) Validate() error {
+	var errs []error
+
+	if !isValidDriver(c.Driver) {
+		errs = append(errs, fmt.Errorf("unknown driver %q", c.Driver))
+	}
+
+	checkAbs := func(field, path string) {
+		if path != "" && !filepath.IsAbs(path) {
+			errs = append(errs, fmt.Errorf("%s must be an absolute path: %s", field, path))
+		}
+	}
+	if c.Paths != nil {
+		checkAbs("paths.available", c.Paths.Available)
+		checkAbs("paths.enabled", c.Paths.Enabled)
+	}
+	checkAbs("nginx_config_path", c.NginxConfigPath)
+	checkAbs("template_dir", c.TemplateDir)
+	checkCommand := func(field, command string) {
+		if _, err := SplitCommand(command); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+		}
+	}
+	checkCommand("test_command", c.TestCommand)
+	checkCommand("reload_command", c.ReloadCommand)
+	checkActivation := func(field, mode string) {
+		if mode != "" && mode != ActivationSymlink && mode != ActivationCopy {
+			errs = append(errs, fmt.Errorf("%s must be %s or %s, got %q", field, ActivationSymlink, ActivationCopy, mode))
+		}
+	}
+	checkActivation("activation_mode", c.ActivationMode)
+	if c.RootPattern != "" {
+		if _, err := ExpandRootPattern(c.RootPattern, "example.com"); err != nil {
+			errs = append(errs, fmt.Errorf("root_pattern: %w", err))
+		}
+	}
+
+	for _, name := range c.ProfileNames() {
+		profile := c.Profiles[name]
+		if profile == nil {
+			errs = append(errs, fmt.Errorf("profile %s: entry is empty", name))
+			continue
+		}
+		if profile.Driver != "" && !isValidDriver(profile.Driver) {
+			errs = append(errs, fmt.Errorf("profile %s: unknown driver %q", name, profile.Driver))
+		}
+		if profile.Paths != nil {
+			checkAbs("profiles."+name+".paths.available", profile.Paths.Available)
+			checkAbs("profiles."+name+".paths.enabled", profile.Paths.Enabled)
+		}
+		checkAbs("profiles."+name+".nginx_config_path", profile.NginxConfigPath)
+		checkCommand("profiles."+name+".test_command", profile.TestCommand)
+		checkCommand("profiles."+name+".reload_command", profile.ReloadCommand)
+		checkActivation("profiles."+name+".activation_mode", profile.ActivationMode)
+	}
+
+	keys := make([]string, 0, len(c.VHosts))
+	for key := range c.VHosts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		vhost := c.VHosts[key]
+		if vhost == nil {
+			errs = append(errs, fmt.Errorf("vhost %s: entry is empty", key))
+			continue
+		}
+		if vhost.Domain != key {
+			errs = append(errs, fmt.Errorf("vhost %s: key does not match domain %q", key, vhost.Domain))
+		}
+		if !IsValidType(vhost.Type) && vhost.Type != TypeDefault {
+			errs = append(errs, fmt.Errorf("vhost %s: unknown type %q", key, vhost.Type))
+		}
+		checkAbs("vhost "+key+": root", vhost.Root)
+		checkAbs("vhost "+key+": ssl_cert", vhost.SSLCert)
+		checkAbs("vhost "+key+": ssl_key", vhost.SSLKey)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Save writes the config to the default config file
+func (c *Config) Save() error {
+	return defaultLoader.Save(c)
+}
+
+// NormalizeDomain returns domain in the form used as a config key. DNS
+// names are case-insensitive, so Example.com and example.com are one site.
+func NormalizeDomain(domain string) string {
+	return strings.ToLower(domain)
+}
+
+// AddVHost adds a vhost to the config, normalizing its domain and aliases
 func (c *Config) AddVHost(vhost *VHost) error {
-	if _, exists := c.VHosts[vhost.Domain]; exists {
-		return fmt.Errorf("vhost %s already exists", vhost.Domain)
+	vhost.Domain = NormalizeDomain(vhost.Domain)
+	for i, alias := range vhost.Aliases {
+		vhost.Aliases[i] = NormalizeDomain(alias)
+	}
+
+	if existing, exists := c.FindByDomainOrAlias(vhost.Domain); exists {
+		return fmt.Errorf("vhost %s already exists", existing.Domain)
 	}
 	c.VHosts[vhost.Domain] = vhost
 	return nil
@@ -130,6 +344,95 @@ func (c *Config) GetVHost(domain string) (*VHost, error) {
 	return vhost, nil
 }
 
+// FindByDomainOrAlias returns the vhost whose primary domain or one of
+// whose aliases is name, ignoring case
+func (c *Config) FindByDomainOrAlias(name string) (*VHost, bool) {
+	if vhost, exists := c.VHosts[name]; exists && vhost != nil {
+		return vhost, true
+	}
+	for _, vhost := range c.VHosts {
+		if vhost == nil {
+			continue
+		}
+		if strings.EqualFold(vhost.Domain, name) {
+			return vhost, true
+		}
+		for _, alias := range vhost.Aliases {
+			if strings.EqualFold(alias, name) {
+				return vhost, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Rekey moves every vhost entry whose key differs from its Domain field to
+// the Domain key, repairing drift from manual edits. Domains are normalized
+// as AddVHost does, so Example.com moves to example.com. It returns the moved
+// keys as old -> new. Nothing changes if two entries would share a key.
+func (c *Config) Rekey() (map[string]string, error) {
+	keys := make([]string, 0, len(c.VHosts))
+	for key := range c.VHosts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rekeyed := make(map[string]*VHost, len(c.VHosts))
+	claimedBy := make(map[string]string, len(c.VHosts))
+	moved := make(map[string]string)
+	var errs []error
+
+	for _, key := range keys {
+		vhost := c.VHosts[key]
+		target := key
+		if vhost != nil {
+			if vhost.Domain == "" {
+				errs = append(errs, fmt.Errorf("vhost %s has no domain", key))
+				continue
+			}
+			target = vhost.Domain
+		}
+
+		normalized := NormalizeDomain(target)
+		if other, exists := claimedBy[normalized]; exists {
+			errs = append(errs, fmt.Errorf("vhost %s and %s both have domain %s", other, key, target))
+			continue
+		}
+		claimedBy[normalized] = key
+
+		rekeyed[normalized] = vhost
+		if normalized != key {
+			moved[key] = normalized
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	for key, vhost := range rekeyed {
+		if vhost == nil {
+			continue
+		}
+		vhost.Domain = key
+		for i, alias := range vhost.Aliases {
+			vhost.Aliases[i] = NormalizeDomain(alias)
+		}
+	}
+	c.VHosts = rekeyed
+	return moved, nil
+}
+
+// TouchVHost records the current time as the vhost's last modification time
+func (c *Config) TouchVHost(domain string) error {
+	vhost, exists := c.VHosts[domain]
+	if !exists {
+		return fmt.Errorf("vhost %s not found", domain)
+	}
+	vhost.UpdatedAt = time.Now()
+	return nil
+}
+
 // RemoveVHost removes a vhost from the config
 func (c *Config) RemoveVHost(domain string) error {
 	if _, exists := c.VHosts[domain]; !exists {
@@ -139,6 +442,141 @@ func (c *Config) RemoveVHost(domain string) error {
 	return nil
 }
 
+// ProfileNames returns the configured profile names, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithProfile returns a copy of the config with the named profile's server
+// settings in place of the top-level ones, or c itself for an empty name.
+// The copy shares VHosts with c; save c, so the profile's values are never
+// written to the top level.
+func (c *Config) WithProfile(name string) (*Config, error) {
+	if name == "" {
+		return c, nil
+	}
+
+	profile := c.Profiles[name]
+	if profile == nil {
+		if len(c.Profiles) == 0 {
+			return nil, fmt.Errorf("unknown profile: %s (no profiles configured)", name)
+		}
+		return nil, fmt.Errorf("unknown profile: %s (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+
+	active := *c
+	if profile.Driver != "" {
+		active.Driver = profile.Driver
+		// Another server's paths don't carry over to this one
+		active.Paths = nil
+		active.NginxConfigPath = ""
+		active.TestCommand = ""
+		active.ReloadCommand = ""
+	}
+	if profile.Paths != nil {
+		active.Paths = profile.Paths
+	}
+	if profile.NginxConfigPath != "" {
+		active.NginxConfigPath = profile.NginxConfigPath
+	}
+	if profile.TestCommand != "" {
+		active.TestCommand = profile.TestCommand
+	}
+	if profile.ReloadCommand != "" {
+		active.ReloadCommand = profile.ReloadCommand
+	}
+	if profile.ActivationMode != "" {
+		active.ActivationMode = profile.ActivationMode
+	}
+	return &active, nil
+}
+
+// ExpandRootPattern fills a document-root pattern such as
+// /var/www/{{.Domain}}/public in for domain. The result must be absolute.
+func ExpandRootPattern(pattern, domain string) (string, error) {
+	tmpl, err := template.New("root").Parse(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid root pattern %q: %w", pattern, err)
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, struct{ Domain string }{domain}); err != nil {
+		return "", fmt.Errorf("invalid root pattern %q: %w", pattern, err)
+	}
+
+	root := buf.String()
+	if !filepath.IsAbs(root) {
+		return "", fmt.Errorf("root pattern %q must expand to an absolute path, got %s", pattern, root)
+	}
+	return root, nil
+}
+
+// SplitCommand splits a test_command or reload_command into the program and
+// its arguments, the way a shell would split words: single quotes keep text
+// as is, and inside double quotes or outside quotes a backslash escapes the
+// next character. Nothing else is interpreted and no shell runs the result.
+// An empty command has no words; one of only spaces is an error.
+func SplitCommand(command string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range command {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	switch {
+	case escaped:
+		return nil, fmt.Errorf("command %q ends in a backslash", command)
+	case quote != 0:
+		return nil, fmt.Errorf("command %q has an unterminated %c quote", command, quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	if command != "" && len(words) == 0 {
+		return nil, fmt.Errorf("command is empty")
+	}
+	return words, nil
+}
+
 // ListVHosts returns all vhosts
 func (c *Config) ListVHosts() []*VHost {
 	vhosts := make([]*VHost, 0, len(c.VHosts))
@@ -3,30 +3,16 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
 
 func TestConfig(t *testing.T) {
-	// Create temp directory for test config
-	tempDir := t.TempDir()
-
-	// Override config path for testing
-	originalHome := os.Getenv("HOME")
-	if err := os.Setenv("HOME", tempDir); err != nil {
-		t.Fatalf("failed to set HOME: %v", err)
-	}
-	defer func() {
-		if err := os.Setenv("HOME", originalHome); err != nil {
-			t.Errorf("failed to restore HOME: %v", err)
-		}
-	}()
-
-	// Create the .config/vhost directory
-	configDir := filepath.Join(tempDir, ".config", "vhost")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		t.Fatalf("failed to create config dir: %v", err)
-	}
+	// Keep the test config in a temp directory
+	configDir := t.TempDir()
+	loader := NewLoader(configDir)
 
 	t.Run("New", func(t *testing.T) {
 		cfg := New()
@@ -42,7 +28,7 @@ func TestConfig(t *testing.T) {
 	})
 
 	t.Run("LoadNonexistent", func(t *testing.T) {
-		cfg, err := Load()
+		cfg, err := loader.Load()
 		if err != nil {
 			t.Fatalf("Load failed: %v", err)
 		}
@@ -60,10 +46,12 @@ func TestConfig(t *testing.T) {
 			Root:      "/var/www/test",
 			SSL:       true,
 			Enabled:   true,
+			Owner:     "ops@example.com",
+			Notes:     "Customer #42",
 			CreatedAt: time.Now(),
 		}
 
-		if err := cfg.Save(); err != nil {
+		if err := loader.Save(cfg); err != nil {
 			t.Fatalf("Save failed: %v", err)
 		}
 
@@ -74,7 +62,7 @@ func TestConfig(t *testing.T) {
 		}
 
 		// Load and verify
-		loaded, err := Load()
+		loaded, err := loader.Load()
 		if err != nil {
 			t.Fatalf("Load failed: %v", err)
 		}
@@ -96,6 +84,12 @@ func TestConfig(t *testing.T) {
 		if !vhost.SSL {
 			t.Error("expected SSL to be true")
 		}
+		if vhost.Owner != "ops@example.com" {
+			t.Errorf("expected owner ops@example.com, got %s", vhost.Owner)
+		}
+		if vhost.Notes != "Customer #42" {
+			t.Errorf("expected notes %q, got %q", "Customer #42", vhost.Notes)
+		}
 	})
 
 	t.Run("AddVHost", func(t *testing.T) {
@@ -134,6 +128,49 @@ func TestConfig(t *testing.T) {
 		}
 	})
 
+	t.Run("FindByDomainOrAlias", func(t *testing.T) {
+		cfg := New()
+		cfg.VHosts["find.example.com"] = &VHost{
+			Domain:  "find.example.com",
+			Aliases: []string{"www.find.example.com"},
+		}
+
+		for _, name := range []string{"find.example.com", "www.find.example.com"} {
+			vhost, found := cfg.FindByDomainOrAlias(name)
+			if !found {
+				t.Fatalf("expected %s to be found", name)
+			}
+			if vhost.Domain != "find.example.com" {
+				t.Errorf("expected find.example.com for %s, got %s", name, vhost.Domain)
+			}
+		}
+
+		if _, found := cfg.FindByDomainOrAlias("unknown.example.com"); found {
+			t.Error("expected unknown name to be not found")
+		}
+	})
+
+	t.Run("TouchVHost", func(t *testing.T) {
+		earlier := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		cfg := New()
+		cfg.VHosts["touch.example.com"] = &VHost{Domain: "touch.example.com", CreatedAt: earlier, UpdatedAt: earlier}
+
+		if err := cfg.TouchVHost("touch.example.com"); err != nil {
+			t.Fatalf("TouchVHost failed: %v", err)
+		}
+		vhost := cfg.VHosts["touch.example.com"]
+		if !vhost.UpdatedAt.After(earlier) {
+			t.Errorf("expected UpdatedAt to move past %v, got %v", earlier, vhost.UpdatedAt)
+		}
+		if !vhost.CreatedAt.Equal(earlier) {
+			t.Errorf("expected CreatedAt to stay %v, got %v", earlier, vhost.CreatedAt)
+		}
+
+		if err := cfg.TouchVHost("nonexistent.example.com"); err == nil {
+			t.Error("expected error for nonexistent vhost")
+		}
+	})
+
 	t.Run("RemoveVHost", func(t *testing.T) {
 		cfg := New()
 		cfg.VHosts["remove.example.com"] = &VHost{Domain: "remove.example.com"}
@@ -165,25 +202,9 @@ func TestConfig(t *testing.T) {
 }
 
 func TestConfigPaths(t *testing.T) {
-	// Create temp directory for test config
-	tempDir := t.TempDir()
-
-	// Override config path for testing
-	originalHome := os.Getenv("HOME")
-	if err := os.Setenv("HOME", tempDir); err != nil {
-		t.Fatalf("failed to set HOME: %v", err)
-	}
-	defer func() {
-		if err := os.Setenv("HOME", originalHome); err != nil {
-			t.Errorf("failed to restore HOME: %v", err)
-		}
-	}()
-
-	// Create the .config/vhost directory
-	configDir := filepath.Join(tempDir, ".config", "vhost")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		t.Fatalf("failed to create config dir: %v", err)
-	}
+	// Keep the test config in a temp directory
+	configDir := t.TempDir()
+	loader := NewLoader(configDir)
 
 	t.Run("NewConfigHasNilPaths", func(t *testing.T) {
 		cfg := New()
@@ -199,11 +220,11 @@ func TestConfigPaths(t *testing.T) {
 			Enabled:   "/custom/sites-enabled",
 		}
 
-		if err := cfg.Save(); err != nil {
+		if err := loader.Save(cfg); err != nil {
 			t.Fatalf("Save failed: %v", err)
 		}
 
-		loaded, err := Load()
+		loaded, err := loader.Load()
 		if err != nil {
 			t.Fatalf("Load failed: %v", err)
 		}
@@ -223,7 +244,7 @@ func TestConfigPaths(t *testing.T) {
 		cfg := New()
 		// Paths is nil by default
 
-		if err := cfg.Save(); err != nil {
+		if err := loader.Save(cfg); err != nil {
 			t.Fatalf("Save failed: %v", err)
 		}
 
@@ -241,6 +262,62 @@ func TestConfigPaths(t *testing.T) {
 	})
 }
 
+func TestLoaderDefaultDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path, err := NewLoader("").Path()
+	if err != nil {
+		t.Fatalf("Path failed: %v", err)
+	}
+	if want := filepath.Join(home, ".config", "vhost", "config.yaml"); path != want {
+		t.Errorf("expected %s, got %s", want, path)
+	}
+
+	cfg := New()
+	cfg.DefaultPHP = "8.3"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.DefaultPHP != "8.3" {
+		t.Errorf("expected the default loader to read the saved config, got PHP %s", loaded.DefaultPHP)
+	}
+}
+
+func TestLoaderParallel(t *testing.T) {
+	for _, driver := range []string{"nginx", "apache", "caddy", "traefik"} {
+		t.Run(driver, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			loader := NewLoader(dir)
+
+			cfg := New()
+			cfg.Driver = driver
+			cfg.VHosts[driver+".example.com"] = &VHost{Domain: driver + ".example.com", Type: TypeStatic, Root: "/var/www"}
+			for i := 0; i < 20; i++ {
+				if err := loader.Save(cfg); err != nil {
+					t.Fatalf("Save failed: %v", err)
+				}
+				loaded, err := loader.Load()
+				if err != nil {
+					t.Fatalf("Load failed: %v", err)
+				}
+				if loaded.Driver != driver || len(loaded.VHosts) != 1 || loaded.VHosts[driver+".example.com"] == nil {
+					t.Fatalf("expected only this test's config, got driver %s and vhosts %v", loaded.Driver, loaded.VHosts)
+				}
+			}
+
+			if path, _ := loader.Path(); path != filepath.Join(dir, "config.yaml") {
+				t.Errorf("expected config in %s, got %s", dir, path)
+			}
+		})
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
 }
@@ -257,8 +334,8 @@ func containsHelper(s, substr string) bool {
 func TestVHostTypes(t *testing.T) {
 	t.Run("ValidTypes", func(t *testing.T) {
 		types := ValidTypes()
-		if len(types) != 5 {
-			t.Errorf("expected 5 types, got %d", len(types))
+		if len(types) != 6 {
+			t.Errorf("expected 6 types, got %d", len(types))
 		}
 	})
 
@@ -278,8 +355,305 @@ func TestVHostTypes(t *testing.T) {
 		if !IsValidType(TypeWordPress) {
 			t.Error("wordpress should be valid")
 		}
+		if !IsValidType(TypeRedirect) {
+			t.Error("redirect should be valid")
+		}
 		if IsValidType("invalid") {
 			t.Error("invalid should not be valid")
 		}
 	})
 }
+
+func TestValidate(t *testing.T) {
+	valid := func() *Config {
+		cfg := New()
+		cfg.Paths = &DriverPaths{Available: "/etc/nginx/sites-available", Enabled: "/etc/nginx/sites-enabled"}
+		cfg.TemplateDir = "/etc/vhost/templates"
+		cfg.VHosts["example.com"] = &VHost{Domain: "example.com", Type: TypeStatic, Root: "/var/www/example"}
+		cfg.VHosts[DefaultServerDomain] = &VHost{Domain: DefaultServerDomain, Type: TypeDefault}
+		return cfg
+	}
+
+	t.Run("valid config", func(t *testing.T) {
+		if err := valid().Validate(); err != nil {
+			t.Errorf("expected valid config, got %v", err)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr string
+	}{
+		{"unknown driver", func(c *Config) { c.Driver = "lighttpd" }, `unknown driver "lighttpd"`},
+		{"key mismatch", func(c *Config) { c.VHosts["example.com"].Domain = "other.com" }, `vhost example.com: key does not match domain "other.com"`},
+		{"unknown type", func(c *Config) { c.VHosts["example.com"].Type = "node" }, `vhost example.com: unknown type "node"`},
+		{"empty entry", func(c *Config) { c.VHosts["empty.com"] = nil }, "vhost empty.com: entry is empty"},
+		{"relative paths", func(c *Config) { c.Paths.Enabled = "sites-enabled" }, "paths.enabled must be an absolute path"},
+		{"relative template dir", func(c *Config) { c.TemplateDir = "templates" }, "template_dir must be an absolute path"},
+		{"relative nginx config", func(c *Config) { c.NginxConfigPath = "nginx.conf" }, "nginx_config_path must be an absolute path"},
+		{"relative root", func(c *Config) { c.VHosts["example.com"].Root = "www" }, "vhost example.com: root must be an absolute path"},
+		{"profile unknown driver", func(c *Config) { c.Profiles = map[string]*Profile{"prod": {Driver: "lighttpd"}} }, `profile prod: unknown driver "lighttpd"`},
+		{"profile relative paths", func(c *Config) {
+			c.Profiles = map[string]*Profile{"staging": {Paths: &DriverPaths{Available: "available", Enabled: "/e"}}}
+		}, "profiles.staging.paths.available must be an absolute path"},
+		{"profile empty entry", func(c *Config) { c.Profiles = map[string]*Profile{"prod": nil} }, "profile prod: entry is empty"},
+		{"unparseable root pattern", func(c *Config) { c.RootPattern = "/var/www/{{.Domain" }, "root_pattern: invalid root pattern"},
+		{"relative root pattern", func(c *Config) { c.RootPattern = "{{.Domain}}/public" }, "must expand to an absolute path"},
+		{"blank reload command", func(c *Config) { c.ReloadCommand = "  " }, "reload_command: command is empty"},
+		{"unterminated test command", func(c *Config) { c.TestCommand = `sh -c "nginx -t` }, "test_command: command"},
+		{"profile blank reload command", func(c *Config) { c.Profiles = map[string]*Profile{"edge": {ReloadCommand: " "}} }, "profiles.edge.reload_command: command is empty"},
+		{"unknown activation mode", func(c *Config) { c.ActivationMode = "hardlink" }, `activation_mode must be symlink or copy, got "hardlink"`},
+		{"profile unknown activation mode", func(c *Config) { c.Profiles = map[string]*Profile{"edge": {ActivationMode: "link"}} }, "profiles.edge.activation_mode must be symlink or copy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("aggregates every problem", func(t *testing.T) {
+		cfg := valid()
+		cfg.Driver = "lighttpd"
+		cfg.VHosts["example.com"].Type = "node"
+		err := cfg.Validate()
+		if err == nil || strings.Count(err.Error(), "\n") != 1 {
+			t.Errorf("expected two problems, got %v", err)
+		}
+	})
+
+	t.Run("Load rejects invalid config", func(t *testing.T) {
+		configDir := t.TempDir()
+		data := "driver: nginx\nvhosts:\n  a.com:\n    domain: b.com\n    type: static\n"
+		if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(data), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+
+		_, err := NewLoader(configDir).Load()
+		if err == nil || !strings.Contains(err.Error(), "key does not match domain") {
+			t.Errorf("expected key mismatch error from Load, got %v", err)
+		}
+	})
+}
+
+func TestExpandRootPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		wantErr string
+	}{
+		{"/var/www/{{.Domain}}/public", "/var/www/example.com/public", ""},
+		{"/srv/{{.Domain}}/{{.Domain}}", "/srv/example.com/example.com", ""},
+		{"/var/www/html", "/var/www/html", ""},
+		{"{{.Domain}}", "", "must expand to an absolute path"},
+		{"/var/www/{{.Name}}", "", "invalid root pattern"},
+		{"/var/www/{{.Domain", "", "invalid root pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := ExpandRootPattern(tt.pattern, "example.com")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr string
+	}{
+		{"", nil, ""},
+		{"nginx -s reload", []string{"nginx", "-s", "reload"}, ""},
+		{"  docker   exec web nginx -s reload ", []string{"docker", "exec", "web", "nginx", "-s", "reload"}, ""},
+		{`sh -c 'nginx -t && echo ok'`, []string{"sh", "-c", "nginx -t && echo ok"}, ""},
+		{`echo "a \"b\"" c\ d`, []string{"echo", `a "b"`, "c d"}, ""},
+		{`run ''`, []string{"run", ""}, ""},
+		{"   ", nil, "command is empty"},
+		{`echo "open`, nil, "unterminated"},
+		{`echo \`, nil, "ends in a backslash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got, err := SplitCommand(tt.command)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWithProfile(t *testing.T) {
+	cfg := New()
+	cfg.Paths = &DriverPaths{Available: "/etc/nginx/sites-available", Enabled: "/etc/nginx/sites-enabled"}
+	cfg.NginxConfigPath = "/etc/nginx/nginx.conf"
+	cfg.Profiles = map[string]*Profile{
+		"staging": {Paths: &DriverPaths{Available: "/srv/staging/available", Enabled: "/srv/staging/enabled"}},
+		"edge":    {Driver: "caddy"},
+		"docker":  {ReloadCommand: "docker exec web nginx -s reload", ActivationMode: ActivationCopy},
+	}
+	cfg.TestCommand = "nginx -t"
+
+	t.Run("empty name is the top level", func(t *testing.T) {
+		active, err := cfg.WithProfile("")
+		if err != nil || active != cfg {
+			t.Errorf("expected the config itself, got %v, %v", active, err)
+		}
+	})
+
+	t.Run("paths override", func(t *testing.T) {
+		active, err := cfg.WithProfile("staging")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if active.Driver != "nginx" || active.Paths.Available != "/srv/staging/available" || active.NginxConfigPath != "/etc/nginx/nginx.conf" {
+			t.Errorf("unexpected active config: driver=%s paths=%+v nginx=%s", active.Driver, active.Paths, active.NginxConfigPath)
+		}
+		if cfg.Paths.Available != "/etc/nginx/sites-available" {
+			t.Errorf("top-level paths changed to %+v", cfg.Paths)
+		}
+	})
+
+	t.Run("driver drops top-level paths", func(t *testing.T) {
+		active, err := cfg.WithProfile("edge")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if active.Driver != "caddy" || active.Paths != nil || active.NginxConfigPath != "" || active.TestCommand != "" {
+			t.Errorf("unexpected active config: driver=%s paths=%+v nginx=%s test=%s", active.Driver, active.Paths, active.NginxConfigPath, active.TestCommand)
+		}
+	})
+
+	t.Run("command override", func(t *testing.T) {
+		active, err := cfg.WithProfile("docker")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if active.ReloadCommand != "docker exec web nginx -s reload" || active.TestCommand != "nginx -t" {
+			t.Errorf("unexpected commands: test=%q reload=%q", active.TestCommand, active.ReloadCommand)
+		}
+		if active.ActivationMode != ActivationCopy || cfg.ActivationMode != "" {
+			t.Errorf("expected the profile to switch to copy mode, got %q (top level %q)", active.ActivationMode, cfg.ActivationMode)
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		_, err := cfg.WithProfile("prod")
+		if err == nil || err.Error() != "unknown profile: prod (available: docker, edge, staging)" {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestNormalizeDomains(t *testing.T) {
+	cfg := New()
+	if err := cfg.AddVHost(&VHost{Domain: "Example.COM", Aliases: []string{"WWW.Example.com"}, Type: TypeStatic}); err != nil {
+		t.Fatalf("AddVHost failed: %v", err)
+	}
+
+	vhost, exists := cfg.VHosts["example.com"]
+	if !exists {
+		t.Fatalf("expected lowercase key, got %v", cfg.VHosts)
+	}
+	if vhost.Domain != "example.com" || vhost.Aliases[0] != "www.example.com" {
+		t.Errorf("expected lowercase domain and alias, got %q %v", vhost.Domain, vhost.Aliases)
+	}
+
+	if err := cfg.AddVHost(&VHost{Domain: "example.com", Type: TypeStatic}); err == nil {
+		t.Error("expected duplicate error for the same domain in another case")
+	}
+
+	if found, ok := cfg.FindByDomainOrAlias("EXAMPLE.com"); !ok || found != vhost {
+		t.Error("lookup should ignore case")
+	}
+}
+
+func TestRekey(t *testing.T) {
+	t.Run("fixes drift without data loss", func(t *testing.T) {
+		cfg := New()
+		cfg.VHosts["old.com"] = &VHost{Domain: "new.com", Type: TypeProxy, ProxyPass: "http://localhost:3000", Notes: "ticket 7"}
+		cfg.VHosts["ok.com"] = &VHost{Domain: "ok.com", Type: TypeStatic}
+
+		moved, err := cfg.Rekey()
+		if err != nil {
+			t.Fatalf("Rekey failed: %v", err)
+		}
+		if len(moved) != 1 || moved["old.com"] != "new.com" {
+			t.Errorf("expected old.com -> new.com, got %v", moved)
+		}
+		if len(cfg.VHosts) != 2 || cfg.VHosts["ok.com"] == nil {
+			t.Errorf("expected both entries kept, got %v", cfg.VHosts)
+		}
+		if vhost := cfg.VHosts["new.com"]; vhost == nil || vhost.ProxyPass != "http://localhost:3000" || vhost.Notes != "ticket 7" {
+			t.Errorf("re-keyed entry lost data: %+v", vhost)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected a valid config after Rekey, got %v", err)
+		}
+	})
+
+	t.Run("normalizes the new key", func(t *testing.T) {
+		cfg := New()
+		cfg.VHosts["old.com"] = &VHost{Domain: "Example.com", Type: TypeStatic, Aliases: []string{"WWW.Example.com"}}
+		cfg.VHosts["Upper.com"] = &VHost{Domain: "Upper.com", Type: TypeStatic}
+
+		moved, err := cfg.Rekey()
+		if err != nil {
+			t.Fatalf("Rekey failed: %v", err)
+		}
+		if moved["old.com"] != "example.com" || moved["Upper.com"] != "upper.com" {
+			t.Errorf("expected lowercase keys, got %v", moved)
+		}
+		vhost, err := cfg.GetVHost("example.com")
+		if err != nil {
+			t.Fatalf("re-keyed vhost not found: %v", err)
+		}
+		if vhost.Domain != "example.com" || vhost.Aliases[0] != "www.example.com" {
+			t.Errorf("expected normalized domain and aliases, got %+v", vhost)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected a valid config after Rekey, got %v", err)
+		}
+	})
+
+	t.Run("collision leaves config unchanged", func(t *testing.T) {
+		cfg := New()
+		cfg.VHosts["a.com"] = &VHost{Domain: "b.com", Type: TypeStatic}
+		cfg.VHosts["b.com"] = &VHost{Domain: "B.com", Type: TypeStatic}
+
+		if _, err := cfg.Rekey(); err == nil || !strings.Contains(err.Error(), "both have domain") {
+			t.Errorf("expected collision error, got %v", err)
+		}
+		if cfg.VHosts["a.com"] == nil || cfg.VHosts["b.com"] == nil {
+			t.Errorf("config changed after collision: %v", cfg.VHosts)
+		}
+	})
+}
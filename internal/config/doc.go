@@ -28,6 +28,7 @@
 //	    ssl: false
 //	    enabled: true
 //	    created_at: 2026-02-01T10:00:00Z
+//	    updated_at: 2026-02-03T09:15:00Z
 //
 // # Virtual Host Types
 //
@@ -64,6 +65,13 @@
 //	// Save changes to disk
 //	err = cfg.Save()
 //
+// A Loader reads and writes the config in another directory, which keeps
+// tests away from the real home directory:
+//
+//	loader := config.NewLoader(t.TempDir())
+//	cfg, err := loader.Load()
+//	err = loader.Save(cfg)
+//
 // # Thread Safety
 //
 // Config operations are NOT thread-safe. Callers must implement their own
@@ -4,17 +4,49 @@ import "time"
 
 // VHost represents a virtual host configuration
 type VHost struct {
-	Domain     string            `yaml:"domain"`
-	Type       string            `yaml:"type"` // static, php, proxy, laravel, wordpress
-	Root       string            `yaml:"root,omitempty"`
-	ProxyPass  string            `yaml:"proxy_pass,omitempty"`
-	PHPVersion string            `yaml:"php_version,omitempty"`
-	SSL        bool              `yaml:"ssl"`
-	SSLCert    string            `yaml:"ssl_cert,omitempty"`
-	SSLKey     string            `yaml:"ssl_key,omitempty"`
-	Enabled    bool              `yaml:"enabled"`
-	Extra      map[string]string `yaml:"extra,omitempty"`
-	CreatedAt  time.Time         `yaml:"created_at"`
+	Domain             string            `yaml:"domain"`
+	Type               string            `yaml:"type"`               // static, php, proxy, laravel, wordpress, redirect
+	Template           string            `yaml:"template,omitempty"` // template variant, rendering <type>.<template>.tmpl
+	Aliases            []string          `yaml:"aliases,omitempty"`
+	Root               string            `yaml:"root,omitempty"`
+	RootOwner          string            `yaml:"root_owner,omitempty"`     // user:group applied to a created root
+	RootPerms          string            `yaml:"root_perms,omitempty"`     // octal mode for a created root, default 0755
+	RootNoCreate       bool              `yaml:"root_no_create,omitempty"` // require an existing root instead of creating it
+	ProxyPass          string            `yaml:"proxy_pass,omitempty"`
+	RedirectURL        string            `yaml:"redirect_url,omitempty"`  // redirect: target every request is sent to
+	RedirectCode       int               `yaml:"redirect_code,omitempty"` // redirect: 301 or 302; 0 means 301
+	PHPVersion         string            `yaml:"php_version,omitempty"`
+	SPA                bool              `yaml:"spa,omitempty"`                  // static: serve /index.html for unknown paths
+	NoStaticCache      bool              `yaml:"no_static_cache,omitempty"`      // static, wordpress: drop the asset cache headers
+	StaticCacheMaxAge  string            `yaml:"static_cache_max_age,omitempty"` // asset cache lifetime such as 30d; empty means 30d
+	WPMultisite        string            `yaml:"wp_multisite,omitempty"`         // WordPress network mode: subdir or subdomain
+	FastCGIReadTimeout string            `yaml:"fastcgi_read_timeout,omitempty"` // duration such as 300s; empty keeps the server default
+	FastCGIBuffers     string            `yaml:"fastcgi_buffers,omitempty"`      // nginx "<count> <size>", e.g. "16 16k"
+	FastCGIBufferSize  string            `yaml:"fastcgi_buffer_size,omitempty"`  // nginx size, e.g. "32k"
+	SSL                bool              `yaml:"ssl"`
+	SSLCert            string            `yaml:"ssl_cert,omitempty"`
+	SSLKey             string            `yaml:"ssl_key,omitempty"`
+	HTTP2              bool              `yaml:"http2,omitempty"` // negotiate HTTP/2 on the SSL listener
+	HTTP3              bool              `yaml:"http3,omitempty"` // also listen for HTTP/3 over QUIC (nginx >= 1.25)
+	Enabled            bool              `yaml:"enabled"`
+	Maintenance        bool              `yaml:"maintenance,omitempty"` // render the 503 maintenance page instead of the type template
+	EnvVars            map[string]string `yaml:"env_vars,omitempty"`
+	Locations          []LocationBlock   `yaml:"locations,omitempty"`
+	CaddyImports       []string          `yaml:"caddy_imports,omitempty"`     // snippets imported at the top of the caddy site block
+	BlockUserAgents    []string          `yaml:"block_user_agents,omitempty"` // User-Agent substrings answered with 403
+	Extra              map[string]string `yaml:"extra,omitempty"`
+	Owner              string            `yaml:"owner,omitempty"`       // contact for the site, metadata only
+	Notes              string            `yaml:"notes,omitempty"`       // free-form notes, metadata only
+	ConfigHash         string            `yaml:"config_hash,omitempty"` // sha256 of the config file vhost last rendered, to spot manual edits
+	CreatedAt          time.Time         `yaml:"created_at"`
+	UpdatedAt          time.Time         `yaml:"updated_at,omitempty"`
+}
+
+// LocationBlock serves a URL path prefix from a directory outside the
+// document root (nginx alias, apache Alias, caddy handle_path)
+type LocationBlock struct {
+	Path string `yaml:"path"` // URL prefix without trailing slash, e.g. /assets
+	Root string `yaml:"root"` // absolute directory served for Path
 }
 
 // VHostType constants
@@ -24,11 +56,26 @@ const (
 	TypeProxy     = "proxy"
 	TypeLaravel   = "laravel"
 	TypeWordPress = "wordpress"
+	TypeRedirect  = "redirect"
 )
 
+// WordPress multisite modes for VHost.WPMultisite
+const (
+	WPMultisiteSubdir    = "subdir"
+	WPMultisiteSubdomain = "subdomain"
+)
+
+// TypeDefault marks the catch-all vhost created by add-default. It is not a
+// user-selectable type, so it is not part of ValidTypes.
+const TypeDefault = "default"
+
+// DefaultServerDomain is the config key and file name of the catch-all
+// vhost; it sorts before real domains so Apache loads it first
+const DefaultServerDomain = "000-catch-all"
+
 // ValidTypes returns all valid vhost types
 func ValidTypes() []string {
-	return []string{TypeStatic, TypePHP, TypeProxy, TypeLaravel, TypeWordPress}
+	return []string{TypeStatic, TypePHP, TypeProxy, TypeLaravel, TypeWordPress, TypeRedirect}
 }
 
 // IsValidType checks if the given type is valid
@@ -0,0 +1,149 @@
+package driver
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ksyq12/vhost/internal/config"
+)
+
+// activateConfig enables the config at source by creating target: a
+// symlink to source, or a copy of it in config.ActivationCopy mode. An
+// existing target is replaced.
+func activateConfig(mode, source, target string) error {
+	if mode == config.ActivationCopy {
+		return copyConfig(source, target)
+	}
+	return replaceSymlink(source, target)
+}
+
+// relinkConfig enables the config at source even when target already
+// exists, repointing a symlink that leads elsewhere or refreshing a copy.
+// Entries vhost didn't create are refused, as Disable refuses them.
+func relinkConfig(mode, domain, source, target string) error {
+	if _, err := os.Stat(source); os.IsNotExist(err) {
+		return fmt.Errorf("vhost %s not found in sites-available", domain)
+	}
+
+	if info, err := os.Lstat(target); err == nil {
+		if err := checkEnabledEntry(mode, domain, info); err != nil {
+			return err
+		}
+	}
+
+	if err := activateConfig(mode, source, target); err != nil {
+		return fmt.Errorf("failed to enable vhost: %w", err)
+	}
+	return nil
+}
+
+// isConfigEnabled reports whether target enables the config at source. In
+// copy mode a copy only counts while it matches source: an outdated copy
+// isn't the config vhost manages, and enabling it again refreshes it.
+func isConfigEnabled(mode, source, target string) (bool, error) {
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check vhost status: %w", err)
+	}
+	if mode != config.ActivationCopy || !info.Mode().IsRegular() {
+		return true, nil
+	}
+
+	want, err := os.ReadFile(source)
+	if os.IsNotExist(err) {
+		// Nothing to compare with; the copy is still what the server loads
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check vhost status: %w", err)
+	}
+	got, err := os.ReadFile(target)
+	if err != nil {
+		return false, fmt.Errorf("failed to check vhost status: %w", err)
+	}
+	return bytes.Equal(got, want), nil
+}
+
+// replaceSymlink creates a symlink to source under a temporary name in
+// target's directory and renames it over target, so the server never sees
+// a missing or half-made entry. The hidden temporary name keeps it out of
+// List.
+func replaceSymlink(source, target string) error {
+	tmp := filepath.Join(filepath.Dir(target), fmt.Sprintf(".%s.tmp-%d", filepath.Base(target), os.Getpid()))
+
+	// A leftover from an interrupted run would make Symlink fail
+	_ = os.Remove(tmp)
+	if err := os.Symlink(source, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// checkEnabledEntry refuses to remove an enabled entry vhost didn't create.
+// Symlinks are always removable; copy mode also removes regular files.
+func checkEnabledEntry(mode, domain string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+	if mode != config.ActivationCopy {
+		return fmt.Errorf("vhost %s is not a symlink, refusing to remove", domain)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("vhost %s is not a symlink or a copied config, refusing to remove", domain)
+	}
+	return nil
+}
+
+// refreshEnabledCopy rewrites the enabled copy at target from source in
+// copy mode, so re-adding a vhost updates the config the server loads.
+// Symlinks follow source by themselves and a missing copy stays missing.
+func refreshEnabledCopy(mode, source, target string) error {
+	if mode != config.ActivationCopy {
+		return nil
+	}
+	info, err := os.Lstat(target)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+	if err := copyConfig(source, target); err != nil {
+		return fmt.Errorf("failed to update enabled copy: %w", err)
+	}
+	return nil
+}
+
+// copyConfig copies source to target through a temporary file in the same
+// directory, so the server never reads a half-written config. The hidden
+// temporary name keeps it out of List.
+func copyConfig(source, target string) error {
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
@@ -0,0 +1,237 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+)
+
+func TestActivationCopy(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		newFn func(available, enabled string) Driver
+	}{
+		{"nginx", "example.com", func(available, enabled string) Driver {
+			n := NewNginxWithPaths(available, enabled)
+			n.paths.ActivationMode = config.ActivationCopy
+			return n
+		}},
+		{"apache", "example.com.conf", func(available, enabled string) Driver {
+			a := NewApacheWithPaths(available, enabled)
+			a.paths.ActivationMode = config.ActivationCopy
+			return a
+		}},
+		{"caddy", "example.com", func(available, enabled string) Driver {
+			c := NewCaddyWithPaths(available, enabled)
+			c.paths.ActivationMode = config.ActivationCopy
+			return c
+		}},
+		{"traefik", "example.com.yml", func(available, enabled string) Driver {
+			tr := NewTraefikWithPaths(available, enabled)
+			tr.paths.ActivationMode = config.ActivationCopy
+			return tr
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			enabledDir := filepath.Join(tempDir, "enabled")
+			drv := tt.newFn(filepath.Join(tempDir, "available"), enabledDir)
+			vhost := &config.VHost{Domain: "example.com", Type: config.TypeStatic}
+			target := filepath.Join(enabledDir, tt.file)
+
+			if err := drv.Add(vhost, "first"); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+			if err := drv.Enable(vhost.Domain); err != nil {
+				t.Fatalf("Enable failed: %v", err)
+			}
+
+			info, err := os.Lstat(target)
+			if err != nil {
+				t.Fatalf("enabled config missing: %v", err)
+			}
+			if !info.Mode().IsRegular() {
+				t.Errorf("expected a regular file, got mode %v", info.Mode())
+			}
+			if content, _ := os.ReadFile(target); string(content) != "first" {
+				t.Errorf("expected the enabled copy to hold %q, got %q", "first", content)
+			}
+			if enabled, err := drv.IsEnabled(vhost.Domain); err != nil || !enabled {
+				t.Errorf("expected vhost to be enabled, got %v, %v", enabled, err)
+			}
+
+			// Re-adding updates the copy the server loads
+			if err := drv.Add(vhost, "second"); err != nil {
+				t.Fatalf("re-Add failed: %v", err)
+			}
+			if content, _ := os.ReadFile(target); string(content) != "second" {
+				t.Errorf("expected the enabled copy to be updated to %q, got %q", "second", content)
+			}
+
+			// A copy that no longer matches sites-available isn't enabled;
+			// enabling it again refreshes it
+			if err := os.WriteFile(target, []byte("outdated"), 0644); err != nil {
+				t.Fatalf("failed to write outdated copy: %v", err)
+			}
+			if enabled, err := drv.IsEnabled(vhost.Domain); err != nil || enabled {
+				t.Errorf("expected an outdated copy not to count as enabled, got %v, %v", enabled, err)
+			}
+			if err := drv.Enable(vhost.Domain); err != nil {
+				t.Fatalf("Enable of an outdated copy failed: %v", err)
+			}
+			if content, _ := os.ReadFile(target); string(content) != "second" {
+				t.Errorf("expected Enable to refresh the copy to %q, got %q", "second", content)
+			}
+
+			if err := drv.Disable(vhost.Domain); err != nil {
+				t.Fatalf("Disable failed: %v", err)
+			}
+			if _, err := os.Lstat(target); !os.IsNotExist(err) {
+				t.Errorf("expected the enabled copy to be removed, got %v", err)
+			}
+			if enabled, _ := drv.IsEnabled(vhost.Domain); enabled {
+				t.Error("expected vhost to be disabled")
+			}
+		})
+	}
+}
+
+func TestActivationSymlink(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	enabledDir := filepath.Join(tempDir, "sites-enabled")
+	drv := NewNginxWithPaths(availableDir, enabledDir)
+	vhost := &config.VHost{Domain: "example.com", Type: config.TypeStatic}
+
+	if err := drv.Add(vhost, "server {}"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := drv.Enable(vhost.Domain); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+	info, err := os.Lstat(filepath.Join(enabledDir, vhost.Domain))
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected a symlink by default, got %v, %v", info, err)
+	}
+
+	// A regular file is only removable in copy mode
+	if err := os.Remove(filepath.Join(enabledDir, vhost.Domain)); err != nil {
+		t.Fatalf("failed to remove symlink: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(enabledDir, vhost.Domain), []byte("server {}"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	err = drv.Disable(vhost.Domain)
+	if err == nil || !strings.Contains(err.Error(), "not a symlink") {
+		t.Errorf("expected refusal to remove a regular file, got %v", err)
+	}
+}
+
+func TestRelink(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		newFn func(available, enabled string) Driver
+	}{
+		{"nginx", "example.com", func(available, enabled string) Driver { return NewNginxWithPaths(available, enabled) }},
+		{"apache", "example.com.conf", func(available, enabled string) Driver { return NewApacheWithPaths(available, enabled) }},
+		{"caddy", "example.com", func(available, enabled string) Driver { return NewCaddyWithPaths(available, enabled) }},
+		{"traefik", "example.com.yml", func(available, enabled string) Driver { return NewTraefikWithPaths(available, enabled) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			availableDir := filepath.Join(tempDir, "available")
+			enabledDir := filepath.Join(tempDir, "enabled")
+			drv := tt.newFn(availableDir, enabledDir)
+			vhost := &config.VHost{Domain: "example.com", Type: config.TypeStatic}
+			source := filepath.Join(availableDir, tt.file)
+			target := filepath.Join(enabledDir, tt.file)
+
+			if err := drv.Add(vhost, "config"); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+			if err := os.MkdirAll(enabledDir, 0755); err != nil {
+				t.Fatalf("failed to create enabled dir: %v", err)
+			}
+			wrong := filepath.Join(tempDir, "old", tt.file)
+			if err := os.Symlink(wrong, target); err != nil {
+				t.Fatalf("failed to create symlink: %v", err)
+			}
+
+			// Enable keeps refusing an existing entry
+			if err := drv.Enable(vhost.Domain); err == nil || !strings.Contains(err.Error(), "already enabled") {
+				t.Errorf("expected already enabled error, got %v", err)
+			}
+			if link, _ := os.Readlink(target); link != wrong {
+				t.Errorf("expected Enable to leave the link alone, got %s", link)
+			}
+
+			relinker, ok := drv.(Relinker)
+			if !ok {
+				t.Fatalf("expected %s to implement Relinker", tt.name)
+			}
+			if err := relinker.Relink(vhost.Domain); err != nil {
+				t.Fatalf("Relink failed: %v", err)
+			}
+			if link, _ := os.Readlink(target); link != source {
+				t.Errorf("expected the link to point at %s, got %s", source, link)
+			}
+
+			// The temporary link is renamed away, not left behind
+			entries, err := os.ReadDir(enabledDir)
+			if err != nil || len(entries) != 1 {
+				t.Errorf("expected only the enabled link, got %v, %v", entries, err)
+			}
+		})
+	}
+}
+
+func TestRelinkRefusals(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	enabledDir := filepath.Join(tempDir, "sites-enabled")
+	drv := NewNginxWithPaths(availableDir, enabledDir)
+
+	if err := drv.Relink("missing.com"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	vhost := &config.VHost{Domain: "example.com", Type: config.TypeStatic}
+	if err := drv.Add(vhost, "server {}"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := os.MkdirAll(enabledDir, 0755); err != nil {
+		t.Fatalf("failed to create enabled dir: %v", err)
+	}
+
+	// Not enabled yet: Relink just enables it
+	if err := drv.Relink(vhost.Domain); err != nil {
+		t.Fatalf("Relink failed: %v", err)
+	}
+	if enabled, _ := drv.IsEnabled(vhost.Domain); !enabled {
+		t.Error("expected vhost to be enabled")
+	}
+
+	// A hand-made file is not replaced
+	target := filepath.Join(enabledDir, vhost.Domain)
+	if err := os.Remove(target); err != nil {
+		t.Fatalf("failed to remove symlink: %v", err)
+	}
+	if err := os.WriteFile(target, []byte("hand written"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := drv.Relink(vhost.Domain); err == nil || !strings.Contains(err.Error(), "not a symlink") {
+		t.Errorf("expected refusal to replace a regular file, got %v", err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "hand written" {
+		t.Errorf("expected the file to be kept, got %q", content)
+	}
+}
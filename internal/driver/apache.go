@@ -66,6 +66,11 @@ func (a *ApacheDriver) configFileName(domain string) string {
 
 // Add creates a vhost config file
 func (a *ApacheDriver) Add(vhost *config.VHost, configContent string) error {
+	// Refuse a missing root up front when creating it is disabled
+	if err := checkDocumentRoot(vhost); err != nil {
+		return err
+	}
+
 	// Create sites-available directory if it doesn't exist
 	if err := os.MkdirAll(a.paths.Available, 0755); err != nil {
 		return fmt.Errorf("failed to create sites-available directory: %w", err)
@@ -82,11 +87,14 @@ func (a *ApacheDriver) Add(vhost *config.VHost, configContent string) error {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
+	// Keep an enabled copy in step with the new config
+	if err := refreshEnabledCopy(a.paths.ActivationMode, configPath, filepath.Join(a.paths.Enabled, a.configFileName(vhost.Domain))); err != nil {
+		return err
+	}
+
 	// Create document root if specified and doesn't exist
-	if vhost.Root != "" {
-		if err := os.MkdirAll(vhost.Root, 0755); err != nil {
-			return fmt.Errorf("failed to create document root: %w", err)
-		}
+	if err := createDocumentRoot(vhost); err != nil {
+		return err
 	}
 
 	return nil
@@ -113,7 +121,7 @@ func (a *ApacheDriver) Remove(domain string) error {
 	return nil
 }
 
-// Enable activates a vhost by creating a symlink
+// Enable activates a vhost by creating a symlink, or by copying it in copy mode
 func (a *ApacheDriver) Enable(domain string) error {
 	source := filepath.Join(a.paths.Available, a.configFileName(domain))
 	target := filepath.Join(a.paths.Enabled, a.configFileName(domain))
@@ -123,20 +131,32 @@ func (a *ApacheDriver) Enable(domain string) error {
 		return fmt.Errorf("vhost %s not found in sites-available", domain)
 	}
 
-	// Check if already enabled
-	if _, err := os.Lstat(target); err == nil {
+	// Check if already enabled; an outdated copy is replaced below
+	enabled, err := isConfigEnabled(a.paths.ActivationMode, source, target)
+	if err != nil {
+		return err
+	}
+	if enabled {
 		return fmt.Errorf("vhost %s is already enabled", domain)
 	}
 
-	// Create symlink
-	if err := os.Symlink(source, target); err != nil {
+	// Create the symlink, or the copy in copy mode
+	if err := activateConfig(a.paths.ActivationMode, source, target); err != nil {
 		return fmt.Errorf("failed to enable vhost: %w", err)
 	}
 
 	return nil
 }
 
-// Disable deactivates a vhost by removing the symlink
+// Relink enables a vhost even if it already is, repointing a symlink that
+// leads to another file
+func (a *ApacheDriver) Relink(domain string) error {
+	source := filepath.Join(a.paths.Available, a.configFileName(domain))
+	target := filepath.Join(a.paths.Enabled, a.configFileName(domain))
+	return relinkConfig(a.paths.ActivationMode, domain, source, target)
+}
+
+// Disable deactivates a vhost by removing the symlink or copy
 func (a *ApacheDriver) Disable(domain string) error {
 	target := filepath.Join(a.paths.Enabled, a.configFileName(domain))
 
@@ -149,9 +169,9 @@ func (a *ApacheDriver) Disable(domain string) error {
 		return fmt.Errorf("failed to check vhost status: %w", err)
 	}
 
-	// Verify it's a symlink
-	if info.Mode()&os.ModeSymlink == 0 {
-		return fmt.Errorf("vhost %s is not a symlink, refusing to remove", domain)
+	// Verify it's a symlink, or a copy in copy mode
+	if err := checkEnabledEntry(a.paths.ActivationMode, domain, info); err != nil {
+		return err
 	}
 
 	// Remove symlink
@@ -188,19 +208,16 @@ func (a *ApacheDriver) List() ([]string, error) {
 
 // IsEnabled checks if a vhost is enabled
 func (a *ApacheDriver) IsEnabled(domain string) (bool, error) {
+	source := filepath.Join(a.paths.Available, a.configFileName(domain))
 	target := filepath.Join(a.paths.Enabled, a.configFileName(domain))
-	_, err := os.Lstat(target)
-	if os.IsNotExist(err) {
-		return false, nil
-	}
-	if err != nil {
-		return false, fmt.Errorf("failed to check vhost status: %w", err)
-	}
-	return true, nil
+	return isConfigEnabled(a.paths.ActivationMode, source, target)
 }
 
 // Test validates the apache config syntax
 func (a *ApacheDriver) Test() error {
+	if len(a.paths.TestCommand) > 0 {
+		return runTestCommand(a.exec, "apache", a.paths.TestCommand)
+	}
 	output, err := a.exec.Execute("apache2ctl", "configtest")
 	if err != nil {
 		return fmt.Errorf("apache config test failed: %s", string(output))
@@ -210,18 +227,21 @@ func (a *ApacheDriver) Test() error {
 
 // Reload reloads apache to apply changes
 func (a *ApacheDriver) Reload() error {
-	_, err := a.exec.Execute("systemctl", "reload", "apache2")
-	if err != nil {
-		// Try apache2ctl graceful as fallback
-		output, err := a.exec.Execute("apache2ctl", "graceful")
-		if err != nil {
-			return fmt.Errorf("failed to reload apache: %s", string(output))
-		}
+	if len(a.paths.ReloadCommand) > 0 {
+		return runReloadCommand(a.exec, "apache", a.paths.ReloadCommand)
+	}
+	// Fall back to apache2ctl graceful
+	if output, err := reloadService(a.exec, "apache2", []string{"apache2ctl", "graceful"}); err != nil {
+		return fmt.Errorf("failed to reload apache: %s", string(output))
 	}
 	return nil
 }
 
-// init registers the apache driver
+// init registers the apache driver factory
 func init() {
-	Register(NewApache())
+	registerBuiltIn(NewApache(), func(paths Paths) Driver {
+		a := NewApacheWithExecutor(paths.Available, paths.Enabled, systemExecutor(paths))
+		a.paths = paths // keeps the test and reload command overrides
+		return a
+	})
 }
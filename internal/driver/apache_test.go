@@ -242,12 +242,7 @@ func TestApacheDriver_WithExecutor(t *testing.T) {
 		}
 
 		// Verify the correct command was called
-		if len(mock.Calls) != 1 {
-			t.Errorf("expected 1 call, got %d", len(mock.Calls))
-		}
-		if mock.Calls[0].Name != "apache2ctl" || mock.Calls[0].Args[0] != "configtest" {
-			t.Errorf("expected apache2ctl configtest, got %s %v", mock.Calls[0].Name, mock.Calls[0].Args)
-		}
+		mock.ExpectSequence(t, [][]string{{"apache2ctl", "configtest"}})
 	})
 
 	t.Run("Test_failure", func(t *testing.T) {
@@ -61,6 +61,11 @@ func (c *CaddyDriver) Paths() Paths {
 
 // Add creates a vhost config file
 func (c *CaddyDriver) Add(vhost *config.VHost, configContent string) error {
+	// Refuse a missing root up front when creating it is disabled
+	if err := checkDocumentRoot(vhost); err != nil {
+		return err
+	}
+
 	// Create sites-available directory if it doesn't exist
 	if err := os.MkdirAll(c.paths.Available, 0755); err != nil {
 		return fmt.Errorf("failed to create sites-available directory: %w", err)
@@ -77,11 +82,14 @@ func (c *CaddyDriver) Add(vhost *config.VHost, configContent string) error {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
+	// Keep an enabled copy in step with the new config
+	if err := refreshEnabledCopy(c.paths.ActivationMode, configPath, filepath.Join(c.paths.Enabled, vhost.Domain)); err != nil {
+		return err
+	}
+
 	// Create document root if specified and doesn't exist
-	if vhost.Root != "" {
-		if err := os.MkdirAll(vhost.Root, 0755); err != nil {
-			return fmt.Errorf("failed to create document root: %w", err)
-		}
+	if err := createDocumentRoot(vhost); err != nil {
+		return err
 	}
 
 	return nil
@@ -108,7 +116,7 @@ func (c *CaddyDriver) Remove(domain string) error {
 	return nil
 }
 
-// Enable activates a vhost by creating a symlink
+// Enable activates a vhost by creating a symlink, or by copying it in copy mode
 func (c *CaddyDriver) Enable(domain string) error {
 	source := filepath.Join(c.paths.Available, domain)
 	target := filepath.Join(c.paths.Enabled, domain)
@@ -118,20 +126,32 @@ func (c *CaddyDriver) Enable(domain string) error {
 		return fmt.Errorf("vhost %s not found in sites-available", domain)
 	}
 
-	// Check if already enabled
-	if _, err := os.Lstat(target); err == nil {
+	// Check if already enabled; an outdated copy is replaced below
+	enabled, err := isConfigEnabled(c.paths.ActivationMode, source, target)
+	if err != nil {
+		return err
+	}
+	if enabled {
 		return fmt.Errorf("vhost %s is already enabled", domain)
 	}
 
-	// Create symlink
-	if err := os.Symlink(source, target); err != nil {
+	// Create the symlink, or the copy in copy mode
+	if err := activateConfig(c.paths.ActivationMode, source, target); err != nil {
 		return fmt.Errorf("failed to enable vhost: %w", err)
 	}
 
 	return nil
 }
 
-// Disable deactivates a vhost by removing the symlink
+// Relink enables a vhost even if it already is, repointing a symlink that
+// leads to another file
+func (c *CaddyDriver) Relink(domain string) error {
+	source := filepath.Join(c.paths.Available, domain)
+	target := filepath.Join(c.paths.Enabled, domain)
+	return relinkConfig(c.paths.ActivationMode, domain, source, target)
+}
+
+// Disable deactivates a vhost by removing the symlink or copy
 func (c *CaddyDriver) Disable(domain string) error {
 	target := filepath.Join(c.paths.Enabled, domain)
 
@@ -144,9 +164,9 @@ func (c *CaddyDriver) Disable(domain string) error {
 		return fmt.Errorf("failed to check vhost status: %w", err)
 	}
 
-	// Verify it's a symlink
-	if info.Mode()&os.ModeSymlink == 0 {
-		return fmt.Errorf("vhost %s is not a symlink, refusing to remove", domain)
+	// Verify it's a symlink, or a copy in copy mode
+	if err := checkEnabledEntry(c.paths.ActivationMode, domain, info); err != nil {
+		return err
 	}
 
 	// Remove symlink
@@ -179,19 +199,16 @@ func (c *CaddyDriver) List() ([]string, error) {
 
 // IsEnabled checks if a vhost is enabled
 func (c *CaddyDriver) IsEnabled(domain string) (bool, error) {
+	source := filepath.Join(c.paths.Available, domain)
 	target := filepath.Join(c.paths.Enabled, domain)
-	_, err := os.Lstat(target)
-	if os.IsNotExist(err) {
-		return false, nil
-	}
-	if err != nil {
-		return false, fmt.Errorf("failed to check vhost status: %w", err)
-	}
-	return true, nil
+	return isConfigEnabled(c.paths.ActivationMode, source, target)
 }
 
 // Test validates the caddy config syntax
 func (c *CaddyDriver) Test() error {
+	if len(c.paths.TestCommand) > 0 {
+		return runTestCommand(c.exec, "caddy", c.paths.TestCommand)
+	}
 	output, err := c.exec.Execute("caddy", "validate", "--config", "/etc/caddy/Caddyfile")
 	if err != nil {
 		return fmt.Errorf("caddy config test failed: %s", string(output))
@@ -201,18 +218,21 @@ func (c *CaddyDriver) Test() error {
 
 // Reload reloads caddy to apply changes
 func (c *CaddyDriver) Reload() error {
-	_, err := c.exec.Execute("systemctl", "reload", "caddy")
-	if err != nil {
-		// Try caddy reload as fallback
-		output, err := c.exec.Execute("caddy", "reload", "--config", "/etc/caddy/Caddyfile")
-		if err != nil {
-			return fmt.Errorf("failed to reload caddy: %s", string(output))
-		}
+	if len(c.paths.ReloadCommand) > 0 {
+		return runReloadCommand(c.exec, "caddy", c.paths.ReloadCommand)
+	}
+	// Fall back to caddy reload
+	if output, err := reloadService(c.exec, "caddy", []string{"caddy", "reload", "--config", "/etc/caddy/Caddyfile"}); err != nil {
+		return fmt.Errorf("failed to reload caddy: %s", string(output))
 	}
 	return nil
 }
 
-// init registers the caddy driver
+// init registers the caddy driver factory
 func init() {
-	Register(NewCaddy())
+	registerBuiltIn(NewCaddy(), func(paths Paths) Driver {
+		c := NewCaddyWithExecutor(paths.Available, paths.Enabled, systemExecutor(paths))
+		c.paths = paths // keeps the test and reload command overrides
+		return c
+	})
 }
@@ -0,0 +1,205 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// snippetsFileName is the shared snippets file, stored next to the sites
+// directories. The main Caddyfile must import it before the sites, e.g.
+//
+//	import snippets.caddy
+//	import sites-enabled/*
+const snippetsFileName = "snippets.caddy"
+
+// snippetNamePattern matches valid snippet names
+var snippetNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// snippetHeaderPattern matches the opening line of a top-level snippet
+var snippetHeaderPattern = regexp.MustCompile(`^\(([^)\s]+)\)\s*\{\s*$`)
+
+// snippetBlock is one top-level section of the snippets file. Name is empty
+// for text between snippets (comments, blank lines), which is preserved.
+type snippetBlock struct {
+	name string
+	text string
+}
+
+// ValidateSnippetName checks that name is usable as a Caddy snippet name
+func ValidateSnippetName(name string) error {
+	if !snippetNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid snippet name %q: use letters, numbers, hyphens, and underscores", name)
+	}
+	return nil
+}
+
+// SnippetsPath returns the path of the shared snippets file
+func (c *CaddyDriver) SnippetsPath() string {
+	return filepath.Join(filepath.Dir(c.paths.Available), snippetsFileName)
+}
+
+// ListSnippets returns the snippet names defined in the snippets file
+func (c *CaddyDriver) ListSnippets() ([]string, error) {
+	blocks, err := c.readSnippets()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, block := range blocks {
+		if block.name != "" {
+			names = append(names, block.name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// SetSnippet adds or replaces a named snippet with the given body
+func (c *CaddyDriver) SetSnippet(name, body string) error {
+	if err := ValidateSnippetName(name); err != nil {
+		return err
+	}
+
+	blocks, err := c.readSnippets()
+	if err != nil {
+		return err
+	}
+
+	text := formatSnippet(name, body)
+	replaced := false
+	for i := range blocks {
+		if blocks[i].name == name {
+			blocks[i].text = text
+			replaced = true
+		}
+	}
+	if !replaced {
+		if len(blocks) > 0 && !strings.HasSuffix(blocks[len(blocks)-1].text, "\n\n") {
+			blocks = append(blocks, snippetBlock{text: "\n"})
+		}
+		blocks = append(blocks, snippetBlock{name: name, text: text})
+	}
+
+	return c.writeSnippets(blocks)
+}
+
+// RemoveSnippet deletes a named snippet
+func (c *CaddyDriver) RemoveSnippet(name string) error {
+	blocks, err := c.readSnippets()
+	if err != nil {
+		return err
+	}
+
+	kept := blocks[:0]
+	found := false
+	for _, block := range blocks {
+		if block.name == name {
+			found = true
+			continue
+		}
+		kept = append(kept, block)
+	}
+	if !found {
+		return fmt.Errorf("snippet %s not found", name)
+	}
+
+	return c.writeSnippets(kept)
+}
+
+// readSnippets parses the snippets file into top-level blocks
+func (c *CaddyDriver) readSnippets() ([]snippetBlock, error) {
+	content, err := os.ReadFile(c.SnippetsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read snippets file: %w", err)
+	}
+
+	return parseSnippets(string(content))
+}
+
+// writeSnippets writes blocks back to the snippets file
+func (c *CaddyDriver) writeSnippets(blocks []snippetBlock) error {
+	var sb strings.Builder
+	for _, block := range blocks {
+		sb.WriteString(block.text)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.SnippetsPath()), 0755); err != nil {
+		return fmt.Errorf("failed to create caddy config directory: %w", err)
+	}
+	if err := os.WriteFile(c.SnippetsPath(), []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write snippets file: %w", err)
+	}
+
+	return nil
+}
+
+// parseSnippets splits Caddyfile content into named snippet blocks and the
+// text between them, tracking brace depth to find each snippet's end
+func parseSnippets(content string) ([]snippetBlock, error) {
+	var blocks []snippetBlock
+	var current strings.Builder
+	name := ""
+	depth := 0
+
+	lines := strings.SplitAfter(content, "\n")
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		if depth == 0 {
+			if matches := snippetHeaderPattern.FindStringSubmatch(strings.TrimSpace(line)); matches != nil {
+				if current.Len() > 0 {
+					blocks = append(blocks, snippetBlock{text: current.String()})
+					current.Reset()
+				}
+				name = matches[1]
+			}
+		}
+
+		current.WriteString(line)
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+
+		if depth < 0 {
+			return nil, fmt.Errorf("failed to parse snippets file: unbalanced braces")
+		}
+		if depth == 0 && name != "" {
+			blocks = append(blocks, snippetBlock{name: name, text: current.String()})
+			current.Reset()
+			name = ""
+		}
+	}
+
+	if depth != 0 {
+		return nil, fmt.Errorf("failed to parse snippets file: unterminated snippet %s", name)
+	}
+	if current.Len() > 0 {
+		blocks = append(blocks, snippetBlock{text: current.String()})
+	}
+
+	return blocks, nil
+}
+
+// formatSnippet renders a snippet definition with an indented body
+func formatSnippet(name, body string) string {
+	var sb strings.Builder
+	sb.WriteString("(" + name + ") {\n")
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString("    " + strings.TrimRight(line, " \t") + "\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
@@ -0,0 +1,98 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCaddySnippets(t *testing.T) {
+	tempDir := t.TempDir()
+	drv := NewCaddyWithPaths(filepath.Join(tempDir, "sites-available"), filepath.Join(tempDir, "sites-enabled"))
+
+	if got := drv.SnippetsPath(); got != filepath.Join(tempDir, "snippets.caddy") {
+		t.Fatalf("unexpected snippets path: %s", got)
+	}
+
+	names, err := drv.ListSnippets()
+	if err != nil {
+		t.Fatalf("ListSnippets on missing file failed: %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("expected no snippets, got %v", names)
+	}
+
+	if err := drv.SetSnippet("logging", "log {\n    output file /var/log/caddy/access.log\n}\n"); err != nil {
+		t.Fatalf("SetSnippet failed: %v", err)
+	}
+	if err := drv.SetSnippet("compress", "encode gzip"); err != nil {
+		t.Fatalf("SetSnippet failed: %v", err)
+	}
+
+	names, err = drv.ListSnippets()
+	if err != nil {
+		t.Fatalf("ListSnippets failed: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"compress", "logging"}) {
+		t.Errorf("unexpected snippets: %v", names)
+	}
+
+	content, _ := os.ReadFile(drv.SnippetsPath())
+	if !strings.Contains(string(content), "(logging) {\n    log {\n        output file /var/log/caddy/access.log\n    }\n}\n") {
+		t.Errorf("unexpected snippet formatting:\n%s", content)
+	}
+
+	// Replacing keeps a single definition
+	if err := drv.SetSnippet("compress", "encode zstd gzip"); err != nil {
+		t.Fatalf("SetSnippet replace failed: %v", err)
+	}
+	content, _ = os.ReadFile(drv.SnippetsPath())
+	if strings.Count(string(content), "(compress)") != 1 || !strings.Contains(string(content), "encode zstd gzip") {
+		t.Errorf("snippet not replaced:\n%s", content)
+	}
+
+	if err := drv.RemoveSnippet("logging"); err != nil {
+		t.Fatalf("RemoveSnippet failed: %v", err)
+	}
+	if err := drv.RemoveSnippet("logging"); err == nil {
+		t.Error("expected error removing missing snippet")
+	}
+	names, _ = drv.ListSnippets()
+	if !reflect.DeepEqual(names, []string{"compress"}) {
+		t.Errorf("unexpected snippets after remove: %v", names)
+	}
+
+	if err := drv.SetSnippet("bad name", "encode gzip"); err == nil {
+		t.Error("expected error for invalid snippet name")
+	}
+}
+
+func TestParseSnippetsPreservesText(t *testing.T) {
+	content := "# Shared snippets\n\n(tls) {\n    tls internal\n}\n\n# trailing comment\n"
+
+	blocks, err := parseSnippets(content)
+	if err != nil {
+		t.Fatalf("parseSnippets failed: %v", err)
+	}
+
+	var rebuilt strings.Builder
+	var names []string
+	for _, block := range blocks {
+		rebuilt.WriteString(block.text)
+		if block.name != "" {
+			names = append(names, block.name)
+		}
+	}
+	if rebuilt.String() != content {
+		t.Errorf("round trip changed content:\n%q\nwant\n%q", rebuilt.String(), content)
+	}
+	if !reflect.DeepEqual(names, []string{"tls"}) {
+		t.Errorf("unexpected names: %v", names)
+	}
+
+	if _, err := parseSnippets("(broken) {\n    tls internal\n"); err == nil {
+		t.Error("expected error for unterminated snippet")
+	}
+}
@@ -232,12 +232,7 @@ func TestCaddyDriver_WithExecutor(t *testing.T) {
 		}
 
 		// Verify the correct command was called
-		if len(mock.Calls) != 1 {
-			t.Errorf("expected 1 call, got %d", len(mock.Calls))
-		}
-		if mock.Calls[0].Name != "caddy" || mock.Calls[0].Args[0] != "validate" {
-			t.Errorf("expected caddy validate, got %s %v", mock.Calls[0].Name, mock.Calls[0].Args)
-		}
+		mock.ExpectSequence(t, [][]string{{"caddy", "validate", "--config", "/etc/caddy/Caddyfile"}})
 	})
 
 	t.Run("Test_failure", func(t *testing.T) {
@@ -1,5 +1,5 @@
 // Package driver provides abstractions for managing virtual host configurations
-// across different web servers (Nginx, Apache, Caddy).
+// across different web servers (Nginx, Apache, Caddy, Traefik).
 //
 // The driver package implements a unified interface for web server operations,
 // allowing the vhost tool to support multiple web server backends without
@@ -10,6 +10,7 @@
 //   - Nginx: Standard sites-available/sites-enabled pattern
 //   - Apache: .conf extension with symlink activation
 //   - Caddy: Caddyfile-based configuration
+//   - Traefik: File provider dynamic config (<domain>.yml), hot-reloaded
 //
 // # Basic Usage
 //
@@ -44,6 +45,41 @@
 //	// Caddy
 //	drv := driver.NewCaddyWithPaths(availablePath, enabledPath)
 //
+//	// Traefik (enabledPath is the file provider's dynamic directory)
+//	drv := driver.NewTraefikWithPaths(availablePath, dynamicPath)
+//
+// # Reading Existing Configs
+//
+// The nginx and apache drivers implement Parser, which reads server names,
+// root, listen ports, SSL files and the proxy target back out of a config
+// file, including ones vhost did not write:
+//
+//	if p, ok := drv.(driver.Parser); ok {
+//	    parsed, err := p.Parse(content)
+//	}
+//
+// # Custom Drivers
+//
+// Any Driver can be added to the set vhost selects from by name. Register
+// it from an init function with RegisterFactory, which receives the
+// configured paths, or with Register for a driver that manages its own.
+// Being internal, the package can only be imported from within this
+// module, so the registering file is built as part of vhost itself (for
+// example under cmd/vhost) rather than in a separate module:
+//
+//	func init() {
+//	    driver.RegisterFactory("myproxy", func(paths driver.Paths) driver.Driver {
+//	        return NewMyProxyDriver(paths.Available, paths.Enabled)
+//	    })
+//	}
+//
+// New creates a registered driver by name and Drivers lists the names.
+// Registered names are also accepted as the config's driver.
+//
+// A driver may also implement Relinker to replace an existing enabled entry
+// in one step; without it, enable --force disables the vhost and enables it
+// again.
+//
 // # Testing
 //
 // Each driver implementation provides a WithExecutor constructor that accepts
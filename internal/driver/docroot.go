@@ -0,0 +1,168 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/ksyq12/vhost/internal/config"
+)
+
+// defaultRootPerms is the mode for created document roots
+const defaultRootPerms os.FileMode = 0755
+
+// createDocumentRoot creates the vhost document root if one is configured.
+// A newly created root gets the configured mode and, when running as root,
+// the configured owner; an existing directory is left as it is.
+func createDocumentRoot(vhost *config.VHost) error {
+	if vhost.Root == "" {
+		return nil
+	}
+
+	mode := defaultRootPerms
+	if vhost.RootPerms != "" {
+		parsed, err := ParseRootPerms(vhost.RootPerms)
+		if err != nil {
+			return err
+		}
+		mode = parsed
+	}
+
+	_, statErr := os.Stat(vhost.Root)
+	created := os.IsNotExist(statErr)
+
+	if err := os.MkdirAll(vhost.Root, mode.Perm()); err != nil {
+		return fmt.Errorf("failed to create document root: %w", err)
+	}
+
+	// Chmod explicitly so the umask and special bits (setgid) don't get lost
+	if created && vhost.RootPerms != "" {
+		if err := os.Chmod(vhost.Root, mode); err != nil {
+			return fmt.Errorf("failed to set document root permissions: %w", err)
+		}
+	}
+
+	if created && vhost.RootOwner != "" && os.Geteuid() == 0 {
+		uid, gid, err := LookupOwner(vhost.RootOwner)
+		if err != nil {
+			return err
+		}
+		if err := os.Chown(vhost.Root, uid, gid); err != nil {
+			return fmt.Errorf("failed to set document root owner: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkDocumentRoot fails when the vhost requires a pre-existing document
+// root (RootNoCreate) and it is missing. Drivers call it before writing any
+// config so a refused root leaves nothing behind.
+func checkDocumentRoot(vhost *config.VHost) error {
+	if vhost.Root == "" || !vhost.RootNoCreate {
+		return nil
+	}
+
+	info, err := os.Stat(vhost.Root)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("document root %s does not exist and root creation is disabled", vhost.Root)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check document root: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("document root %s is not a directory", vhost.Root)
+	}
+	return nil
+}
+
+// ParseRootPerms parses an octal mode string such as "0750" or "2775".
+// The setuid, setgid and sticky bits map to their os.FileMode flags.
+func ParseRootPerms(perms string) (os.FileMode, error) {
+	value, err := strconv.ParseUint(perms, 8, 32)
+	if err != nil || value > 07777 {
+		return 0, fmt.Errorf("invalid root permissions %q: expected an octal mode like 0755", perms)
+	}
+
+	mode := os.FileMode(value & 0777)
+	if value&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if value&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if value&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode, nil
+}
+
+// LookupOwner resolves a "user" or "user:group" string to a uid and gid.
+// Names and numeric IDs are both accepted; without a group, the user's
+// primary group is used.
+func LookupOwner(owner string) (int, int, error) {
+	userName, groupName, hasGroup := strings.Cut(owner, ":")
+	if userName == "" || (hasGroup && groupName == "") {
+		return 0, 0, fmt.Errorf("invalid owner %q: expected user or user:group", owner)
+	}
+
+	uid, primaryGID, err := lookupUser(userName)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !hasGroup {
+		return uid, primaryGID, nil
+	}
+
+	gid, err := lookupGroup(groupName)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uid, gid, nil
+}
+
+// lookupUser returns the uid and primary gid for a user name or numeric id
+func lookupUser(name string) (int, int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		u, err := user.LookupId(name)
+		if err != nil {
+			// Unknown numeric ids are allowed; the group defaults to the same id
+			return id, id, nil
+		}
+		gid, _ := strconv.Atoi(u.Gid)
+		return id, gid, nil
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unknown user %q: %w", name, err)
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return 0, 0, fmt.Errorf("user %q has non-numeric uid %q", name, u.Uid)
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return 0, 0, fmt.Errorf("user %q has non-numeric gid %q", name, u.Gid)
+	}
+	return uid, gid, nil
+}
+
+// lookupGroup returns the gid for a group name or numeric id
+func lookupGroup(name string) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, fmt.Errorf("unknown group %q: %w", name, err)
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return 0, fmt.Errorf("group %q has non-numeric gid %q", name, g.Gid)
+	}
+	return gid, nil
+}
@@ -0,0 +1,215 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+)
+
+func TestCreateDocumentRootOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("chown requires root")
+	}
+
+	root := filepath.Join(t.TempDir(), "www", "example.com")
+	vhost := &config.VHost{
+		Domain:    "example.com",
+		Root:      root,
+		RootOwner: "65534:65534",
+	}
+
+	if err := createDocumentRoot(vhost); err != nil {
+		t.Fatalf("createDocumentRoot failed: %v", err)
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		t.Fatalf("document root not created: %v", err)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		t.Skip("ownership not available on this platform")
+	}
+	if stat.Uid != 65534 || stat.Gid != 65534 {
+		t.Errorf("expected owner 65534:65534, got %d:%d", stat.Uid, stat.Gid)
+	}
+
+	t.Run("existing root keeps its owner", func(t *testing.T) {
+		existing := t.TempDir()
+		vhost := &config.VHost{Domain: "example.com", Root: existing, RootOwner: "65534:65534"}
+		if err := createDocumentRoot(vhost); err != nil {
+			t.Fatalf("createDocumentRoot failed: %v", err)
+		}
+
+		info, err := os.Stat(existing)
+		if err != nil {
+			t.Fatalf("failed to stat root: %v", err)
+		}
+		if stat := info.Sys().(*syscall.Stat_t); stat.Uid == 65534 || stat.Gid == 65534 {
+			t.Errorf("expected the existing owner to be kept, got %d:%d", stat.Uid, stat.Gid)
+		}
+	})
+}
+
+func TestLookupOwner(t *testing.T) {
+	tests := []struct {
+		name    string
+		owner   string
+		wantUID int
+		wantGID int
+		wantErr bool
+	}{
+		{"numeric user and group", "1001:1002", 1001, 1002, false},
+		{"root by name", "root:root", 0, 0, false},
+		{"root user primary group", "root", 0, 0, false},
+		{"unknown user", "no-such-user-vhost", 0, 0, true},
+		{"unknown group", "root:no-such-group-vhost", 0, 0, true},
+		{"empty group", "root:", 0, 0, true},
+		{"empty user", ":root", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uid, gid, err := LookupOwner(tt.owner)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LookupOwner(%q) error = %v, wantErr %v", tt.owner, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if uid != tt.wantUID || gid != tt.wantGID {
+				t.Errorf("LookupOwner(%q) = %d:%d, want %d:%d", tt.owner, uid, gid, tt.wantUID, tt.wantGID)
+			}
+		})
+	}
+}
+
+func TestCreateDocumentRootPerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		perms string
+		want  os.FileMode
+	}{
+		{"default", "", 0755},
+		{"custom", "0750", 0750},
+		{"setgid", "2775", 0775 | os.ModeSetgid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "www")
+			vhost := &config.VHost{Domain: "example.com", Root: root, RootPerms: tt.perms}
+
+			if err := createDocumentRoot(vhost); err != nil {
+				t.Fatalf("createDocumentRoot failed: %v", err)
+			}
+
+			info, err := os.Stat(root)
+			if err != nil {
+				t.Fatalf("document root not created: %v", err)
+			}
+			got := info.Mode() & (os.ModePerm | os.ModeSetgid)
+			if tt.perms == "" {
+				// Default mode is subject to the umask
+				got &^= 0022
+				if got != tt.want&^0022 {
+					t.Errorf("mode = %v, want %v", got, tt.want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("mode = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid perms rejected", func(t *testing.T) {
+		vhost := &config.VHost{Domain: "example.com", Root: filepath.Join(t.TempDir(), "www"), RootPerms: "0789"}
+		if err := createDocumentRoot(vhost); err == nil {
+			t.Error("expected error for invalid perms")
+		}
+	})
+}
+
+func TestAddRootNoCreate(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	enabledDir := filepath.Join(tempDir, "sites-enabled")
+
+	t.Run("missing root refused", func(t *testing.T) {
+		drv := NewNginxWithPaths(availableDir, enabledDir)
+		root := filepath.Join(tempDir, "missing")
+		vhost := &config.VHost{Domain: "missing.example.com", Root: root, RootNoCreate: true}
+
+		if err := drv.Add(vhost, "server {}"); err == nil {
+			t.Fatal("expected error for missing root")
+		}
+		if _, err := os.Stat(root); !os.IsNotExist(err) {
+			t.Error("document root should not be created")
+		}
+		if _, err := os.Stat(filepath.Join(availableDir, vhost.Domain)); !os.IsNotExist(err) {
+			t.Error("config file should not be written")
+		}
+	})
+
+	t.Run("existing root accepted", func(t *testing.T) {
+		drv := NewNginxWithPaths(availableDir, enabledDir)
+		root := filepath.Join(tempDir, "mounted")
+		if err := os.Mkdir(root, 0755); err != nil {
+			t.Fatalf("failed to create root: %v", err)
+		}
+		vhost := &config.VHost{Domain: "mounted.example.com", Root: root, RootNoCreate: true}
+
+		if err := drv.Add(vhost, "server {}"); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(availableDir, vhost.Domain)); err != nil {
+			t.Errorf("config file not written: %v", err)
+		}
+	})
+
+	t.Run("root that is a file refused", func(t *testing.T) {
+		root := filepath.Join(tempDir, "file")
+		if err := os.WriteFile(root, nil, 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		vhost := &config.VHost{Domain: "file.example.com", Root: root, RootNoCreate: true}
+
+		if err := checkDocumentRoot(vhost); err == nil {
+			t.Error("expected error when root is not a directory")
+		}
+	})
+}
+
+func TestParseRootPerms(t *testing.T) {
+	tests := []struct {
+		perms   string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{"0755", 0755, false},
+		{"750", 0750, false},
+		{"2775", 0775 | os.ModeSetgid, false},
+		{"1777", 0777 | os.ModeSticky, false},
+		{"4755", 0755 | os.ModeSetuid, false},
+		{"0789", 0, true},
+		{"rwxr-xr-x", 0, true},
+		{"", 0, true},
+		{"17777", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.perms, func(t *testing.T) {
+			got, err := ParseRootPerms(tt.perms)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRootPerms(%q) error = %v, wantErr %v", tt.perms, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRootPerms(%q) = %v, want %v", tt.perms, got, tt.want)
+			}
+		})
+	}
+}
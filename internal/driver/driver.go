@@ -1,6 +1,14 @@
 package driver
 
-import "github.com/ksyq12/vhost/internal/config"
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/executor"
+)
 
 // Driver is the interface that all web server drivers must implement
 type Driver interface {
@@ -35,37 +43,160 @@ type Driver interface {
 	Paths() Paths
 }
 
+// Relinker is implemented by drivers that can enable a vhost in place of
+// an existing enabled entry, such as a symlink to the wrong file. All
+// built-in drivers implement it.
+type Relinker interface {
+	Relink(domain string) error
+}
+
 // Paths contains the web server config directory paths
 type Paths struct {
 	Available string // config available directory
 	Enabled   string // config enabled directory
+
+	// ConfigPath is the server's main config file; empty means the default.
+	// Only the nginx driver uses it.
+	ConfigPath string
+
+	// TestCommand and ReloadCommand, when set, replace the built-in config
+	// test and reload commands. They run as given, without a shell.
+	TestCommand   []string
+	ReloadCommand []string
+
+	// ActivationMode is how Enable puts a config in the enabled directory:
+	// config.ActivationSymlink (the default when empty) or
+	// config.ActivationCopy, for systems without usable symlinks
+	ActivationMode string
+
+	// Context, when set, kills the test and reload commands of built-in
+	// drivers once it is done
+	Context context.Context
 }
 
-// registry holds all registered drivers
-// Deprecated: The global registry is kept for backward compatibility.
-// New code should use NewNginxWithPaths, NewApacheWithPaths, or NewCaddyWithPaths
-// to create drivers with platform-specific paths.
+// Factory creates a driver that manages the given paths
+type Factory func(paths Paths) Driver
+
+// registry holds the default-path instance of each registered driver,
+// for Get
 var registry = make(map[string]Driver)
 
-// Register adds a driver to the registry
-// Deprecated: Use NewXxxWithPaths constructors instead for platform-aware driver creation.
+// factories holds the path-aware constructor of each registered driver
+var factories = make(map[string]Factory)
+
+// Register adds a driver under d.Name(), typically from an init function.
+// This package is internal, so only code built inside this module, such as
+// a file added to cmd/vhost, can register a driver; other modules can't
+// import it.
+// The driver is used as-is by New, so it keeps managing its own paths
+// regardless of the configured ones; use RegisterFactory for a driver
+// that should honour them. Registering a name again replaces the earlier
+// driver, including a built-in one. Register is not safe for concurrent use.
 func Register(d Driver) {
 	registry[d.Name()] = d
+	factories[d.Name()] = func(Paths) Driver { return d }
+	config.RegisterDriver(d.Name())
+}
+
+// RegisterFactory adds a driver that New constructs with the configured
+// paths. It replaces any driver registered under name, and like Register
+// is meant to be called from an init function.
+func RegisterFactory(name string, factory Factory) {
+	factories[name] = factory
+	config.RegisterDriver(name)
+}
+
+// registerBuiltIn registers a built-in driver's factory, plus its
+// default-path instance for Get
+func registerBuiltIn(defaults Driver, factory Factory) {
+	registry[defaults.Name()] = defaults
+	RegisterFactory(defaults.Name(), factory)
+}
+
+// systemExecutor returns the executor a built-in driver runs its server
+// commands with, bound to paths.Context when there is one
+func systemExecutor(paths Paths) executor.CommandExecutor {
+	if paths.Context == nil {
+		return executor.NewSystemExecutor()
+	}
+	return executor.NewSystemExecutorContext(paths.Context)
+}
+
+// runTestCommand runs command, a configured replacement for the config
+// test of the server name
+func runTestCommand(exec executor.CommandExecutor, name string, command []string) error {
+	output, err := exec.Execute(command[0], command[1:]...)
+	if err != nil {
+		return fmt.Errorf("%s config test failed: %s", name, commandFailure(output, err))
+	}
+	return nil
+}
+
+// runReloadCommand runs command, a configured replacement for the reload
+// of the server name. There is no fallback when it fails.
+func runReloadCommand(exec executor.CommandExecutor, name string, command []string) error {
+	output, err := exec.Execute(command[0], command[1:]...)
+	if err != nil {
+		return fmt.Errorf("failed to reload %s: %s", name, commandFailure(output, err))
+	}
+	return nil
+}
+
+// reloadService reloads service through the detected init system. When
+// that fails, or there is no init system, it runs fallback, the server's
+// own reload command, and returns its output.
+func reloadService(exec executor.CommandExecutor, service string, fallback []string) ([]byte, error) {
+	switch executor.DetectInitSystem(exec) {
+	case executor.InitSystemd:
+		if _, err := exec.Execute("systemctl", "reload", service); err == nil {
+			return nil, nil
+		}
+	case executor.InitOpenRC:
+		if _, err := exec.Execute("rc-service", service, "reload"); err == nil {
+			return nil, nil
+		}
+	}
+	return exec.Execute(fallback[0], fallback[1:]...)
+}
+
+// commandFailure describes a failed command by its output, or by err when
+// it printed nothing (e.g. the program doesn't exist)
+func commandFailure(output []byte, err error) string {
+	if text := strings.TrimSpace(string(output)); text != "" {
+		return text
+	}
+	return err.Error()
+}
+
+// New creates the registered driver name rooted at paths
+func New(name string, paths Paths) (Driver, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown driver: %s (available: %s)", name, strings.Join(Drivers(), ", "))
+	}
+	return factory(paths), nil
+}
+
+// Drivers returns the names of all registered drivers, sorted
+func Drivers() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // Get returns a driver by name
-// Deprecated: Returns a driver with default Linux paths. Use NewXxxWithPaths constructors
-// with platform-detected paths for cross-platform compatibility.
+// Deprecated: Returns a driver with default Linux paths. Use New with
+// platform-detected paths for cross-platform compatibility.
 func Get(name string) (Driver, bool) {
 	d, ok := registry[name]
 	return d, ok
 }
 
 // Available returns all registered driver names
+// Deprecated: Use Drivers, which is sorted and includes RegisterFactory drivers.
 func Available() []string {
-	names := make([]string, 0, len(registry))
-	for name := range registry {
-		names = append(names, name)
-	}
-	return names
+	return Drivers()
 }
@@ -0,0 +1,286 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/executor"
+)
+
+// unregister removes a test driver so other tests see the built-in set
+func unregister(t *testing.T, name string) {
+	t.Cleanup(func() {
+		delete(registry, name)
+		delete(factories, name)
+	})
+}
+
+func TestDriversBuiltIn(t *testing.T) {
+	want := []string{"apache", "caddy", "nginx", "traefik"}
+	if got := Drivers(); !slices.Equal(got, want) {
+		t.Errorf("Drivers() = %v, want %v", got, want)
+	}
+}
+
+func TestNewBuiltIn(t *testing.T) {
+	paths := Paths{Available: "/test/available", Enabled: "/test/enabled", ConfigPath: "/test/nginx.conf"}
+
+	tests := []struct {
+		name  string
+		check func(Driver) bool
+	}{
+		{"nginx", func(d Driver) bool { n, ok := d.(*NginxDriver); return ok && n.configPath == paths.ConfigPath }},
+		{"apache", func(d Driver) bool { _, ok := d.(*ApacheDriver); return ok }},
+		{"caddy", func(d Driver) bool { _, ok := d.(*CaddyDriver); return ok }},
+		{"traefik", func(d Driver) bool { _, ok := d.(*TraefikDriver); return ok }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drv, err := New(tt.name, paths)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			if !tt.check(drv) {
+				t.Errorf("unexpected driver %T for %s", drv, tt.name)
+			}
+			if drv.Name() != tt.name {
+				t.Errorf("expected name %s, got %s", tt.name, drv.Name())
+			}
+			if drv.Paths().Available != paths.Available || drv.Paths().Enabled != paths.Enabled {
+				t.Errorf("expected configured paths, got %+v", drv.Paths())
+			}
+
+			// Get keeps returning the default-path instance
+			if def, ok := Get(tt.name); !ok || def.Paths().Available == paths.Available {
+				t.Errorf("expected default-path driver from Get, got %v", def)
+			}
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := New("unknown", paths)
+		if err == nil || !strings.Contains(err.Error(), "available: apache, caddy, nginx, traefik") {
+			t.Errorf("expected unknown driver error listing drivers, got %v", err)
+		}
+	})
+}
+
+func TestRegister(t *testing.T) {
+	unregister(t, "fake")
+	fake := NewMockDriver("fake", "/fake/available", "/fake/enabled")
+	Register(fake)
+
+	if !slices.Contains(Drivers(), "fake") {
+		t.Errorf("expected fake in Drivers(), got %v", Drivers())
+	}
+	if !slices.Contains(config.ValidDrivers(), "fake") {
+		t.Errorf("expected fake in config.ValidDrivers(), got %v", config.ValidDrivers())
+	}
+
+	// A registered instance keeps its own paths
+	drv, err := New("fake", Paths{Available: "/other", Enabled: "/other"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if drv != Driver(fake) {
+		t.Errorf("expected the registered instance, got %v", drv)
+	}
+	if got, ok := Get("fake"); !ok || got != Driver(fake) {
+		t.Errorf("Get(fake) = %v, %v", got, ok)
+	}
+}
+
+func TestRegisterFactory(t *testing.T) {
+	unregister(t, "fakeproxy")
+	RegisterFactory("fakeproxy", func(paths Paths) Driver {
+		return NewMockDriver("fakeproxy", paths.Available, paths.Enabled)
+	})
+
+	paths := Paths{Available: "/srv/proxy/available", Enabled: "/srv/proxy/enabled"}
+	drv, err := New("fakeproxy", paths)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if drv.Name() != "fakeproxy" || !reflect.DeepEqual(drv.Paths(), paths) {
+		t.Errorf("expected fakeproxy at %+v, got %s at %+v", paths, drv.Name(), drv.Paths())
+	}
+
+	cfg := config.New()
+	cfg.Driver = "fakeproxy"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected registered driver to validate, got %v", err)
+	}
+}
+
+func TestCommandOverrides(t *testing.T) {
+	paths := Paths{
+		Available:     t.TempDir(),
+		Enabled:       t.TempDir(),
+		TestCommand:   []string{"docker", "exec", "web", "nginx", "-t"},
+		ReloadCommand: []string{"docker", "exec", "web", "nginx", "-s", "reload"},
+	}
+
+	drivers := map[string]func(exec executor.CommandExecutor) Driver{
+		"nginx": func(exec executor.CommandExecutor) Driver {
+			n := NewNginxWithExecutor(paths.Available, paths.Enabled, exec)
+			n.paths = paths
+			return n
+		},
+		"apache": func(exec executor.CommandExecutor) Driver {
+			a := NewApacheWithExecutor(paths.Available, paths.Enabled, exec)
+			a.paths = paths
+			return a
+		},
+		"caddy": func(exec executor.CommandExecutor) Driver {
+			c := NewCaddyWithExecutor(paths.Available, paths.Enabled, exec)
+			c.paths = paths
+			return c
+		},
+		"traefik": func(exec executor.CommandExecutor) Driver {
+			tr := NewTraefikWithExecutor(paths.Available, paths.Enabled, exec)
+			tr.paths = paths
+			return tr
+		},
+	}
+
+	for name, newDriver := range drivers {
+		t.Run(name, func(t *testing.T) {
+			// The registered factory keeps the overrides
+			built, err := New(name, paths)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			if !reflect.DeepEqual(built.Paths(), paths) {
+				t.Errorf("factory dropped the overrides: %+v", built.Paths())
+			}
+
+			mock := &executor.MockExecutor{}
+			drv := newDriver(mock)
+			if err := drv.Test(); err != nil {
+				t.Fatalf("Test failed: %v", err)
+			}
+			if err := drv.Reload(); err != nil {
+				t.Fatalf("Reload failed: %v", err)
+			}
+			mock.ExpectSequence(t, [][]string{paths.TestCommand, paths.ReloadCommand})
+		})
+	}
+
+	t.Run("failure has no fallback", func(t *testing.T) {
+		mock := &executor.MockExecutor{ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+			return nil, errors.New("exec: \"docker\": executable file not found in $PATH")
+		}}
+		drv := drivers["nginx"](mock)
+
+		err := drv.Reload()
+		if err == nil || !strings.Contains(err.Error(), "failed to reload nginx: exec: \"docker\"") {
+			t.Errorf("expected reload error naming the command, got %v", err)
+		}
+		err = drv.Test()
+		if err == nil || !strings.Contains(err.Error(), "nginx config test failed") {
+			t.Errorf("expected test error, got %v", err)
+		}
+		if len(mock.Calls) != 2 {
+			t.Errorf("expected only the configured commands to run, got %v", mock.Calls)
+		}
+	})
+}
+
+func TestReloadService(t *testing.T) {
+	// onPath makes LookPath find only the given programs
+	onPath := func(programs ...string) func(string) (string, error) {
+		return func(file string) (string, error) {
+			if slices.Contains(programs, file) {
+				return "/usr/bin/" + file, nil
+			}
+			return "", errors.New("executable file not found in $PATH")
+		}
+	}
+	// failing makes Execute fail for the given programs
+	failing := func(programs ...string) func(string, ...string) ([]byte, error) {
+		return func(name string, args ...string) ([]byte, error) {
+			if slices.Contains(programs, name) {
+				return []byte(name + " failed"), errors.New("exit status 1")
+			}
+			return nil, nil
+		}
+	}
+
+	tests := []struct {
+		name    string
+		path    []string
+		fail    []string
+		want    [][]string
+		wantErr bool
+	}{
+		{"systemd", []string{"systemctl", "rc-service"}, nil, [][]string{{"systemctl", "reload", "nginx"}}, false},
+		{"systemd fails", []string{"systemctl"}, []string{"systemctl"}, [][]string{{"systemctl", "reload", "nginx"}, {"nginx", "-s", "reload"}}, false},
+		{"openrc", []string{"rc-service"}, nil, [][]string{{"rc-service", "nginx", "reload"}}, false},
+		{"openrc fails", []string{"rc-service"}, []string{"rc-service"}, [][]string{{"rc-service", "nginx", "reload"}, {"nginx", "-s", "reload"}}, false},
+		{"no init system", nil, nil, [][]string{{"nginx", "-s", "reload"}}, false},
+		{"everything fails", []string{"rc-service"}, []string{"rc-service", "nginx"}, [][]string{{"rc-service", "nginx", "reload"}, {"nginx", "-s", "reload"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &executor.MockExecutor{LookPathFunc: onPath(tt.path...), ExecuteFunc: failing(tt.fail...)}
+			drv := NewNginxWithExecutor(t.TempDir(), t.TempDir(), mock)
+
+			err := drv.Reload()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "nginx failed") {
+					t.Errorf("expected the fallback's output in the error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Reload failed: %v", err)
+			}
+			mock.ExpectSequence(t, tt.want)
+		})
+	}
+
+	t.Run("service names", func(t *testing.T) {
+		drivers := map[string]func(executor.CommandExecutor) Driver{
+			"apache2": func(exec executor.CommandExecutor) Driver {
+				return NewApacheWithExecutor(t.TempDir(), t.TempDir(), exec)
+			},
+			"caddy": func(exec executor.CommandExecutor) Driver {
+				return NewCaddyWithExecutor(t.TempDir(), t.TempDir(), exec)
+			},
+		}
+		for service, newDriver := range drivers {
+			mock := &executor.MockExecutor{LookPathFunc: onPath("rc-service")}
+			if err := newDriver(mock).Reload(); err != nil {
+				t.Fatalf("%s: Reload failed: %v", service, err)
+			}
+			mock.ExpectSequence(t, [][]string{{"rc-service", service, "reload"}})
+		}
+	})
+}
+
+func TestNewBindsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, name := range []string{"nginx", "apache", "caddy", "traefik"} {
+		t.Run(name, func(t *testing.T) {
+			drv, err := New(name, Paths{Available: t.TempDir(), Enabled: t.TempDir(), TestCommand: []string{"sleep", "5"}, Context: ctx})
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			start := time.Now()
+			if err := drv.Test(); err == nil {
+				t.Error("expected the test command to be stopped by the done context")
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("test command ran for %s after its context was done", elapsed)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ type MockDriver struct {
 	AddFunc       func(vhost *config.VHost, configContent string) error
 	RemoveFunc    func(domain string) error
 	EnableFunc    func(domain string) error
+	RelinkFunc    func(domain string) error
 	DisableFunc   func(domain string) error
 	ListFunc      func() ([]string, error)
 	IsEnabledFunc func(domain string) (bool, error)
@@ -23,6 +24,7 @@ type MockDriver struct {
 	AddCalls       []AddCall
 	RemoveCalls    []string
 	EnableCalls    []string
+	RelinkCalls    []string
 	DisableCalls   []string
 	ListCalls      int
 	IsEnabledCalls []string
@@ -47,6 +49,7 @@ func NewMockDriver(name, availableDir, enabledDir string) *MockDriver {
 		AddCalls:       make([]AddCall, 0),
 		RemoveCalls:    make([]string, 0),
 		EnableCalls:    make([]string, 0),
+		RelinkCalls:    make([]string, 0),
 		DisableCalls:   make([]string, 0),
 		IsEnabledCalls: make([]string, 0),
 	}
@@ -89,6 +92,15 @@ func (m *MockDriver) Enable(domain string) error {
 	return nil
 }
 
+// Relink records the call and invokes the mock function if set
+func (m *MockDriver) Relink(domain string) error {
+	m.RelinkCalls = append(m.RelinkCalls, domain)
+	if m.RelinkFunc != nil {
+		return m.RelinkFunc(domain)
+	}
+	return nil
+}
+
 // Disable records the call and invokes the mock function if set
 func (m *MockDriver) Disable(domain string) error {
 	m.DisableCalls = append(m.DisableCalls, domain)
@@ -139,6 +151,7 @@ func (m *MockDriver) Reset() {
 	m.AddCalls = make([]AddCall, 0)
 	m.RemoveCalls = make([]string, 0)
 	m.EnableCalls = make([]string, 0)
+	m.RelinkCalls = make([]string, 0)
 	m.DisableCalls = make([]string, 0)
 	m.IsEnabledCalls = make([]string, 0)
 	m.ListCalls = 0
@@ -12,8 +12,21 @@ import (
 
 // NginxDriver implements the Driver interface for Nginx
 type NginxDriver struct {
-	paths Paths
-	exec  executor.CommandExecutor
+	paths      Paths
+	exec       executor.CommandExecutor
+	configPath string
+}
+
+// NginxOption configures optional NginxDriver settings
+type NginxOption func(*NginxDriver)
+
+// WithNginxConfigPath makes Test and Reload pass "-c <path>" to nginx, for
+// installs whose main config is not at the compiled-in default location.
+// An empty path keeps the default.
+func WithNginxConfigPath(path string) NginxOption {
+	return func(n *NginxDriver) {
+		n.configPath = path
+	}
 }
 
 // NewNginx creates a new Nginx driver with default paths
@@ -28,25 +41,23 @@ func NewNginx() *NginxDriver {
 }
 
 // NewNginxWithPaths creates a new Nginx driver with custom paths
-func NewNginxWithPaths(available, enabled string) *NginxDriver {
-	return &NginxDriver{
-		paths: Paths{
-			Available: available,
-			Enabled:   enabled,
-		},
-		exec: executor.NewSystemExecutor(),
-	}
+func NewNginxWithPaths(available, enabled string, opts ...NginxOption) *NginxDriver {
+	return NewNginxWithExecutor(available, enabled, executor.NewSystemExecutor(), opts...)
 }
 
 // NewNginxWithExecutor creates a new Nginx driver with custom paths and executor (for testing)
-func NewNginxWithExecutor(available, enabled string, exec executor.CommandExecutor) *NginxDriver {
-	return &NginxDriver{
+func NewNginxWithExecutor(available, enabled string, exec executor.CommandExecutor, opts ...NginxOption) *NginxDriver {
+	n := &NginxDriver{
 		paths: Paths{
 			Available: available,
 			Enabled:   enabled,
 		},
 		exec: exec,
 	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
 }
 
 // Name returns the driver name
@@ -61,6 +72,11 @@ func (n *NginxDriver) Paths() Paths {
 
 // Add creates and enables a vhost config
 func (n *NginxDriver) Add(vhost *config.VHost, configContent string) error {
+	// Refuse a missing root up front when creating it is disabled
+	if err := checkDocumentRoot(vhost); err != nil {
+		return err
+	}
+
 	// Create sites-available directory if it doesn't exist
 	if err := os.MkdirAll(n.paths.Available, 0755); err != nil {
 		return fmt.Errorf("failed to create sites-available directory: %w", err)
@@ -77,11 +93,14 @@ func (n *NginxDriver) Add(vhost *config.VHost, configContent string) error {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
+	// Keep an enabled copy in step with the new config
+	if err := refreshEnabledCopy(n.paths.ActivationMode, configPath, filepath.Join(n.paths.Enabled, vhost.Domain)); err != nil {
+		return err
+	}
+
 	// Create document root if specified and doesn't exist
-	if vhost.Root != "" {
-		if err := os.MkdirAll(vhost.Root, 0755); err != nil {
-			return fmt.Errorf("failed to create document root: %w", err)
-		}
+	if err := createDocumentRoot(vhost); err != nil {
+		return err
 	}
 
 	return nil
@@ -108,7 +127,7 @@ func (n *NginxDriver) Remove(domain string) error {
 	return nil
 }
 
-// Enable activates a vhost by creating a symlink
+// Enable activates a vhost by creating a symlink, or by copying it in copy mode
 func (n *NginxDriver) Enable(domain string) error {
 	source := filepath.Join(n.paths.Available, domain)
 	target := filepath.Join(n.paths.Enabled, domain)
@@ -118,20 +137,32 @@ func (n *NginxDriver) Enable(domain string) error {
 		return fmt.Errorf("vhost %s not found in sites-available", domain)
 	}
 
-	// Check if already enabled
-	if _, err := os.Lstat(target); err == nil {
+	// Check if already enabled; an outdated copy is replaced below
+	enabled, err := isConfigEnabled(n.paths.ActivationMode, source, target)
+	if err != nil {
+		return err
+	}
+	if enabled {
 		return fmt.Errorf("vhost %s is already enabled", domain)
 	}
 
-	// Create symlink
-	if err := os.Symlink(source, target); err != nil {
+	// Create the symlink, or the copy in copy mode
+	if err := activateConfig(n.paths.ActivationMode, source, target); err != nil {
 		return fmt.Errorf("failed to enable vhost: %w", err)
 	}
 
 	return nil
 }
 
-// Disable deactivates a vhost by removing the symlink
+// Relink enables a vhost even if it already is, repointing a symlink that
+// leads to another file
+func (n *NginxDriver) Relink(domain string) error {
+	source := filepath.Join(n.paths.Available, domain)
+	target := filepath.Join(n.paths.Enabled, domain)
+	return relinkConfig(n.paths.ActivationMode, domain, source, target)
+}
+
+// Disable deactivates a vhost by removing the symlink or copy
 func (n *NginxDriver) Disable(domain string) error {
 	target := filepath.Join(n.paths.Enabled, domain)
 
@@ -144,9 +175,9 @@ func (n *NginxDriver) Disable(domain string) error {
 		return fmt.Errorf("failed to check vhost status: %w", err)
 	}
 
-	// Verify it's a symlink
-	if info.Mode()&os.ModeSymlink == 0 {
-		return fmt.Errorf("vhost %s is not a symlink, refusing to remove", domain)
+	// Verify it's a symlink, or a copy in copy mode
+	if err := checkEnabledEntry(n.paths.ActivationMode, domain, info); err != nil {
+		return err
 	}
 
 	// Remove symlink
@@ -179,40 +210,57 @@ func (n *NginxDriver) List() ([]string, error) {
 
 // IsEnabled checks if a vhost is enabled
 func (n *NginxDriver) IsEnabled(domain string) (bool, error) {
+	source := filepath.Join(n.paths.Available, domain)
 	target := filepath.Join(n.paths.Enabled, domain)
-	_, err := os.Lstat(target)
-	if os.IsNotExist(err) {
-		return false, nil
-	}
-	if err != nil {
-		return false, fmt.Errorf("failed to check vhost status: %w", err)
-	}
-	return true, nil
+	return isConfigEnabled(n.paths.ActivationMode, source, target)
 }
 
 // Test validates the nginx config syntax
 func (n *NginxDriver) Test() error {
-	output, err := n.exec.Execute("nginx", "-t")
+	if len(n.paths.TestCommand) > 0 {
+		return runTestCommand(n.exec, "nginx", n.paths.TestCommand)
+	}
+	output, err := n.exec.Execute("nginx", n.nginxArgs("-t")...)
 	if err != nil {
 		return fmt.Errorf("nginx config test failed: %s", string(output))
 	}
 	return nil
 }
 
-// Reload reloads nginx to apply changes
+// Reload reloads nginx to apply changes. With a custom main config path it
+// signals nginx directly, since the service unit reloads whatever config it
+// was started with rather than the one Test checked.
 func (n *NginxDriver) Reload() error {
-	_, err := n.exec.Execute("systemctl", "reload", "nginx")
-	if err != nil {
-		// Try nginx -s reload as fallback
-		output, err := n.exec.Execute("nginx", "-s", "reload")
-		if err != nil {
-			return fmt.Errorf("failed to reload nginx: %s", string(output))
+	if len(n.paths.ReloadCommand) > 0 {
+		return runReloadCommand(n.exec, "nginx", n.paths.ReloadCommand)
+	}
+	args := n.nginxArgs("-s", "reload")
+	if n.configPath != "" {
+		if output, err := n.exec.Execute("nginx", args...); err != nil {
+			return fmt.Errorf("failed to reload nginx: %s", commandFailure(output, err))
 		}
+		return nil
+	}
+	// Fall back to nginx -s reload
+	if output, err := reloadService(n.exec, "nginx", append([]string{"nginx"}, args...)); err != nil {
+		return fmt.Errorf("failed to reload nginx: %s", string(output))
 	}
 	return nil
 }
 
-// init registers the nginx driver
+// nginxArgs prefixes args with "-c <path>" when a main config path is set
+func (n *NginxDriver) nginxArgs(args ...string) []string {
+	if n.configPath == "" {
+		return args
+	}
+	return append([]string{"-c", n.configPath}, args...)
+}
+
+// init registers the nginx driver factory
 func init() {
-	Register(NewNginx())
+	registerBuiltIn(NewNginx(), func(paths Paths) Driver {
+		n := NewNginxWithExecutor(paths.Available, paths.Enabled, systemExecutor(paths), WithNginxConfigPath(paths.ConfigPath))
+		n.paths = paths // keeps the test and reload command overrides
+		return n
+	})
 }
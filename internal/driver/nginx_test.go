@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/ksyq12/vhost/internal/config"
@@ -193,12 +194,7 @@ func TestNginxDriver_WithExecutor(t *testing.T) {
 		}
 
 		// Verify the correct command was called
-		if len(mock.Calls) != 1 {
-			t.Errorf("expected 1 call, got %d", len(mock.Calls))
-		}
-		if mock.Calls[0].Name != "nginx" || mock.Calls[0].Args[0] != "-t" {
-			t.Errorf("expected nginx -t, got %s %v", mock.Calls[0].Name, mock.Calls[0].Args)
-		}
+		mock.ExpectSequence(t, [][]string{{"nginx", "-t"}})
 	})
 
 	t.Run("Test_failure", func(t *testing.T) {
@@ -275,6 +271,73 @@ func TestNginxDriver_WithExecutor(t *testing.T) {
 	})
 }
 
+func TestNginxDriver_ConfigPath(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	enabledDir := filepath.Join(tempDir, "sites-enabled")
+
+	// systemctl fails so Reload falls back to nginx -s reload
+	failSystemctl := func(name string, args ...string) ([]byte, error) {
+		if name == "systemctl" {
+			return nil, errors.New("systemctl not found")
+		}
+		return nil, nil
+	}
+
+	t.Run("default", func(t *testing.T) {
+		mock := &executor.MockExecutor{ExecuteFunc: failSystemctl}
+		drv := NewNginxWithExecutor(availableDir, enabledDir, mock)
+
+		if err := drv.Test(); err != nil {
+			t.Fatalf("Test failed: %v", err)
+		}
+		if err := drv.Reload(); err != nil {
+			t.Fatalf("Reload failed: %v", err)
+		}
+
+		mock.ExpectSequence(t, [][]string{
+			{"nginx", "-t"},
+			{"systemctl", "reload", "nginx"},
+			{"nginx", "-s", "reload"},
+		})
+	})
+
+	// The service unit would reload its own config, not the one tested
+	t.Run("custom", func(t *testing.T) {
+		mock := &executor.MockExecutor{
+			ExecuteFunc:  func(name string, args ...string) ([]byte, error) { return nil, nil },
+			LookPathFunc: func(file string) (string, error) { return "/usr/bin/" + file, nil },
+		}
+		drv := NewNginxWithExecutor(availableDir, enabledDir, mock, WithNginxConfigPath("/opt/nginx/conf/nginx.conf"))
+
+		if err := drv.Test(); err != nil {
+			t.Fatalf("Test failed: %v", err)
+		}
+		if err := drv.Reload(); err != nil {
+			t.Fatalf("Reload failed: %v", err)
+		}
+
+		mock.ExpectSequence(t, [][]string{
+			{"nginx", "-c", "/opt/nginx/conf/nginx.conf", "-t"},
+			{"nginx", "-c", "/opt/nginx/conf/nginx.conf", "-s", "reload"},
+		})
+	})
+
+	t.Run("custom reload failure", func(t *testing.T) {
+		mock := &executor.MockExecutor{
+			ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+				return []byte("nginx: [error] invalid PID number"), errors.New("exit status 1")
+			},
+		}
+		drv := NewNginxWithExecutor(availableDir, enabledDir, mock, WithNginxConfigPath("/opt/nginx/conf/nginx.conf"))
+
+		err := drv.Reload()
+		if err == nil || !strings.Contains(err.Error(), "invalid PID number") {
+			t.Errorf("expected the nginx output in the error, got %v", err)
+		}
+	})
+}
+
 func TestNginxDriver_EdgeCases(t *testing.T) {
 	t.Run("EnableAlreadyEnabled", func(t *testing.T) {
 		tempDir := t.TempDir()
@@ -0,0 +1,163 @@
+package driver
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ParsedVHost is what can be read back from a server config file, whether
+// or not vhost wrote it. Fields the file doesn't set are empty.
+type ParsedVHost struct {
+	ServerNames []string // server_name / ServerName + ServerAlias, in order
+	Root        string   // first document root
+	Listen      []string // ports listened on, e.g. "80", "443"
+	SSLCert     string
+	SSLKey      string
+	ProxyPass   string // first proxy target
+}
+
+// Parser is implemented by drivers that can read their own config format.
+// Parsing is best-effort: unfamiliar directives are ignored.
+type Parser interface {
+	Parse(content string) (ParsedVHost, error)
+}
+
+// ErrNoVHost is returned when a config holds no server block or VirtualHost
+var ErrNoVHost = errors.New("no virtual host found in config")
+
+// Parse reads an nginx config. Values from every server block are merged,
+// so a redirect block and its HTTPS block parse as one vhost.
+func (n *NginxDriver) Parse(content string) (ParsedVHost, error) {
+	return ParseNginx(content)
+}
+
+// Parse reads an apache config, merging every VirtualHost in it
+func (a *ApacheDriver) Parse(content string) (ParsedVHost, error) {
+	return ParseApache(content)
+}
+
+var (
+	nginxServerBlock = regexp.MustCompile(`(?m)^\s*server\s*\{`)
+	nginxDirective   = regexp.MustCompile(`(?m)^\s*(server_name|root|listen|ssl_certificate|ssl_certificate_key|proxy_pass)\s+([^;]+);`)
+
+	apacheVirtualHost = regexp.MustCompile(`(?mi)^\s*<VirtualHost\s+([^>]+)>`)
+	apacheDirective   = regexp.MustCompile(`(?mi)^\s*(ServerName|ServerAlias|DocumentRoot|Listen|SSLCertificateFile|SSLCertificateKeyFile|ProxyPass)\s+(.+?)\s*$`)
+
+	// portSuffix captures the port of an address such as [::]:443 or *:80
+	portSuffix = regexp.MustCompile(`:(\d+)$`)
+)
+
+// ParseNginx extracts server names, root, listen ports, SSL files and the
+// proxy target from nginx config content
+func ParseNginx(content string) (ParsedVHost, error) {
+	content = stripComments(content)
+	if !nginxServerBlock.MatchString(content) {
+		return ParsedVHost{}, ErrNoVHost
+	}
+
+	var parsed ParsedVHost
+	for _, m := range nginxDirective.FindAllStringSubmatch(content, -1) {
+		args := strings.Fields(m[2])
+		if len(args) == 0 {
+			continue
+		}
+		switch m[1] {
+		case "server_name":
+			for _, name := range args {
+				parsed.ServerNames = appendUnique(parsed.ServerNames, name)
+			}
+		case "root":
+			setFirst(&parsed.Root, args[0])
+		case "listen":
+			parsed.Listen = appendUnique(parsed.Listen, listenPort(args[0]))
+		case "ssl_certificate":
+			setFirst(&parsed.SSLCert, args[0])
+		case "ssl_certificate_key":
+			setFirst(&parsed.SSLKey, args[0])
+		case "proxy_pass":
+			setFirst(&parsed.ProxyPass, args[0])
+		}
+	}
+	return parsed, nil
+}
+
+// ParseApache extracts server names, document root, listen ports, SSL files
+// and the proxy target from apache config content
+func ParseApache(content string) (ParsedVHost, error) {
+	content = stripComments(content)
+	hosts := apacheVirtualHost.FindAllStringSubmatch(content, -1)
+	if len(hosts) == 0 {
+		return ParsedVHost{}, ErrNoVHost
+	}
+
+	var parsed ParsedVHost
+	for _, m := range hosts {
+		for _, addr := range strings.Fields(m[1]) {
+			parsed.Listen = appendUnique(parsed.Listen, listenPort(addr))
+		}
+	}
+	for _, m := range apacheDirective.FindAllStringSubmatch(content, -1) {
+		args := strings.Fields(strings.ReplaceAll(m[2], `"`, ""))
+		if len(args) == 0 {
+			continue
+		}
+		switch strings.ToLower(m[1]) {
+		case "servername", "serveralias":
+			for _, name := range args {
+				parsed.ServerNames = appendUnique(parsed.ServerNames, name)
+			}
+		case "documentroot":
+			setFirst(&parsed.Root, args[0])
+		case "listen":
+			parsed.Listen = appendUnique(parsed.Listen, listenPort(args[0]))
+		case "sslcertificatefile":
+			setFirst(&parsed.SSLCert, args[0])
+		case "sslcertificatekeyfile":
+			setFirst(&parsed.SSLKey, args[0])
+		case "proxypass":
+			// ProxyPass <path> <url>; only the site root is the vhost's backend
+			if len(args) >= 2 && args[0] == "/" {
+				setFirst(&parsed.ProxyPass, args[1])
+			}
+		}
+	}
+	return parsed, nil
+}
+
+// stripComments removes # comments, which both formats use
+func stripComments(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			lines[i] = line[:idx]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// listenPort returns the port of a listen address: "443" for "443",
+// "[::]:443" or "*:443". An address without a port is returned as-is.
+func listenPort(addr string) string {
+	if m := portSuffix.FindStringSubmatch(addr); m != nil {
+		return m[1]
+	}
+	return addr
+}
+
+// appendUnique appends value unless it is already present
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// setFirst sets *field to value unless it already has one
+func setFirst(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
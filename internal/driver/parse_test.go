@@ -0,0 +1,144 @@
+package driver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseNginx(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    ParsedVHost
+	}{
+		{
+			name: "static",
+			content: `server {
+    listen 80;
+    listen [::]:80;
+    server_name example.com www.example.com;
+    root /var/www/example;  # site files
+    index index.html;
+}
+`,
+			want: ParsedVHost{
+				ServerNames: []string{"example.com", "www.example.com"},
+				Root:        "/var/www/example",
+				Listen:      []string{"80"},
+			},
+		},
+		{
+			name: "ssl with redirect block",
+			content: `server {
+    listen 80;
+    server_name example.com;
+    return 301 https://$host$request_uri;
+}
+
+server {
+    listen 443 ssl http2;
+    server_name example.com api.example.com;
+    ssl_certificate /etc/ssl/example.crt;
+    ssl_certificate_key /etc/ssl/example.key;
+    # root /old/root;
+
+    location / {
+        proxy_pass http://127.0.0.1:3000;
+        proxy_set_header Host $host;
+    }
+}
+`,
+			want: ParsedVHost{
+				ServerNames: []string{"example.com", "api.example.com"},
+				Listen:      []string{"80", "443"},
+				SSLCert:     "/etc/ssl/example.crt",
+				SSLKey:      "/etc/ssl/example.key",
+				ProxyPass:   "http://127.0.0.1:3000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewNginx().Parse(tt.content)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseApache(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    ParsedVHost
+	}{
+		{
+			name: "static",
+			content: `<VirtualHost *:80>
+    ServerName example.com
+    ServerAlias www.example.com static.example.com
+    DocumentRoot "/var/www/example"
+</VirtualHost>
+`,
+			want: ParsedVHost{
+				ServerNames: []string{"example.com", "www.example.com", "static.example.com"},
+				Root:        "/var/www/example",
+				Listen:      []string{"80"},
+			},
+		},
+		{
+			name: "ssl proxy",
+			content: `Listen 8443
+<VirtualHost *:80>
+    ServerName api.example.com
+    Redirect permanent / https://api.example.com/
+</VirtualHost>
+
+<VirtualHost *:443>
+    ServerName api.example.com
+    SSLEngine on
+    SSLCertificateFile /etc/ssl/api.crt
+    SSLCertificateKeyFile /etc/ssl/api.key
+    ProxyPass /static !
+    ProxyPass / http://localhost:8080/
+    ProxyPassReverse / http://localhost:8080/
+</VirtualHost>
+`,
+			want: ParsedVHost{
+				ServerNames: []string{"api.example.com"},
+				Listen:      []string{"80", "443", "8443"},
+				SSLCert:     "/etc/ssl/api.crt",
+				SSLKey:      "/etc/ssl/api.key",
+				ProxyPass:   "http://localhost:8080/",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewApache().Parse(tt.content)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNoVHost(t *testing.T) {
+	content := "# just a comment\nupstream backend { server 127.0.0.1:3000; }\n"
+	if _, err := ParseNginx(content); !errors.Is(err, ErrNoVHost) {
+		t.Errorf("ParseNginx() error = %v, want ErrNoVHost", err)
+	}
+	if _, err := ParseApache(content); !errors.Is(err, ErrNoVHost) {
+		t.Errorf("ParseApache() error = %v, want ErrNoVHost", err)
+	}
+}
@@ -0,0 +1,266 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/executor"
+	"gopkg.in/yaml.v3"
+)
+
+// TraefikDriver implements the Driver interface for Traefik's file provider.
+// The enabled directory is the dynamic configuration directory watched by
+// Traefik; each vhost is a <domain>.yml file describing a router and service.
+type TraefikDriver struct {
+	paths Paths
+	exec  executor.CommandExecutor
+}
+
+// NewTraefik creates a new Traefik driver with default paths
+func NewTraefik() *TraefikDriver {
+	return &TraefikDriver{
+		paths: Paths{
+			Available: "/etc/traefik/sites-available",
+			Enabled:   "/etc/traefik/dynamic",
+		},
+		exec: executor.NewSystemExecutor(),
+	}
+}
+
+// NewTraefikWithPaths creates a new Traefik driver with custom paths
+func NewTraefikWithPaths(available, enabled string) *TraefikDriver {
+	return &TraefikDriver{
+		paths: Paths{
+			Available: available,
+			Enabled:   enabled,
+		},
+		exec: executor.NewSystemExecutor(),
+	}
+}
+
+// NewTraefikWithExecutor creates a new Traefik driver with custom paths and executor (for testing)
+func NewTraefikWithExecutor(available, enabled string, exec executor.CommandExecutor) *TraefikDriver {
+	return &TraefikDriver{
+		paths: Paths{
+			Available: available,
+			Enabled:   enabled,
+		},
+		exec: exec,
+	}
+}
+
+// Name returns the driver name
+func (t *TraefikDriver) Name() string {
+	return "traefik"
+}
+
+// Paths returns the config paths
+func (t *TraefikDriver) Paths() Paths {
+	return t.paths
+}
+
+// configFileName returns the config file name with .yml extension
+func (t *TraefikDriver) configFileName(domain string) string {
+	return domain + ".yml"
+}
+
+// Add creates a vhost dynamic config file
+func (t *TraefikDriver) Add(vhost *config.VHost, configContent string) error {
+	// Refuse a missing root up front when creating it is disabled
+	if err := checkDocumentRoot(vhost); err != nil {
+		return err
+	}
+
+	// Create sites-available directory if it doesn't exist
+	if err := os.MkdirAll(t.paths.Available, 0755); err != nil {
+		return fmt.Errorf("failed to create sites-available directory: %w", err)
+	}
+
+	// Create dynamic config directory if it doesn't exist
+	if err := os.MkdirAll(t.paths.Enabled, 0755); err != nil {
+		return fmt.Errorf("failed to create dynamic config directory: %w", err)
+	}
+
+	// Write config file to sites-available with .yml extension
+	configPath := filepath.Join(t.paths.Available, t.configFileName(vhost.Domain))
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	// Keep an enabled copy in step with the new config
+	if err := refreshEnabledCopy(t.paths.ActivationMode, configPath, filepath.Join(t.paths.Enabled, t.configFileName(vhost.Domain))); err != nil {
+		return err
+	}
+
+	// Create document root if specified and doesn't exist
+	if err := createDocumentRoot(vhost); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Remove deletes a vhost config
+func (t *TraefikDriver) Remove(domain string) error {
+	// First disable the site
+	if enabled, _ := t.IsEnabled(domain); enabled {
+		if err := t.Disable(domain); err != nil {
+			return err
+		}
+	}
+
+	// Remove config file from sites-available
+	configPath := filepath.Join(t.paths.Available, t.configFileName(domain))
+	if err := os.Remove(configPath); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("vhost %s not found", domain)
+		}
+		return fmt.Errorf("failed to remove config file: %w", err)
+	}
+
+	return nil
+}
+
+// Enable activates a vhost by linking it, or copying it in copy mode, into
+// the dynamic config directory
+func (t *TraefikDriver) Enable(domain string) error {
+	source := filepath.Join(t.paths.Available, t.configFileName(domain))
+	target := filepath.Join(t.paths.Enabled, t.configFileName(domain))
+
+	// Check if source exists
+	if _, err := os.Stat(source); os.IsNotExist(err) {
+		return fmt.Errorf("vhost %s not found in sites-available", domain)
+	}
+
+	// Check if already enabled; an outdated copy is replaced below
+	enabled, err := isConfigEnabled(t.paths.ActivationMode, source, target)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		return fmt.Errorf("vhost %s is already enabled", domain)
+	}
+
+	// Create the symlink, or the copy in copy mode
+	if err := activateConfig(t.paths.ActivationMode, source, target); err != nil {
+		return fmt.Errorf("failed to enable vhost: %w", err)
+	}
+
+	return nil
+}
+
+// Relink enables a vhost even if it already is, repointing a symlink that
+// leads to another file
+func (t *TraefikDriver) Relink(domain string) error {
+	source := filepath.Join(t.paths.Available, t.configFileName(domain))
+	target := filepath.Join(t.paths.Enabled, t.configFileName(domain))
+	return relinkConfig(t.paths.ActivationMode, domain, source, target)
+}
+
+// Disable deactivates a vhost by removing it from the dynamic config directory
+func (t *TraefikDriver) Disable(domain string) error {
+	target := filepath.Join(t.paths.Enabled, t.configFileName(domain))
+
+	// Check if symlink exists
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("vhost %s is not enabled", domain)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check vhost status: %w", err)
+	}
+
+	// Verify it's a symlink, or a copy in copy mode
+	if err := checkEnabledEntry(t.paths.ActivationMode, domain, info); err != nil {
+		return err
+	}
+
+	// Remove symlink
+	if err := os.Remove(target); err != nil {
+		return fmt.Errorf("failed to disable vhost: %w", err)
+	}
+
+	return nil
+}
+
+// List returns all vhost domains from sites-available
+func (t *TraefikDriver) List() ([]string, error) {
+	entries, err := os.ReadDir(t.paths.Available)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read sites-available: %w", err)
+	}
+
+	domains := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		// Only include .yml files (not directories or hidden files)
+		if !entry.IsDir() && !strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".yml") {
+			domains = append(domains, strings.TrimSuffix(name, ".yml"))
+		}
+	}
+
+	return domains, nil
+}
+
+// IsEnabled checks if a vhost is enabled
+func (t *TraefikDriver) IsEnabled(domain string) (bool, error) {
+	source := filepath.Join(t.paths.Available, t.configFileName(domain))
+	target := filepath.Join(t.paths.Enabled, t.configFileName(domain))
+	return isConfigEnabled(t.paths.ActivationMode, source, target)
+}
+
+// Test validates that every file in the dynamic config directory parses as YAML
+func (t *TraefikDriver) Test() error {
+	if len(t.paths.TestCommand) > 0 {
+		return runTestCommand(t.exec, "traefik", t.paths.TestCommand)
+	}
+	entries, err := os.ReadDir(t.paths.Enabled)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read dynamic config directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".yml") || strings.HasSuffix(name, ".yaml")) {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(t.paths.Enabled, name))
+		if err != nil {
+			return fmt.Errorf("traefik config test failed: %s: %w", name, err)
+		}
+
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return fmt.Errorf("traefik config test failed: %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Reload is a no-op because Traefik watches the dynamic config directory
+func (t *TraefikDriver) Reload() error {
+	if len(t.paths.ReloadCommand) > 0 {
+		return runReloadCommand(t.exec, "traefik", t.paths.ReloadCommand)
+	}
+	return nil
+}
+
+// init registers the traefik driver factory
+func init() {
+	registerBuiltIn(NewTraefik(), func(paths Paths) Driver {
+		t := NewTraefikWithExecutor(paths.Available, paths.Enabled, systemExecutor(paths))
+		t.paths = paths // keeps the test and reload command overrides
+		return t
+	})
+}
@@ -0,0 +1,192 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
+	"github.com/ksyq12/vhost/internal/executor"
+)
+
+func TestTraefikDriver(t *testing.T) {
+	// Create temp directories for testing
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	dynamicDir := filepath.Join(tempDir, "dynamic")
+
+	// Create driver with test paths (directories created by Add)
+	drv := NewTraefikWithPaths(availableDir, dynamicDir)
+
+	configContent := "http:\n  routers:\n    test-example-com:\n      rule: \"Host(`test.example.com`)\"\n      service: test-example-com\n"
+
+	t.Run("Name", func(t *testing.T) {
+		if drv.Name() != "traefik" {
+			t.Errorf("expected traefik, got %s", drv.Name())
+		}
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		vhost := &config.VHost{
+			Domain:    "test.example.com",
+			Type:      "proxy",
+			ProxyPass: "http://127.0.0.1:3000",
+		}
+
+		if err := drv.Add(vhost, configContent); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+
+		// Check config file exists with .yml extension
+		configPath := filepath.Join(availableDir, "test.example.com.yml")
+		content, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatalf("config file was not created: %v", err)
+		}
+		if string(content) != configContent {
+			t.Errorf("config content mismatch")
+		}
+
+		// Dynamic directory should be created
+		if _, err := os.Stat(dynamicDir); err != nil {
+			t.Errorf("dynamic directory was not created: %v", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		domains, err := drv.List()
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(domains) != 1 || domains[0] != "test.example.com" {
+			t.Errorf("expected [test.example.com], got %v", domains)
+		}
+	})
+
+	t.Run("Enable", func(t *testing.T) {
+		if err := drv.Enable("test.example.com"); err != nil {
+			t.Fatalf("Enable failed: %v", err)
+		}
+
+		info, err := os.Lstat(filepath.Join(dynamicDir, "test.example.com.yml"))
+		if err != nil {
+			t.Fatalf("dynamic config not found: %v", err)
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			t.Error("expected symlink, got regular file")
+		}
+
+		enabled, err := drv.IsEnabled("test.example.com")
+		if err != nil {
+			t.Fatalf("IsEnabled failed: %v", err)
+		}
+		if !enabled {
+			t.Error("expected enabled to be true")
+		}
+	})
+
+	t.Run("EnableAlreadyEnabled", func(t *testing.T) {
+		if err := drv.Enable("test.example.com"); err == nil {
+			t.Error("expected error when enabling already enabled vhost")
+		}
+	})
+
+	t.Run("Test", func(t *testing.T) {
+		if err := drv.Test(); err != nil {
+			t.Errorf("Test should succeed for valid YAML: %v", err)
+		}
+	})
+
+	t.Run("Reload", func(t *testing.T) {
+		if err := drv.Reload(); err != nil {
+			t.Errorf("Reload should be a no-op: %v", err)
+		}
+	})
+
+	t.Run("Disable", func(t *testing.T) {
+		if err := drv.Disable("test.example.com"); err != nil {
+			t.Fatalf("Disable failed: %v", err)
+		}
+
+		enabled, _ := drv.IsEnabled("test.example.com")
+		if enabled {
+			t.Error("expected enabled to be false after disable")
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		if err := drv.Enable("test.example.com"); err != nil {
+			t.Fatalf("Enable failed: %v", err)
+		}
+		if err := drv.Remove("test.example.com"); err != nil {
+			t.Fatalf("Remove failed: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(availableDir, "test.example.com.yml")); !os.IsNotExist(err) {
+			t.Error("config file should be removed")
+		}
+		if _, err := os.Lstat(filepath.Join(dynamicDir, "test.example.com.yml")); !os.IsNotExist(err) {
+			t.Error("dynamic config should be removed")
+		}
+	})
+
+	t.Run("RemoveNonexistent", func(t *testing.T) {
+		if err := drv.Remove("nonexistent.example.com"); err == nil {
+			t.Error("expected error for nonexistent domain")
+		}
+	})
+}
+
+func TestTraefikDriverListFiltersCorrectly(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	dynamicDir := filepath.Join(tempDir, "dynamic")
+
+	if err := os.MkdirAll(availableDir, 0755); err != nil {
+		t.Fatalf("failed to create available dir: %v", err)
+	}
+
+	drv := NewTraefikWithPaths(availableDir, dynamicDir)
+
+	files := []string{"example.com.yml", "test.org.yml", ".hidden.yml", "notes.txt"}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(availableDir, f), []byte("http: {}\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", f, err)
+		}
+	}
+
+	domains, err := drv.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	// Should only include example.com and test.org
+	if len(domains) != 2 {
+		t.Errorf("expected 2 domains, got %d: %v", len(domains), domains)
+	}
+}
+
+func TestTraefikDriverTestInvalidYAML(t *testing.T) {
+	tempDir := t.TempDir()
+	availableDir := filepath.Join(tempDir, "sites-available")
+	dynamicDir := filepath.Join(tempDir, "dynamic")
+
+	if err := os.MkdirAll(dynamicDir, 0755); err != nil {
+		t.Fatalf("failed to create dynamic dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dynamicDir, "broken.com.yml"), []byte("http:\n  routers: [unclosed\n"), 0644); err != nil {
+		t.Fatalf("failed to write broken config: %v", err)
+	}
+
+	mock := &executor.MockExecutor{}
+	drv := NewTraefikWithExecutor(availableDir, dynamicDir, mock)
+
+	if err := drv.Test(); err == nil {
+		t.Error("Test should fail for invalid YAML")
+	}
+
+	// Traefik validation never shells out
+	if len(mock.Calls) != 0 {
+		t.Errorf("expected no executor calls, got %d", len(mock.Calls))
+	}
+}
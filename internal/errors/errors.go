@@ -170,6 +170,14 @@ func Validation(msg string) error {
 	}
 }
 
+// Newf creates an error with the specified code and a formatted message.
+func Newf(code ErrorCode, format string, args ...interface{}) error {
+	return &VHostError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
 // Wrap creates an error with the specified code, message, and underlying error.
 func Wrap(code ErrorCode, msg string, err error) error {
 	return &VHostError{
@@ -188,6 +196,16 @@ func WrapDomain(code ErrorCode, domain string, err error) error {
 	}
 }
 
+// CodeOf returns the code of the first VHostError in err's chain, or
+// ErrCodeInternal when there is none.
+func CodeOf(err error) ErrorCode {
+	var vhostErr *VHostError
+	if errors.As(err, &vhostErr) {
+		return vhostErr.Code
+	}
+	return ErrCodeInternal
+}
+
 // Is reports whether any error in err's chain matches target.
 // This is a re-export of errors.Is for convenience.
 var Is = errors.Is
@@ -199,6 +199,41 @@ func TestWrap(t *testing.T) {
 	}
 }
 
+func TestNewf(t *testing.T) {
+	err := Newf(ErrCodeNotFound, "vhost %s not found", "example.com")
+
+	var vhostErr *VHostError
+	if !errors.As(err, &vhostErr) {
+		t.Fatal("Newf() should return *VHostError")
+	}
+	if vhostErr.Code != ErrCodeNotFound {
+		t.Errorf("Code = %v, want %v", vhostErr.Code, ErrCodeNotFound)
+	}
+	if err.Error() != "vhost example.com not found" {
+		t.Errorf("Error() = %q, want %q", err.Error(), "vhost example.com not found")
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCode
+	}{
+		{"vhost error", Validation("bad domain"), ErrCodeValidation},
+		{"wrapped by fmt", fmt.Errorf("enable: %w", NotFound("example.com")), ErrCodeNotFound},
+		{"plain error", fmt.Errorf("boom"), ErrCodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.want {
+				t.Errorf("CodeOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestWrapDomain(t *testing.T) {
 	underlying := fmt.Errorf("symlink failed")
 	err := WrapDomain(ErrCodeDriver, "example.com", underlying)
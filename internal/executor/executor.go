@@ -1,6 +1,11 @@
 package executor
 
-import "os/exec"
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
 
 // CommandExecutor is an interface for executing system commands
 type CommandExecutor interface {
@@ -12,16 +17,28 @@ type CommandExecutor interface {
 }
 
 // SystemExecutor implements CommandExecutor using os/exec
-type SystemExecutor struct{}
+type SystemExecutor struct {
+	ctx context.Context
+}
 
 // NewSystemExecutor creates a new SystemExecutor
 func NewSystemExecutor() *SystemExecutor {
-	return &SystemExecutor{}
+	return &SystemExecutor{ctx: context.Background()}
+}
+
+// NewSystemExecutorContext creates a SystemExecutor whose commands are
+// killed once ctx is done, e.g. by --timeout or Ctrl-C
+func NewSystemExecutorContext(ctx context.Context) *SystemExecutor {
+	return &SystemExecutor{ctx: ctx}
 }
 
 // Execute runs a command and returns combined output
 func (e *SystemExecutor) Execute(name string, args ...string) ([]byte, error) {
-	cmd := exec.Command(name, args...)
+	ctx := e.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
 	return cmd.CombinedOutput()
 }
 
@@ -30,6 +47,26 @@ func (e *SystemExecutor) LookPath(file string) (string, error) {
 	return exec.LookPath(file)
 }
 
+// Init systems reported by DetectInitSystem
+const (
+	InitSystemd = "systemd"
+	InitOpenRC  = "openrc"
+	InitNone    = ""
+)
+
+// DetectInitSystem reports the service manager available through exec:
+// systemd when systemctl is on the PATH, otherwise OpenRC (Alpine, Gentoo)
+// when rc-service is, otherwise InitNone.
+func DetectInitSystem(exec CommandExecutor) string {
+	if _, err := exec.LookPath("systemctl"); err == nil {
+		return InitSystemd
+	}
+	if _, err := exec.LookPath("rc-service"); err == nil {
+		return InitOpenRC
+	}
+	return InitNone
+}
+
 // MockExecutor is a mock implementation for testing
 type MockExecutor struct {
 	ExecuteFunc  func(name string, args ...string) ([]byte, error)
@@ -59,3 +96,81 @@ func (m *MockExecutor) LookPath(file string) (string, error) {
 	}
 	return "/usr/bin/" + file, nil
 }
+
+// TestingT is the part of testing.TB the assertion helpers use, so this
+// package doesn't import testing
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// String returns the call as a command line, e.g. "nginx -t"
+func (c CommandCall) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// matches reports whether the call ran name with exactly args
+func (c CommandCall) matches(name string, args []string) bool {
+	if c.Name != name || len(c.Args) != len(args) {
+		return false
+	}
+	for i := range args {
+		if c.Args[i] != args[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// AssertCalled reports a test failure unless some recorded call ran name
+// with exactly args
+func (m *MockExecutor) AssertCalled(t TestingT, name string, args ...string) bool {
+	t.Helper()
+	for _, call := range m.Calls {
+		if call.matches(name, args) {
+			return true
+		}
+	}
+	want := CommandCall{Name: name, Args: args}
+	t.Errorf("expected call %q, got:\n%s", want.String(), m.formatCalls())
+	return false
+}
+
+// ExpectSequence reports a test failure unless the recorded calls are
+// exactly want, in order. Each entry is a command name followed by its
+// arguments, e.g. {"nginx", "-t"}.
+func (m *MockExecutor) ExpectSequence(t TestingT, want [][]string) bool {
+	t.Helper()
+	for i, expected := range want {
+		if len(expected) == 0 {
+			t.Errorf("expected call %d is empty", i+1)
+			return false
+		}
+		wantCall := CommandCall{Name: expected[0], Args: expected[1:]}
+		if i >= len(m.Calls) {
+			t.Errorf("call %d: expected %q, but only %d call(s) were made:\n%s", i+1, wantCall.String(), len(m.Calls), m.formatCalls())
+			return false
+		}
+		if !m.Calls[i].matches(wantCall.Name, wantCall.Args) {
+			t.Errorf("call %d: expected %q, got %q:\n%s", i+1, wantCall.String(), m.Calls[i].String(), m.formatCalls())
+			return false
+		}
+	}
+	if len(m.Calls) > len(want) {
+		t.Errorf("expected %d call(s), got %d:\n%s", len(want), len(m.Calls), m.formatCalls())
+		return false
+	}
+	return true
+}
+
+// formatCalls lists the recorded calls one per line for failure messages
+func (m *MockExecutor) formatCalls() string {
+	if len(m.Calls) == 0 {
+		return "  (no calls)"
+	}
+	lines := make([]string, len(m.Calls))
+	for i, call := range m.Calls {
+		lines[i] = fmt.Sprintf("  %d. %s", i+1, call.String())
+	}
+	return strings.Join(lines, "\n")
+}
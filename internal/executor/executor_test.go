@@ -1,8 +1,13 @@
 package executor
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSystemExecutor_Execute(t *testing.T) {
@@ -133,3 +138,170 @@ func TestMockExecutor_LookPath(t *testing.T) {
 		}
 	})
 }
+
+// recordingT captures assertion failures instead of failing the test
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockExecutor_AssertCalled(t *testing.T) {
+	mock := &MockExecutor{}
+	_, _ = mock.Execute("nginx", "-t")
+	_, _ = mock.Execute("systemctl", "reload", "nginx")
+
+	t.Run("match", func(t *testing.T) {
+		rec := &recordingT{}
+		if !mock.AssertCalled(rec, "systemctl", "reload", "nginx") || len(rec.errors) != 0 {
+			t.Errorf("expected match, got %v", rec.errors)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		rec := &recordingT{}
+		if mock.AssertCalled(rec, "nginx", "-s", "reload") {
+			t.Error("expected no match")
+		}
+		if len(rec.errors) != 1 {
+			t.Fatalf("expected 1 failure, got %v", rec.errors)
+		}
+		for _, want := range []string{`expected call "nginx -s reload"`, "1. nginx -t", "2. systemctl reload nginx"} {
+			if !strings.Contains(rec.errors[0], want) {
+				t.Errorf("failure %q missing %q", rec.errors[0], want)
+			}
+		}
+	})
+
+	t.Run("arguments must match exactly", func(t *testing.T) {
+		rec := &recordingT{}
+		if mock.AssertCalled(rec, "systemctl", "reload") {
+			t.Error("expected a prefix of the arguments not to match")
+		}
+	})
+}
+
+func TestMockExecutor_ExpectSequence(t *testing.T) {
+	newMock := func() *MockExecutor {
+		mock := &MockExecutor{}
+		_, _ = mock.Execute("nginx", "-t")
+		_, _ = mock.Execute("systemctl", "reload", "nginx")
+		return mock
+	}
+
+	tests := []struct {
+		name     string
+		want     [][]string
+		ok       bool
+		contains []string
+	}{
+		{
+			name: "match",
+			want: [][]string{{"nginx", "-t"}, {"systemctl", "reload", "nginx"}},
+			ok:   true,
+		},
+		{
+			name:     "mismatch",
+			want:     [][]string{{"nginx", "-t"}, {"nginx", "-s", "reload"}},
+			contains: []string{`call 2: expected "nginx -s reload", got "systemctl reload nginx"`},
+		},
+		{
+			name:     "fewer calls than expected",
+			want:     [][]string{{"nginx", "-t"}, {"systemctl", "reload", "nginx"}, {"nginx", "-s", "reload"}},
+			contains: []string{`call 3: expected "nginx -s reload", but only 2 call(s) were made`, "2. systemctl reload nginx"},
+		},
+		{
+			name:     "more calls than expected",
+			want:     [][]string{{"nginx", "-t"}},
+			contains: []string{"expected 1 call(s), got 2", "2. systemctl reload nginx"},
+		},
+		{
+			name:     "out of order",
+			want:     [][]string{{"systemctl", "reload", "nginx"}, {"nginx", "-t"}},
+			contains: []string{`call 1: expected "systemctl reload nginx", got "nginx -t"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingT{}
+			if got := newMock().ExpectSequence(rec, tt.want); got != tt.ok {
+				t.Errorf("ExpectSequence = %v, want %v (failures %v)", got, tt.ok, rec.errors)
+			}
+			if tt.ok {
+				if len(rec.errors) != 0 {
+					t.Errorf("unexpected failures: %v", rec.errors)
+				}
+				return
+			}
+			if len(rec.errors) != 1 {
+				t.Fatalf("expected 1 failure, got %v", rec.errors)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(rec.errors[0], want) {
+					t.Errorf("failure %q missing %q", rec.errors[0], want)
+				}
+			}
+		})
+	}
+
+	t.Run("no calls", func(t *testing.T) {
+		rec := &recordingT{}
+		if (&MockExecutor{}).ExpectSequence(rec, [][]string{{"nginx", "-t"}}) {
+			t.Error("expected failure without calls")
+		}
+		if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], "(no calls)") {
+			t.Errorf("expected failure listing no calls, got %v", rec.errors)
+		}
+	})
+}
+
+func TestDetectInitSystem(t *testing.T) {
+	tests := []struct {
+		name      string
+		available []string
+		want      string
+	}{
+		{"systemd", []string{"systemctl", "rc-service"}, InitSystemd},
+		{"openrc", []string{"rc-service"}, InitOpenRC},
+		{"none", nil, InitNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockExecutor{LookPathFunc: func(file string) (string, error) {
+				for _, name := range tt.available {
+					if name == file {
+						return "/usr/bin/" + file, nil
+					}
+				}
+				return "", errors.New("executable file not found in $PATH")
+			}}
+			if got := DetectInitSystem(mock); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSystemExecutorContext(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewSystemExecutorContext(ctx).Execute("sleep", "5")
+	if err == nil {
+		t.Fatal("expected the command to be killed")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("command ran for %s after its context was done", elapsed)
+	}
+}
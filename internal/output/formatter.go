@@ -1,12 +1,16 @@
 package output
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
+	"golang.org/x/term"
 )
 
 var (
@@ -16,6 +20,32 @@ var (
 	infoColor    = color.New(color.FgCyan)
 )
 
+// Color modes accepted by SetColorMode
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// autoNoColor is the terminal detection result from fatih/color at startup
+var autoNoColor = color.NoColor
+
+// SetColorMode controls colored output. ColorAuto colors only when stdout
+// is a terminal and NO_COLOR is unset; ColorAlways and ColorNever force it.
+func SetColorMode(mode string) error {
+	switch mode {
+	case ColorAuto, "":
+		color.NoColor = autoNoColor || os.Getenv("NO_COLOR") != ""
+	case ColorAlways:
+		color.NoColor = false
+	case ColorNever:
+		color.NoColor = true
+	default:
+		return fmt.Errorf("invalid color mode: %s (use %s, %s or %s)", mode, ColorAuto, ColorAlways, ColorNever)
+	}
+	return nil
+}
+
 // JSON outputs data as JSON
 func JSON(data interface{}) error {
 	encoder := json.NewEncoder(os.Stdout)
@@ -23,8 +53,45 @@ func JSON(data interface{}) error {
 	return encoder.Encode(data)
 }
 
-// Table outputs data as a formatted table
+// TableOptions controls how TableWithOptions fits cells to the screen
+type TableOptions struct {
+	// MaxWidths caps the width of each column; 0 or a missing entry
+	// leaves that column uncapped
+	MaxWidths []int
+	// Width is the line width to fit the table into. 0 uses the terminal
+	// width, or no limit when stdout isn't a terminal; a negative width
+	// never limits.
+	Width int
+	// Wide turns off all truncation
+	Wide bool
+}
+
+// minColumnWidth is the narrowest a column is cut to when fitting a
+// table into the terminal
+const minColumnWidth = 8
+
+// terminalWidth returns the width of the terminal on stdout, or 0 when
+// stdout isn't a terminal
+var terminalWidth = func() int {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// Table outputs data as a formatted table, fitted to the terminal width
 func Table(headers []string, rows [][]string) {
+	TableWithOptions(headers, rows, TableOptions{})
+}
+
+// TableWithOptions outputs data as a formatted table. Cells wider than
+// their column are cut with an ellipsis.
+func TableWithOptions(headers []string, rows [][]string, opts TableOptions) {
 	if len(headers) == 0 {
 		return
 	}
@@ -32,20 +99,23 @@ func Table(headers []string, rows [][]string) {
 	// Calculate column widths
 	widths := make([]int, len(headers))
 	for i, h := range headers {
-		widths[i] = len(h)
+		widths[i] = cellWidth(h)
 	}
 	for _, row := range rows {
 		for i, cell := range row {
-			if i < len(widths) && len(cell) > widths[i] {
-				widths[i] = len(cell)
+			if i < len(widths) && cellWidth(cell) > widths[i] {
+				widths[i] = cellWidth(cell)
 			}
 		}
 	}
+	if !opts.Wide {
+		fitColumns(widths, opts)
+	}
 
 	// Print headers
 	headerLine := make([]string, len(headers))
 	for i, h := range headers {
-		headerLine[i] = fmt.Sprintf("%-*s", widths[i], h)
+		headerLine[i] = fmt.Sprintf("%-*s", widths[i], truncate(h, widths[i]))
 	}
 	fmt.Println(strings.Join(headerLine, "  "))
 
@@ -64,33 +134,173 @@ func Table(headers []string, rows [][]string) {
 			if i < len(row) {
 				cell = row[i]
 			}
-			rowLine[i] = fmt.Sprintf("%-*s", widths[i], cell)
+			rowLine[i] = fmt.Sprintf("%-*s", widths[i], truncate(cell, widths[i]))
 		}
 		fmt.Println(strings.Join(rowLine, "  "))
 	}
 }
 
+// fitColumns applies opts.MaxWidths to widths, then narrows the widest
+// columns until the table fits the line width
+func fitColumns(widths []int, opts TableOptions) {
+	for i, maxWidth := range opts.MaxWidths {
+		if i < len(widths) && maxWidth > 0 && widths[i] > maxWidth {
+			widths[i] = maxWidth
+		}
+	}
+
+	limit := opts.Width
+	if limit == 0 {
+		limit = terminalWidth()
+	}
+	if limit <= 0 {
+		return
+	}
+
+	total := 2 * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for total > limit {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+// cellWidth returns the number of characters in s
+func cellWidth(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// truncate cuts s to width characters, ending in an ellipsis when
+// anything was removed
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// Table formats accepted by commands with --output
+const (
+	FormatTable = "table"
+	FormatCSV   = "csv"
+	FormatTSV   = "tsv"
+)
+
+// CSV outputs the same data as Table as RFC 4180 comma-separated values
+func CSV(headers []string, rows [][]string) error {
+	return delimited(',', headers, rows)
+}
+
+// TSV outputs the same data as Table as tab-separated values, quoting
+// fields the same way as CSV
+func TSV(headers []string, rows [][]string) error {
+	return delimited('\t', headers, rows)
+}
+
+// delimited writes headers and rows to stdout separated by comma. Rows are
+// padded or cut to the header width, as in Table.
+func delimited(comma rune, headers []string, rows [][]string) error {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	w.Comma = comma
+	if err := w.Write(headers); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(headers))
+		copy(record, row)
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// messageOutput receives the Success, Error, Warn and Info messages when
+// set; nil leaves them on stdout
+var messageOutput io.Writer
+
+// SetMessageOutput sends Success, Error, Warn and Info messages to w, e.g.
+// stderr so that stdout carries only a JSON document. nil restores stdout.
+func SetMessageOutput(w io.Writer) {
+	messageOutput = w
+}
+
+// messages returns where Success, Error, Warn and Info write
+func messages() io.Writer {
+	if messageOutput != nil {
+		return messageOutput
+	}
+	return color.Output
+}
+
 // Success prints a success message
 func Success(format string, args ...interface{}) {
-	_, _ = successColor.Printf("✓ "+format+"\n", args...)
+	_, _ = successColor.Fprintf(messages(), "✓ "+format+"\n", args...)
 }
 
 // Error prints an error message
 func Error(format string, args ...interface{}) {
-	_, _ = errorColor.Printf("✗ "+format+"\n", args...)
+	_, _ = errorColor.Fprintf(messages(), "✗ "+format+"\n", args...)
 }
 
 // Warn prints a warning message
 func Warn(format string, args ...interface{}) {
-	_, _ = warnColor.Printf("! "+format+"\n", args...)
+	_, _ = warnColor.Fprintf(messages(), "! "+format+"\n", args...)
 }
 
 // Info prints an info message
 func Info(format string, args ...interface{}) {
-	_, _ = infoColor.Printf("→ "+format+"\n", args...)
+	_, _ = infoColor.Fprintf(messages(), "→ "+format+"\n", args...)
+}
+
+// ClearScreen clears the terminal and moves the cursor home. It does
+// nothing when stdout is not a terminal.
+func ClearScreen() {
+	if isTerminal(os.Stdout) {
+		fmt.Print("\033[H\033[2J")
+	}
 }
 
 // Print prints a plain message
 func Print(format string, args ...interface{}) {
 	fmt.Printf(format+"\n", args...)
 }
+
+// Diff prints a unified diff, coloring removed lines red, added lines
+// green and hunk headers cyan
+func Diff(diff string) {
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Println(line)
+		case strings.HasPrefix(line, "+"):
+			_, _ = successColor.Println(line)
+		case strings.HasPrefix(line, "-"):
+			_, _ = errorColor.Println(line)
+		case strings.HasPrefix(line, "@@"):
+			_, _ = infoColor.Println(line)
+		default:
+			fmt.Println(line)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 )
@@ -224,6 +225,115 @@ func TestTable(t *testing.T) {
 	})
 }
 
+func TestTableWithOptions(t *testing.T) {
+	headers := []string{"DOMAIN", "ROOT/PROXY"}
+	rows := [][]string{
+		{"example.com", "http://localhost:3000/a/very/long/upstream/path"},
+	}
+
+	t.Run("truncates to width", func(t *testing.T) {
+		out := captureStdout(func() {
+			TableWithOptions(headers, rows, TableOptions{Width: 40})
+		})
+		for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+			if n := utf8.RuneCountInString(strings.TrimRight(line, " ")); n > 40 {
+				t.Errorf("line %q is %d characters wide, want at most 40", line, n)
+			}
+		}
+		if !strings.Contains(out, "example.com  http://localhost:3000/a/ve…") {
+			t.Errorf("expected the proxy column cut with an ellipsis, got:\n%s", out)
+		}
+	})
+
+	t.Run("max widths", func(t *testing.T) {
+		out := captureStdout(func() {
+			TableWithOptions(headers, rows, TableOptions{MaxWidths: []int{0, 12}, Width: -1})
+		})
+		if !strings.Contains(out, "example.com  http://loca…") {
+			t.Errorf("expected the proxy column capped at 12, got:\n%s", out)
+		}
+	})
+
+	t.Run("wide shows full content", func(t *testing.T) {
+		out := captureStdout(func() {
+			TableWithOptions(headers, rows, TableOptions{MaxWidths: []int{4, 4}, Width: 20, Wide: true})
+		})
+		if !strings.Contains(out, rows[0][1]) || strings.Contains(out, "…") {
+			t.Errorf("expected untruncated output, got:\n%s", out)
+		}
+	})
+
+	t.Run("terminal width", func(t *testing.T) {
+		oldWidth := terminalWidth
+		terminalWidth = func() int { return 30 }
+		defer func() { terminalWidth = oldWidth }()
+
+		out := captureStdout(func() {
+			Table(headers, rows)
+		})
+		if !strings.Contains(out, "example.com  http://localhost…") {
+			t.Errorf("expected the table fitted to 30 columns, got:\n%s", out)
+		}
+	})
+}
+
+func TestCSV(t *testing.T) {
+	t.Run("quotes commas and double quotes", func(t *testing.T) {
+		headers := []string{"DOMAIN", "NOTES"}
+		rows := [][]string{
+			{"example.com", "shop, blog"},
+			{"test.com", `the "old" site`},
+		}
+
+		output := captureStdout(func() {
+			if err := CSV(headers, rows); err != nil {
+				t.Fatalf("CSV() error: %v", err)
+			}
+		})
+
+		expected := "DOMAIN,NOTES\n" +
+			"example.com,\"shop, blog\"\n" +
+			"test.com,\"the \"\"old\"\" site\"\n"
+		if output != expected {
+			t.Errorf("expected %q, got %q", expected, output)
+		}
+	})
+
+	t.Run("uneven columns", func(t *testing.T) {
+		output := captureStdout(func() {
+			_ = CSV([]string{"A", "B"}, [][]string{{"1"}, {"x", "y", "z"}})
+		})
+
+		expected := "A,B\n1,\nx,y\n"
+		if output != expected {
+			t.Errorf("expected %q, got %q", expected, output)
+		}
+	})
+
+	t.Run("empty headers", func(t *testing.T) {
+		output := captureStdout(func() {
+			_ = CSV(nil, [][]string{{"data"}})
+		})
+
+		if output != "" {
+			t.Errorf("expected no output for empty headers, got %s", output)
+		}
+	})
+}
+
+func TestTSV(t *testing.T) {
+	output := captureStdout(func() {
+		if err := TSV([]string{"DOMAIN", "ROOT"}, [][]string{{"example.com", "/var/www/a, b"}}); err != nil {
+			t.Fatalf("TSV() error: %v", err)
+		}
+	})
+
+	expected := "DOMAIN\tROOT\nexample.com\t/var/www/a, b\n"
+	if output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
 func TestSuccess(t *testing.T) {
 	output := captureStdout(func() {
 		Success("operation completed")
@@ -250,6 +360,27 @@ func TestError(t *testing.T) {
 	}
 }
 
+func TestSetMessageOutput(t *testing.T) {
+	var buf bytes.Buffer
+	SetMessageOutput(&buf)
+	defer SetMessageOutput(nil)
+
+	out := captureStdout(func() {
+		Info("info")
+		Success("done")
+		Warn("careful")
+		Error("failed")
+		Print("result")
+	})
+
+	if out != "result\n" {
+		t.Errorf("expected only Print on stdout, got %q", out)
+	}
+	if want := "→ info\n✓ done\n! careful\n✗ failed\n"; buf.String() != want {
+		t.Errorf("expected messages %q, got %q", want, buf.String())
+	}
+}
+
 func TestWarn(t *testing.T) {
 	output := captureStdout(func() {
 		Warn("warning message")
@@ -337,3 +468,48 @@ func TestFormattedOutput(t *testing.T) {
 		}
 	})
 }
+
+func TestSetColorMode(t *testing.T) {
+	defer func() { color.NoColor = true }()
+
+	printAll := func() string {
+		return captureStdout(func() {
+			Success("done")
+			Error("failed")
+		})
+	}
+
+	t.Run("never strips escape codes", func(t *testing.T) {
+		if err := SetColorMode(ColorNever); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out := printAll(); strings.Contains(out, "\x1b[") {
+			t.Errorf("expected no ANSI escape codes, got %q", out)
+		}
+	})
+
+	t.Run("always forces escape codes", func(t *testing.T) {
+		if err := SetColorMode(ColorAlways); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out := printAll(); !strings.Contains(out, "\x1b[") {
+			t.Errorf("expected ANSI escape codes, got %q", out)
+		}
+	})
+
+	t.Run("auto honors NO_COLOR", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		if err := SetColorMode(ColorAuto); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out := printAll(); strings.Contains(out, "\x1b[") {
+			t.Errorf("expected no ANSI escape codes with NO_COLOR, got %q", out)
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		if err := SetColorMode("sometimes"); err == nil {
+			t.Error("expected error for invalid mode")
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// spinnerOutput is where the spinner animates; a non-terminal disables it
+var spinnerOutput io.Writer = os.Stderr
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+const spinnerInterval = 100 * time.Millisecond
+
+// StartSpinner animates msg on stderr until the returned stop function is
+// called. It does nothing when stderr is not a terminal.
+func StartSpinner(msg string) (stop func()) {
+	w := spinnerOutput
+	if !isTerminal(w) {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			_, _ = fmt.Fprintf(w, "\r%s %s", spinnerFrames[i%len(spinnerFrames)], msg)
+			select {
+			case <-done:
+				// Clear the spinner line
+				_, _ = fmt.Fprint(w, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// isTerminal reports whether w is a character device such as a TTY
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,45 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartSpinnerNotTerminal(t *testing.T) {
+	oldOutput := spinnerOutput
+	defer func() { spinnerOutput = oldOutput }()
+
+	t.Run("buffer", func(t *testing.T) {
+		var buf bytes.Buffer
+		spinnerOutput = &buf
+
+		stop := StartSpinner("Issuing certificate...")
+		stop()
+		stop() // safe to call twice
+
+		if buf.Len() != 0 {
+			t.Errorf("expected no output, got %q", buf.String())
+		}
+	})
+
+	t.Run("regular file", func(t *testing.T) {
+		f, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+		if err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		defer func() { _ = f.Close() }()
+		spinnerOutput = f
+
+		StartSpinner("Issuing certificate...")()
+
+		info, err := f.Stat()
+		if err != nil {
+			t.Fatalf("stat failed: %v", err)
+		}
+		if info.Size() != 0 {
+			t.Errorf("expected no output, got %d bytes", info.Size())
+		}
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+
+	"github.com/ksyq12/vhost/internal/config"
 )
 
 // PathConfig contains the paths for a web server driver.
@@ -15,9 +17,14 @@ type PathConfig struct {
 
 // PlatformPaths contains the detected paths for all supported web servers.
 type PlatformPaths struct {
-	Nginx  PathConfig
-	Apache PathConfig
-	Caddy  PathConfig
+	Nginx   PathConfig
+	Apache  PathConfig
+	Caddy   PathConfig
+	Traefik PathConfig
+
+	// ActivationMode is how vhosts are enabled on this platform; empty
+	// means the default, symlinks
+	ActivationMode string
 }
 
 // DetectPaths returns platform-specific default paths for web servers.
@@ -28,6 +35,8 @@ func DetectPaths() (*PlatformPaths, error) {
 		return detectDarwinPaths()
 	case "linux":
 		return detectLinuxPaths()
+	case "windows":
+		return detectWindowsPaths()
 	default:
 		return nil, fmt.Errorf("unsupported platform: %s", runtime.GOOS)
 	}
@@ -50,6 +59,10 @@ func detectDarwinPaths() (*PlatformPaths, error) {
 				Available: "/opt/homebrew/etc/caddy/sites-available",
 				Enabled:   "/opt/homebrew/etc/caddy/sites-enabled",
 			},
+			Traefik: PathConfig{
+				Available: "/opt/homebrew/etc/traefik/sites-available",
+				Enabled:   "/opt/homebrew/etc/traefik/dynamic",
+			},
 		}, nil
 	}
 
@@ -68,6 +81,10 @@ func detectDarwinPaths() (*PlatformPaths, error) {
 				Available: "/usr/local/etc/caddy/sites-available",
 				Enabled:   "/usr/local/etc/caddy/sites-enabled",
 			},
+			Traefik: PathConfig{
+				Available: "/usr/local/etc/traefik/sites-available",
+				Enabled:   "/usr/local/etc/traefik/dynamic",
+			},
 		}, nil
 	}
 
@@ -91,6 +108,10 @@ func detectLinuxPaths() (*PlatformPaths, error) {
 				Available: "/etc/caddy/sites-available",
 				Enabled:   "/etc/caddy/sites-enabled",
 			},
+			Traefik: PathConfig{
+				Available: "/etc/traefik/sites-available",
+				Enabled:   "/etc/traefik/dynamic",
+			},
 		}, nil
 	}
 
@@ -109,12 +130,46 @@ func detectLinuxPaths() (*PlatformPaths, error) {
 				Available: "/etc/caddy/conf.d",
 				Enabled:   "/etc/caddy/conf.d",
 			},
+			Traefik: PathConfig{
+				Available: "/etc/traefik/sites-available",
+				Enabled:   "/etc/traefik/dynamic",
+			},
 		}, nil
 	}
 
 	return nil, fmt.Errorf("web server configuration paths not found (checked /etc/nginx, /etc/nginx/conf.d, /etc/httpd)")
 }
 
+// detectWindowsPaths returns paths for servers unpacked to the system
+// drive, as the nginx for Windows and Apache Lounge builds suggest. Creating
+// symlinks needs administrator rights on Windows, so configs are copied.
+func detectWindowsPaths() (*PlatformPaths, error) {
+	drive := os.Getenv("SystemDrive")
+	if drive == "" {
+		drive = "C:"
+	}
+
+	return &PlatformPaths{
+		Nginx: PathConfig{
+			Available: drive + `\nginx\conf\sites-available`,
+			Enabled:   drive + `\nginx\conf\sites-enabled`,
+		},
+		Apache: PathConfig{
+			Available: drive + `\Apache24\conf\sites-available`,
+			Enabled:   drive + `\Apache24\conf\sites-enabled`,
+		},
+		Caddy: PathConfig{
+			Available: drive + `\caddy\sites-available`,
+			Enabled:   drive + `\caddy\sites-enabled`,
+		},
+		Traefik: PathConfig{
+			Available: drive + `\traefik\sites-available`,
+			Enabled:   drive + `\traefik\dynamic`,
+		},
+		ActivationMode: config.ActivationCopy,
+	}, nil
+}
+
 // GetPathsForDriver returns the paths for a specific driver from PlatformPaths.
 func (p *PlatformPaths) GetPathsForDriver(driverName string) (PathConfig, error) {
 	switch driverName {
@@ -124,8 +179,10 @@ func (p *PlatformPaths) GetPathsForDriver(driverName string) (PathConfig, error)
 		return p.Apache, nil
 	case "caddy":
 		return p.Caddy, nil
+	case "traefik":
+		return p.Traefik, nil
 	default:
-		return PathConfig{}, fmt.Errorf("unknown driver: %s (available: nginx, apache, caddy)", driverName)
+		return PathConfig{}, fmt.Errorf("unknown driver: %s (available: nginx, apache, caddy, traefik)", driverName)
 	}
 }
 
@@ -2,7 +2,10 @@ package platform
 
 import (
 	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/ksyq12/vhost/internal/config"
 )
 
 func TestDetectPaths(t *testing.T) {
@@ -30,6 +33,11 @@ func TestDetectPaths(t *testing.T) {
 			t.Error("caddy available path is empty")
 		}
 
+	case "windows":
+		if err != nil || paths.ActivationMode != config.ActivationCopy {
+			t.Errorf("expected Windows paths with copy activation, got %+v, %v", paths, err)
+		}
+
 	default:
 		if err == nil {
 			t.Errorf("expected error on unsupported platform %s, but got nil", runtime.GOOS)
@@ -63,6 +71,10 @@ func TestPlatformPathsGetPathsForDriver(t *testing.T) {
 			Available: "/etc/caddy/sites-available",
 			Enabled:   "/etc/caddy/sites-enabled",
 		},
+		Traefik: PathConfig{
+			Available: "/etc/traefik/sites-available",
+			Enabled:   "/etc/traefik/dynamic",
+		},
 	}
 
 	tests := []struct {
@@ -73,6 +85,7 @@ func TestPlatformPathsGetPathsForDriver(t *testing.T) {
 		{"nginx", "/etc/nginx/sites-available", false},
 		{"apache", "/etc/apache2/sites-available", false},
 		{"caddy", "/etc/caddy/sites-available", false},
+		{"traefik", "/etc/traefik/sites-available", false},
 		{"unknown", "", true},
 	}
 
@@ -147,3 +160,29 @@ func TestDetectLinuxPaths(t *testing.T) {
 		t.Error("nginx available path should not be empty on Linux")
 	}
 }
+
+func TestDetectWindowsPaths(t *testing.T) {
+	t.Setenv("SystemDrive", "D:")
+
+	paths, err := detectWindowsPaths()
+	if err != nil {
+		t.Fatalf("Windows detection should not fail: %v", err)
+	}
+
+	for _, p := range []PathConfig{paths.Nginx, paths.Apache, paths.Caddy, paths.Traefik} {
+		if p.Available == "" || p.Enabled == "" {
+			t.Errorf("expected non-empty paths, got %+v", p)
+		}
+		if !strings.HasPrefix(p.Available, `D:\`) || !strings.HasPrefix(p.Enabled, `D:\`) {
+			t.Errorf("expected paths on the system drive, got %+v", p)
+		}
+	}
+	if paths.Nginx.Available != `D:\nginx\conf\sites-available` {
+		t.Errorf("unexpected nginx path: %s", paths.Nginx.Available)
+	}
+
+	// Symlinks need administrator rights, so configs are copied
+	if paths.ActivationMode != config.ActivationCopy {
+		t.Errorf("expected activation mode %q, got %q", config.ActivationCopy, paths.ActivationMode)
+	}
+}
@@ -1,9 +1,13 @@
 package ssl
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/ksyq12/vhost/internal/executor"
 )
@@ -15,8 +19,22 @@ type Cert struct {
 	KeyPath  string
 }
 
-// letsencryptDir is the base directory for Let's Encrypt certificates
-const letsencryptDir = "/etc/letsencrypt/live"
+// defaultLiveDir is where certbot keeps the current certificate of each domain
+const defaultLiveDir = "/etc/letsencrypt/live"
+
+// letsencryptDir is the base directory for Let's Encrypt certificates (can be
+// replaced for testing)
+var letsencryptDir = defaultLiveDir
+
+// SetLiveDir allows tests to read certificates from another directory
+func SetLiveDir(dir string) {
+	letsencryptDir = dir
+}
+
+// ResetLiveDir restores the default Let's Encrypt live directory
+func ResetLiveDir() {
+	letsencryptDir = defaultLiveDir
+}
 
 // cmdExecutor is the command executor (can be replaced for testing)
 var cmdExecutor executor.CommandExecutor = executor.NewSystemExecutor()
@@ -26,11 +44,73 @@ func SetExecutor(exec executor.CommandExecutor) {
 	cmdExecutor = exec
 }
 
+// SetContext makes certbot runs stop once ctx is done. An executor set
+// with SetExecutor is kept as is.
+func SetContext(ctx context.Context) {
+	if _, ok := cmdExecutor.(*executor.SystemExecutor); ok {
+		cmdExecutor = executor.NewSystemExecutorContext(ctx)
+	}
+}
+
 // ResetExecutor resets the executor to the default system executor
 func ResetExecutor() {
 	cmdExecutor = executor.NewSystemExecutor()
 }
 
+// staging makes new certificates come from the Let's Encrypt staging CA
+var staging bool
+
+// SetStaging toggles issuing untrusted test certificates from the staging
+// CA, which has much higher rate limits
+func SetStaging(enabled bool) {
+	staging = enabled
+}
+
+// issueArgs appends the options shared by every issuing mode
+func issueArgs(args []string) []string {
+	if staging {
+		args = append(args, "--staging")
+	}
+	return args
+}
+
+var (
+	// certDomainPattern matches a hostname, optionally a wildcard; it can't
+	// start with '-', so it is never read as a certbot option
+	certDomainPattern = regexp.MustCompile(`^(\*\.)?([A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?\.)*[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?$`)
+
+	// certEmailPattern matches a plain user@host address
+	certEmailPattern = regexp.MustCompile(`^[^\s@-][^\s@]*@[^\s@]+\.[^\s@]+$`)
+)
+
+// checkIssueArgs rejects a domain, extra name or email certbot could
+// misread, such as one starting with '-' or containing whitespace
+func checkIssueArgs(domain, email string, sans []string) error {
+	for _, name := range append([]string{domain}, sans...) {
+		if !certDomainPattern.MatchString(name) {
+			return fmt.Errorf("invalid domain for certificate: %q", name)
+		}
+	}
+	if !certEmailPattern.MatchString(email) {
+		return fmt.Errorf("invalid email for Let's Encrypt: %q", email)
+	}
+	return nil
+}
+
+// domainArgs returns the -d options for domain and its extra names. With
+// extra names the certificate is still filed under domain, where
+// GetCertPaths looks for it.
+func domainArgs(domain string, sans []string) []string {
+	args := []string{"-d", domain}
+	for _, name := range sans {
+		args = append(args, "-d", name)
+	}
+	if len(sans) > 0 {
+		args = append(args, "--cert-name", domain)
+	}
+	return args
+}
+
 // IsInstalled checks if certbot is installed
 func IsInstalled() bool {
 	_, err := cmdExecutor.LookPath("certbot")
@@ -59,19 +139,85 @@ func GetCertPaths(domain string) *Cert {
 	}
 }
 
-// Issue obtains a new SSL certificate using certbot webroot mode
-func Issue(domain, email, webroot string) (*Cert, error) {
-	args := []string{
-		"certonly",
-		"--webroot",
-		"-w", webroot,
-		"-d", domain,
+// CopyCert copies cert's chain and key into dir/<domain>/ as fullchain.pem
+// and privkey.pem, following certbot's live symlinks, and returns the
+// copies' paths. The key is written with mode 0600.
+func CopyCert(cert *Cert, dir string) (*Cert, error) {
+	if !filepath.IsAbs(dir) {
+		return nil, fmt.Errorf("certificate copy directory must be an absolute path: %q", dir)
+	}
+
+	target := filepath.Join(dir, cert.Domain)
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", target, err)
+	}
+
+	copied := &Cert{
+		Domain:   cert.Domain,
+		CertPath: filepath.Join(target, "fullchain.pem"),
+		KeyPath:  filepath.Join(target, "privkey.pem"),
+	}
+	if err := copyFile(cert.CertPath, copied.CertPath, 0644); err != nil {
+		return nil, err
+	}
+	if err := copyFile(cert.KeyPath, copied.KeyPath, 0600); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
+// copyFile writes src's content to dst with mode perm, replacing dst in a
+// single rename so a reader never sees a partial file
+func copyFile(src, dst string, perm os.FileMode) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	// CreateTemp makes 0600 files; set the mode before any content lands
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	return nil
+}
+
+// Issue obtains a new SSL certificate using certbot webroot mode. Like the
+// other Issue functions it takes extra names (SANs) the certificate should
+// also cover.
+func Issue(domain, email, webroot string, sans ...string) (*Cert, error) {
+	if err := checkIssueArgs(domain, email, sans); err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(webroot) {
+		return nil, fmt.Errorf("webroot must be an absolute path: %q", webroot)
+	}
+
+	args := []string{"certonly", "--webroot", "-w", webroot}
+	args = append(args, domainArgs(domain, sans)...)
+	args = append(args,
 		"--email", email,
 		"--agree-tos",
 		"--non-interactive",
-	}
+	)
 
-	if err := runCertbot(args); err != nil {
+	if err := runCertbot(issueArgs(args)); err != nil {
 		return nil, err
 	}
 
@@ -79,35 +225,103 @@ func Issue(domain, email, webroot string) (*Cert, error) {
 }
 
 // IssueStandalone obtains a certificate using standalone mode
-func IssueStandalone(domain, email string) (*Cert, error) {
-	args := []string{
-		"certonly",
-		"--standalone",
-		"-d", domain,
+func IssueStandalone(domain, email string, sans ...string) (*Cert, error) {
+	if err := checkIssueArgs(domain, email, sans); err != nil {
+		return nil, err
+	}
+
+	args := []string{"certonly", "--standalone"}
+	args = append(args, domainArgs(domain, sans)...)
+	args = append(args,
+		"--email", email,
+		"--agree-tos",
+		"--non-interactive",
+	)
+
+	if err := runCertbot(issueArgs(args)); err != nil {
+		return nil, err
+	}
+
+	return GetCertPaths(domain), nil
+}
+
+// IssueNginx obtains a certificate using the nginx plugin for the
+// challenge only. certonly keeps certbot from rewriting the vhost config;
+// vhost renders the SSL directives itself.
+func IssueNginx(domain, email string, sans ...string) (*Cert, error) {
+	if err := checkIssueArgs(domain, email, sans); err != nil {
+		return nil, err
+	}
+
+	args := []string{"certonly", "--nginx"}
+	args = append(args, domainArgs(domain, sans)...)
+	args = append(args,
 		"--email", email,
 		"--agree-tos",
 		"--non-interactive",
+	)
+
+	if err := runCertbot(issueArgs(args)); err != nil {
+		return nil, err
 	}
 
-	if err := runCertbot(args); err != nil {
+	return GetCertPaths(domain), nil
+}
+
+// IssueApache obtains a certificate using the apache plugin for the
+// challenge only. certonly keeps certbot from rewriting the vhost config;
+// vhost renders the SSL directives itself.
+func IssueApache(domain, email string, sans ...string) (*Cert, error) {
+	if err := checkIssueArgs(domain, email, sans); err != nil {
+		return nil, err
+	}
+
+	args := []string{"certonly", "--apache"}
+	args = append(args, domainArgs(domain, sans)...)
+	args = append(args,
+		"--email", email,
+		"--agree-tos",
+		"--non-interactive",
+	)
+
+	if err := runCertbot(issueArgs(args)); err != nil {
 		return nil, err
 	}
 
 	return GetCertPaths(domain), nil
 }
 
-// IssueNginx obtains a certificate using nginx plugin
-func IssueNginx(domain, email string) (*Cert, error) {
+// dnsPluginPattern matches certbot DNS plugin names such as cloudflare or
+// route53, which select the --dns-<name> authenticator
+var dnsPluginPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// IssueDNS obtains a certificate using a certbot DNS plugin, which proves
+// control of the domain with a TXT record instead of an HTTP request.
+// credentials is the plugin's credentials file; it may be empty for
+// plugins that read their credentials elsewhere, such as route53.
+func IssueDNS(domain, email, plugin, credentials string, sans ...string) (*Cert, error) {
+	if err := checkIssueArgs(domain, email, sans); err != nil {
+		return nil, err
+	}
+	if !dnsPluginPattern.MatchString(plugin) {
+		return nil, fmt.Errorf("invalid DNS plugin name %q", plugin)
+	}
+
 	args := []string{
-		"--nginx",
-		"-d", domain,
+		"certonly",
+		"--dns-" + plugin,
+	}
+	if credentials != "" {
+		args = append(args, "--dns-"+plugin+"-credentials", credentials)
+	}
+	args = append(args, domainArgs(domain, sans)...)
+	args = append(args,
 		"--email", email,
 		"--agree-tos",
 		"--non-interactive",
-		"--redirect",
-	}
+	)
 
-	if err := runCertbot(args); err != nil {
+	if err := runCertbot(issueArgs(args)); err != nil {
 		return nil, err
 	}
 
@@ -124,6 +338,18 @@ func Renew(domain string) error {
 	return runCertbot(args)
 }
 
+// RenewForce renews a specific certificate even if it is not due yet.
+// Every forced renewal counts against Let's Encrypt's rate limits.
+func RenewForce(domain string) error {
+	args := []string{
+		"renew",
+		"--cert-name", domain,
+		"--non-interactive",
+		"--force-renewal",
+	}
+	return runCertbot(args)
+}
+
 // RenewAll renews all certificates
 func RenewAll() error {
 	return runCertbot([]string{"renew", "--non-interactive"})
@@ -139,20 +365,37 @@ func Delete(domain string) error {
 	return runCertbot(args)
 }
 
-// List returns all managed certificates
-func List() ([]string, error) {
+// CertInfo describes a certificate as reported by certbot certificates
+type CertInfo struct {
+	Name       string    `json:"name"`
+	Domains    []string  `json:"domains"`
+	ExpiryDate time.Time `json:"expiry_date"`
+	ValidDays  int       `json:"valid_days"` // 0 once certbot reports it invalid
+}
+
+// certificates runs certbot certificates and returns its output
+func certificates() (string, error) {
 	if !IsInstalled() {
-		return nil, fmt.Errorf("certbot is not installed")
+		return "", fmt.Errorf("certbot is not installed")
 	}
 
 	output, err := cmdExecutor.Execute("certbot", "certificates")
 	if err != nil {
-		return nil, fmt.Errorf("certbot certificates failed: %s", string(output))
+		return "", fmt.Errorf("certbot certificates failed: %s", string(output))
+	}
+	return string(output), nil
+}
+
+// List returns all managed certificates
+func List() ([]string, error) {
+	output, err := certificates()
+	if err != nil {
+		return nil, err
 	}
 
 	// Parse output to extract domain names
 	var domains []string
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		if strings.Contains(line, "Certificate Name:") {
 			parts := strings.Split(line, ":")
@@ -164,3 +407,66 @@ func List() ([]string, error) {
 
 	return domains, nil
 }
+
+// ListDetailed returns every managed certificate with its names and expiry
+func ListDetailed() ([]CertInfo, error) {
+	output, err := certificates()
+	if err != nil {
+		return nil, err
+	}
+	return parseCertificates(output)
+}
+
+// expiryLayouts are the date formats certbot prints for Expiry Date
+var expiryLayouts = []string{"2006-01-02 15:04:05-07:00", "2006-01-02"}
+
+// parseCertificates reads the output of certbot certificates: a
+// "Certificate Name:" line starts each certificate, followed by indented
+// "Domains:" and "Expiry Date: <date> (VALID: <n> days)" lines
+func parseCertificates(output string) ([]CertInfo, error) {
+	certs := []CertInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		if key == "Certificate Name" {
+			certs = append(certs, CertInfo{Name: value, Domains: []string{}})
+			continue
+		}
+		if len(certs) == 0 {
+			continue
+		}
+		cert := &certs[len(certs)-1]
+
+		switch key {
+		case "Domains":
+			cert.Domains = strings.Fields(value)
+		case "Expiry Date":
+			date, status, _ := strings.Cut(value, " (")
+			expiry, err := parseExpiry(date)
+			if err != nil {
+				return nil, fmt.Errorf("certificate %s: %w", cert.Name, err)
+			}
+			cert.ExpiryDate = expiry
+
+			var days int
+			if _, err := fmt.Sscanf(status, "VALID: %d", &days); err == nil {
+				cert.ValidDays = days
+			}
+		}
+	}
+	return certs, nil
+}
+
+// parseExpiry parses an expiry date in any of expiryLayouts
+func parseExpiry(date string) (time.Time, error) {
+	for _, layout := range expiryLayouts {
+		if expiry, err := time.Parse(layout, date); err == nil {
+			return expiry, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized expiry date %q", date)
+}
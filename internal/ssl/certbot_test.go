@@ -2,7 +2,11 @@ package ssl
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ksyq12/vhost/internal/executor"
 )
@@ -25,6 +29,26 @@ func TestIsInstalled(t *testing.T) {
 		}
 	})
 
+	t.Run("staging", func(t *testing.T) {
+		mock := &executor.MockExecutor{
+			LookPathFunc: func(file string) (string, error) {
+				return "/usr/bin/" + file, nil
+			},
+		}
+		SetExecutor(mock)
+		defer ResetExecutor()
+		SetStaging(true)
+		defer SetStaging(false)
+
+		if _, err := Issue("example.com", "admin@example.com", "/var/www/html"); err != nil {
+			t.Fatalf("Issue failed: %v", err)
+		}
+		args := mock.Calls[0].Args
+		if args[len(args)-1] != "--staging" {
+			t.Errorf("expected --staging in certbot args, got %v", args)
+		}
+	})
+
 	t.Run("certbot not installed", func(t *testing.T) {
 		mock := &executor.MockExecutor{
 			LookPathFunc: func(file string) (string, error) {
@@ -54,6 +78,84 @@ func TestGetCertPaths(t *testing.T) {
 	}
 }
 
+func TestCopyCert(t *testing.T) {
+	// certbot's live files are symlinks into archive/
+	tempDir := t.TempDir()
+	archive := filepath.Join(tempDir, "archive", "example.com")
+	live := filepath.Join(tempDir, "live", "example.com")
+	for _, dir := range []string{archive, live} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]os.FileMode{"fullchain1.pem": 0644, "privkey1.pem": 0600}
+	for name, perm := range files {
+		if err := os.WriteFile(filepath.Join(archive, name), []byte(name), perm); err != nil {
+			t.Fatal(err)
+		}
+		link := strings.Replace(name, "1", "", 1)
+		if err := os.Symlink(filepath.Join(archive, name), filepath.Join(live, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cert := &Cert{Domain: "example.com", CertPath: filepath.Join(live, "fullchain.pem"), KeyPath: filepath.Join(live, "privkey.pem")}
+
+	dest := filepath.Join(tempDir, "certs")
+
+	// A key left world-readable by an earlier copy is tightened
+	if err := os.MkdirAll(filepath.Join(dest, "example.com"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "example.com", "privkey.pem"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := CopyCert(cert, dest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]struct {
+		path    string
+		content string
+		perm    os.FileMode
+	}{
+		"cert": {filepath.Join(dest, "example.com", "fullchain.pem"), "fullchain1.pem", 0644},
+		"key":  {filepath.Join(dest, "example.com", "privkey.pem"), "privkey1.pem", 0600},
+	}
+	if copied.CertPath != want["cert"].path || copied.KeyPath != want["key"].path {
+		t.Fatalf("unexpected paths %s, %s", copied.CertPath, copied.KeyPath)
+	}
+	for name, w := range want {
+		info, err := os.Lstat(w.path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !info.Mode().IsRegular() {
+			t.Errorf("%s: expected a regular file, got %v", name, info.Mode())
+		}
+		if info.Mode().Perm() != w.perm {
+			t.Errorf("%s: expected mode %o, got %o", name, w.perm, info.Mode().Perm())
+		}
+		if content, _ := os.ReadFile(w.path); string(content) != w.content {
+			t.Errorf("%s: expected content %q, got %q", name, w.content, content)
+		}
+	}
+
+	t.Run("relative dir", func(t *testing.T) {
+		if _, err := CopyCert(cert, "certs"); err == nil || !strings.Contains(err.Error(), "absolute path") {
+			t.Errorf("expected absolute path error, got %v", err)
+		}
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		missing := &Cert{Domain: "missing.com", CertPath: filepath.Join(tempDir, "nope.pem"), KeyPath: filepath.Join(tempDir, "nope.key")}
+		if _, err := CopyCert(missing, dest); err == nil || !strings.Contains(err.Error(), "failed to read") {
+			t.Errorf("expected read error, got %v", err)
+		}
+	})
+}
+
 func TestIssue(t *testing.T) {
 	t.Run("successful issue", func(t *testing.T) {
 		mock := &executor.MockExecutor{
@@ -166,15 +268,14 @@ func TestIssueNginx(t *testing.T) {
 			},
 			ExecuteFunc: func(name string, args ...string) ([]byte, error) {
 				if name == "certbot" {
-					hasNginx := false
+					if len(args) < 2 || args[0] != "certonly" || args[1] != "--nginx" {
+						return nil, errors.New("expected certonly --nginx")
+					}
 					for _, arg := range args {
-						if arg == "--nginx" {
-							hasNginx = true
+						if arg == "--redirect" {
+							return nil, errors.New("certonly must not edit the config")
 						}
 					}
-					if !hasNginx {
-						return nil, errors.New("expected --nginx flag")
-					}
 					return []byte("Success"), nil
 				}
 				return nil, errors.New("unexpected command")
@@ -193,6 +294,187 @@ func TestIssueNginx(t *testing.T) {
 	})
 }
 
+func TestIssueApache(t *testing.T) {
+	var gotArgs []string
+	mock := &executor.MockExecutor{
+		LookPathFunc: func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		},
+		ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+			gotArgs = args
+			return []byte("Success"), nil
+		},
+	}
+	SetExecutor(mock)
+	defer ResetExecutor()
+
+	cert, err := IssueApache("example.com", "admin@example.com")
+	if err != nil {
+		t.Fatalf("IssueApache failed: %v", err)
+	}
+	if cert.Domain != "example.com" {
+		t.Errorf("expected domain example.com, got %s", cert.Domain)
+	}
+	if len(gotArgs) < 2 || gotArgs[0] != "certonly" || gotArgs[1] != "--apache" {
+		t.Errorf("expected certonly --apache, got %v", gotArgs)
+	}
+	for _, arg := range gotArgs {
+		if arg == "--redirect" {
+			t.Errorf("certonly must not pass --redirect, got %v", gotArgs)
+		}
+	}
+	if cert.CertPath != "/etc/letsencrypt/live/example.com/fullchain.pem" {
+		t.Errorf("unexpected cert path: %s", cert.CertPath)
+	}
+	if cert.KeyPath != "/etc/letsencrypt/live/example.com/privkey.pem" {
+		t.Errorf("unexpected key path: %s", cert.KeyPath)
+	}
+}
+
+func TestIssueSANs(t *testing.T) {
+	sans := []string{"www.example.com", "api.example.com"}
+	names := "-d example.com -d www.example.com -d api.example.com --cert-name example.com"
+
+	tests := []struct {
+		name  string
+		issue func() (*Cert, error)
+		want  string
+	}{
+		{"webroot", func() (*Cert, error) { return Issue("example.com", "admin@example.com", "/var/www/html", sans...) }, "certonly --webroot -w /var/www/html " + names},
+		{"standalone", func() (*Cert, error) { return IssueStandalone("example.com", "admin@example.com", sans...) }, "certonly --standalone " + names},
+		{"nginx", func() (*Cert, error) { return IssueNginx("example.com", "admin@example.com", sans...) }, "certonly --nginx " + names},
+		{"apache", func() (*Cert, error) { return IssueApache("example.com", "admin@example.com", sans...) }, "certonly --apache " + names},
+		{"dns", func() (*Cert, error) { return IssueDNS("example.com", "admin@example.com", "cloudflare", "", sans...) }, "certonly --dns-cloudflare " + names},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &executor.MockExecutor{}
+			SetExecutor(mock)
+			defer ResetExecutor()
+
+			cert, err := tt.issue()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(mock.Calls) != 1 || !strings.HasPrefix(strings.Join(mock.Calls[0].Args, " "), tt.want+" --email") {
+				t.Errorf("expected certbot %q..., got %v", tt.want, mock.Calls)
+			}
+			// The certificate stays filed under the primary domain
+			if cert.CertPath != GetCertPaths("example.com").CertPath {
+				t.Errorf("unexpected cert path %s", cert.CertPath)
+			}
+		})
+	}
+}
+
+func TestIssueRejectsUnsafeArgs(t *testing.T) {
+	ran := false
+	mock := &executor.MockExecutor{
+		LookPathFunc: func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		},
+		ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+			ran = true
+			return nil, nil
+		},
+	}
+	SetExecutor(mock)
+	defer ResetExecutor()
+
+	tests := []struct {
+		name   string
+		domain string
+		email  string
+	}{
+		{"option as domain", "--dry-run", "admin@example.com"},
+		{"domain with space", "example.com --staging", "admin@example.com"},
+		{"empty domain", "", "admin@example.com"},
+		{"option as email", "example.com", "-m@example.com"},
+		{"email with space", "example.com", "admin@example.com --force"},
+		{"email without host", "example.com", "admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := IssueApache(tt.domain, tt.email); err == nil {
+				t.Error("expected IssueApache to reject the input")
+			}
+			if _, err := Issue(tt.domain, tt.email, "/var/www/html"); err == nil {
+				t.Error("expected Issue to reject the input")
+			}
+			if ran {
+				t.Error("certbot should not run for rejected input")
+			}
+		})
+	}
+
+	t.Run("option as extra name", func(t *testing.T) {
+		if _, err := IssueNginx("example.com", "admin@example.com", "www.example.com", "--staging"); err == nil {
+			t.Error("expected IssueNginx to reject the extra name")
+		}
+		if ran {
+			t.Error("certbot should not run for rejected input")
+		}
+	})
+
+	t.Run("relative webroot", func(t *testing.T) {
+		if _, err := Issue("example.com", "admin@example.com", "var/www"); err == nil {
+			t.Error("expected Issue to reject a relative webroot")
+		}
+	})
+
+	t.Run("wildcard domain", func(t *testing.T) {
+		if _, err := IssueDNS("*.example.com", "admin@example.com", "cloudflare", ""); err != nil {
+			t.Errorf("expected wildcard domain to be accepted, got %v", err)
+		}
+	})
+}
+
+func TestIssueDNS(t *testing.T) {
+	var gotArgs []string
+	mock := &executor.MockExecutor{
+		LookPathFunc: func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		},
+		ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+			gotArgs = args
+			return []byte("Success"), nil
+		},
+	}
+	SetExecutor(mock)
+	defer ResetExecutor()
+
+	t.Run("with credentials", func(t *testing.T) {
+		if _, err := IssueDNS("example.com", "admin@example.com", "cloudflare", "/root/.secrets/cloudflare.ini"); err != nil {
+			t.Fatalf("IssueDNS failed: %v", err)
+		}
+		got := strings.Join(gotArgs, " ")
+		if !strings.HasPrefix(got, "certonly --dns-cloudflare --dns-cloudflare-credentials /root/.secrets/cloudflare.ini -d example.com") {
+			t.Errorf("unexpected certbot args: %s", got)
+		}
+	})
+
+	t.Run("without credentials", func(t *testing.T) {
+		if _, err := IssueDNS("example.com", "admin@example.com", "route53", ""); err != nil {
+			t.Fatalf("IssueDNS failed: %v", err)
+		}
+		if got := strings.Join(gotArgs, " "); strings.Contains(got, "-credentials") {
+			t.Errorf("unexpected credentials flag: %s", got)
+		}
+	})
+
+	t.Run("invalid plugin", func(t *testing.T) {
+		gotArgs = nil
+		if _, err := IssueDNS("example.com", "admin@example.com", "cloudflare --dry-run", ""); err == nil {
+			t.Error("expected error for invalid plugin name")
+		}
+		if gotArgs != nil {
+			t.Errorf("certbot should not run, got %v", gotArgs)
+		}
+	})
+}
+
 func TestRenew(t *testing.T) {
 	t.Run("successful renew", func(t *testing.T) {
 		mock := &executor.MockExecutor{
@@ -234,6 +516,30 @@ func TestRenew(t *testing.T) {
 	})
 }
 
+func TestRenewForce(t *testing.T) {
+	tests := []struct {
+		name  string
+		renew func(string) error
+		want  []string
+	}{
+		{"forced", RenewForce, []string{"renew", "--cert-name", "example.com", "--non-interactive", "--force-renewal"}},
+		{"not forced", Renew, []string{"renew", "--cert-name", "example.com", "--non-interactive"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &executor.MockExecutor{}
+			SetExecutor(mock)
+			defer ResetExecutor()
+
+			if err := tt.renew("example.com"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			mock.ExpectSequence(t, [][]string{append([]string{"certbot"}, tt.want...)})
+		})
+	}
+}
+
 func TestRenewAll(t *testing.T) {
 	t.Run("successful renew all", func(t *testing.T) {
 		mock := &executor.MockExecutor{
@@ -390,3 +696,78 @@ func TestList(t *testing.T) {
 		}
 	})
 }
+
+func TestParseCertificates(t *testing.T) {
+	output := `Saving debug log to /var/log/letsencrypt/letsencrypt.log
+
+Found the following certificates:
+  Certificate Name: example.com
+    Serial Number: 3a1b2c
+    Key Type: ECDSA
+    Domains: example.com www.example.com
+    Expiry Date: 2024-05-15 (VALID: 89 days)
+    Certificate Path: /etc/letsencrypt/live/example.com/fullchain.pem
+    Private Key Path: /etc/letsencrypt/live/example.com/privkey.pem
+  Certificate Name: test.com
+    Domains: test.com
+    Expiry Date: 2024-04-20 08:30:00+00:00 (VALID: 64 days)
+  Certificate Name: old.com
+    Domains: old.com
+    Expiry Date: 2023-01-01 00:00:00+00:00 (INVALID: EXPIRED)`
+
+	certs, err := parseCertificates(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []CertInfo{
+		{Name: "example.com", Domains: []string{"example.com", "www.example.com"}, ExpiryDate: time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC), ValidDays: 89},
+		{Name: "test.com", Domains: []string{"test.com"}, ExpiryDate: time.Date(2024, 4, 20, 8, 30, 0, 0, time.UTC), ValidDays: 64},
+		{Name: "old.com", Domains: []string{"old.com"}, ExpiryDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), ValidDays: 0},
+	}
+	if len(certs) != len(want) {
+		t.Fatalf("expected %d certificates, got %+v", len(want), certs)
+	}
+	for i, w := range want {
+		got := certs[i]
+		if got.Name != w.Name || strings.Join(got.Domains, " ") != strings.Join(w.Domains, " ") || !got.ExpiryDate.Equal(w.ExpiryDate) || got.ValidDays != w.ValidDays {
+			t.Errorf("certificate %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+
+	t.Run("no certificates", func(t *testing.T) {
+		certs, err := parseCertificates("No certificates found.")
+		if err != nil || len(certs) != 0 {
+			t.Errorf("expected no certificates, got %+v (%v)", certs, err)
+		}
+	})
+
+	t.Run("bad expiry date", func(t *testing.T) {
+		_, err := parseCertificates("  Certificate Name: bad.com\n    Expiry Date: soon (VALID: 1 days)")
+		if err == nil || !strings.Contains(err.Error(), "certificate bad.com") {
+			t.Errorf("expected expiry error naming the certificate, got %v", err)
+		}
+	})
+}
+
+func TestListDetailed(t *testing.T) {
+	mock := &executor.MockExecutor{
+		LookPathFunc: func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		},
+		ExecuteFunc: func(name string, args ...string) ([]byte, error) {
+			return []byte("  Certificate Name: example.com\n    Domains: example.com\n    Expiry Date: 2024-05-15 (VALID: 89 days)"), nil
+		},
+	}
+	SetExecutor(mock)
+	defer ResetExecutor()
+
+	certs, err := ListDetailed()
+	if err != nil {
+		t.Fatalf("ListDetailed failed: %v", err)
+	}
+	if len(certs) != 1 || certs[0].Name != "example.com" || certs[0].ValidDays != 89 {
+		t.Errorf("unexpected certificates %+v", certs)
+	}
+	mock.ExpectSequence(t, [][]string{{"certbot", "certificates"}})
+}
@@ -10,7 +10,7 @@
 // Certbot must be installed on the system:
 //
 //	# Ubuntu/Debian
-//	sudo apt install certbot python3-certbot-nginx
+//	sudo apt install certbot python3-certbot-nginx   # or python3-certbot-apache
 //
 //	# CentOS/RHEL
 //	sudo dnf install certbot python3-certbot-nginx
@@ -38,12 +38,32 @@
 //
 //	err := ssl.Issue("example.com", "admin@example.com", "/var/www/html")
 //
+// Extra names for the same certificate follow the other arguments:
+//
+//	err := ssl.Issue("example.com", "admin@example.com", "/var/www/html", "www.example.com")
+//
+// IssueStandalone runs certbot's own HTTP server, IssueNginx and IssueApache
+// use the web server plugins, and IssueDNS validates with a DNS plugin:
+//
+//	err := ssl.IssueDNS("example.com", "admin@example.com", "cloudflare", "/root/.secrets/cloudflare.ini")
+//
+// CopyCert copies an issued certificate to a fixed location, for servers
+// that cannot follow the live directory's symlinks:
+//
+//	copied, err := ssl.CopyCert(cert, "/etc/vhost/certs")
+//	fmt.Println(copied.KeyPath) // /etc/vhost/certs/example.com/privkey.pem
+//
 // # Certificate Renewal
 //
 // Renew a specific certificate:
 //
 //	err := ssl.Renew("example.com")
 //
+// RenewForce renews even when the certificate is not near expiry, e.g.
+// after its names changed:
+//
+//	err := ssl.RenewForce("example.com")
+//
 // Renew all managed certificates:
 //
 //	err := ssl.RenewAll()
@@ -70,6 +90,8 @@
 // All functions return descriptive errors that include Certbot's output
 // when commands fail. Common error scenarios:
 //   - Certbot not installed: check with IsInstalled() first
+//   - Invalid domain or email: rejected before certbot runs, so a value
+//     starting with '-' is never read as an option
 //   - Port 80 in use: stop web server or use webroot method
 //   - Rate limiting: Let's Encrypt has strict limits
 //   - DNS not configured: ensure domain points to server
@@ -14,9 +14,19 @@
 //	nginx/proxy.tmpl
 //	nginx/laravel.tmpl
 //	nginx/wordpress.tmpl
+//	nginx/maintenance.tmpl
+//	nginx/default.tmpl
 //	apache/ (same structure)
 //	caddy/ (same structure)
 //
+// maintenance.tmpl answers every request with a 503 page. It is rendered
+// instead of the type template while VHost.Maintenance is set. default.tmpl
+// is the catch-all for unknown hostnames, used by vhosts of config.TypeDefault.
+//
+// A variant such as static.spa.tmpl is rendered instead of static.tmpl when
+// VHost.Template is "spa". Drivers without the variant use the base template;
+// HasVariant reports which is the case.
+//
 // # Rendering Templates
 //
 // To render a configuration file:
@@ -43,15 +53,45 @@
 //   - Domain: The domain name
 //   - Root: Document root path
 //   - ProxyPass: Proxy backend URL
+//   - RedirectURL: redirect target without a trailing slash (redirect)
+//   - RedirectCode: redirect status, 301 (default) or 302 (redirect)
 //   - PHPVersion: PHP-FPM version
+//   - SPA: static sites fall back to /index.html for unknown paths
+//   - WPMultisite: WordPress multisite mode ("subdir", "subdomain" or empty)
+//   - FastCGIReadTimeout: FastCGI read timeout in seconds, 0 when unset
+//   - FastCGIBuffers, FastCGIBufferSize: nginx FastCGI buffer specs
 //   - SSL: Whether HTTPS is enabled
 //   - SSLCert: Path to certificate
 //   - SSLKey: Path to private key
+//   - HTTP2, HTTP3: protocols negotiated on the SSL listener
+//   - Locations: extra path prefixes served from other directories
+//   - DeniedPaths: URL prefixes refused with 403 (/.env, /storage/framework, /storage/logs, /.git for laravel)
+//   - BlockedUserAgents: escaped regexp alternation of User-Agent substrings refused with 403, empty when none
+//   - StaticCache, StaticCacheMaxAge, StaticCacheSeconds: asset cache lifetime (static and wordpress)
+//   - StaticCacheExtensions: file extensions cached as static assets
 //
 // # Custom Functions
 //
 // Templates have access to these functions:
-//   - replace: strings.ReplaceAll for string manipulation
+//   - replace: replace all occurrences, pipeline-friendly ({{ .Domain | replace "." "_" }})
+//   - lower, upper: change case ({{ lower .Domain }})
+//   - join: join a list with a separator ({{ join .Aliases " " }})
+//   - default: fall back when a value is empty ({{ .PHPVersion | default "8.2" }})
+//   - hasPrefix: test a prefix ({{ if hasPrefix .ProxyPass "https://" }})
+//
+// # Custom Templates
+//
+// SetTemplateDir points the package at a directory of override templates
+// laid out as <dir>/<driver>/<type>.tmpl. Render prefers an override when one
+// exists and falls back to the embedded template otherwise.
+//
+// Validate parses every embedded and override template and renders each
+// against a sample vhost, returning one error per broken file:
+//
+//	template.SetTemplateDir("/etc/vhost/templates")
+//	for _, err := range template.Validate() {
+//	    fmt.Println(err)
+//	}
 //
 // # Adding New Templates
 //
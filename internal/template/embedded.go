@@ -14,6 +14,12 @@ var apacheTemplates embed.FS
 //go:embed caddy/*.tmpl
 var caddyTemplates embed.FS
 
+//go:embed traefik/*.tmpl
+var traefikTemplates embed.FS
+
+// templateDrivers lists the drivers that have embedded templates
+var templateDrivers = []string{"nginx", "apache", "caddy", "traefik"}
+
 // getTemplateFS returns the embed.FS for the given driver
 func getTemplateFS(driverName string) (embed.FS, error) {
 	switch driverName {
@@ -23,6 +29,8 @@ func getTemplateFS(driverName string) (embed.FS, error) {
 		return apacheTemplates, nil
 	case "caddy":
 		return caddyTemplates, nil
+	case "traefik":
+		return traefikTemplates, nil
 	default:
 		return embed.FS{}, fmt.Errorf("unknown driver: %s", driverName)
 	}
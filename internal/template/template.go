@@ -2,9 +2,17 @@ package template
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
+	"unicode"
 
 	"github.com/ksyq12/vhost/internal/config"
 )
@@ -16,62 +24,534 @@ type TemplateData struct {
 	Root       string
 	ProxyPass  string
 	PHPVersion string
+	SPA        bool
 	SSL        bool
 	SSLCert    string
 	SSLKey     string
+	HTTP2      bool
+	HTTP3      bool
+	EnvVars    []EnvVar
+	Imports    []string
+	Locations  []config.LocationBlock
+
+	RedirectURL        string   // redirect target without a trailing slash
+	RedirectCode       int      // redirect status: 301 or 302
+	DeniedPaths        []string // URL prefixes answered with 403 (laravel)
+	WPMultisite        string   // "", "subdir" or "subdomain"
+	FastCGIReadTimeout int      // seconds; 0 keeps the server default
+	FastCGIBuffers     string   // nginx fastcgi_buffers, e.g. "16 16k"
+	FastCGIBufferSize  string   // nginx fastcgi_buffer_size, e.g. "32k"
+	BlockedUserAgents  string   // regexp alternation answered with 403, e.g. "(curl|wget)"
+
+	StaticCache           bool     // send long cache lifetimes for static assets
+	StaticCacheMaxAge     string   // asset lifetime as written, e.g. "30d" (nginx expires)
+	StaticCacheSeconds    int      // the same lifetime in seconds
+	StaticCacheExtensions []string // file extensions treated as static assets
+}
+
+// DefaultRedirectCode is the redirect status when a vhost sets none
+const DefaultRedirectCode = 301
+
+// DefaultStaticCacheMaxAge is the asset cache lifetime when a vhost sets none
+const DefaultStaticCacheMaxAge = "30d"
+
+// DefaultPHPVersion is the PHP-FPM version a PHP vhost is rendered with when
+// it sets none
+const DefaultPHPVersion = "8.2"
+
+// staticCacheExtensions are the file extensions served with long cache
+// lifetimes on static and wordpress vhosts
+var staticCacheExtensions = []string{"css", "js", "png", "jpg", "jpeg", "gif", "ico", "svg", "webp", "woff", "woff2", "ttf", "eot"}
+
+// cacheMaxAgePattern matches a cache lifetime: a count and a unit that
+// nginx's expires directive also understands
+var cacheMaxAgePattern = regexp.MustCompile(`^([1-9][0-9]*)([smhdwy])$`)
+
+// cacheUnitSeconds maps each cacheMaxAgePattern unit to its length in seconds
+var cacheUnitSeconds = map[string]int{"s": 1, "m": 60, "h": 3600, "d": 86400, "w": 7 * 86400, "y": 365 * 86400}
+
+// laravelDeniedPaths are the URL prefixes a laravel vhost never serves, so a
+// misplaced .env, framework cache, log or repository can't leak. The rest of
+// /storage stays reachable for the public disk linked by storage:link.
+var laravelDeniedPaths = []string{"/.env", "/storage/framework", "/storage/logs", "/.git"}
+
+// EnvVar is a single environment variable, rendered as fastcgi_param or
+// SetEnv for PHP vhosts and as a request header for proxy vhosts
+type EnvVar struct {
+	Key   string
+	Value string
+}
+
+// templateDir is an optional directory of user templates that override the
+// embedded ones, laid out as <dir>/<driver>/<type>.tmpl
+var templateDir string
+
+// SetTemplateDir sets the directory searched for override templates.
+// An empty string disables overrides.
+func SetTemplateDir(dir string) {
+	templateDir = dir
+}
+
+// funcMap returns the custom functions available to templates
+func funcMap() template.FuncMap {
+	return template.FuncMap{
+		"replace":   replace,
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"join":      strings.Join,
+		"default":   defaultValue,
+		"hasPrefix": strings.HasPrefix,
+	}
+}
+
+// replace replaces all occurrences of old with new in s.
+// The subject comes last so it works in pipelines: {{ .Domain | replace "." "_" }}
+func replace(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+// defaultValue returns value, or def when value is empty.
+// The fallback comes first so it works in pipelines: {{ .PHPVersion | default "8.2" }}
+func defaultValue(def, value string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// MaintenanceTemplate is rendered instead of the type template while a
+// vhost is in maintenance mode
+const MaintenanceTemplate = "maintenance"
+
+// variantPattern matches template variant names, which become part of a
+// file name
+var variantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidateVariant checks a template variant name; empty selects the base
+// template
+func ValidateVariant(variant string) error {
+	if variant != "" && !variantPattern.MatchString(variant) {
+		return fmt.Errorf("invalid template variant %q: use lowercase letters, digits, '-' and '_'", variant)
+	}
+	return nil
+}
+
+// HasVariant reports whether driverName has a template for the variant of
+// vhostType, either embedded or in the override directory
+func HasVariant(driverName, vhostType, variant string) bool {
+	_, err := loadTemplate(driverName, vhostType+"."+variant)
+	return err == nil
 }
 
 // Render renders a template for the given vhost and driver
 func Render(driverName string, vhost *config.VHost) (string, error) {
-	tmplPath := fmt.Sprintf("%s/%s.tmpl", driverName, vhost.Type)
+	if err := checkProtocols(vhost); err != nil {
+		return "", err
+	}
+	switch vhost.WPMultisite {
+	case "", config.WPMultisiteSubdir, config.WPMultisiteSubdomain:
+	default:
+		return "", fmt.Errorf("invalid wordpress multisite mode %q (use %s or %s)", vhost.WPMultisite, config.WPMultisiteSubdir, config.WPMultisiteSubdomain)
+	}
+	timeout, err := FastCGITimeoutSeconds(vhost.FastCGIReadTimeout)
+	if err != nil {
+		return "", err
+	}
+	blockedUAs, err := UserAgentPattern(vhost.BlockUserAgents)
+	if err != nil {
+		return "", err
+	}
+	cacheSeconds, err := StaticCacheSeconds(vhost.StaticCacheMaxAge)
+	if err != nil {
+		return "", err
+	}
 
-	// Get template filesystem for the driver
-	fs, err := getTemplateFS(driverName)
+	if err := ValidateVariant(vhost.Template); err != nil {
+		return "", err
+	}
+	if vhost.Type == config.TypeRedirect && !vhost.Maintenance && vhost.RedirectURL == "" {
+		return "", fmt.Errorf("redirect vhost %s has no redirect URL", vhost.Domain)
+	}
+	if err := ValidateRedirectCode(vhost.RedirectCode); err != nil {
+		return "", err
+	}
+
+	name := vhost.Type
+	if vhost.Maintenance {
+		name = MaintenanceTemplate
+	}
+
+	// A variant falls back to the base template when a driver lacks it
+	var content []byte
+	if vhost.Template != "" && !vhost.Maintenance {
+		variant, err := loadTemplate(driverName, name+"."+vhost.Template)
+		switch {
+		case err == nil:
+			name += "." + vhost.Template
+			content = variant
+		case !errors.Is(err, errTemplateNotFound):
+			return "", err
+		}
+	}
+	if content == nil {
+		content, err = loadTemplate(driverName, name)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	tmpl, err := parseTemplate(name, content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	data := newTemplateData(vhost)
+	data.FastCGIReadTimeout = timeout
+	data.BlockedUserAgents = blockedUAs
+	data.StaticCacheSeconds = cacheSeconds
+
+	result, err := execute(tmpl, data)
 	if err != nil {
 		return "", err
 	}
 
-	// Read template content
-	content, err := fs.ReadFile(tmplPath)
+	if err := checkRendered(driverName, vhost.Domain, result); err != nil {
+		return "", fmt.Errorf("template %s/%s: %w", driverName, name, err)
+	}
+
+	return result, nil
+}
+
+// checkProtocols rejects HTTP/2 and HTTP/3 on a vhost without SSL; both are
+// negotiated over TLS only
+func checkProtocols(vhost *config.VHost) error {
+	if vhost.SSL {
+		return nil
+	}
+	if vhost.HTTP2 {
+		return fmt.Errorf("http2 requires SSL")
+	}
+	if vhost.HTTP3 {
+		return fmt.Errorf("http3 requires SSL")
+	}
+	return nil
+}
+
+// ValidateUserAgent checks a blocked user agent entry. Entries are rendered
+// inside quoted strings in every driver's config, so quotes, backslashes and
+// control characters are rejected.
+func ValidateUserAgent(agent string) error {
+	if strings.TrimSpace(agent) == "" {
+		return fmt.Errorf("blocked user agent must not be empty")
+	}
+	for _, r := range agent {
+		if r == '"' || r == '\\' || unicode.IsControl(r) {
+			return fmt.Errorf("invalid blocked user agent %q: quotes, backslashes and control characters are not allowed", agent)
+		}
+	}
+	return nil
+}
+
+// UserAgentPattern combines blocked user agents into one regexp alternation
+// such as "(curl|python-requests)". Each entry matches as a literal
+// substring: regexp metacharacters are escaped. No entries returns "".
+func UserAgentPattern(agents []string) (string, error) {
+	if len(agents) == 0 {
+		return "", nil
+	}
+	quoted := make([]string, len(agents))
+	for i, agent := range agents {
+		if err := ValidateUserAgent(agent); err != nil {
+			return "", err
+		}
+		quoted[i] = regexp.QuoteMeta(agent)
+	}
+	return "(" + strings.Join(quoted, "|") + ")", nil
+}
+
+// ValidateRedirectCode checks a redirect status: 301 (permanent) or 302
+// (temporary). 0 selects DefaultRedirectCode.
+func ValidateRedirectCode(code int) error {
+	switch code {
+	case 0, 301, 302:
+		return nil
+	}
+	return fmt.Errorf("invalid redirect code %d (use 301 or 302)", code)
+}
+
+// FastCGITimeoutSeconds parses a FastCGI read timeout such as "300s" or
+// "5m" into whole seconds. An empty value returns 0.
+func FastCGITimeoutSeconds(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
 	if err != nil {
-		return "", fmt.Errorf("template not found: %s/%s", driverName, vhost.Type)
+		return 0, fmt.Errorf("invalid fastcgi read timeout %q: %w", value, err)
+	}
+	if d < time.Second || d%time.Second != 0 {
+		return 0, fmt.Errorf("invalid fastcgi read timeout %q: must be a whole number of seconds", value)
 	}
+	return int(d / time.Second), nil
+}
 
-	// Create template with custom functions
-	funcMap := template.FuncMap{
-		"replace": strings.ReplaceAll,
+// StaticCacheSeconds parses a static asset cache lifetime such as "30d",
+// "12h" or "1y" into seconds. An empty value is DefaultStaticCacheMaxAge.
+func StaticCacheSeconds(value string) (int, error) {
+	if value == "" {
+		value = DefaultStaticCacheMaxAge
 	}
+	m := cacheMaxAgePattern.FindStringSubmatch(value)
+	if m == nil {
+		return 0, fmt.Errorf("invalid static cache max-age %q: use a count and unit such as 30d, 12h or 1y", value)
+	}
+	count, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid static cache max-age %q: %w", value, err)
+	}
+	return count * cacheUnitSeconds[m[2]], nil
+}
 
-	tmpl, err := template.New(vhost.Type).Funcs(funcMap).Parse(string(content))
+// domainAnchor in ExpectedTokens stands for the vhost's own domain
+const domainAnchor = "{{domain}}"
+
+// ExpectedTokens maps each driver to a token every rendered config for it
+// must contain. Output without it is a template logic bug, even when the
+// server's config test would accept it.
+var ExpectedTokens = map[string]string{
+	"nginx":   "server",
+	"apache":  "<VirtualHost",
+	"caddy":   domainAnchor,
+	"traefik": "http:",
+}
+
+// checkRendered rejects empty output and output missing the driver's
+// expected token
+func checkRendered(driverName, domain, result string) error {
+	if strings.TrimSpace(result) == "" {
+		return fmt.Errorf("rendered empty output")
+	}
+
+	token, ok := ExpectedTokens[driverName]
+	if !ok {
+		return nil
+	}
+	if token == domainAnchor {
+		token = domain
+	}
+	if !strings.Contains(result, token) {
+		return fmt.Errorf("rendered output is missing %q", token)
+	}
+	return nil
+}
+
+// errTemplateNotFound is returned by loadTemplate when neither an override
+// nor an embedded template exists
+var errTemplateNotFound = errors.New("template not found")
+
+// loadTemplate returns the override template if one exists, otherwise the
+// embedded one. An override that exists but can't be read is an error rather
+// than a silent fallback.
+func loadTemplate(driverName, vhostType string) ([]byte, error) {
+	if templateDir != "" {
+		overridePath := filepath.Join(templateDir, driverName, vhostType+".tmpl")
+		content, err := os.ReadFile(overridePath)
+		if err == nil {
+			return content, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read override template: %w", err)
+		}
+	}
+
+	// Get template filesystem for the driver
+	fs, err := getTemplateFS(driverName)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
+		return nil, err
+	}
+
+	// Read template content
+	content, err := fs.ReadFile(fmt.Sprintf("%s/%s.tmpl", driverName, vhostType))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s/%s", errTemplateNotFound, driverName, vhostType)
 	}
 
-	// Prepare template data
+	return content, nil
+}
+
+// parseTemplate parses template content with the custom function set
+func parseTemplate(name string, content []byte) (*template.Template, error) {
+	return template.New(name).Funcs(funcMap()).Parse(string(content))
+}
+
+// newTemplateData prepares template data from a vhost
+func newTemplateData(vhost *config.VHost) TemplateData {
 	data := TemplateData{
 		Domain:     vhost.Domain,
+		Aliases:    vhost.Aliases,
 		Root:       vhost.Root,
 		ProxyPass:  vhost.ProxyPass,
 		PHPVersion: vhost.PHPVersion,
+		SPA:        vhost.SPA,
 		SSL:        vhost.SSL,
 		SSLCert:    vhost.SSLCert,
 		SSLKey:     vhost.SSLKey,
+		HTTP2:      vhost.HTTP2,
+		HTTP3:      vhost.HTTP3,
+		Imports:    vhost.CaddyImports,
+		Locations:  vhost.Locations,
+
+		RedirectURL:       strings.TrimRight(vhost.RedirectURL, "/"),
+		RedirectCode:      vhost.RedirectCode,
+		WPMultisite:       vhost.WPMultisite,
+		FastCGIBuffers:    vhost.FastCGIBuffers,
+		FastCGIBufferSize: vhost.FastCGIBufferSize,
+	}
+
+	// Sort env vars so rendered output is stable
+	for key, value := range vhost.EnvVars {
+		data.EnvVars = append(data.EnvVars, EnvVar{Key: key, Value: value})
+	}
+	sort.Slice(data.EnvVars, func(i, j int) bool {
+		return data.EnvVars[i].Key < data.EnvVars[j].Key
+	})
+
+	if vhost.Type == config.TypeLaravel {
+		data.DeniedPaths = laravelDeniedPaths
+	}
+
+	// Asset caching is on by default for the types that serve files directly
+	if (vhost.Type == config.TypeStatic || vhost.Type == config.TypeWordPress) && !vhost.NoStaticCache {
+		data.StaticCache = true
+		data.StaticCacheMaxAge = defaultValue(DefaultStaticCacheMaxAge, vhost.StaticCacheMaxAge)
+		data.StaticCacheExtensions = staticCacheExtensions
 	}
 
 	// Set default PHP version if not specified
 	if data.PHPVersion == "" {
-		data.PHPVersion = "8.2"
+		data.PHPVersion = DefaultPHPVersion
+	}
+	if data.RedirectCode == 0 {
+		data.RedirectCode = DefaultRedirectCode
 	}
 
-	// Render template
+	return data
+}
+
+// execute renders a parsed template with the given data
+func execute(tmpl *template.Template, data TemplateData) (string, error) {
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("failed to render template: %w", err)
 	}
-
 	return buf.String(), nil
 }
 
+// Validate parses every embedded and override template and renders each
+// against a sample vhost with and without SSL. It returns one error per
+// broken template, naming the offending file.
+func Validate() []error {
+	var errs []error
+
+	for _, driverName := range templateDrivers {
+		fs, err := getTemplateFS(driverName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		entries, err := fs.ReadDir(driverName)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", driverName, err))
+			continue
+		}
+		for _, entry := range entries {
+			name := driverName + "/" + entry.Name()
+			content, err := fs.ReadFile(name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			if err := validateTemplate(driverName, name, content); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if templateDir == "" {
+			continue
+		}
+
+		overrideDir := filepath.Join(templateDir, driverName)
+		overrides, err := os.ReadDir(overrideDir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("%s: %w", overrideDir, err))
+			}
+			continue
+		}
+		for _, entry := range overrides {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmpl") {
+				continue
+			}
+			path := filepath.Join(overrideDir, entry.Name())
+			content, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				continue
+			}
+			if err := validateTemplate(driverName, path, content); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateTemplate parses and renders a single template file and checks
+// the output the same way Render does
+func validateTemplate(driverName, name string, content []byte) error {
+	// Variants such as static.spa.tmpl render the base type's sample
+	vhostType, _, _ := strings.Cut(strings.TrimSuffix(filepath.Base(name), ".tmpl"), ".")
+
+	tmpl, err := parseTemplate(vhostType, content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	for _, ssl := range []bool{false, true} {
+		vhost := sampleVHost(vhostType, ssl)
+		result, err := execute(tmpl, newTemplateData(vhost))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := checkRendered(driverName, vhost.Domain, result); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// sampleVHost returns a fully populated vhost used for template validation
+func sampleVHost(vhostType string, ssl bool) *config.VHost {
+	return &config.VHost{
+		Domain:       "example.com",
+		Aliases:      []string{"www.example.com"},
+		Type:         vhostType,
+		Root:         "/var/www/example.com",
+		ProxyPass:    "http://127.0.0.1:3000",
+		RedirectURL:  "https://example.org",
+		PHPVersion:   "8.2",
+		SSL:          ssl,
+		SSLCert:      "/etc/letsencrypt/live/example.com/fullchain.pem",
+		SSLKey:       "/etc/letsencrypt/live/example.com/privkey.pem",
+		EnvVars:      map[string]string{"APP_ENV": "production"},
+		CaddyImports: []string{"logging"},
+		Locations:    []config.LocationBlock{{Path: "/assets", Root: "/srv/assets"}},
+	}
+}
+
 // Available returns all available template types for a driver
 func Available(driverName string) []string {
 	return config.ValidTypes()
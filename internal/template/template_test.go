@@ -1,10 +1,13 @@
 package template
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/ksyq12/vhost/internal/config"
+	"gopkg.in/yaml.v3"
 )
 
 func TestRender(t *testing.T) {
@@ -119,6 +122,132 @@ func TestRender(t *testing.T) {
 	}
 }
 
+func TestRenderTraefik(t *testing.T) {
+	t.Run("proxy", func(t *testing.T) {
+		vhost := &config.VHost{
+			Domain:    "api.example.com",
+			Type:      config.TypeProxy,
+			ProxyPass: "http://127.0.0.1:3000",
+		}
+
+		content, err := Render("traefik", vhost)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+			t.Fatalf("rendered config is not valid YAML: %v\n%s", err, content)
+		}
+
+		for _, expected := range []string{
+			"rule: \"Host(`api.example.com`)\"",
+			"service: api-example-com",
+			"url: \"http://127.0.0.1:3000\"",
+			"- web",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("expected %q in output:\n%s", expected, content)
+			}
+		}
+		if strings.Contains(content, "websecure") {
+			t.Error("non-SSL config should not use websecure entrypoint")
+		}
+	})
+
+	t.Run("proxy with SSL", func(t *testing.T) {
+		vhost := &config.VHost{
+			Domain:    "api.example.com",
+			Type:      config.TypeProxy,
+			ProxyPass: "http://127.0.0.1:3000",
+			SSL:       true,
+			SSLCert:   "/etc/letsencrypt/live/api.example.com/fullchain.pem",
+			SSLKey:    "/etc/letsencrypt/live/api.example.com/privkey.pem",
+		}
+
+		content, err := Render("traefik", vhost)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+			t.Fatalf("rendered config is not valid YAML: %v\n%s", err, content)
+		}
+
+		for _, expected := range []string{
+			"- websecure",
+			"redirectScheme:",
+			"certFile: /etc/letsencrypt/live/api.example.com/fullchain.pem",
+			"keyFile: /etc/letsencrypt/live/api.example.com/privkey.pem",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("expected %q in output:\n%s", expected, content)
+			}
+		}
+	})
+
+	t.Run("proxy scheme", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			proxyPass string
+			ssl       bool
+			want      string
+		}{
+			{"bare backend", "127.0.0.1:3000", false, `url: "http://127.0.0.1:3000"`},
+			{"bare backend with SSL", "127.0.0.1:3443", true, `url: "https://127.0.0.1:3443"`},
+			{"explicit scheme kept", "http://127.0.0.1:3000", true, `url: "http://127.0.0.1:3000"`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				vhost := &config.VHost{
+					Domain:    "api.example.com",
+					Type:      config.TypeProxy,
+					ProxyPass: tt.proxyPass,
+					SSL:       tt.ssl,
+					SSLCert:   "/etc/ssl/cert.pem",
+					SSLKey:    "/etc/ssl/key.pem",
+				}
+				content, err := Render("traefik", vhost)
+				if err != nil {
+					t.Fatalf("Render failed: %v", err)
+				}
+				if !strings.Contains(content, tt.want) {
+					t.Errorf("expected %q in output:\n%s", tt.want, content)
+				}
+			})
+		}
+	})
+
+	t.Run("static", func(t *testing.T) {
+		vhost := &config.VHost{
+			Domain: "static.example.com",
+			Type:   config.TypeStatic,
+			Root:   "/var/www/static",
+		}
+
+		content, err := Render("traefik", vhost)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+			t.Fatalf("rendered config is not valid YAML: %v\n%s", err, content)
+		}
+
+		for _, expected := range []string{
+			"fileserver:",
+			"root: \"/var/www/static\"",
+			"- static-example-com-static",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("expected %q in output:\n%s", expected, content)
+			}
+		}
+	})
+}
+
 func TestRenderInvalidType(t *testing.T) {
 	vhost := &config.VHost{
 		Domain: "invalid.example.com",
@@ -161,3 +290,1290 @@ func TestDefaultPHPVersion(t *testing.T) {
 		t.Error("expected default PHP version 8.2 in output")
 	}
 }
+
+func TestValidate(t *testing.T) {
+	t.Run("embedded templates are valid", func(t *testing.T) {
+		SetTemplateDir("")
+
+		if errs := Validate(); len(errs) != 0 {
+			t.Errorf("expected no errors, got %v", errs)
+		}
+	})
+
+	t.Run("broken override is reported", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "nginx"), 0755); err != nil {
+			t.Fatalf("failed to create override dir: %v", err)
+		}
+		brokenPath := filepath.Join(dir, "nginx", "static.tmpl")
+		if err := os.WriteFile(brokenPath, []byte("server { server_name {{ .Domain }"), 0644); err != nil {
+			t.Fatalf("failed to write template: %v", err)
+		}
+		validPath := filepath.Join(dir, "nginx", "proxy.tmpl")
+		if err := os.WriteFile(validPath, []byte("server { proxy_pass {{ .ProxyPass }}; }"), 0644); err != nil {
+			t.Fatalf("failed to write template: %v", err)
+		}
+
+		SetTemplateDir(dir)
+		defer SetTemplateDir("")
+
+		errs := Validate()
+		if len(errs) != 1 {
+			t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+		}
+		if !strings.Contains(errs[0].Error(), brokenPath) {
+			t.Errorf("error %q should name %s", errs[0], brokenPath)
+		}
+	})
+
+	t.Run("execution error is reported", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "caddy"), 0755); err != nil {
+			t.Fatalf("failed to create override dir: %v", err)
+		}
+		brokenPath := filepath.Join(dir, "caddy", "php.tmpl")
+		if err := os.WriteFile(brokenPath, []byte("{{ .NoSuchField }}"), 0644); err != nil {
+			t.Fatalf("failed to write template: %v", err)
+		}
+
+		SetTemplateDir(dir)
+		defer SetTemplateDir("")
+
+		errs := Validate()
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), "php.tmpl") {
+			t.Errorf("expected error naming php.tmpl, got %v", errs)
+		}
+	})
+}
+
+func TestRenderOverride(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "nginx"), 0755); err != nil {
+		t.Fatalf("failed to create override dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "nginx", "static.tmpl"), []byte("server { # custom {{ .Domain }}\n}"), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	SetTemplateDir(dir)
+	defer SetTemplateDir("")
+
+	vhost := &config.VHost{Domain: "example.com", Type: config.TypeStatic, Root: "/var/www"}
+	result, err := Render("nginx", vhost)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if result != "server { # custom example.com\n}" {
+		t.Errorf("expected override template output, got %q", result)
+	}
+
+	// Types without an override fall back to the embedded template
+	vhost.Type = config.TypePHP
+	result, err = Render("nginx", vhost)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(result, "fastcgi_pass") {
+		t.Error("expected embedded php template")
+	}
+
+	// An unreadable override is reported, not replaced by the embedded one
+	if err := os.Mkdir(filepath.Join(dir, "nginx", "proxy.tmpl"), 0755); err != nil {
+		t.Fatalf("failed to create unreadable override: %v", err)
+	}
+	vhost.Type = config.TypeProxy
+	vhost.ProxyPass = "127.0.0.1:3000"
+	if _, err := Render("nginx", vhost); err == nil || !strings.Contains(err.Error(), "proxy.tmpl") {
+		t.Errorf("expected a read error naming the override, got %v", err)
+	}
+
+	// Likewise for a variant, which would otherwise fall back to the base type
+	if err := os.Mkdir(filepath.Join(dir, "nginx", "php.custom.tmpl"), 0755); err != nil {
+		t.Fatalf("failed to create unreadable variant: %v", err)
+	}
+	vhost.Type = config.TypePHP
+	vhost.Template = "custom"
+	if _, err := Render("nginx", vhost); err == nil || !strings.Contains(err.Error(), "php.custom.tmpl") {
+		t.Errorf("expected a read error naming the variant, got %v", err)
+	}
+}
+
+func TestRenderRejectsBadOutput(t *testing.T) {
+	dir := t.TempDir()
+	for _, driverName := range []string{"nginx", "apache", "caddy"} {
+		if err := os.MkdirAll(filepath.Join(dir, driverName), 0755); err != nil {
+			t.Fatalf("failed to create override dir: %v", err)
+		}
+	}
+	overrides := map[string]string{
+		"nginx/static.tmpl":  "{{ if .SSL }}server {}{{ end }}\n",
+		"nginx/php.tmpl":     "# {{ .Domain }}\n",
+		"apache/static.tmpl": "ServerName {{ .Domain }}\n",
+		"caddy/static.tmpl":  "http://other.example {\n}\n",
+	}
+	for name, content := range overrides {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write template: %v", err)
+		}
+	}
+
+	SetTemplateDir(dir)
+	defer SetTemplateDir("")
+
+	tests := []struct {
+		driver    string
+		vhostType string
+		want      string
+	}{
+		{"nginx", config.TypeStatic, "nginx/static: rendered empty output"},
+		{"nginx", config.TypePHP, `missing "server"`},
+		{"apache", config.TypeStatic, `missing "<VirtualHost"`},
+		{"caddy", config.TypeStatic, `missing "example.com"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver+"/"+tt.vhostType, func(t *testing.T) {
+			_, err := Render(tt.driver, &config.VHost{Domain: "example.com", Type: tt.vhostType, Root: "/var/www"})
+			if err == nil {
+				t.Fatal("expected error for bad output")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	t.Run("validate", func(t *testing.T) {
+		errs := Validate()
+		if len(errs) != len(overrides) {
+			t.Errorf("expected %d validation errors, got %v", len(overrides), errs)
+		}
+	})
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "nginx"), 0755); err != nil {
+		t.Fatalf("failed to create override dir: %v", err)
+	}
+	content := strings.Join([]string{
+		`replace={{ .Domain | replace "." "_" }}`,
+		`lower={{ lower .Domain }}`,
+		`upper={{ upper .Domain }}`,
+		`join={{ join .Aliases " " }}`,
+		`default={{ .Root | default "/var/www/default" }}`,
+		`hasPrefix={{ if hasPrefix .ProxyPass "https://" }}tls{{ else }}plain{{ end }}`,
+	}, "\n")
+	if err := os.WriteFile(filepath.Join(dir, "nginx", "proxy.tmpl"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	SetTemplateDir(dir)
+	defer SetTemplateDir("")
+
+	tmpl, err := loadTemplate("nginx", config.TypeProxy)
+	if err != nil {
+		t.Fatalf("loadTemplate failed: %v", err)
+	}
+
+	parsed, err := parseTemplate(config.TypeProxy, tmpl)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	result, err := execute(parsed, TemplateData{
+		Domain:    "Api.Example.com",
+		Aliases:   []string{"www.example.com", "example.org"},
+		ProxyPass: "https://127.0.0.1:3000",
+	})
+	if err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+
+	expected := []string{
+		"replace=Api_Example_com",
+		"lower=api.example.com",
+		"upper=API.EXAMPLE.COM",
+		"join=www.example.com example.org",
+		"default=/var/www/default",
+		"hasPrefix=tls",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected %q in output:\n%s", want, result)
+		}
+	}
+}
+
+func TestRenderProxyUpstreamName(t *testing.T) {
+	vhost := &config.VHost{
+		Domain:    "proxy.example.com",
+		Type:      config.TypeProxy,
+		ProxyPass: "127.0.0.1:3000",
+	}
+
+	result, err := Render("nginx", vhost)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(result, "upstream proxy_example_com_backend") {
+		t.Errorf("expected upstream named after domain, got:\n%s", result)
+	}
+
+	vhost.ProxyPass = "http://127.0.0.1:3000"
+	result, err = Render("apache", vhost)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(result, "ws://127.0.0.1:3000/$1") {
+		t.Errorf("expected websocket rewrite without scheme, got:\n%s", result)
+	}
+}
+
+func TestRenderEnvVars(t *testing.T) {
+	envVars := map[string]string{
+		"APP_ENV":   "production",
+		"APP_DEBUG": "false",
+	}
+
+	testCases := []struct {
+		name     string
+		driver   string
+		vhost    *config.VHost
+		contains []string
+	}{
+		{
+			name:   "nginx php fastcgi_param",
+			driver: "nginx",
+			vhost: &config.VHost{
+				Domain:  "php.example.com",
+				Type:    config.TypePHP,
+				Root:    "/var/www/php",
+				EnvVars: envVars,
+			},
+			contains: []string{
+				"fastcgi_param APP_DEBUG \"false\";\n        fastcgi_param APP_ENV \"production\";",
+			},
+		},
+		{
+			name:   "nginx laravel fastcgi_param",
+			driver: "nginx",
+			vhost: &config.VHost{
+				Domain:  "laravel.example.com",
+				Type:    config.TypeLaravel,
+				Root:    "/var/www/laravel",
+				EnvVars: envVars,
+			},
+			contains: []string{`fastcgi_param APP_ENV "production";`},
+		},
+		{
+			name:   "nginx proxy proxy_set_header",
+			driver: "nginx",
+			vhost: &config.VHost{
+				Domain:    "proxy.example.com",
+				Type:      config.TypeProxy,
+				ProxyPass: "127.0.0.1:3000",
+				EnvVars:   envVars,
+			},
+			contains: []string{
+				`proxy_set_header APP_DEBUG "false";`,
+				`proxy_set_header APP_ENV "production";`,
+			},
+		},
+		{
+			name:   "apache wordpress SetEnv",
+			driver: "apache",
+			vhost: &config.VHost{
+				Domain:  "wp.example.com",
+				Type:    config.TypeWordPress,
+				Root:    "/var/www/wp",
+				EnvVars: envVars,
+			},
+			contains: []string{`SetEnv APP_ENV "production"`},
+		},
+		{
+			name:   "apache proxy RequestHeader",
+			driver: "apache",
+			vhost: &config.VHost{
+				Domain:    "proxy.example.com",
+				Type:      config.TypeProxy,
+				ProxyPass: "http://127.0.0.1:3000",
+				SSL:       true,
+				EnvVars:   envVars,
+			},
+			contains: []string{`RequestHeader set APP_ENV "production"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Render(tc.driver, tc.vhost)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			for _, expected := range tc.contains {
+				if !strings.Contains(result, expected) {
+					t.Errorf("expected %q in output:\n%s", expected, result)
+				}
+			}
+		})
+	}
+
+	t.Run("no env vars renders nothing extra", func(t *testing.T) {
+		result, err := Render("apache", &config.VHost{
+			Domain: "php.example.com",
+			Type:   config.TypePHP,
+			Root:   "/var/www/php",
+		})
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if strings.Contains(result, "SetEnv") || strings.Contains(result, "Environment Variables") {
+			t.Errorf("unexpected env block in output:\n%s", result)
+		}
+	})
+}
+
+func TestRenderCaddyImports(t *testing.T) {
+	for _, vhostType := range config.ValidTypes() {
+		t.Run(vhostType, func(t *testing.T) {
+			vhost := &config.VHost{
+				Domain:       "example.com",
+				Type:         vhostType,
+				Root:         "/var/www/example",
+				ProxyPass:    "localhost:3000",
+				RedirectURL:  "https://example.org",
+				CaddyImports: []string{"logging", "security-headers"},
+			}
+
+			result, err := Render("caddy", vhost)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+
+			lines := strings.Split(result, "\n")
+			if !strings.HasSuffix(lines[0], "{") {
+				t.Fatalf("expected site block opening on first line, got %q", lines[0])
+			}
+			if lines[1] != "    import logging" || lines[2] != "    import security-headers" {
+				t.Errorf("expected imports at the top of the site block, got:\n%s", result)
+			}
+		})
+	}
+
+	t.Run("no imports", func(t *testing.T) {
+		result, err := Render("caddy", &config.VHost{Domain: "example.com", Type: config.TypeStatic, Root: "/var/www"})
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if strings.Contains(result, "import") {
+			t.Errorf("unexpected import directive:\n%s", result)
+		}
+	})
+}
+
+func TestRenderCaddyPHP(t *testing.T) {
+	tests := []struct {
+		vhostType string
+		root      string
+	}{
+		{config.TypePHP, "root * /var/www/app\n"},
+		{config.TypeLaravel, "root * /var/www/app/public\n"},
+		{config.TypeWordPress, "root * /var/www/app\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vhostType, func(t *testing.T) {
+			result, err := Render("caddy", &config.VHost{
+				Domain:     "example.com",
+				Type:       tt.vhostType,
+				Root:       "/var/www/app",
+				PHPVersion: "8.3",
+			})
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+
+			for _, want := range []string{
+				tt.root,
+				"php_fastcgi unix//run/php/php8.3-fpm.sock\n",
+				"encode gzip zstd\n",
+				"file_server\n",
+			} {
+				if !strings.Contains(result, want) {
+					t.Errorf("expected %q in output:\n%s", want, result)
+				}
+			}
+
+			// nginx and apache directives are not valid Caddyfile syntax
+			for _, leak := range []string{"fastcgi_pass", "fastcgi_param", "location ", "unix:/", "SetHandler", ";\n"} {
+				if strings.Contains(result, leak) {
+					t.Errorf("unexpected %q in caddy output:\n%s", leak, result)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderDefaultServer(t *testing.T) {
+	tests := []struct {
+		driver string
+		want   []string
+	}{
+		{"nginx", []string{"listen 80 default_server;", "listen 443 ssl default_server;", "server_name _;", "ssl_reject_handshake on;", "return 444;"}},
+		{"apache", []string{"<VirtualHost *:80>", "ServerName 000-catch-all.invalid", "Require all denied"}},
+		{"caddy", []string{":80 {", "abort"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			result, err := Render(tt.driver, &config.VHost{Domain: config.DefaultServerDomain, Type: config.TypeDefault})
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("expected %q in output:\n%s", want, result)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderMaintenance(t *testing.T) {
+	tests := []struct {
+		driver string
+		want   []string
+	}{
+		{driver: "nginx", want: []string{"return 503;", "error_page 503 @maintenance;", "server_name example.com www.example.com;"}},
+		{driver: "apache", want: []string{"RewriteRule ^ - [R=503,L]", "ErrorDocument 503", "ServerAlias www.example.com"}},
+		{driver: "caddy", want: []string{"respond \"", "\" 503", "http://example.com, http://www.example.com {"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			vhost := &config.VHost{
+				Domain:      "example.com",
+				Aliases:     []string{"www.example.com"},
+				Type:        config.TypePHP,
+				Root:        "/var/www/example",
+				Maintenance: true,
+			}
+
+			result, err := Render(tt.driver, vhost)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("expected %q in maintenance config:\n%s", want, result)
+				}
+			}
+			if strings.Contains(result, "php8.2-fpm") {
+				t.Error("maintenance config should not include the PHP handler")
+			}
+
+			// Leaving maintenance renders the type template again
+			vhost.Maintenance = false
+			restored, err := Render(tt.driver, vhost)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if strings.Contains(restored, "503") {
+				t.Errorf("restored config should not return 503:\n%s", restored)
+			}
+		})
+	}
+}
+
+func TestRenderLocations(t *testing.T) {
+	locations := []config.LocationBlock{
+		{Path: "/assets", Root: "/srv/assets"},
+		{Path: "/media", Root: "/data/media"},
+	}
+
+	tests := []struct {
+		driver    string
+		vhostType string
+		want      []string
+	}{
+		{
+			driver:    "nginx",
+			vhostType: config.TypePHP,
+			want: []string{
+				"location ^~ /assets/ {\n        alias /srv/assets/;\n    }",
+				"location ^~ /media/ {\n        alias /data/media/;\n    }",
+			},
+		},
+		{
+			driver:    "apache",
+			vhostType: config.TypeStatic,
+			want: []string{
+				"Alias /assets /srv/assets\n    <Directory /srv/assets>",
+				"Alias /media /data/media\n    <Directory /data/media>",
+			},
+		},
+		{
+			driver:    "apache",
+			vhostType: config.TypeProxy,
+			want: []string{
+				"ProxyPass /assets !\n    ProxyPass /media !\n    ProxyPass / http://localhost:3000/",
+				"Alias /media /data/media",
+			},
+		},
+		{
+			driver:    "caddy",
+			vhostType: config.TypeStatic,
+			want: []string{
+				"handle_path /assets/* {\n        root * /srv/assets\n        file_server\n    }",
+				"handle_path /media/* {\n        root * /data/media\n        file_server\n    }",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver+"/"+tt.vhostType, func(t *testing.T) {
+			vhost := &config.VHost{
+				Domain:    "example.com",
+				Type:      tt.vhostType,
+				Root:      "/var/www/example",
+				ProxyPass: "http://localhost:3000",
+				Locations: locations,
+			}
+
+			result, err := Render(tt.driver, vhost)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("expected %q in config:\n%s", want, result)
+				}
+			}
+		})
+	}
+
+	t.Run("no locations renders nothing extra", func(t *testing.T) {
+		vhost := &config.VHost{Domain: "example.com", Type: config.TypeStatic, Root: "/var/www/example"}
+		result, err := Render("nginx", vhost)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if strings.Contains(result, "alias") {
+			t.Errorf("unexpected alias in config:\n%s", result)
+		}
+	})
+}
+
+func TestRenderHTTPProtocols(t *testing.T) {
+	sslVHost := func(vhostType string, http2, http3 bool) *config.VHost {
+		return &config.VHost{
+			Domain:      "example.com",
+			Type:        vhostType,
+			Root:        "/var/www/app",
+			ProxyPass:   "http://localhost:3000",
+			RedirectURL: "https://example.org",
+			SSL:         true,
+			SSLCert:     "/etc/ssl/cert.pem",
+			SSLKey:      "/etc/ssl/key.pem",
+			HTTP2:       http2,
+			HTTP3:       http3,
+		}
+	}
+
+	t.Run("nginx http2", func(t *testing.T) {
+		for _, vhostType := range config.ValidTypes() {
+			result, err := Render("nginx", sslVHost(vhostType, true, false))
+			if err != nil {
+				t.Fatalf("%s: Render failed: %v", vhostType, err)
+			}
+			if !strings.Contains(result, "listen 443 ssl http2;\n") {
+				t.Errorf("%s: expected http2 listen in output:\n%s", vhostType, result)
+			}
+			if strings.Contains(result, "quic") {
+				t.Errorf("%s: unexpected quic listen without http3:\n%s", vhostType, result)
+			}
+		}
+	})
+
+	t.Run("nginx http3", func(t *testing.T) {
+		result, err := Render("nginx", sslVHost(config.TypeStatic, true, true))
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		for _, want := range []string{
+			"listen 443 ssl;\n",
+			"listen 443 quic;\n",
+			"http2 on;\n",
+			`add_header Alt-Svc 'h3=":443"; ma=86400' always;`,
+		} {
+			if !strings.Contains(result, want) {
+				t.Errorf("expected %q in output:\n%s", want, result)
+			}
+		}
+	})
+
+	t.Run("nginx ssl without protocols", func(t *testing.T) {
+		result, err := Render("nginx", sslVHost(config.TypeStatic, false, false))
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if !strings.Contains(result, "listen 443 ssl;\n") || strings.Contains(result, "http2") {
+			t.Errorf("expected plain ssl listen in output:\n%s", result)
+		}
+	})
+
+	t.Run("apache http2", func(t *testing.T) {
+		result, err := Render("apache", sslVHost(config.TypeStatic, true, false))
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if !strings.Contains(result, "Protocols h2 http/1.1\n") {
+			t.Errorf("expected Protocols in output:\n%s", result)
+		}
+	})
+
+	t.Run("requires SSL", func(t *testing.T) {
+		for _, vhost := range []*config.VHost{
+			{Domain: "example.com", Type: config.TypeStatic, Root: "/var/www", HTTP2: true},
+			{Domain: "example.com", Type: config.TypeStatic, Root: "/var/www", HTTP3: true},
+		} {
+			for _, drv := range []string{"nginx", "apache", "caddy"} {
+				_, err := Render(drv, vhost)
+				if err == nil || !strings.Contains(err.Error(), "requires SSL") {
+					t.Errorf("%s: expected requires SSL error, got %v", drv, err)
+				}
+			}
+		}
+	})
+}
+
+func TestRenderFastCGITuning(t *testing.T) {
+	tuned := func(vhostType string) *config.VHost {
+		return &config.VHost{
+			Domain:             "example.com",
+			Type:               vhostType,
+			Root:               "/var/www/app",
+			PHPVersion:         "8.3",
+			FastCGIReadTimeout: "5m",
+			FastCGIBuffers:     "16 16k",
+			FastCGIBufferSize:  "32k",
+		}
+	}
+
+	for _, vhostType := range []string{config.TypePHP, config.TypeLaravel, config.TypeWordPress} {
+		t.Run("nginx "+vhostType, func(t *testing.T) {
+			result, err := Render("nginx", tuned(vhostType))
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			for _, want := range []string{
+				"fastcgi_read_timeout 300s;\n",
+				"fastcgi_buffers 16 16k;\n",
+				"fastcgi_buffer_size 32k;\n",
+			} {
+				if strings.Count(result, want) != 1 {
+					t.Errorf("expected %q once in output:\n%s", want, result)
+				}
+			}
+		})
+
+		t.Run("apache "+vhostType, func(t *testing.T) {
+			result, err := Render("apache", tuned(vhostType))
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if !strings.Contains(result, "ProxyTimeout 300\n") {
+				t.Errorf("expected ProxyTimeout in output:\n%s", result)
+			}
+		})
+	}
+
+	t.Run("defaults when unset", func(t *testing.T) {
+		vhost := &config.VHost{Domain: "example.com", Type: config.TypePHP, Root: "/var/www/app"}
+		result, err := Render("nginx", vhost)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if strings.Contains(result, "fastcgi_read_timeout") || strings.Contains(result, "fastcgi_buffer") {
+			t.Errorf("unexpected fastcgi tuning in output:\n%s", result)
+		}
+
+		vhost.Type = config.TypeWordPress
+		result, err = Render("nginx", vhost)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if !strings.Contains(result, "fastcgi_buffer_size 128k;\n") || !strings.Contains(result, "fastcgi_buffers 256 16k;\n") {
+			t.Errorf("expected wordpress default buffers in output:\n%s", result)
+		}
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		vhost := tuned(config.TypePHP)
+		vhost.FastCGIReadTimeout = "soon"
+		if _, err := Render("nginx", vhost); err == nil {
+			t.Error("expected error for invalid timeout")
+		}
+	})
+}
+
+func TestRenderWPMultisite(t *testing.T) {
+	site := func(mode string) *config.VHost {
+		return &config.VHost{
+			Domain:      "example.com",
+			Type:        config.TypeWordPress,
+			Root:        "/var/www/wordpress",
+			PHPVersion:  "8.3",
+			WPMultisite: mode,
+		}
+	}
+
+	tests := []struct {
+		driver   string
+		mode     string
+		contains []string
+	}{
+		{"nginx", config.WPMultisiteSubdir, []string{
+			"rewrite ^/([_0-9a-zA-Z-]+/)?wp-admin$ $scheme://$host$uri/ permanent;",
+			"rewrite ^/([_0-9a-zA-Z-]+/)?(wp-(content|admin|includes).*) /$2 last;",
+			"rewrite ^/([_0-9a-zA-Z-]+/)?(.*\\.php)$ /$2 last;",
+		}},
+		{"nginx", config.WPMultisiteSubdomain, []string{
+			"rewrite ^/files/(.+) /wp-includes/ms-files.php?file=$1 last;",
+		}},
+		{"apache", config.WPMultisiteSubdir, []string{
+			"RewriteRule ^([_0-9a-zA-Z-]+/)?wp-admin$ $1wp-admin/ [R=301,L]",
+			"RewriteRule ^([_0-9a-zA-Z-]+/)?(wp-(content|admin|includes).*) $2 [L]",
+			"RewriteRule ^([_0-9a-zA-Z-]+/)?(.*\\.php)$ $2 [L]",
+		}},
+		{"apache", config.WPMultisiteSubdomain, []string{
+			"RewriteRule ^wp-admin$ wp-admin/ [R=301,L]",
+		}},
+		{"caddy", config.WPMultisiteSubdir, []string{
+			"rewrite @multisite /{re.multisite.2}",
+			"redir @wpadmin {path}/ 301",
+		}},
+		{"caddy", config.WPMultisiteSubdomain, []string{
+			"redir @wpadmin {path}/ 301",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver+" "+tt.mode, func(t *testing.T) {
+			result, err := Render(tt.driver, site(tt.mode))
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(result, want) {
+					t.Errorf("expected %q in output:\n%s", want, result)
+				}
+			}
+			if tt.mode == config.WPMultisiteSubdomain && strings.Contains(result, "{re.multisite") {
+				t.Errorf("subdomain mode should not rewrite subdirectory paths:\n%s", result)
+			}
+		})
+	}
+
+	t.Run("single site", func(t *testing.T) {
+		for _, driverName := range []string{"nginx", "apache", "caddy"} {
+			result, err := Render(driverName, site(""))
+			if err != nil {
+				t.Fatalf("Render %s failed: %v", driverName, err)
+			}
+			if strings.Contains(result, "wp-admin$") || strings.Contains(result, "ms-files.php") {
+				t.Errorf("unexpected multisite rules in %s output:\n%s", driverName, result)
+			}
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		if _, err := Render("nginx", site("subfolder")); err == nil {
+			t.Error("expected error for invalid multisite mode")
+		}
+	})
+}
+
+func TestRenderLaravelDeniedPaths(t *testing.T) {
+	vhost := &config.VHost{
+		Domain:     "laravel.test",
+		Type:       config.TypeLaravel,
+		Root:       "/var/www/laravel",
+		PHPVersion: "8.3",
+	}
+
+	tests := []struct {
+		driver   string
+		contains []string
+	}{
+		{"nginx", []string{
+			"location ^~ /.env {\n        deny all;\n    }",
+			"location ^~ /storage/framework {\n        deny all;\n    }",
+			"location ^~ /storage/logs {\n        deny all;\n    }",
+			"location ^~ /.git {\n        deny all;\n    }",
+			"try_files $uri $uri/ /index.php?$query_string;",
+		}},
+		{"apache", []string{
+			"<Location /.env>\n        Require all denied\n    </Location>",
+			"<Location /storage/framework>\n        Require all denied\n    </Location>",
+			"<Location /storage/logs>\n        Require all denied\n    </Location>",
+			"<Location /.git>\n        Require all denied\n    </Location>",
+		}},
+		{"caddy", []string{
+			"@denied path /.env /.env/* /storage/framework /storage/framework/* /storage/logs /storage/logs/* /.git /.git/*\n",
+			"respond @denied 403\n",
+		}},
+	}
+
+	for _, tt := range tests {
+		for _, ssl := range []bool{false, true} {
+			name := tt.driver
+			if ssl {
+				name += " ssl"
+			}
+			t.Run(name, func(t *testing.T) {
+				v := *vhost
+				if ssl {
+					v.SSL = true
+					v.SSLCert = "/etc/ssl/cert.pem"
+					v.SSLKey = "/etc/ssl/key.pem"
+				}
+				result, err := Render(tt.driver, &v)
+				if err != nil {
+					t.Fatalf("Render failed: %v", err)
+				}
+				for _, want := range tt.contains {
+					if !strings.Contains(result, want) {
+						t.Errorf("expected %q in output:\n%s", want, result)
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("public storage still served", func(t *testing.T) {
+		result, err := Render("nginx", vhost)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if strings.Contains(result, "location ^~ /storage {") {
+			t.Errorf("unexpected deny rule for all of /storage:\n%s", result)
+		}
+	})
+
+	t.Run("other types unaffected", func(t *testing.T) {
+		php := *vhost
+		php.Type = config.TypePHP
+		result, err := Render("nginx", &php)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if strings.Contains(result, "location ^~ /storage") {
+			t.Errorf("unexpected laravel deny rules in php output:\n%s", result)
+		}
+	})
+}
+
+func TestRenderSPA(t *testing.T) {
+	tests := []struct {
+		driver string
+		spa    string
+		base   string
+	}{
+		{"nginx", "try_files $uri $uri/ /index.html;\n", "try_files $uri $uri/ =404;\n"},
+		{"apache", "FallbackResource /index.html\n", ""},
+		{"caddy", "try_files {path} /index.html\n", ""},
+	}
+
+	for _, tt := range tests {
+		for _, ssl := range []bool{false, true} {
+			name := tt.driver
+			if ssl {
+				name += " ssl"
+			}
+			t.Run(name, func(t *testing.T) {
+				vhost := &config.VHost{Domain: "app.example.com", Type: config.TypeStatic, Root: "/var/www/app", SPA: true}
+				if ssl {
+					vhost.SSL, vhost.SSLCert, vhost.SSLKey = true, "/etc/ssl/cert.pem", "/etc/ssl/key.pem"
+				}
+				result, err := Render(tt.driver, vhost)
+				if err != nil {
+					t.Fatalf("Render failed: %v", err)
+				}
+				if strings.Count(result, tt.spa) != 1 {
+					t.Errorf("expected %q once in output:\n%s", tt.spa, result)
+				}
+
+				vhost.SPA = false
+				result, err = Render(tt.driver, vhost)
+				if err != nil {
+					t.Fatalf("Render failed: %v", err)
+				}
+				if strings.Contains(result, "/index.html") {
+					t.Errorf("expected no SPA fallback without --spa:\n%s", result)
+				}
+				if tt.base != "" && !strings.Contains(result, tt.base) {
+					t.Errorf("expected %q in standard output:\n%s", tt.base, result)
+				}
+			})
+		}
+	}
+}
+
+func TestRenderTemplateVariant(t *testing.T) {
+	variant := func(name string) *config.VHost {
+		return &config.VHost{
+			Domain:   "app.example.com",
+			Type:     config.TypeStatic,
+			Template: name,
+			Root:     "/var/www/app",
+		}
+	}
+
+	tests := []struct {
+		driver string
+		want   string
+	}{
+		{"nginx", "try_files $uri /index.html;\n"},
+		{"apache", "FallbackResource /index.html\n"},
+		{"caddy", "try_files {path} /index.html\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver+" spa", func(t *testing.T) {
+			if !HasVariant(tt.driver, config.TypeStatic, "spa") {
+				t.Errorf("expected embedded %s/static.spa template", tt.driver)
+			}
+			result, err := Render(tt.driver, variant("spa"))
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if !strings.Contains(result, tt.want) {
+				t.Errorf("expected %q in output:\n%s", tt.want, result)
+			}
+
+			result, err = Render(tt.driver, variant(""))
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if strings.Contains(result, tt.want) {
+				t.Errorf("base template should not have the SPA fallback:\n%s", result)
+			}
+		})
+	}
+
+	t.Run("missing variant falls back to base", func(t *testing.T) {
+		vhost := variant("nosuch")
+		if HasVariant("nginx", config.TypeStatic, "nosuch") {
+			t.Error("expected no nosuch variant")
+		}
+		result, err := Render("nginx", vhost)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if !strings.Contains(result, "try_files $uri $uri/ =404;") {
+			t.Errorf("expected base static template:\n%s", result)
+		}
+	})
+
+	t.Run("override directory variant", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "nginx"), 0755); err != nil {
+			t.Fatalf("failed to create override dir: %v", err)
+		}
+		content := "server { server_name {{ .Domain }}; # custom variant }"
+		if err := os.WriteFile(filepath.Join(dir, "nginx", "static.custom.tmpl"), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write template: %v", err)
+		}
+		SetTemplateDir(dir)
+		defer SetTemplateDir("")
+
+		if !HasVariant("nginx", config.TypeStatic, "custom") {
+			t.Error("expected override custom variant")
+		}
+		vhost := variant("custom")
+		result, err := Render("nginx", vhost)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if !strings.Contains(result, "# custom variant") {
+			t.Errorf("expected override variant, got:\n%s", result)
+		}
+	})
+
+	t.Run("maintenance ignores variant", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "nginx"), 0755); err != nil {
+			t.Fatalf("failed to create override dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "nginx", "static.custom.tmpl"), []byte("server { # custom variant }"), 0644); err != nil {
+			t.Fatalf("failed to write template: %v", err)
+		}
+		SetTemplateDir(dir)
+		defer SetTemplateDir("")
+
+		vhost := variant("custom")
+		vhost.Maintenance = true
+		result, err := Render("nginx", vhost)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if strings.Contains(result, "# custom variant") {
+			t.Errorf("expected maintenance page, got:\n%s", result)
+		}
+	})
+
+	t.Run("invalid variant", func(t *testing.T) {
+		vhost := variant("../proxy")
+		if _, err := Render("nginx", vhost); err == nil {
+			t.Error("expected error for invalid variant")
+		}
+	})
+}
+
+func TestRenderBlockUserAgents(t *testing.T) {
+	agents := []string{"curl", "python-requests", "Bad.Bot (v1+)"}
+	pattern := `(curl|python-requests|Bad\.Bot \(v1\+\))`
+
+	tests := []struct {
+		driver   string
+		contains []string
+	}{
+		{"nginx", []string{
+			"if ($http_user_agent ~* \"" + pattern + "\") {\n        return 403;\n    }",
+		}},
+		{"apache", []string{
+			"BrowserMatchNoCase \"" + pattern + "\" vhost_blocked_ua",
+			"Require not env vhost_blocked_ua",
+		}},
+		{"caddy", []string{
+			"@badbots header_regexp User-Agent \"(?i)" + pattern + "\"",
+			"respond @badbots 403",
+		}},
+	}
+
+	for _, tt := range tests {
+		for _, vhostType := range []string{config.TypeStatic, config.TypePHP, config.TypeProxy, config.TypeLaravel, config.TypeWordPress} {
+			for _, ssl := range []bool{false, true} {
+				name := tt.driver + " " + vhostType
+				if ssl {
+					name += " ssl"
+				}
+				t.Run(name, func(t *testing.T) {
+					vhost := &config.VHost{
+						Domain:          "example.com",
+						Type:            vhostType,
+						Root:            "/var/www/example",
+						ProxyPass:       "http://localhost:3000",
+						BlockUserAgents: agents,
+					}
+					if ssl {
+						vhost.SSL = true
+						vhost.SSLCert = "/etc/ssl/cert.pem"
+						vhost.SSLKey = "/etc/ssl/key.pem"
+					}
+					result, err := Render(tt.driver, vhost)
+					if err != nil {
+						t.Fatalf("Render failed: %v", err)
+					}
+					for _, want := range tt.contains {
+						if strings.Count(result, want) != 1 {
+							t.Errorf("expected %q once in output:\n%s", want, result)
+						}
+					}
+				})
+			}
+		}
+	}
+
+	t.Run("no agents", func(t *testing.T) {
+		for _, driverName := range []string{"nginx", "apache", "caddy"} {
+			result, err := Render(driverName, &config.VHost{Domain: "example.com", Type: config.TypeStatic, Root: "/var/www/example"})
+			if err != nil {
+				t.Fatalf("Render %s failed: %v", driverName, err)
+			}
+			if strings.Contains(result, "Blocked user agents") {
+				t.Errorf("unexpected user agent block in %s output:\n%s", driverName, result)
+			}
+		}
+	})
+
+	t.Run("invalid entries", func(t *testing.T) {
+		for _, agent := range []string{"", "   ", `say "hi"`, `back\slash`, "new\nline"} {
+			vhost := &config.VHost{Domain: "example.com", Type: config.TypeStatic, Root: "/var/www/example", BlockUserAgents: []string{"curl", agent}}
+			if _, err := Render("nginx", vhost); err == nil {
+				t.Errorf("expected error for blocked user agent %q", agent)
+			}
+		}
+	})
+}
+
+func TestRenderStaticCache(t *testing.T) {
+	site := func(vhostType, maxAge string) *config.VHost {
+		return &config.VHost{
+			Domain:            "example.com",
+			Type:              vhostType,
+			Root:              "/var/www/example",
+			ProxyPass:         "http://localhost:3000",
+			StaticCacheMaxAge: maxAge,
+		}
+	}
+	extensions := "css|js|png|jpg|jpeg|gif|ico|svg|webp|woff|woff2|ttf|eot"
+
+	tests := []struct {
+		driver   string
+		maxAge   string
+		contains []string
+	}{
+		{"nginx", "", []string{
+			"location ~* \\.(" + extensions + ")$ {\n        expires 30d;",
+			"add_header Cache-Control \"public\" always;",
+		}},
+		{"nginx", "12h", []string{"expires 12h;"}},
+		{"apache", "", []string{
+			"<FilesMatch \"\\.(" + extensions + ")$\">",
+			"ExpiresDefault \"access plus 2592000 seconds\"",
+		}},
+		{"apache", "1w", []string{"ExpiresDefault \"access plus 604800 seconds\""}},
+		{"caddy", "", []string{
+			"path *.css *.js *.png",
+			"header @static Cache-Control \"public, max-age=2592000\"",
+		}},
+		{"caddy", "1y", []string{"header @static Cache-Control \"public, max-age=31536000\""}},
+	}
+
+	for _, tt := range tests {
+		for _, vhostType := range []string{config.TypeStatic, config.TypeWordPress} {
+			t.Run(tt.driver+" "+vhostType+" "+tt.maxAge, func(t *testing.T) {
+				result, err := Render(tt.driver, site(vhostType, tt.maxAge))
+				if err != nil {
+					t.Fatalf("Render failed: %v", err)
+				}
+				for _, want := range tt.contains {
+					if !strings.Contains(result, want) {
+						t.Errorf("expected %q in output:\n%s", want, result)
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		for _, driverName := range []string{"nginx", "apache", "caddy"} {
+			vhost := site(config.TypeStatic, "")
+			vhost.NoStaticCache = true
+			result, err := Render(driverName, vhost)
+			if err != nil {
+				t.Fatalf("Render %s failed: %v", driverName, err)
+			}
+			if strings.Contains(result, "woff2") {
+				t.Errorf("unexpected asset caching in %s output:\n%s", driverName, result)
+			}
+		}
+	})
+
+	t.Run("other types unaffected", func(t *testing.T) {
+		for _, vhostType := range []string{config.TypePHP, config.TypeProxy, config.TypeLaravel} {
+			result, err := Render("nginx", site(vhostType, ""))
+			if err != nil {
+				t.Fatalf("Render %s failed: %v", vhostType, err)
+			}
+			if strings.Contains(result, "expires") {
+				t.Errorf("unexpected asset caching in %s output:\n%s", vhostType, result)
+			}
+		}
+	})
+
+	t.Run("invalid max-age", func(t *testing.T) {
+		for _, maxAge := range []string{"30", "0d", "30 days", "-1d", "1M"} {
+			if _, err := Render("nginx", site(config.TypeStatic, maxAge)); err == nil {
+				t.Errorf("expected error for max-age %q", maxAge)
+			}
+		}
+	})
+}
+
+func TestRenderRedirect(t *testing.T) {
+	tests := []struct {
+		driver   string
+		redirect string
+	}{
+		{"nginx", "return 301 https://example.org$request_uri;\n"},
+		{"apache", "Redirect permanent / https://example.org/\n"},
+		{"caddy", "redir https://example.org{uri} permanent\n"},
+		{"traefik", `replacement: "https://example.org${1}"`},
+	}
+
+	for _, tt := range tests {
+		for _, ssl := range []bool{false, true} {
+			name := tt.driver
+			if ssl {
+				name += " ssl"
+			}
+			t.Run(name, func(t *testing.T) {
+				// A trailing slash on the target must not double up with the path
+				vhost := &config.VHost{Domain: "old.example.com", Aliases: []string{"www.old.example.com"}, Type: config.TypeRedirect, RedirectURL: "https://example.org/"}
+				if ssl {
+					vhost.SSL, vhost.SSLCert, vhost.SSLKey = true, "/etc/ssl/cert.pem", "/etc/ssl/key.pem"
+				}
+				result, err := Render(tt.driver, vhost)
+				if err != nil {
+					t.Fatalf("Render failed: %v", err)
+				}
+				// apache repeats the redirect in the SSL VirtualHost
+				want := 1
+				if ssl && tt.driver == "apache" {
+					want = 2
+				}
+				if got := strings.Count(result, tt.redirect); got != want {
+					t.Errorf("expected %q %d time(s), got %d:\n%s", tt.redirect, want, got, result)
+				}
+				if !strings.Contains(result, "www.old.example.com") {
+					t.Errorf("expected alias in output:\n%s", result)
+				}
+				if strings.Contains(result, "root ") || strings.Contains(result, "DocumentRoot") {
+					t.Errorf("unexpected document root in redirect config:\n%s", result)
+				}
+				if ssl && tt.driver != "caddy" && !strings.Contains(result, "/etc/ssl/cert.pem") {
+					t.Errorf("expected certificate in SSL output:\n%s", result)
+				}
+			})
+		}
+	}
+
+	t.Run("temporary", func(t *testing.T) {
+		for driverName, want := range map[string]string{
+			"nginx":   "return 302 https://example.org$request_uri;\n",
+			"apache":  "Redirect temp / https://example.org/\n",
+			"caddy":   "redir https://example.org{uri} temporary\n",
+			"traefik": "permanent: false",
+		} {
+			vhost := &config.VHost{Domain: "promo.example.com", Type: config.TypeRedirect, RedirectURL: "https://example.org", RedirectCode: 302}
+			result, err := Render(driverName, vhost)
+			if err != nil {
+				t.Fatalf("%s: Render failed: %v", driverName, err)
+			}
+			if !strings.Contains(result, want) {
+				t.Errorf("%s: expected %q in output:\n%s", driverName, want, result)
+			}
+			if strings.Contains(result, "301") || strings.Contains(result, "permanent ") {
+				t.Errorf("%s: unexpected permanent redirect in output:\n%s", driverName, result)
+			}
+		}
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		vhost := &config.VHost{Domain: "old.example.com", Type: config.TypeRedirect, RedirectURL: "https://example.org", RedirectCode: 307}
+		if _, err := Render("nginx", vhost); err == nil || !strings.Contains(err.Error(), "use 301 or 302") {
+			t.Errorf("expected invalid redirect code error, got %v", err)
+		}
+	})
+
+	t.Run("missing target", func(t *testing.T) {
+		vhost := &config.VHost{Domain: "old.example.com", Type: config.TypeRedirect}
+		if _, err := Render("nginx", vhost); err == nil {
+			t.Error("expected error for a redirect vhost without a target")
+		}
+	})
+}